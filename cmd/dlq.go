@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/rabbitmq"
+	"github.com/ncfex/dcart-auth/internal/config"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+const dlqUsage = `usage: auth dlq [-queue name] <command>
+
+options:
+  -queue name              the consumer queue whose DLQ to use, auth.events or auth.webhooks (default auth.events)
+
+commands:
+  list [-limit n] [-json]  show dead-lettered messages and why they failed
+  replay [id ...]          send messages back to the queue they failed on, all when no id is given
+  purge                    drop every dead-lettered message
+  migrate                  move a queue declared before it had its own dead-letter exchange onto it,
+                           keeping its messages; stop the service first`
+
+// runDeadLetterCommand is the operator entry point for the rabbitmq DLQs;
+// it talks to the broker directly and starts none of the service.
+func runDeadLetterCommand(ctx context.Context, cfg *config.Config, eventRegistry shared.EventRegistry, args []string) error {
+	flags := flag.NewFlagSet("dlq", flag.ContinueOnError)
+	queue := flags.String("queue", eventsQueue, "consumer queue whose DLQ to use")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return fmt.Errorf("missing dlq command\n%s", dlqUsage)
+	}
+	if *queue != eventsQueue && *queue != webhooksQueue {
+		return fmt.Errorf("unknown queue: %s\n%s", *queue, dlqUsage)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	if args[0] == "migrate" {
+		migrated, err := rabbitmq.MigrateConsumerTopology(ctx, consumerConfig(cfg, *queue))
+		if err != nil {
+			return err
+		}
+		if migrated {
+			fmt.Printf("migrated %s to %s\n", *queue, *queue+".dlx")
+		} else {
+			fmt.Printf("%s is up to date\n", *queue)
+		}
+		return nil
+	}
+
+	deadLetters, err := rabbitmq.NewDeadLetterQueue(deadLetterConfig(cfg, *queue), eventRegistry)
+	if err != nil {
+		return err
+	}
+	defer deadLetters.Close()
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("dlq list", flag.ContinueOnError)
+		limit := flags.Int("limit", 100, "maximum number of messages to show, 0 for all")
+		asJSON := flags.Bool("json", false, "print full messages as JSON")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		messages, err := deadLetters.List(ctx, *limit)
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(messages)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEVENT TYPE\tRETRIES\tREASON")
+		for _, message := range messages {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", message.ID, message.EventType, message.Retries, message.Reason)
+		}
+		return w.Flush()
+	case "replay":
+		replayed, err := deadLetters.Replay(ctx, args[1:])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("replayed %d message(s)\n", replayed)
+	case "purge":
+		purged, err := deadLetters.Purge(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("purged %d message(s)\n", purged)
+	default:
+		return fmt.Errorf("unknown dlq command: %s\n%s", args[0], dlqUsage)
+	}
+
+	return nil
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/encryption"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/kafka"
+	memoryMessaging "github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/memory"
+	natsMessaging "github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/nats"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/rabbitmq"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/memory"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/mongodb"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/postgres"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/sqlite"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/webhook"
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/services"
+	"github.com/ncfex/dcart-auth/internal/config"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+const refreshTokenTTL = 24 * 7 * time.Hour
+
+const (
+	eventsExchange = "domain.events"
+	eventsQueue    = "auth.events"
+	webhooksQueue  = "auth.webhooks"
+)
+
+// webhookTimeout bounds a single webhook delivery attempt.
+const webhookTimeout = 10 * time.Second
+
+type closer struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+// infrastructure holds the secondary adapters the application is wired with.
+type infrastructure struct {
+	eventStore     secondary.EventStore
+	snapshots      secondary.SnapshotStore
+	tokenRepo      secondary.TokenRepository
+	dataKeys       secondary.DataKeyRepository
+	eventPublisher secondary.EventPublisher
+	userQueries    query.UserQueryPort
+	deadLetters    map[string]secondary.DeadLetterQueue
+	webhooks       secondary.WebhookRepository
+	closers        []closer
+}
+
+// close releases the adapters in reverse order of creation, so subscribers
+// and dispatchers stop before the databases they write to are closed.
+func (i *infrastructure) close(ctx context.Context) []error {
+	var errs []error
+	for n := len(i.closers) - 1; n >= 0; n-- {
+		c := i.closers[n]
+		if err := c.close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s close: %w", c.name, err))
+		}
+	}
+	return errs
+}
+
+// newInMemoryInfrastructure wires every secondary port to a process-local
+// adapter, for demos and end-to-end tests without postgres, mongodb or
+// rabbitmq.
+func newInMemoryInfrastructure(ctx context.Context, eventRegistry shared.EventRegistry) (*infrastructure, error) {
+	readModel := memory.NewUserReadModelStore()
+	projector := memory.NewMemoryProjector(readModel)
+	dataKeys := memory.NewDataKeyRepository()
+
+	eventStore := memory.NewEventStore(eventRegistry, encryption.NewFieldCipher(dataKeys, eventRegistry))
+
+	infra := &infrastructure{
+		eventStore:  eventStore,
+		snapshots:   eventStore,
+		tokenRepo:   memory.NewTokenRepository(refreshTokenTTL),
+		dataKeys:    dataKeys,
+		userQueries: memory.NewUserQueryHandler(readModel),
+		webhooks:    memory.NewWebhookRepository(),
+	}
+
+	dispatcher, err := infra.startWebhookDispatcher(ctx, eventRegistry)
+	if err != nil {
+		return nil, err
+	}
+	infra.eventPublisher = memoryMessaging.NewPublisher(projector, dispatcher)
+
+	return infra, nil
+}
+
+func newInfrastructure(ctx context.Context, cfg *config.Config, eventRegistry shared.EventRegistry) (*infrastructure, error) {
+	infra := &infrastructure{}
+
+	// write db
+	if err := infra.connectWriteSide(ctx, cfg, eventRegistry); err != nil {
+		return nil, err
+	}
+
+	// read db
+	readProjector, err := infra.connectReadSide(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// webhooks
+	dispatcher, err := infra.startWebhookDispatcher(ctx, eventRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	// messaging
+	feeds := []eventFeed{
+		{listener: "auth.read-model", queue: eventsQueue, projector: readProjector},
+		{listener: "auth.webhooks", queue: webhooksQueue, projector: dispatcher},
+	}
+	if err := infra.connectMessaging(ctx, cfg, eventRegistry, feeds...); err != nil {
+		return nil, err
+	}
+
+	return infra, nil
+}
+
+// connectReadSide sets up the read model queries are answered from and
+// returns the projector that keeps it up to date.
+func (i *infrastructure) connectReadSide(ctx context.Context, cfg *config.Config) (secondary.EventProjector, error) {
+	switch cfg.ReadModel {
+	case config.ReadModelPostgres:
+		postgresDB, err := postgres.NewDatabase(postgresDSN(cfg))
+		if err != nil {
+			return nil, err
+		}
+		i.closers = append(i.closers, closer{"read database", func(context.Context) error {
+			return postgresDB.Close()
+		}})
+
+		i.userQueries = postgres.NewUserQueryHandler(postgresDB)
+		return postgres.NewPostgresProjector(postgresDB), nil
+	default:
+		mongoConfig := mongodb.Config{
+			URI:            cfg.MongoURI,
+			Database:       cfg.MongoDatabase,
+			ConnectTimeout: 10 * time.Second,
+			MaxPoolSize:    100,
+			MinPoolSize:    10,
+		}
+		mongoClient, err := mongodb.NewClient(mongoConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := mongoClient.Connect(ctx); err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		i.closers = append(i.closers, closer{"read database", mongoClient.Disconnect})
+
+		i.userQueries = mongodb.NewUserQueryHandler(mongoClient.Database())
+		return mongodb.NewMongoProjector(mongoClient.Database(), "users"), nil
+	}
+}
+
+func (i *infrastructure) connectWriteSide(ctx context.Context, cfg *config.Config, eventRegistry shared.EventRegistry) error {
+	switch cfg.StorageBackend {
+	case config.BackendSQLite:
+		sqliteDB, err := sqlite.NewDatabase(ctx, cfg.SQLitePath)
+		if err != nil {
+			return err
+		}
+		i.closers = append(i.closers, closer{"database", func(context.Context) error {
+			return sqliteDB.Close()
+		}})
+
+		i.tokenRepo = sqlite.NewTokenRepository(sqliteDB, refreshTokenTTL)
+		i.dataKeys = sqlite.NewDataKeyRepository(sqliteDB)
+		i.webhooks = sqlite.NewWebhookRepository(sqliteDB)
+		eventStore := sqlite.NewSQLiteEventStore(
+			sqliteDB.DB,
+			eventRegistry,
+			encryption.NewFieldCipher(i.dataKeys, eventRegistry),
+		)
+		i.eventStore = eventStore
+		i.snapshots = eventStore
+	default:
+		postgresDB, err := postgres.NewDatabase(postgresDSN(cfg))
+		if err != nil {
+			return err
+		}
+		i.closers = append(i.closers, closer{"database", func(context.Context) error {
+			return postgresDB.Close()
+		}})
+
+		i.tokenRepo = postgres.NewTokenRepository(postgresDB, refreshTokenTTL)
+		i.dataKeys = postgres.NewDataKeyRepository(postgresDB)
+		i.webhooks = postgres.NewWebhookRepository(postgresDB)
+		eventStore := postgres.NewPostgresEventStore(
+			postgresDB.DB,
+			eventRegistry,
+			encryption.NewFieldCipher(i.dataKeys, eventRegistry),
+		)
+		i.eventStore = eventStore
+		i.snapshots = eventStore
+	}
+	return nil
+}
+
+// eventFeed is one independent subscription to the event stream; each has
+// its own listener position, consumer group or queue so a slow feed never
+// holds up another.
+type eventFeed struct {
+	// listener names the postgres listener, the kafka consumer group and,
+	// with dots as dashes, the nats durable consumer
+	listener string
+	// queue names the rabbitmq queue, and the feed's dead letters or parked
+	// events in the admin api
+	queue     string
+	projector secondary.EventProjector
+}
+
+func (i *infrastructure) connectMessaging(
+	ctx context.Context,
+	cfg *config.Config,
+	eventRegistry shared.EventRegistry,
+	feeds ...eventFeed,
+) error {
+	subscribers := make([]secondary.EventSubscriber, 0, len(feeds))
+	switch cfg.EventTransport {
+	case config.TransportPostgres:
+		eventStore, ok := i.eventStore.(*postgres.PostgresEventStore)
+		if !ok {
+			return fmt.Errorf("event transport %s needs the postgres event store", cfg.EventTransport)
+		}
+
+		// pub: SaveEvents notifies on commit
+		eventStore.EnableListeners()
+		i.eventPublisher = discardPublisher{}
+
+		// sub, with parked events standing in for each feed's dlq
+		i.deadLetters = make(map[string]secondary.DeadLetterQueue, len(feeds))
+		for _, feed := range feeds {
+			listenerConfig := postgres.ListenerConfig{
+				Name:                 feed.listener,
+				BatchSize:            100,
+				PollInterval:         time.Second * 30,
+				MinReconnectInterval: time.Second,
+				MaxReconnectInterval: time.Minute,
+				ProcessingTimeout:    time.Second * 30,
+				MaxRetries:           5,
+				RetryBaseDelay:       time.Second,
+			}
+			subscribers = append(subscribers, postgres.NewEventListener(postgresDSN(cfg), eventStore, listenerConfig, feed.projector))
+			i.deadLetters[feed.queue] = postgres.NewParkedEvents(eventStore, feed.listener, feed.projector)
+		}
+	case config.TransportKafka:
+		// pub
+		publisherConfig := kafka.PublisherConfig{
+			Brokers: cfg.KafkaBrokers,
+			Topic:   cfg.KafkaTopic,
+			Timeout: 5 * time.Second,
+		}
+		kafkaPublisher, err := kafka.NewPublisher(publisherConfig, eventRegistry)
+		if err != nil {
+			return err
+		}
+		i.eventPublisher = kafkaPublisher
+		i.closers = append(i.closers, closer{"kafka pub", func(context.Context) error {
+			return kafkaPublisher.Close()
+		}})
+
+		// sub
+		for _, feed := range feeds {
+			kafkaConsumerConfig := kafka.ConsumerConfig{
+				Brokers:           cfg.KafkaBrokers,
+				Topic:             cfg.KafkaTopic,
+				GroupID:           feed.listener,
+				ProcessingTimeout: time.Second * 30,
+				MaxRetries:        5,
+				RetryBaseDelay:    time.Second,
+			}
+			kafkaConsumer, err := kafka.NewConsumer(kafkaConsumerConfig, feed.projector, eventRegistry)
+			if err != nil {
+				return fmt.Errorf("failed to create consumer: %w", err)
+			}
+			subscribers = append(subscribers, kafkaConsumer)
+		}
+	case config.TransportNATS:
+		streamConfig := natsMessaging.StreamConfig{
+			Stream:        cfg.NATSStream,
+			SubjectPrefix: cfg.NATSSubject,
+		}
+
+		// pub
+		publisherConfig := natsMessaging.PublisherConfig{
+			URL:            cfg.NATSURL,
+			StreamConfig:   streamConfig,
+			Timeout:        5 * time.Second,
+			ReconnectDelay: 5 * time.Second,
+		}
+		natsPublisher, err := natsMessaging.NewPublisher(publisherConfig, eventRegistry)
+		if err != nil {
+			return fmt.Errorf("publisher initialization failed: %w", err)
+		}
+		i.eventPublisher = natsPublisher
+		i.closers = append(i.closers, closer{"nats pub", func(context.Context) error {
+			return natsPublisher.Close()
+		}})
+
+		// sub
+		for _, feed := range feeds {
+			natsConsumerConfig := natsMessaging.ConsumerConfig{
+				URL:               cfg.NATSURL,
+				StreamConfig:      streamConfig,
+				Durable:           strings.ReplaceAll(feed.listener, ".", "-"),
+				AckWait:           time.Minute,
+				ProcessingTimeout: time.Second * 30,
+				ReconnectDelay:    time.Second * 5,
+				MaxDeliver:        6,
+				RetryBaseDelay:    time.Second,
+			}
+			natsConsumer, err := natsMessaging.NewConsumer(natsConsumerConfig, feed.projector, eventRegistry)
+			if err != nil {
+				return fmt.Errorf("failed to create consumer: %w", err)
+			}
+			subscribers = append(subscribers, natsConsumer)
+		}
+	default:
+		// pub
+		publisherConfig := rabbitmq.RabbitMQConfig{
+			URI:            cfg.RabbitMQURI,
+			Exchange:       eventsExchange,
+			ExchangeType:   "topic",
+			RoutingKey:     "auth.#",
+			Timeout:        5 * time.Second,
+			ReconnectDelay: 5 * time.Second,
+			BufferSize:     1000,
+			Format:         rabbitmq.MessageFormat(cfg.MessageFormat),
+			Source:         cfg.EventSource,
+		}
+		rabbitmqPublisher, err := rabbitmq.NewRabbitMQAdapter(publisherConfig, eventRegistry)
+		if err != nil {
+			return fmt.Errorf("publisher initialization failed: %w", err)
+		}
+		i.eventPublisher = rabbitmqPublisher
+		i.closers = append(i.closers, closer{"rabbitmq pub", func(context.Context) error {
+			return rabbitmqPublisher.Close()
+		}})
+
+		// sub
+		for _, feed := range feeds {
+			rabbitmqConsumer, err := rabbitmq.NewConsumer(
+				consumerConfig(cfg, feed.queue),
+				feed.projector,
+				eventRegistry,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create consumer: %w", err)
+			}
+			subscribers = append(subscribers, rabbitmqConsumer)
+		}
+
+		// dlq tooling, one per feed since every queue dead-letters on its own
+		i.deadLetters = make(map[string]secondary.DeadLetterQueue, len(feeds))
+		for _, feed := range feeds {
+			deadLetters, err := rabbitmq.NewDeadLetterQueue(deadLetterConfig(cfg, feed.queue), eventRegistry)
+			if err != nil {
+				return fmt.Errorf("dead letter queue initialization failed: %w", err)
+			}
+			i.deadLetters[feed.queue] = deadLetters
+			i.closers = append(i.closers, closer{"rabbitmq dlq " + feed.queue, func(context.Context) error {
+				return deadLetters.Close()
+			}})
+		}
+	}
+
+	for n, subscriber := range subscribers {
+		if err := subscriber.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start consumer: %w", err)
+		}
+		i.closers = append(i.closers, closer{cfg.EventTransport + " sub " + feeds[n].listener, func(context.Context) error {
+			return subscriber.Stop()
+		}})
+	}
+
+	return nil
+}
+
+// startWebhookDispatcher starts sending the deliveries the returned
+// dispatcher records as it is fed events.
+func (i *infrastructure) startWebhookDispatcher(ctx context.Context, eventRegistry shared.EventRegistry) (*services.WebhookDispatcher, error) {
+	sender := webhook.NewHTTPSender(&http.Client{Timeout: webhookTimeout})
+	dispatcher := services.NewWebhookDispatcher(i.webhooks, sender, eventRegistry, services.WebhookDispatcherConfig{
+		PollInterval: 5 * time.Second,
+		BatchSize:    50,
+		MaxAttempts:  8,
+		BaseDelay:    10 * time.Second,
+		MaxDelay:     time.Hour,
+		Timeout:      webhookTimeout,
+	})
+	if err := dispatcher.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start webhook dispatcher: %w", err)
+	}
+	i.closers = append(i.closers, closer{"webhook dispatcher", func(context.Context) error {
+		return dispatcher.Stop()
+	}})
+	return dispatcher, nil
+}
+
+// todo improve
+func postgresDSN(cfg *config.Config) string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresHost,
+		cfg.PostgresPort,
+		cfg.PostgresDB,
+	)
+}
+
+func consumerConfig(cfg *config.Config, queue string) rabbitmq.ConsumerConfig {
+	return rabbitmq.ConsumerConfig{
+		URI:               cfg.RabbitMQURI,
+		Exchange:          eventsExchange,
+		ExchangeType:      "topic",
+		Queue:             queue,
+		RoutingKey:        "#",
+		PrefetchCount:     50,
+		Workers:           8,
+		ReconnectDelay:    time.Second * 5,
+		ProcessingTimeout: time.Second * 30,
+		MaxRetries:        5,
+		RetryBaseDelay:    time.Second,
+	}
+}
+
+func deadLetterConfig(cfg *config.Config, queue string) rabbitmq.DeadLetterConfig {
+	return rabbitmq.DeadLetterConfig{
+		URI:   cfg.RabbitMQURI,
+		Queue: queue,
+	}
+}
+
+// discardPublisher is used when the event store itself signals subscribers.
+type discardPublisher struct{}
+
+func (discardPublisher) PublishEvent(context.Context, shared.Event) error {
+	return nil
+}
+
+func (discardPublisher) PublishEvents(context.Context, []shared.Event) error {
+	return nil
+}
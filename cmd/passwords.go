@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ncfex/dcart-auth/internal/config"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+func newPasswordPolicy(cfg *config.Config) (user.PasswordPolicy, error) {
+	policyConfig := user.PasswordPolicyConfig{
+		MinLength:        cfg.PasswordMinLength,
+		MaxLength:        cfg.PasswordMaxLength,
+		DisallowUsername: cfg.PasswordDisallowUsername,
+		MinEntropyBits:   cfg.PasswordMinEntropyBits,
+	}
+	for _, class := range cfg.PasswordRequiredClasses {
+		policyConfig.RequiredClasses = append(policyConfig.RequiredClasses, user.PasswordCharacterClass(class))
+	}
+
+	var dictionary user.PasswordDictionary
+	if cfg.PasswordDictionaryFile != "" {
+		file, err := os.Open(cfg.PasswordDictionaryFile)
+		if err != nil {
+			return user.PasswordPolicy{}, fmt.Errorf("opening password dictionary: %w", err)
+		}
+		defer file.Close()
+
+		passwords, err := user.ReadPasswordSet(file)
+		if err != nil {
+			return user.PasswordPolicy{}, fmt.Errorf("loading password dictionary: %w", err)
+		}
+		dictionary = passwords
+	}
+
+	return user.NewPasswordPolicy(policyConfig, dictionary)
+}
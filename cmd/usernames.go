@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ncfex/dcart-auth/internal/application/command"
+	"github.com/ncfex/dcart-auth/internal/config"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+// runReserveUsernames backfills the reservations table for users registered
+// before it existed, so they can still sign in and keep their usernames.
+// Run it once after migrating and before the new version takes traffic; it
+// only connects to the write database.
+func runReserveUsernames(ctx context.Context, cfg *config.Config, eventRegistry shared.EventRegistry) error {
+	infra := &infrastructure{}
+	if err := infra.connectWriteSide(ctx, cfg, eventRegistry); err != nil {
+		return err
+	}
+	defer func() {
+		for _, err := range infra.close(ctx) {
+			log.Printf("Error during %v", err)
+		}
+	}()
+
+	reserved, err := command.ReserveExistingUsernames(ctx, infra.eventStore)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("reserved %d usernames\n", reserved)
+	return nil
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+const defaultDeadLetterLimit = 100
+
+// deadLetterQueue picks the DLQ of the queue named in the path; each
+// consumer queue has its own.
+func (h *handler) deadLetterQueue(w http.ResponseWriter, r *http.Request) (secondary.DeadLetterQueue, bool) {
+	deadLetters, ok := h.deadLetters[r.PathValue("queue")]
+	if !ok {
+		h.responder.RespondWithError(w, http.StatusNotFound, "Unknown queue", nil)
+		return nil, false
+	}
+	return deadLetters, true
+}
+
+func (h *handler) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	deadLetters, ok := h.deadLetterQueue(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeadLetterLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = parsed
+	}
+
+	messages, err := deadLetters.List(r.Context(), limit)
+	if err != nil {
+		h.responder.RespondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	h.responder.RespondWithJSON(w, http.StatusOK, types.DeadLetterListResponse{Messages: messages})
+}
+
+func (h *handler) replayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	deadLetters, ok := h.deadLetterQueue(w, r)
+	if !ok {
+		return
+	}
+
+	// an empty body replays everything
+	var req types.ReplayDeadLettersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	replayed, err := deadLetters.Replay(r.Context(), req.IDs)
+	if err != nil {
+		h.responder.RespondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	h.responder.RespondWithJSON(w, http.StatusOK, types.DeadLetterCountResponse{Count: replayed})
+}
+
+func (h *handler) purgeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	deadLetters, ok := h.deadLetterQueue(w, r)
+	if !ok {
+		return
+	}
+
+	purged, err := deadLetters.Purge(r.Context())
+	if err != nil {
+		h.responder.RespondWithError(w, http.StatusInternalServerError, err.Error(), err)
+		return
+	}
+
+	h.responder.RespondWithJSON(w, http.StatusOK, types.DeadLetterCountResponse{Count: purged})
+}
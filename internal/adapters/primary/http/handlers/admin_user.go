@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+// listUsers serves GET /admin/users. sort is a field name, prefixed with "-"
+// for descending order; created_from and created_to are RFC 3339 and locked
+// is a boolean.
+func (h *handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := query.ListUsersQuery{
+		UsernamePrefix: params.Get("username"),
+		EmailPrefix:    params.Get("email"),
+		Role:           params.Get("role"),
+		Cursor:         params.Get("cursor"),
+	}
+
+	if raw := params.Get("locked"); raw != "" {
+		locked, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid locked", err)
+			return
+		}
+		q.Locked = &locked
+	}
+
+	sortBy := params.Get("sort")
+	q.SortBy, q.Descending = strings.TrimPrefix(sortBy, "-"), strings.HasPrefix(sortBy, "-")
+
+	if raw := params.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		q.Limit = limit
+	}
+
+	for name, target := range map[string]*time.Time{"created_from": &q.CreatedFrom, "created_to": &q.CreatedTo} {
+		raw := params.Get(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid "+name, err)
+			return
+		}
+		*target = parsed
+	}
+
+	users, err := h.userQueries.ListUsers(r.Context(), q)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, query.ErrInvalidUserQuery) || errors.Is(err, query.ErrInvalidCursor) {
+			status = http.StatusBadRequest
+		}
+		h.responder.RespondWithError(w, status, err.Error(), err)
+		return
+	}
+
+	h.responder.RespondWithJSON(w, http.StatusOK, users)
+}
+
+// listUserLogins serves GET /admin/users/{id}/logins.
+func (h *handler) listUserLogins(w http.ResponseWriter, r *http.Request) {
+	h.respondWithLoginHistory(w, r, r.PathValue("id"))
+}
+
+// respondWithLoginHistory answers with the login history of userID, taking
+// an optional limit from the query string.
+func (h *handler) respondWithLoginHistory(w http.ResponseWriter, r *http.Request, userID string) {
+	q := query.GetLoginHistoryQuery{UserID: userID}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		q.Limit = limit
+	}
+
+	history, err := h.userQueries.GetLoginHistory(r.Context(), q)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, query.ErrInvalidUserQuery) {
+			status = http.StatusBadRequest
+		}
+		h.responder.RespondWithError(w, status, err.Error(), err)
+		return
+	}
+
+	h.responder.RespondWithJSON(w, http.StatusOK, history)
+}
+
+// lockUser serves POST /admin/users/{id}/lock.
+func (h *handler) lockUser(w http.ResponseWriter, r *http.Request) {
+	userResponse, err := h.userAdmin.LockUser(r.Context(), r.PathValue("id"))
+	h.respondWithAdminChange(w, userResponse, err)
+}
+
+// unlockUser serves DELETE /admin/users/{id}/lock.
+func (h *handler) unlockUser(w http.ResponseWriter, r *http.Request) {
+	userResponse, err := h.userAdmin.UnlockUser(r.Context(), r.PathValue("id"))
+	h.respondWithAdminChange(w, userResponse, err)
+}
+
+// assignRole serves PUT /admin/users/{id}/role.
+func (h *handler) assignRole(w http.ResponseWriter, r *http.Request) {
+	var req types.AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userResponse, err := h.userAdmin.AssignRole(r.Context(), r.PathValue("id"), req)
+	h.respondWithAdminChange(w, userResponse, err)
+}
+
+// respondWithAdminChange answers with the changed user, or maps the error of
+// an admin command to its status.
+func (h *handler) respondWithAdminChange(w http.ResponseWriter, userResponse *types.UserResponse, err error) {
+	if err != nil {
+		status := commandErrorStatus(err, http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, userDomain.ErrInvalidRole):
+			status = http.StatusBadRequest
+		case errors.Is(err, userDomain.ErrUserNotFound), errors.Is(err, userDomain.ErrUserErased):
+			status = http.StatusNotFound
+		}
+		h.responder.RespondWithError(w, status, err.Error(), err)
+		return
+	}
+
+	setVersionHeader(w, userResponse.Version)
+	h.responder.RespondWithJSON(w, http.StatusOK, userResponse)
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
 	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
 	"github.com/ncfex/dcart-auth/pkg/httputil/request"
 )
 
@@ -17,10 +21,14 @@ func (h *handler) register(w http.ResponseWriter, r *http.Request) {
 
 	userResponse, err := h.authenticationService.Register(r.Context(), req)
 	if err != nil {
-		h.responder.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		if h.respondWithValidationError(w, err) {
+			return
+		}
+		h.responder.RespondWithError(w, commandErrorStatus(err, http.StatusBadRequest), err.Error(), err)
 		return
 	}
 
+	setVersionHeader(w, userResponse.Version)
 	h.responder.RespondWithJSON(w, http.StatusCreated, userResponse)
 }
 
@@ -33,7 +41,11 @@ func (h *handler) login(w http.ResponseWriter, r *http.Request) {
 
 	tokenPairResponse, err := h.authenticationService.Login(r.Context(), req)
 	if err != nil {
-		h.responder.RespondWithError(w, http.StatusUnauthorized, err.Error(), err)
+		status := commandErrorStatus(err, http.StatusUnauthorized)
+		if errors.Is(err, userDomain.ErrUserLocked) {
+			status = http.StatusForbidden
+		}
+		h.responder.RespondWithError(w, status, err.Error(), err)
 		return
 	}
 
@@ -47,14 +59,73 @@ func (h *handler) changePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.authenticationService.ChangePassword(r.Context(), req); err != nil {
-		h.responder.RespondWithError(w, http.StatusUnauthorized, err.Error(), err)
+	version, err := h.authenticationService.ChangePassword(r.Context(), req)
+	if err != nil {
+		if h.respondWithValidationError(w, err) {
+			return
+		}
+		h.responder.RespondWithError(w, commandErrorStatus(err, http.StatusUnauthorized), err.Error(), err)
 		return
 	}
 
+	setVersionHeader(w, version)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *handler) changeUsername(w http.ResponseWriter, r *http.Request) {
+	var req types.ChangeUsernameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userResponse, err := h.authenticationService.ChangeUsername(r.Context(), req)
+	if err != nil {
+		if h.respondWithValidationError(w, err) {
+			return
+		}
+		status := commandErrorStatus(err, http.StatusBadRequest)
+		switch {
+		case errors.Is(err, userDomain.ErrUsernameTaken):
+			status = http.StatusConflict
+		case errors.Is(err, userDomain.ErrUserNotFound), errors.Is(err, userDomain.ErrUserErased):
+			status = http.StatusNotFound
+		}
+		h.responder.RespondWithError(w, status, err.Error(), err)
+		return
+	}
+
+	setVersionHeader(w, userResponse.Version)
+	h.responder.RespondWithJSON(w, http.StatusOK, userResponse)
+}
+
+func (h *handler) changeEmail(w http.ResponseWriter, r *http.Request) {
+	var req types.ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userResponse, err := h.authenticationService.ChangeEmail(r.Context(), req)
+	if err != nil {
+		if h.respondWithValidationError(w, err) {
+			return
+		}
+		status := commandErrorStatus(err, http.StatusBadRequest)
+		switch {
+		case errors.Is(err, userDomain.ErrEmailTaken):
+			status = http.StatusConflict
+		case errors.Is(err, userDomain.ErrUserNotFound), errors.Is(err, userDomain.ErrUserErased):
+			status = http.StatusNotFound
+		}
+		h.responder.RespondWithError(w, status, err.Error(), err)
+		return
+	}
+
+	setVersionHeader(w, userResponse.Version)
+	h.responder.RespondWithJSON(w, http.StatusOK, userResponse)
+}
+
 func (h *handler) logout(w http.ResponseWriter, r *http.Request) {
 	refreshToken, err := request.GetBearerToken(r.Header)
 	if err != nil {
@@ -72,3 +143,35 @@ func (h *handler) logout(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// commandErrorStatus maps a command that kept losing optimistic concurrency
+// races to 409 so clients know a plain retry may succeed.
+func commandErrorStatus(err error, fallback int) int {
+	if errors.Is(err, secondary.ErrConcurrencyConflict) {
+		return http.StatusConflict
+	}
+	return fallback
+}
+
+// respondWithValidationError answers 400 with every rejected field when err
+// is a *shared.ValidationError and reports whether it did.
+func (h *handler) respondWithValidationError(w http.ResponseWriter, err error) bool {
+	var validationErr *shared.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	fields := make([]types.FieldErrorResponse, 0, len(validationErr.Fields))
+	for _, field := range validationErr.Fields {
+		fields = append(fields, types.FieldErrorResponse{
+			Field:   field.Field,
+			Code:    field.Code,
+			Message: field.Message,
+		})
+	}
+	h.responder.RespondWithJSON(w, http.StatusBadRequest, types.ValidationErrorResponse{
+		Error:  shared.ErrValidation.Error(),
+		Fields: fields,
+	})
+	return true
+}
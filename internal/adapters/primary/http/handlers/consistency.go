@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// versionHeader carries the aggregate version a command committed
+	versionHeader = "X-Aggregate-Version"
+	// minVersionHeader echoes it back on a later request that must observe
+	// that write
+	minVersionHeader = "X-Min-Version"
+)
+
+func setVersionHeader(w http.ResponseWriter, version int) {
+	if version > 0 {
+		w.Header().Set(versionHeader, strconv.Itoa(version))
+	}
+}
+
+func minVersionFromHeader(header http.Header) (int, error) {
+	raw := header.Get(minVersionHeader)
+	if raw == "" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid %s header", minVersionHeader)
+	}
+	return version, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/ncfex/dcart-auth/internal/adapters/primary/http/middlewares"
 
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
 	"github.com/ncfex/dcart-auth/internal/application/ports/primary/services"
 	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
 	"github.com/ncfex/dcart-auth/internal/application/ports/security"
@@ -18,41 +19,59 @@ type handler struct {
 	logger                *log.Logger
 	responder             response.Responder
 	authenticationService services.AuthenticationService
+	userAdmin             services.UserAdminService
+	userQueries           query.UserQueryPort
 	tokenManager          security.TokenGeneratorValidator
 	tokenRepo             secondary.TokenRepository
 	eventStore            secondary.EventStore
+	deadLetters           map[string]secondary.DeadLetterQueue
+	webhooks              services.WebhookService
+	adminToken            string
 }
 
 func NewHandler(
 	logger *log.Logger,
 	responder response.Responder,
 	authenticationService services.AuthenticationService,
+	userAdmin services.UserAdminService,
+	userQueries query.UserQueryPort,
 	tokenManager security.TokenGeneratorValidator,
 	tokenRepo secondary.TokenRepository,
 	eventStore secondary.EventStore,
+	deadLetters map[string]secondary.DeadLetterQueue,
+	webhooks services.WebhookService,
+	adminToken string,
 ) *handler {
 	return &handler{
 		logger:                logger,
 		authenticationService: authenticationService,
+		userAdmin:             userAdmin,
+		userQueries:           userQueries,
 		responder:             responder,
 		tokenManager:          tokenManager,
 		tokenRepo:             tokenRepo,
 		eventStore:            eventStore,
+		deadLetters:           deadLetters,
+		webhooks:              webhooks,
+		adminToken:            adminToken,
 	}
 }
 
 func (h *handler) RegisterRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 
+	requestContextMiddleware := middleware.RequestContext()
 	loggingMiddleware := middleware.Logging(h.logger)
 	recoveryMiddleware := middleware.Recovery(h.responder, h.logger)
 
 	publicChain := middleware.Chain(
+		requestContextMiddleware,
 		loggingMiddleware,
 		recoveryMiddleware,
 	)
 
 	refreshTokenRequiredChain := middleware.Chain(
+		requestContextMiddleware,
 		middlewares.RequireRefreshToken(
 			h.tokenRepo,
 			h.responder,
@@ -62,6 +81,7 @@ func (h *handler) RegisterRoutes() *http.ServeMux {
 	)
 
 	accessTokenProtectedChain := middleware.Chain(
+		requestContextMiddleware,
 		middlewares.RequireJWTAuth(
 			h.tokenManager,
 			h.responder,
@@ -70,18 +90,55 @@ func (h *handler) RegisterRoutes() *http.ServeMux {
 		recoveryMiddleware,
 	)
 
+	adminChain := middleware.Chain(
+		requestContextMiddleware,
+		middlewares.RequireAdminToken(
+			h.adminToken,
+			h.responder,
+		),
+		loggingMiddleware,
+		recoveryMiddleware,
+	)
+
 	// public
 	mux.Handle("POST /register", publicChain(http.HandlerFunc(h.register)))
 	mux.Handle("POST /login", publicChain(http.HandlerFunc(h.login)))
 
 	// protected
 	mux.Handle("GET /profile", accessTokenProtectedChain(http.HandlerFunc(h.profile)))
+	mux.Handle("GET /me", accessTokenProtectedChain(http.HandlerFunc(h.profile)))
+	mux.Handle("PATCH /me", accessTokenProtectedChain(http.HandlerFunc(h.updateProfile)))
 	mux.Handle("POST /validate", accessTokenProtectedChain(http.HandlerFunc(h.validateToken)))
 	mux.Handle("PUT /password", accessTokenProtectedChain(http.HandlerFunc(h.changePassword)))
+	mux.Handle("PUT /username", accessTokenProtectedChain(http.HandlerFunc(h.changeUsername)))
+	mux.Handle("PUT /email", accessTokenProtectedChain(http.HandlerFunc(h.changeEmail)))
+	mux.Handle("DELETE /me", accessTokenProtectedChain(http.HandlerFunc(h.eraseMe)))
+	mux.Handle("GET /me/logins", accessTokenProtectedChain(http.HandlerFunc(h.myLogins)))
 
 	// refresh required
 	mux.Handle("POST /refresh", refreshTokenRequiredChain(http.HandlerFunc(h.refreshToken)))
 	mux.Handle("POST /logout", refreshTokenRequiredChain(http.HandlerFunc(h.logout)))
 
+	// admin
+	mux.Handle("GET /admin/users", adminChain(http.HandlerFunc(h.listUsers)))
+	mux.Handle("GET /admin/users/{id}/logins", adminChain(http.HandlerFunc(h.listUserLogins)))
+	mux.Handle("POST /admin/users/{id}/lock", adminChain(http.HandlerFunc(h.lockUser)))
+	mux.Handle("DELETE /admin/users/{id}/lock", adminChain(http.HandlerFunc(h.unlockUser)))
+	mux.Handle("PUT /admin/users/{id}/role", adminChain(http.HandlerFunc(h.assignRole)))
+
+	// the dlq only exists with the rabbitmq transport
+	if len(h.deadLetters) > 0 {
+		mux.Handle("GET /admin/dlq/{queue}", adminChain(http.HandlerFunc(h.listDeadLetters)))
+		mux.Handle("POST /admin/dlq/{queue}/replay", adminChain(http.HandlerFunc(h.replayDeadLetters)))
+		mux.Handle("DELETE /admin/dlq/{queue}", adminChain(http.HandlerFunc(h.purgeDeadLetters)))
+	}
+	if h.webhooks != nil {
+		mux.Handle("POST /admin/webhooks", adminChain(http.HandlerFunc(h.createWebhook)))
+		mux.Handle("GET /admin/webhooks", adminChain(http.HandlerFunc(h.listWebhooks)))
+		mux.Handle("DELETE /admin/webhooks/{id}", adminChain(http.HandlerFunc(h.deleteWebhook)))
+		mux.Handle("GET /admin/webhooks/{id}/deliveries", adminChain(http.HandlerFunc(h.listWebhookDeliveries)))
+		mux.Handle("POST /admin/webhooks/deliveries/{id}/redeliver", adminChain(http.HandlerFunc(h.redeliverWebhook)))
+	}
+
 	return mux
 }
@@ -14,8 +14,15 @@ func (h *handler) refreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	minVersion, err := minVersionFromHeader(r.Header)
+	if err != nil {
+		h.responder.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
 	tokenPairResponse, err := h.authenticationService.Refresh(r.Context(), types.TokenRequest{
-		Token: refreshToken,
+		Token:      refreshToken,
+		MinVersion: minVersion,
 	})
 	if err != nil {
 		h.responder.RespondWithError(w, http.StatusUnauthorized, "not authorized", err)
@@ -32,8 +39,15 @@ func (h *handler) validateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	minVersion, err := minVersionFromHeader(r.Header)
+	if err != nil {
+		h.responder.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
 	validateResponse, err := h.authenticationService.Validate(r.Context(), types.TokenRequest{
-		Token: accessToken,
+		Token:      accessToken,
+		MinVersion: minVersion,
 	})
 	if err != nil {
 		h.responder.RespondWithError(w, http.StatusUnauthorized, "unauthorized", err)
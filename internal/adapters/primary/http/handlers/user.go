@@ -1,26 +1,83 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
-	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
 	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
 	"github.com/ncfex/dcart-auth/pkg/httputil/request"
 )
 
-// todo improve
+// profile serves GET /me, honouring X-Min-Version so a client sees its own
+// profile update.
 func (h *handler) profile(w http.ResponseWriter, r *http.Request) {
-	type response struct {
-		User userDomain.User `json:"user"`
+	minVersion, err := minVersionFromHeader(r.Header)
+	if err != nil {
+		h.responder.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	profile, err := h.authenticationService.Profile(r.Context(), minVersion)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, userDomain.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		h.responder.RespondWithError(w, status, err.Error(), err)
+		return
+	}
+
+	setVersionHeader(w, profile.Version)
+	h.responder.RespondWithJSON(w, http.StatusOK, profile)
+}
+
+// updateProfile serves PATCH /me; fields left out of the body are kept.
+func (h *handler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	var req types.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid request", err)
+		return
 	}
 
-	userID, exists := request.GetDataFromContext[string](r.Context(), request.ContextUserKey)
-	if !exists {
-		h.responder.RespondWithError(w, http.StatusNotFound, userDomain.ErrUserNotFound.Error(), userDomain.ErrUserNotFound)
+	profile, err := h.authenticationService.UpdateProfile(r.Context(), req)
+	if err != nil {
+		status := commandErrorStatus(err, http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, userDomain.ErrInvalidProfile):
+			status = http.StatusBadRequest
+		case errors.Is(err, userDomain.ErrUserNotFound), errors.Is(err, userDomain.ErrUserErased):
+			status = http.StatusNotFound
+		}
+		h.responder.RespondWithError(w, status, err.Error(), err)
+		return
+	}
+
+	setVersionHeader(w, profile.Version)
+	h.responder.RespondWithJSON(w, http.StatusOK, profile)
+}
+
+func (h *handler) eraseMe(w http.ResponseWriter, r *http.Request) {
+	if err := h.authenticationService.Erase(r.Context()); err != nil {
+		status := commandErrorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, userDomain.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		h.responder.RespondWithError(w, status, err.Error(), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// myLogins serves GET /me/logins, the caller's own login history.
+func (h *handler) myLogins(w http.ResponseWriter, r *http.Request) {
+	userID := request.GetStringFromContext(r.Context(), request.ContextUserKey)
+	if userID == "" {
+		h.responder.RespondWithError(w, http.StatusUnauthorized, "unauthorized", userDomain.ErrUserNotFound)
 		return
 	}
 
-	h.responder.RespondWithJSON(w, http.StatusOK, response{
-		User: userDomain.User{BaseAggregateRoot: shared.BaseAggregateRoot{ID: *userID}},
-	})
+	h.respondWithLoginHistory(w, r, userID)
 }
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/services"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+func (h *handler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var req types.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	subscription, err := h.webhooks.CreateSubscription(r.Context(), req)
+	if err != nil {
+		h.responder.RespondWithError(w, webhookErrorStatus(err), err.Error(), err)
+		return
+	}
+
+	h.responder.RespondWithJSON(w, http.StatusCreated, subscription)
+}
+
+func (h *handler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.webhooks.ListSubscriptions(r.Context())
+	if err != nil {
+		h.responder.RespondWithError(w, webhookErrorStatus(err), err.Error(), err)
+		return
+	}
+
+	h.responder.RespondWithJSON(w, http.StatusOK, types.WebhookListResponse{Subscriptions: subscriptions})
+}
+
+func (h *handler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.webhooks.DeleteSubscription(r.Context(), r.PathValue("id")); err != nil {
+		h.responder.RespondWithError(w, webhookErrorStatus(err), err.Error(), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			h.responder.RespondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.webhooks.ListDeliveries(r.Context(), r.PathValue("id"), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		h.responder.RespondWithError(w, webhookErrorStatus(err), err.Error(), err)
+		return
+	}
+
+	h.responder.RespondWithJSON(w, http.StatusOK, types.WebhookDeliveryListResponse{Deliveries: deliveries})
+}
+
+func (h *handler) redeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.webhooks.Redeliver(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.responder.RespondWithError(w, webhookErrorStatus(err), err.Error(), err)
+		return
+	}
+
+	h.responder.RespondWithJSON(w, http.StatusAccepted, delivery)
+}
+
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebhook):
+		return http.StatusBadRequest
+	case errors.Is(err, secondary.ErrWebhookNotFound), errors.Is(err, secondary.ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/ncfex/dcart-auth/pkg/httputil/request"
+	"github.com/ncfex/dcart-auth/pkg/httputil/response"
+	"github.com/ncfex/dcart-auth/pkg/middleware"
+)
+
+var errAdminDisabled = errors.New("admin token not configured")
+
+// RequireAdminToken guards operator endpoints with a static bearer token.
+// With no token configured every request is rejected.
+func RequireAdminToken(
+	adminToken string,
+	responder response.Responder,
+) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if adminToken == "" {
+				responder.RespondWithError(w, http.StatusForbidden, "Forbidden: admin endpoints disabled", errAdminDisabled)
+				return
+			}
+
+			token, err := request.GetBearerToken(r.Header)
+			if err != nil {
+				responder.RespondWithError(w, http.StatusUnauthorized, "Unauthorized: missing or invalid token", err)
+				return
+			}
+
+			if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+				responder.RespondWithError(w, http.StatusUnauthorized, "Unauthorized: invalid admin token", errors.New("admin token mismatch"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
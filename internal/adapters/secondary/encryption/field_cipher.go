@@ -0,0 +1,280 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+const (
+	keySize = 32 // AES-256
+
+	// ciphertextPrefix marks an encrypted field value so payloads written
+	// before encryption was introduced still load as plaintext.
+	ciphertextPrefix = "enc:v1:"
+)
+
+// FieldCipher encrypts the personal data fields the registry declares for an
+// event type with AES-256-GCM, under a data key per aggregate. Each field
+// is bound to its aggregate and name, so ciphertexts cannot be swapped
+// between fields or users.
+type FieldCipher struct {
+	keys     secondary.DataKeyRepository
+	registry shared.EventRegistry
+}
+
+func NewFieldCipher(keys secondary.DataKeyRepository, registry shared.EventRegistry) *FieldCipher {
+	return &FieldCipher{
+		keys:     keys,
+		registry: registry,
+	}
+}
+
+func (c *FieldCipher) EncryptPayload(ctx context.Context, aggregateID string, eventType shared.EventType, payload json.RawMessage) (json.RawMessage, error) {
+	fields := c.registry.PersonalDataFields(eventType)
+	if len(fields) == 0 {
+		return payload, nil
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &document); err != nil {
+		return nil, fmt.Errorf("unmarshal payload: %w", err)
+	}
+
+	key, err := c.subjectKey(ctx, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		value, exists := document[field]
+		if !exists {
+			continue
+		}
+
+		sealed, err := seal(aead, value, additionalData(aggregateID, field))
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := json.Marshal(sealed)
+		if err != nil {
+			return nil, fmt.Errorf("marshal encrypted field %s: %w", field, err)
+		}
+		document[field] = encrypted
+	}
+
+	return json.Marshal(document)
+}
+
+func (c *FieldCipher) DecryptPayload(ctx context.Context, aggregateID string, eventType shared.EventType, payload json.RawMessage) (json.RawMessage, error) {
+	fields := c.registry.PersonalDataFields(eventType)
+	if len(fields) == 0 {
+		return payload, nil
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &document); err != nil {
+		return nil, fmt.Errorf("unmarshal payload: %w", err)
+	}
+
+	var aead cipher.AEAD
+	shredded := false
+	for _, field := range fields {
+		var value string
+		if err := json.Unmarshal(document[field], &value); err != nil || !strings.HasPrefix(value, ciphertextPrefix) {
+			continue
+		}
+
+		if aead == nil && !shredded {
+			var err error
+			if aead, shredded, err = c.existingAEAD(ctx, aggregateID); err != nil {
+				return nil, err
+			}
+		}
+		if shredded {
+			document[field] = json.RawMessage("null")
+			continue
+		}
+
+		plaintext, err := open(aead, value, additionalData(aggregateID, field))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt field %s: %w", field, err)
+		}
+		document[field] = plaintext
+	}
+
+	return json.Marshal(document)
+}
+
+// EncryptMetadata returns a copy of metadata with the values of
+// shared.PersonalMetadata encrypted under the data key of aggregateID.
+func (c *FieldCipher) EncryptMetadata(ctx context.Context, aggregateID string, metadata shared.Metadata) (shared.Metadata, error) {
+	if !hasPersonalMetadata(metadata) {
+		return metadata, nil
+	}
+
+	key, err := c.subjectKey(ctx, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := make(shared.Metadata, len(metadata))
+	for name, value := range metadata {
+		encrypted[name] = value
+	}
+	for _, name := range shared.PersonalMetadata {
+		value, exists := metadata[name]
+		if !exists {
+			continue
+		}
+		if encrypted[name], err = seal(aead, []byte(value), metadataAdditionalData(aggregateID, name)); err != nil {
+			return nil, err
+		}
+	}
+	return encrypted, nil
+}
+
+// DecryptMetadata returns a copy of metadata with its personal values
+// decrypted, or without them once the data key of aggregateID is deleted.
+func (c *FieldCipher) DecryptMetadata(ctx context.Context, aggregateID string, metadata shared.Metadata) (shared.Metadata, error) {
+	if !hasPersonalMetadata(metadata) {
+		return metadata, nil
+	}
+
+	decrypted := make(shared.Metadata, len(metadata))
+	for name, value := range metadata {
+		decrypted[name] = value
+	}
+
+	var aead cipher.AEAD
+	shredded := false
+	for _, name := range shared.PersonalMetadata {
+		value, exists := metadata[name]
+		if !exists || !strings.HasPrefix(value, ciphertextPrefix) {
+			continue
+		}
+
+		if aead == nil && !shredded {
+			var err error
+			if aead, shredded, err = c.existingAEAD(ctx, aggregateID); err != nil {
+				return nil, err
+			}
+		}
+		if shredded {
+			delete(decrypted, name)
+			continue
+		}
+
+		plaintext, err := open(aead, value, metadataAdditionalData(aggregateID, name))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt metadata %s: %w", name, err)
+		}
+		decrypted[name] = string(plaintext)
+	}
+	return decrypted, nil
+}
+
+// existingAEAD opens the data key of aggregateID without creating it,
+// reporting shredded when the key has been deleted.
+func (c *FieldCipher) existingAEAD(ctx context.Context, aggregateID string) (aead cipher.AEAD, shredded bool, err error) {
+	key, err := c.keys.GetKey(ctx, aggregateID)
+	switch {
+	case errors.Is(err, secondary.ErrDataKeyNotFound):
+		return nil, true, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("get data key: %w", err)
+	}
+	aead, err = newAEAD(key)
+	return aead, false, err
+}
+
+// subjectKey returns the data key of aggregateID, creating it on first use.
+func (c *FieldCipher) subjectKey(ctx context.Context, aggregateID string) ([]byte, error) {
+	key, err := c.keys.GetKey(ctx, aggregateID)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, secondary.ErrDataKeyNotFound) {
+		return nil, fmt.Errorf("get data key: %w", err)
+	}
+
+	key = make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+
+	err = c.keys.CreateKey(ctx, aggregateID, key)
+	if errors.Is(err, secondary.ErrDataKeyExists) {
+		// another writer created it first
+		return c.keys.GetKey(ctx, aggregateID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create data key: %w", err)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a fresh nonce into a prefixed, base64 encoded
+// value.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return ciphertextPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open reverses seal.
+func open(aead cipher.AEAD, value string, additionalData []byte) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, ciphertextPrefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, errors.New("malformed ciphertext")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func hasPersonalMetadata(metadata shared.Metadata) bool {
+	for _, name := range shared.PersonalMetadata {
+		if _, exists := metadata[name]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+func additionalData(aggregateID, field string) []byte {
+	return []byte(aggregateID + "/" + field)
+}
+
+// metadataAdditionalData keeps metadata ciphertexts from being swapped with
+// payload fields of the same name.
+func metadataAdditionalData(aggregateID, name string) []byte {
+	return []byte(aggregateID + "/metadata/" + name)
+}
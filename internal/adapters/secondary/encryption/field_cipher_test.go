@@ -0,0 +1,128 @@
+package encryption
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/memory"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+func newTestCipher() *FieldCipher {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	return NewFieldCipher(memory.NewDataKeyRepository(), registry)
+}
+
+func TestFieldCipher_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	cipher := newTestCipher()
+
+	payload, _ := json.Marshal(user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+	encrypted, err := cipher.EncryptPayload(ctx, "user-1", user.EventTypeUserRegistered, payload)
+	if err != nil {
+		t.Fatalf("EncryptPayload() error = %v", err)
+	}
+	if strings.Contains(string(encrypted), "alice") {
+		t.Errorf("EncryptPayload() = %s, expected username to be encrypted", encrypted)
+	}
+	if !strings.Contains(string(encrypted), `"aggregate_id":"user-1"`) {
+		t.Errorf("EncryptPayload() = %s, expected other fields to stay readable", encrypted)
+	}
+
+	decrypted, err := cipher.DecryptPayload(ctx, "user-1", user.EventTypeUserRegistered, encrypted)
+	if err != nil {
+		t.Fatalf("DecryptPayload() error = %v", err)
+	}
+	var event user.UserRegisteredEvent
+	if err := json.Unmarshal(decrypted, &event); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if event.Username != "alice" || event.PasswordHash != "hash" {
+		t.Errorf("DecryptPayload() = %+v, expected original fields", event)
+	}
+}
+
+func TestFieldCipher_PassesPlaintextThrough(t *testing.T) {
+	cipher := newTestCipher()
+
+	// payloads stored before encryption was introduced
+	payload, _ := json.Marshal(user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+	decrypted, err := cipher.DecryptPayload(context.Background(), "user-1", user.EventTypeUserRegistered, payload)
+	if err != nil {
+		t.Fatalf("DecryptPayload() error = %v", err)
+	}
+	if !strings.Contains(string(decrypted), "alice") {
+		t.Errorf("DecryptPayload() = %s, expected plaintext to pass through", decrypted)
+	}
+}
+
+func TestFieldCipher_BindsCiphertextToAggregate(t *testing.T) {
+	ctx := context.Background()
+	cipher := newTestCipher()
+
+	payload, _ := json.Marshal(user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+	encrypted, err := cipher.EncryptPayload(ctx, "user-1", user.EventTypeUserRegistered, payload)
+	if err != nil {
+		t.Fatalf("EncryptPayload() error = %v", err)
+	}
+	if _, err := cipher.EncryptPayload(ctx, "user-2", user.EventTypeUserRegistered, payload); err != nil {
+		t.Fatalf("EncryptPayload() error = %v", err)
+	}
+
+	if _, err := cipher.DecryptPayload(ctx, "user-2", user.EventTypeUserRegistered, encrypted); err == nil {
+		t.Error("DecryptPayload() expected error for a ciphertext of another aggregate")
+	}
+}
+
+func TestFieldCipher_Metadata(t *testing.T) {
+	ctx := context.Background()
+	keys := memory.NewDataKeyRepository()
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	cipher := NewFieldCipher(keys, registry)
+
+	metadata := shared.Metadata{
+		shared.MetadataCorrelationID: "req-1",
+		shared.MetadataClientIP:      "10.0.0.1",
+		shared.MetadataUserAgent:     "curl/8.0",
+	}
+	encrypted, err := cipher.EncryptMetadata(ctx, "user-1", metadata)
+	if err != nil {
+		t.Fatalf("EncryptMetadata() error = %v", err)
+	}
+	if metadata[shared.MetadataClientIP] != "10.0.0.1" {
+		t.Errorf("EncryptMetadata() changed its input: %v", metadata)
+	}
+	if !strings.HasPrefix(encrypted[shared.MetadataClientIP], ciphertextPrefix) ||
+		!strings.HasPrefix(encrypted[shared.MetadataUserAgent], ciphertextPrefix) {
+		t.Errorf("EncryptMetadata() = %v, expected client details to be encrypted", encrypted)
+	}
+	if encrypted[shared.MetadataCorrelationID] != "req-1" {
+		t.Errorf("EncryptMetadata() = %v, expected other metadata to stay readable", encrypted)
+	}
+
+	decrypted, err := cipher.DecryptMetadata(ctx, "user-1", encrypted)
+	if err != nil {
+		t.Fatalf("DecryptMetadata() error = %v", err)
+	}
+	for key, expected := range metadata {
+		if decrypted[key] != expected {
+			t.Errorf("DecryptMetadata()[%s] = %s, expected %s", key, decrypted[key], expected)
+		}
+	}
+
+	if err := keys.DeleteKey(ctx, "user-1"); err != nil {
+		t.Fatalf("DeleteKey() error = %v", err)
+	}
+	shredded, err := cipher.DecryptMetadata(ctx, "user-1", encrypted)
+	if err != nil {
+		t.Fatalf("DecryptMetadata() error = %v", err)
+	}
+	if len(shredded) != 1 || shredded[shared.MetadataCorrelationID] != "req-1" {
+		t.Errorf("DecryptMetadata() = %v, expected only the correlation id once shredded", shredded)
+	}
+}
@@ -0,0 +1,19 @@
+package id
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/id"
+)
+
+// uuidV7Generator hands out UUIDv7s, which sort by creation time and so
+// keep index inserts append-only.
+type uuidV7Generator struct{}
+
+func NewUUIDv7Generator() id.UniqueIDGenerator {
+	return uuidV7Generator{}
+}
+
+func (uuidV7Generator) Generate() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
@@ -0,0 +1,206 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+type ConsumerConfig struct {
+	Brokers           []string
+	Topic             string
+	GroupID           string
+	ProcessingTimeout time.Duration
+	// a failed message is retried in place MaxRetries times, waiting
+	// RetryBaseDelay and doubling the wait each attempt, before it is written
+	// to the dead letter topic, Topic+".dlq"
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+}
+
+// Consumer projects the messages of its consumer group one at a time, and
+// commits each offset only once the message is projected or dead-lettered.
+// Retrying in place holds up the partition, but keeps a user's events in
+// order; more partitions and instances are the way to more throughput.
+type Consumer struct {
+	config      ConsumerConfig
+	reader      messageReader
+	deadLetters messageWriter
+	cancel      context.CancelFunc
+	done        chan struct{}
+
+	// app
+	projector     secondary.EventProjector
+	eventRegistry shared.EventRegistry
+}
+
+func NewConsumer(config ConsumerConfig, projector secondary.EventProjector, eventRegistry shared.EventRegistry) (*Consumer, error) {
+	readerConfig := kafkago.ReaderConfig{
+		Brokers:     config.Brokers,
+		GroupID:     config.GroupID,
+		Topic:       config.Topic,
+		StartOffset: kafkago.FirstOffset,
+		// commit synchronously, see Consumer
+		CommitInterval: 0,
+	}
+	if err := readerConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("consumer initialization failed: %w", err)
+	}
+
+	reader := kafkago.NewReader(readerConfig)
+	deadLetters := newWriter(config.Brokers, deadLetterTopic(config.Topic), config.ProcessingTimeout)
+	return newConsumer(config, reader, deadLetters, projector, eventRegistry), nil
+}
+
+func newConsumer(
+	config ConsumerConfig,
+	reader messageReader,
+	deadLetters messageWriter,
+	projector secondary.EventProjector,
+	eventRegistry shared.EventRegistry,
+) *Consumer {
+	return &Consumer{
+		config:        config,
+		reader:        reader,
+		deadLetters:   deadLetters,
+		done:          make(chan struct{}),
+		projector:     projector,
+		eventRegistry: eventRegistry,
+	}
+}
+
+func (c *Consumer) Start(ctx context.Context) error {
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.consume(ctx)
+	return nil
+}
+
+// Stop waits for the message in flight. A message still being retried is
+// left uncommitted and goes to whichever member next owns its partition.
+func (c *Consumer) Stop() error {
+	if c.cancel != nil {
+		c.cancel()
+		<-c.done
+	}
+	return errors.Join(c.reader.Close(), c.deadLetters.Close())
+}
+
+func (c *Consumer) consume(ctx context.Context) {
+	defer close(c.done)
+
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("failed to fetch message: %v", err)
+			if !sleep(ctx, c.config.RetryBaseDelay) {
+				return
+			}
+			continue
+		}
+
+		if !c.process(ctx, msg) {
+			return
+		}
+
+		// the offset is committed even when stopping, the message is done
+		if err := c.reader.CommitMessages(context.WithoutCancel(ctx), msg); err != nil {
+			// it will be projected again after the next rebalance
+			log.Printf("failed to commit offset %d of partition %d: %v", msg.Offset, msg.Partition, err)
+		}
+	}
+}
+
+// process reports whether msg is done with, either projected or parked on
+// the dead letter topic; it is not when the consumer stops first.
+func (c *Consumer) process(ctx context.Context, msg kafkago.Message) bool {
+	// malformed messages would fail every retry, so they skip straight to the DLQ
+	event, err := decodeMessage(msg, c.eventRegistry)
+	if err != nil {
+		return c.deadLetter(ctx, msg, fmt.Errorf("invalid message format: %w", err))
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.project(ctx, event)
+		if err == nil {
+			return true
+		}
+		if attempt > c.config.MaxRetries {
+			return c.deadLetter(ctx, msg, fmt.Errorf("projection failed: %w", err))
+		}
+
+		log.Printf("retrying message %d of partition %d, attempt %d: %v", msg.Offset, msg.Partition, attempt, err)
+		if !sleep(ctx, retryDelay(c.config.RetryBaseDelay, attempt)) {
+			return false
+		}
+	}
+}
+
+// project finishes an attempt in flight rather than abandon it on shutdown.
+func (c *Consumer) project(ctx context.Context, event shared.Event) error {
+	processCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.config.ProcessingTimeout)
+	defer cancel()
+
+	return c.projector.ProjectEvent(processCtx, event)
+}
+
+// deadLetter writes msg to the dead letter topic with the reason it failed,
+// retrying until it is written or the consumer stops.
+func (c *Consumer) deadLetter(ctx context.Context, msg kafkago.Message, reason error) bool {
+	log.Printf("dead-lettering message %d of partition %d: %v", msg.Offset, msg.Partition, reason)
+
+	parked := kafkago.Message{
+		Key:   msg.Key,
+		Value: msg.Value,
+		Time:  msg.Time,
+		Headers: append(append([]kafkago.Header(nil), msg.Headers...),
+			kafkago.Header{Key: headerLastError, Value: []byte(reason.Error())},
+			kafkago.Header{Key: headerOriginalTopic, Value: []byte(msg.Topic)},
+			kafkago.Header{Key: headerOriginalPosition, Value: []byte(strconv.Itoa(msg.Partition) + ":" + strconv.FormatInt(msg.Offset, 10))},
+		),
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.deadLetters.WriteMessages(ctx, parked)
+		if err == nil {
+			return true
+		}
+
+		log.Printf("failed to dead-letter message, attempt %d: %v", attempt, err)
+		if !sleep(ctx, c.config.RetryBaseDelay) {
+			return false
+		}
+	}
+}
+
+func deadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+// retryDelay is the wait before the given retry attempt, doubling from base.
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	return base << (attempt - 1)
+}
+
+// sleep waits for d and reports false if ctx ends first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
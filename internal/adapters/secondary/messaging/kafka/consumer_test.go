@@ -0,0 +1,225 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+const testGroup = "auth.read-model"
+
+// flakyProjector fails the first failures projections, or all of them when
+// failures is negative.
+type flakyProjector struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  int
+	projected []shared.Event
+}
+
+func (p *flakyProjector) ProjectEvent(_ context.Context, event shared.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.attempts++
+	if p.failures < 0 || p.attempts <= p.failures {
+		return errors.New("read model unavailable")
+	}
+	p.projected = append(p.projected, event)
+	return nil
+}
+
+func (p *flakyProjector) counts() (attempts, projected int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.attempts, len(p.projected)
+}
+
+func startTestConsumer(t *testing.T, broker *fakeBroker, projector *flakyProjector, maxRetries int) *Consumer {
+	t.Helper()
+
+	consumer := newConsumer(
+		ConsumerConfig{
+			Topic:             testTopic,
+			GroupID:           testGroup,
+			ProcessingTimeout: time.Second,
+			MaxRetries:        maxRetries,
+			RetryBaseDelay:    time.Millisecond,
+		},
+		broker.reader(testGroup, testTopic),
+		broker.writer(deadLetterTopic(testTopic)),
+		projector,
+		newTestRegistry(),
+	)
+	if err := consumer.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	return consumer
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConsumer(t *testing.T) {
+	tests := []struct {
+		name               string
+		failures           int
+		maxRetries         int
+		malformed          bool
+		expectedProjected  int
+		expectedDeadLetter bool
+	}{
+		{
+			name:              "projects and commits",
+			expectedProjected: 1,
+		},
+		{
+			name:              "retries before committing",
+			failures:          2,
+			maxRetries:        3,
+			expectedProjected: 1,
+		},
+		{
+			name:               "dead-letters once retries are exhausted",
+			failures:           -1,
+			maxRetries:         2,
+			expectedDeadLetter: true,
+		},
+		{
+			name:               "dead-letters malformed messages without retrying",
+			maxRetries:         5,
+			malformed:          true,
+			expectedDeadLetter: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			broker := newFakeBroker(1)
+			if tt.malformed {
+				if err := broker.writer(testTopic).WriteMessages(ctx, kafkago.Message{Key: []byte("user-1"), Value: []byte("not protobuf")}); err != nil {
+					t.Fatalf("WriteMessages() error = %v", err)
+				}
+			} else if err := newTestPublisher(broker).PublishEvent(ctx, user.NewUserRegisteredEvent("user-1", "alice", "hash")); err != nil {
+				t.Fatalf("PublishEvent() error = %v", err)
+			}
+
+			projector := &flakyProjector{failures: tt.failures}
+			consumer := startTestConsumer(t, broker, projector, tt.maxRetries)
+			waitFor(t, func() bool {
+				return broker.committedOffset(testGroup, testTopic, 0) == 1
+			})
+			if err := consumer.Stop(); err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+
+			attempts, projected := projector.counts()
+			if projected != tt.expectedProjected {
+				t.Errorf("projected %d events, expected %d", projected, tt.expectedProjected)
+			}
+			if tt.malformed && attempts != 0 {
+				t.Errorf("malformed message was projected %d times", attempts)
+			}
+			if !tt.malformed && tt.failures < 0 && attempts != tt.maxRetries+1 {
+				t.Errorf("projection attempted %d times, expected %d", attempts, tt.maxRetries+1)
+			}
+
+			deadLetters := broker.messages(deadLetterTopic(testTopic), 0)
+			if (len(deadLetters) == 1) != tt.expectedDeadLetter {
+				t.Fatalf("got %d dead letters, expected dead letter %v", len(deadLetters), tt.expectedDeadLetter)
+			}
+			if tt.expectedDeadLetter {
+				if header(deadLetters[0], headerLastError) == "" {
+					t.Error("dead letter carries no failure reason")
+				}
+				if got := header(deadLetters[0], headerOriginalPosition); got != "0:0" {
+					t.Errorf("original position = %q, expected 0:0", got)
+				}
+			}
+		})
+	}
+}
+
+func TestConsumer_KeepsOrderWithinAggregate(t *testing.T) {
+	ctx := context.Background()
+	broker := newFakeBroker(4)
+	events := []shared.Event{
+		user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+		user.NewUserRegisteredEvent("user-2", "bob", "hash"),
+		user.NewUserPasswordChangedEvent("user-1", "hash-2", 2),
+		user.NewUserPasswordChangedEvent("user-2", "hash-2", 2),
+		user.NewUserPasswordChangedEvent("user-1", "hash-3", 3),
+	}
+	if err := newTestPublisher(broker).PublishEvents(ctx, events); err != nil {
+		t.Fatalf("PublishEvents() error = %v", err)
+	}
+
+	projector := &flakyProjector{}
+	consumer := startTestConsumer(t, broker, projector, 0)
+	waitFor(t, func() bool {
+		_, projected := projector.counts()
+		return projected == len(events)
+	})
+	if err := consumer.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	lastVersion := map[string]int{}
+	for _, event := range projector.projected {
+		if event.GetVersion() <= lastVersion[event.GetAggregateID()] {
+			t.Fatalf("%s version %d projected after version %d", event.GetAggregateID(), event.GetVersion(), lastVersion[event.GetAggregateID()])
+		}
+		lastVersion[event.GetAggregateID()] = event.GetVersion()
+	}
+}
+
+func TestConsumer_StopLeavesFailingMessageUncommitted(t *testing.T) {
+	ctx := context.Background()
+	broker := newFakeBroker(1)
+	if err := newTestPublisher(broker).PublishEvent(ctx, user.NewUserRegisteredEvent("user-1", "alice", "hash")); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+
+	failing := &flakyProjector{failures: -1}
+	consumer := startTestConsumer(t, broker, failing, 100)
+	waitFor(t, func() bool {
+		attempts, _ := failing.counts()
+		return attempts >= 2
+	})
+	if err := consumer.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if offset := broker.committedOffset(testGroup, testTopic, 0); offset != 0 {
+		t.Fatalf("committed offset %d while the message was still failing", offset)
+	}
+
+	// the next member of the group picks the message up again
+	recovered := &flakyProjector{}
+	consumer = startTestConsumer(t, broker, recovered, 100)
+	waitFor(t, func() bool {
+		return broker.committedOffset(testGroup, testTopic, 0) == 1
+	})
+	if err := consumer.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, projected := recovered.counts(); projected != 1 {
+		t.Errorf("projected %d events after restart, expected 1", projected)
+	}
+}
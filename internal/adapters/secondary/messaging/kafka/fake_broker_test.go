@@ -0,0 +1,186 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// fakeBroker is an in-process stand-in for a cluster: topics of partitioned
+// logs and the committed offsets of consumer groups. Writers partition by
+// key with the same balancer as the real writer.
+type fakeBroker struct {
+	mu         sync.Mutex
+	partitions int
+	topics     map[string][][]kafkago.Message
+	committed  map[string][]int64
+	// closed and replaced on every write, to wake fetching readers
+	written chan struct{}
+	// writeErr fails writes while set
+	writeErr error
+}
+
+func newFakeBroker(partitions int) *fakeBroker {
+	return &fakeBroker{
+		partitions: partitions,
+		topics:     make(map[string][][]kafkago.Message),
+		committed:  make(map[string][]int64),
+		written:    make(chan struct{}),
+	}
+}
+
+func (b *fakeBroker) failWrites(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.writeErr = err
+}
+
+func (b *fakeBroker) topic(name string) [][]kafkago.Message {
+	if _, ok := b.topics[name]; !ok {
+		b.topics[name] = make([][]kafkago.Message, b.partitions)
+	}
+	return b.topics[name]
+}
+
+// messages returns the log of a partition of topic.
+func (b *fakeBroker) messages(topic string, partition int) []kafkago.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]kafkago.Message(nil), b.topic(topic)[partition]...)
+}
+
+// committedOffset is the next offset group reads from partition of topic.
+func (b *fakeBroker) committedOffset(group, topic string, partition int) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.offsets(group, topic)[partition]
+}
+
+func (b *fakeBroker) offsets(group, topic string) []int64 {
+	key := group + "/" + topic
+	if _, ok := b.committed[key]; !ok {
+		b.committed[key] = make([]int64, b.partitions)
+	}
+	return b.committed[key]
+}
+
+type fakeWriter struct {
+	broker *fakeBroker
+	topic  string
+}
+
+func (b *fakeBroker) writer(topic string) *fakeWriter {
+	return &fakeWriter{broker: b, topic: topic}
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafkago.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b := w.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.writeErr != nil {
+		return b.writeErr
+	}
+
+	partitions := make([]int, b.partitions)
+	for i := range partitions {
+		partitions[i] = i
+	}
+
+	log := b.topic(w.topic)
+	balancer := &kafkago.Murmur2Balancer{}
+	for _, msg := range msgs {
+		partition := balancer.Balance(msg, partitions...)
+		msg.Topic = w.topic
+		msg.Partition = partition
+		msg.Offset = int64(len(log[partition]))
+		log[partition] = append(log[partition], msg)
+	}
+
+	close(b.written)
+	b.written = make(chan struct{})
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+// fakeReader is a group member owning every partition. It starts from the
+// committed offsets, as a member taking over after a rebalance would.
+type fakeReader struct {
+	broker   *fakeBroker
+	group    string
+	topic    string
+	position []int64
+	next     int
+	closed   bool
+}
+
+func (b *fakeBroker) reader(group, topic string) *fakeReader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return &fakeReader{
+		broker:   b,
+		group:    group,
+		topic:    topic,
+		position: append([]int64(nil), b.offsets(group, topic)...),
+	}
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafkago.Message, error) {
+	b := r.broker
+	for {
+		b.mu.Lock()
+		if r.closed {
+			b.mu.Unlock()
+			return kafkago.Message{}, errors.New("reader closed")
+		}
+
+		log := b.topic(r.topic)
+		for i := 0; i < b.partitions; i++ {
+			partition := (r.next + i) % b.partitions
+			if r.position[partition] < int64(len(log[partition])) {
+				msg := log[partition][r.position[partition]]
+				r.position[partition]++
+				r.next = partition + 1
+				b.mu.Unlock()
+				return msg, nil
+			}
+		}
+		written := b.written
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return kafkago.Message{}, ctx.Err()
+		case <-written:
+		}
+	}
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafkago.Message) error {
+	b := r.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	offsets := b.offsets(r.group, r.topic)
+	for _, msg := range msgs {
+		offsets[msg.Partition] = max(offsets[msg.Partition], msg.Offset+1)
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	r.broker.mu.Lock()
+	defer r.broker.mu.Unlock()
+	r.closed = true
+	return nil
+}
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/proto"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/rabbitmq"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProtobuf = "application/protobuf"
+
+	headerContentType = "content-type"
+	headerEventType   = "event-type"
+	// message-id is aggregate id and version, as in the event store, so
+	// consumers can deduplicate redelivered messages
+	headerMessageID = "message-id"
+
+	headerLastError        = "x-last-error"
+	headerOriginalTopic    = "x-original-topic"
+	headerOriginalPosition = "x-original-position"
+)
+
+// messageWriter is the part of *kafkago.Writer the adapters use, so tests can
+// run against an in-process broker.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafkago.Message) error
+	Close() error
+}
+
+// messageReader is the part of *kafkago.Reader the consumer uses.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafkago.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafkago.Message) error
+	Close() error
+}
+
+// encodeMessage keys event by its aggregate id, so all events of a user land
+// on one partition and are consumed in the order they were published.
+func encodeMessage(event shared.Event, registry shared.EventRegistry) (kafkago.Message, error) {
+	eventMsg, err := rabbitmq.SerializeEvent(event, registry)
+	if err != nil {
+		return kafkago.Message{}, fmt.Errorf("event serialization failed: %w", err)
+	}
+
+	body, err := proto.Marshal(eventMsg)
+	if err != nil {
+		return kafkago.Message{}, fmt.Errorf("event message serialization failed: %w", err)
+	}
+
+	return kafkago.Message{
+		Key:   []byte(event.GetAggregateID()),
+		Value: body,
+		Time:  event.GetTimestamp(),
+		Headers: []kafkago.Header{
+			{Key: headerContentType, Value: []byte(contentTypeProtobuf)},
+			{Key: headerEventType, Value: []byte(event.GetEventType())},
+			{Key: headerMessageID, Value: []byte(fmt.Sprintf("%s-%d", event.GetAggregateID(), event.GetVersion()))},
+		},
+	}, nil
+}
+
+func decodeMessage(msg kafkago.Message, registry shared.EventRegistry) (shared.Event, error) {
+	if contentType := header(msg, headerContentType); contentType != "" && contentType != contentTypeProtobuf {
+		return nil, fmt.Errorf("unexpected content type: %s", contentType)
+	}
+
+	var eventMsg pb.EventMessage
+	if err := proto.Unmarshal(msg.Value, &eventMsg); err != nil {
+		return nil, fmt.Errorf("payload deserialization failed: %w", err)
+	}
+	return rabbitmq.DeserializeEvent(&eventMsg, registry)
+}
+
+func header(msg kafkago.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
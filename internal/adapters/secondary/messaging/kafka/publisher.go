@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+type PublisherConfig struct {
+	Brokers []string
+	Topic   string
+	Timeout time.Duration
+}
+
+type Publisher struct {
+	config   PublisherConfig
+	registry shared.EventRegistry
+	writer   messageWriter
+}
+
+func NewPublisher(config PublisherConfig, registry shared.EventRegistry) (*Publisher, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("publisher initialization failed: no brokers configured")
+	}
+	return newPublisher(config, registry, newWriter(config.Brokers, config.Topic, config.Timeout)), nil
+}
+
+func newPublisher(config PublisherConfig, registry shared.EventRegistry, writer messageWriter) *Publisher {
+	return &Publisher{
+		config:   config,
+		registry: registry,
+		writer:   writer,
+	}
+}
+
+// newWriter partitions by key the way the Java client does, so services on
+// other stacks producing to the same topic agree on where a user's events go.
+func newWriter(brokers []string, topic string, timeout time.Duration) *kafkago.Writer {
+	return &kafkago.Writer{
+		Addr:         kafkago.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafkago.Murmur2Balancer{},
+		RequiredAcks: kafkago.RequireAll,
+		// writes are synchronous; don't hold them back waiting for a batch
+		BatchTimeout: 10 * time.Millisecond,
+		WriteTimeout: timeout,
+	}
+}
+
+func (p *Publisher) PublishEvent(ctx context.Context, event shared.Event) error {
+	return p.PublishEvents(ctx, []shared.Event{event})
+}
+
+// PublishEvents writes events in one request and returns once every broker
+// in sync has them. Events of an aggregate keep their order on its partition.
+func (p *Publisher) PublishEvents(ctx context.Context, events []shared.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	msgs := make([]kafkago.Message, 0, len(events))
+	for _, event := range events {
+		msg, err := encodeMessage(event, p.registry)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	if err := p.writer.WriteMessages(ctx, msgs...); err != nil {
+		return fmt.Errorf("failed to publish events: %w", err)
+	}
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+const testTopic = "auth.events"
+
+func newTestRegistry() shared.EventRegistry {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	return registry
+}
+
+func newTestPublisher(broker *fakeBroker) *Publisher {
+	return newPublisher(PublisherConfig{Topic: testTopic, Timeout: time.Second}, newTestRegistry(), broker.writer(testTopic))
+}
+
+func TestPublisher_KeysByAggregate(t *testing.T) {
+	broker := newFakeBroker(8)
+	publisher := newTestPublisher(broker)
+
+	events := []shared.Event{
+		user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+		user.NewUserRegisteredEvent("user-2", "bob", "hash"),
+		user.NewUserPasswordChangedEvent("user-1", "hash-2", 2),
+		user.NewUserPasswordChangedEvent("user-1", "hash-3", 3),
+	}
+	if err := publisher.PublishEvents(context.Background(), events); err != nil {
+		t.Fatalf("PublishEvents() error = %v", err)
+	}
+
+	var versions []int
+	userPartition := -1
+	for partition := 0; partition < 8; partition++ {
+		for _, msg := range broker.messages(testTopic, partition) {
+			if string(msg.Key) != "user-1" {
+				continue
+			}
+			if userPartition >= 0 && userPartition != partition {
+				t.Fatalf("user-1 events spread over partitions %d and %d", userPartition, partition)
+			}
+			userPartition = partition
+
+			event, err := decodeMessage(msg, newTestRegistry())
+			if err != nil {
+				t.Fatalf("decodeMessage() error = %v", err)
+			}
+			if event.GetAggregateID() != "user-1" {
+				t.Errorf("message keyed user-1 carries %s", event.GetAggregateID())
+			}
+			if got, expected := header(msg, headerMessageID), fmt.Sprintf("user-1-%d", event.GetVersion()); got != expected {
+				t.Errorf("message id = %s, expected %s", got, expected)
+			}
+			versions = append(versions, event.GetVersion())
+		}
+	}
+
+	expected := []int{1, 2, 3}
+	if len(versions) != len(expected) {
+		t.Fatalf("got user-1 versions %v, expected %v", versions, expected)
+	}
+	for i := range expected {
+		if versions[i] != expected[i] {
+			t.Fatalf("got user-1 versions %v, expected %v", versions, expected)
+		}
+	}
+}
+
+func TestPublisher_WriteError(t *testing.T) {
+	broker := newFakeBroker(1)
+	publisher := newTestPublisher(broker)
+	errBroker := errors.New("leader not available")
+	broker.failWrites(errBroker)
+
+	err := publisher.PublishEvent(context.Background(), user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+	if !errors.Is(err, errBroker) {
+		t.Errorf("PublishEvent() error = %v, expected %v", err, errBroker)
+	}
+}
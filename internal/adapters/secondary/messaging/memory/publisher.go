@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+// Publisher delivers events straight to its projectors in the caller's
+// goroutine, so read models are up to date once PublishEvent returns.
+type Publisher struct {
+	mu         sync.Mutex
+	projectors []secondary.EventProjector
+	published  []shared.Event
+}
+
+func NewPublisher(projectors ...secondary.EventProjector) *Publisher {
+	return &Publisher{
+		projectors: projectors,
+	}
+}
+
+func (p *Publisher) PublishEvent(ctx context.Context, event shared.Event) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("publish event: %w", err)
+	}
+
+	// serialize delivery so projectors observe events in publish order
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.published = append(p.published, event)
+
+	var errs []error
+	for _, projector := range p.projectors {
+		if err := projector.ProjectEvent(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("projection failed: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Publisher) PublishEvents(ctx context.Context, events []shared.Event) error {
+	var errs []error
+	for _, event := range events {
+		if err := p.PublishEvent(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Publisher) PublishedEvents() []shared.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]shared.Event(nil), p.published...)
+}
@@ -0,0 +1,213 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type ConsumerConfig struct {
+	URL string
+	StreamConfig
+	// Durable names the consumer on the server; it may not contain dots
+	Durable string
+	// FilterSubject narrows the stream, e.g. "dcart.user.>"; empty is all events
+	FilterSubject     string
+	AckWait           time.Duration
+	ProcessingTimeout time.Duration
+	ReconnectDelay    time.Duration
+	// a message is delivered up to MaxDeliver times, waiting RetryBaseDelay
+	// and doubling the wait after each failure, before it is dead-lettered
+	MaxDeliver     int
+	RetryBaseDelay time.Duration
+}
+
+// deadLetterPublisher is the part of jetstream.JetStream dead-lettering uses.
+type deadLetterPublisher interface {
+	PublishMsg(ctx context.Context, msg *natsgo.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+type Consumer struct {
+	config      ConsumerConfig
+	conn        *natsgo.Conn
+	consumer    jetstream.Consumer
+	deadLetters deadLetterPublisher
+	consumeCtx  jetstream.ConsumeContext
+
+	// app
+	projector     secondary.EventProjector
+	eventRegistry shared.EventRegistry
+}
+
+func NewConsumer(config ConsumerConfig, projector secondary.EventProjector, eventRegistry shared.EventRegistry) (*Consumer, error) {
+	conn, err := connect(config.URL, config.ReconnectDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("jetstream initialization failed: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ProcessingTimeout)
+	defer cancel()
+	if err := ensureStreams(ctx, js, config.StreamConfig); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	filterSubject := config.FilterSubject
+	if filterSubject == "" {
+		filterSubject = config.eventsSubjects()
+	}
+	consumer, err := js.CreateOrUpdateConsumer(ctx, config.Stream, jetstream.ConsumerConfig{
+		Durable:       config.Durable,
+		FilterSubject: filterSubject,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       config.AckWait,
+		// the server would silently stop redelivering at its own limit, so
+		// MaxDeliver is enforced here where the message can be dead-lettered
+		MaxDeliver: -1,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("consumer declaration failed: %w", err)
+	}
+
+	return &Consumer{
+		config:        config,
+		conn:          conn,
+		consumer:      consumer,
+		deadLetters:   js,
+		projector:     projector,
+		eventRegistry: eventRegistry,
+	}, nil
+}
+
+func (c *Consumer) Start(ctx context.Context) error {
+	// in-flight messages are finished on shutdown rather than abandoned
+	processCtx := context.WithoutCancel(ctx)
+	consumeCtx, err := c.consumer.Consume(func(msg jetstream.Msg) {
+		c.processMessage(processCtx, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("consume setup failed: %w", err)
+	}
+	c.consumeCtx = consumeCtx
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			consumeCtx.Drain()
+		case <-consumeCtx.Closed():
+		}
+	}()
+
+	return nil
+}
+
+// Stop finishes the messages already fetched. Unacknowledged ones are
+// redelivered once AckWait passes.
+func (c *Consumer) Stop() error {
+	if c.consumeCtx != nil {
+		c.consumeCtx.Drain()
+		<-c.consumeCtx.Closed()
+	}
+	return c.conn.Drain()
+}
+
+// processMessage acks a projected message. A failed one is redelivered after
+// a backoff, and behind newer events of its aggregate, until MaxDeliver.
+func (c *Consumer) processMessage(ctx context.Context, msg jetstream.Msg) {
+	deliveries := 1
+	if metadata, err := msg.Metadata(); err == nil {
+		deliveries = int(metadata.NumDelivered)
+	}
+
+	// malformed messages would fail every retry, so they skip straight to the DLQ
+	event, err := decodeMessage(msg, c.eventRegistry)
+	if err != nil {
+		c.deadLetter(ctx, msg, deliveries, fmt.Errorf("invalid message format: %w", err))
+		return
+	}
+
+	processCtx, cancel := context.WithTimeout(ctx, c.config.ProcessingTimeout)
+	defer cancel()
+
+	if err := c.projector.ProjectEvent(processCtx, event); err != nil {
+		c.handleProcessingError(ctx, msg, deliveries, fmt.Errorf("projection failed: %w", err))
+		return
+	}
+
+	if err := msg.Ack(); err != nil {
+		log.Printf("failed to ack message: %v", err)
+	}
+}
+
+func (c *Consumer) handleProcessingError(ctx context.Context, msg jetstream.Msg, deliveries int, err error) {
+	if deliveries >= c.config.MaxDeliver {
+		c.deadLetter(ctx, msg, deliveries, err)
+		return
+	}
+
+	log.Printf("scheduling redelivery %d: %v", deliveries+1, err)
+	if err := msg.NakWithDelay(retryDelay(c.config.RetryBaseDelay, deliveries)); err != nil {
+		log.Printf("failed to nak message: %v", err)
+	}
+}
+
+// deadLetter stores msg in the dead letter stream with the reason it failed
+// and terminates its delivery. When that fails the message is redelivered
+// rather than lost.
+func (c *Consumer) deadLetter(ctx context.Context, msg jetstream.Msg, deliveries int, reason error) {
+	log.Printf("dead-lettering message: %v", reason)
+
+	parked := natsgo.NewMsg(c.config.deadLetterSubject(c.config.Durable, msg.Subject()))
+	parked.Data = msg.Data()
+	for key, values := range msg.Headers() {
+		parked.Header[key] = append([]string(nil), values...)
+	}
+	parked.Header.Set(headerLastError, reason.Error())
+	parked.Header.Set(headerOriginalSubject, msg.Subject())
+	parked.Header.Set(headerDeliveries, strconv.Itoa(deliveries))
+	// the same event dead-lettered by two durables is two dead letters, but
+	// one durable retrying the publish stores it once
+	originalID := parked.Header.Get(jetstream.MsgIDHeader)
+	parked.Header.Del(jetstream.MsgIDHeader)
+
+	var opts []jetstream.PublishOpt
+	if originalID != "" {
+		opts = append(opts, jetstream.WithMsgID(c.config.Durable+"-"+originalID))
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, c.config.ProcessingTimeout)
+	defer cancel()
+
+	if _, err := c.deadLetters.PublishMsg(publishCtx, parked, opts...); err != nil {
+		log.Printf("failed to dead-letter message: %v", err)
+		if err := msg.NakWithDelay(c.config.RetryBaseDelay); err != nil {
+			log.Printf("failed to nak message: %v", err)
+		}
+		return
+	}
+
+	if err := msg.TermWithReason(reason.Error()); err != nil {
+		log.Printf("failed to terminate message: %v", err)
+	}
+}
+
+// retryDelay is the wait after the given failed delivery, doubling from base.
+func retryDelay(base time.Duration, deliveries int) time.Duration {
+	return base << (deliveries - 1)
+}
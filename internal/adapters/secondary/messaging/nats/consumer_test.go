@@ -0,0 +1,175 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// fakeMsg records how a delivery was settled.
+type fakeMsg struct {
+	subject      string
+	data         []byte
+	headers      natsgo.Header
+	numDelivered uint64
+
+	acked      bool
+	nakDelay   time.Duration
+	nakked     bool
+	terminated bool
+}
+
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumDelivered: m.numDelivered}, nil
+}
+func (m *fakeMsg) Data() []byte                       { return m.data }
+func (m *fakeMsg) Headers() natsgo.Header             { return m.headers }
+func (m *fakeMsg) Subject() string                    { return m.subject }
+func (m *fakeMsg) Reply() string                      { return "" }
+func (m *fakeMsg) Ack() error                         { m.acked = true; return nil }
+func (m *fakeMsg) DoubleAck(context.Context) error    { m.acked = true; return nil }
+func (m *fakeMsg) Nak() error                         { m.nakked = true; return nil }
+func (m *fakeMsg) InProgress() error                  { return nil }
+func (m *fakeMsg) Term() error                        { m.terminated = true; return nil }
+func (m *fakeMsg) TermWithReason(reason string) error { m.terminated = true; return nil }
+func (m *fakeMsg) NakWithDelay(delay time.Duration) error {
+	m.nakked = true
+	m.nakDelay = delay
+	return nil
+}
+
+type fakeDeadLetters struct {
+	err       error
+	published []*natsgo.Msg
+}
+
+func (d *fakeDeadLetters) PublishMsg(_ context.Context, msg *natsgo.Msg, _ ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	d.published = append(d.published, msg)
+	return &jetstream.PubAck{}, nil
+}
+
+type failingProjector struct {
+	err error
+}
+
+func (p failingProjector) ProjectEvent(context.Context, shared.Event) error {
+	return p.err
+}
+
+func TestConsumer_ProcessMessage(t *testing.T) {
+	tests := []struct {
+		name               string
+		projectErr         error
+		deadLetterErr      error
+		malformed          bool
+		numDelivered       uint64
+		expectAck          bool
+		expectNakDelay     time.Duration
+		expectTerminated   bool
+		expectedDeadLetter bool
+	}{
+		{
+			name:         "projected message is acked",
+			numDelivered: 1,
+			expectAck:    true,
+		},
+		{
+			name:           "failure is redelivered after a backoff",
+			projectErr:     errors.New("read model unavailable"),
+			numDelivered:   2,
+			expectNakDelay: 2 * time.Second,
+		},
+		{
+			name:               "last delivery is dead-lettered",
+			projectErr:         errors.New("read model unavailable"),
+			numDelivered:       5,
+			expectTerminated:   true,
+			expectedDeadLetter: true,
+		},
+		{
+			name:               "malformed message is dead-lettered straight away",
+			malformed:          true,
+			numDelivered:       1,
+			expectTerminated:   true,
+			expectedDeadLetter: true,
+		},
+		{
+			name:           "message is kept when dead-lettering fails",
+			malformed:      true,
+			deadLetterErr:  errors.New("no responders"),
+			numDelivered:   1,
+			expectNakDelay: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := newTestRegistry()
+			encoded, err := encodeMessage("dcart", user.NewUserRegisteredEvent("user-1", "alice", "hash"), registry)
+			if err != nil {
+				t.Fatalf("encodeMessage() error = %v", err)
+			}
+			encoded.Header.Set(jetstream.MsgIDHeader, "user-1-1")
+			if tt.malformed {
+				encoded.Data = []byte("not protobuf")
+			}
+			msg := &fakeMsg{subject: encoded.Subject, data: encoded.Data, headers: encoded.Header, numDelivered: tt.numDelivered}
+
+			deadLetters := &fakeDeadLetters{err: tt.deadLetterErr}
+			consumer := &Consumer{
+				config: ConsumerConfig{
+					StreamConfig:      StreamConfig{Stream: "DCART_EVENTS", SubjectPrefix: "dcart"},
+					Durable:           "auth-read-model",
+					ProcessingTimeout: time.Second,
+					MaxDeliver:        5,
+					RetryBaseDelay:    time.Second,
+				},
+				deadLetters:   deadLetters,
+				projector:     failingProjector{err: tt.projectErr},
+				eventRegistry: registry,
+			}
+
+			consumer.processMessage(context.Background(), msg)
+
+			if msg.acked != tt.expectAck {
+				t.Errorf("acked = %v, expected %v", msg.acked, tt.expectAck)
+			}
+			if msg.nakDelay != tt.expectNakDelay {
+				t.Errorf("nak delay = %v, expected %v", msg.nakDelay, tt.expectNakDelay)
+			}
+			if msg.terminated != tt.expectTerminated {
+				t.Errorf("terminated = %v, expected %v", msg.terminated, tt.expectTerminated)
+			}
+			if (len(deadLetters.published) == 1) != tt.expectedDeadLetter {
+				t.Fatalf("got %d dead letters, expected dead letter %v", len(deadLetters.published), tt.expectedDeadLetter)
+			}
+			if !tt.expectedDeadLetter {
+				return
+			}
+
+			parked := deadLetters.published[0]
+			if expected := "dlq.DCART_EVENTS.auth-read-model.dcart.user.user.registered"; parked.Subject != expected {
+				t.Errorf("dead letter subject = %q, expected %q", parked.Subject, expected)
+			}
+			if parked.Header.Get(headerLastError) == "" {
+				t.Error("dead letter carries no failure reason")
+			}
+			if parked.Header.Get(headerOriginalSubject) != encoded.Subject {
+				t.Errorf("original subject = %q", parked.Header.Get(headerOriginalSubject))
+			}
+			if parked.Header.Get(jetstream.MsgIDHeader) != "" {
+				t.Error("dead letter kept the original message id")
+			}
+		})
+	}
+}
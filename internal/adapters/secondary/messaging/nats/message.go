@@ -0,0 +1,143 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/proto"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/rabbitmq"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProtobuf = "application/protobuf"
+
+	headerContentType = "Content-Type"
+	headerEventType   = "Event-Type"
+
+	headerLastError       = "X-Last-Error"
+	headerOriginalSubject = "X-Original-Subject"
+	headerDeliveries      = "X-Deliveries"
+)
+
+// duplicateWindow is how long the server remembers message ids, so a publish
+// retried after a lost ack is stored once.
+const duplicateWindow = 2 * time.Minute
+
+// StreamConfig names the stream events are stored in and the subject prefix
+// it captures.
+type StreamConfig struct {
+	Stream        string
+	SubjectPrefix string
+}
+
+// eventsSubjects is the subject filter of the events stream.
+func (c StreamConfig) eventsSubjects() string {
+	return c.SubjectPrefix + ".>"
+}
+
+// deadLetterStream holds dead letters of every durable of the events stream.
+func (c StreamConfig) deadLetterStream() string {
+	return c.Stream + "_DLQ"
+}
+
+func (c StreamConfig) deadLetterSubjects() string {
+	return "dlq." + c.Stream + ".>"
+}
+
+// deadLetterSubject keeps the subject a message failed on, so dead letters
+// can be filtered by durable, aggregate type and event type alike.
+func (c StreamConfig) deadLetterSubject(durable, subject string) string {
+	return "dlq." + c.Stream + "." + durable + "." + subject
+}
+
+// ensureStreams creates the events and dead letter streams, or brings their
+// configuration up to date.
+func ensureStreams(ctx context.Context, js jetstream.JetStream, config StreamConfig) error {
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       config.Stream,
+		Subjects:   []string{config.eventsSubjects()},
+		Storage:    jetstream.FileStorage,
+		Duplicates: duplicateWindow,
+	}); err != nil {
+		return fmt.Errorf("stream declaration failed: %w", err)
+	}
+
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     config.deadLetterStream(),
+		Subjects: []string{config.deadLetterSubjects()},
+		Storage:  jetstream.FileStorage,
+		MaxAge:   72 * time.Hour,
+	}); err != nil {
+		return fmt.Errorf("dead letter stream declaration failed: %w", err)
+	}
+	return nil
+}
+
+// subject derives "<prefix>.<aggregate_type>.<event_type>", e.g.
+// "dcart.user.user.registered", so consumers can filter on either part.
+func subject(prefix string, event shared.Event) string {
+	return prefix + "." + subjectTokens(strings.ToLower(event.GetAggregateType())) + "." + subjectTokens(event.GetEventType())
+}
+
+// subjectTokens replaces what may not appear in a subject token, keeping dots
+// as token separators.
+func subjectTokens(value string) string {
+	tokens := strings.Split(value, ".")
+	for i, token := range tokens {
+		token = strings.Map(func(r rune) rune {
+			switch r {
+			case '*', '>', ' ', '\t', '\r', '\n':
+				return '_'
+			default:
+				return r
+			}
+		}, token)
+		if token == "" {
+			token = "_"
+		}
+		tokens[i] = token
+	}
+	return strings.Join(tokens, ".")
+}
+
+// messageID is the event store id, which the server deduplicates on.
+func messageID(event shared.Event) string {
+	return fmt.Sprintf("%s-%d", event.GetAggregateID(), event.GetVersion())
+}
+
+func encodeMessage(prefix string, event shared.Event, registry shared.EventRegistry) (*natsgo.Msg, error) {
+	eventMsg, err := rabbitmq.SerializeEvent(event, registry)
+	if err != nil {
+		return nil, fmt.Errorf("event serialization failed: %w", err)
+	}
+
+	body, err := proto.Marshal(eventMsg)
+	if err != nil {
+		return nil, fmt.Errorf("event message serialization failed: %w", err)
+	}
+
+	msg := natsgo.NewMsg(subject(prefix, event))
+	msg.Data = body
+	msg.Header.Set(headerContentType, contentTypeProtobuf)
+	msg.Header.Set(headerEventType, event.GetEventType())
+	return msg, nil
+}
+
+func decodeMessage(msg jetstream.Msg, registry shared.EventRegistry) (shared.Event, error) {
+	if contentType := msg.Headers().Get(headerContentType); contentType != "" && contentType != contentTypeProtobuf {
+		return nil, fmt.Errorf("unexpected content type: %s", contentType)
+	}
+
+	var eventMsg pb.EventMessage
+	if err := proto.Unmarshal(msg.Data(), &eventMsg); err != nil {
+		return nil, fmt.Errorf("payload deserialization failed: %w", err)
+	}
+	return rabbitmq.DeserializeEvent(&eventMsg, registry)
+}
@@ -0,0 +1,74 @@
+package nats
+
+import (
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+func newTestRegistry() shared.EventRegistry {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	return registry
+}
+
+func TestSubject(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    shared.Event
+		expected string
+	}{
+		{
+			name:     "aggregate type and event type",
+			event:    user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+			expected: "dcart.user.user.registered",
+		},
+		{
+			name:     "case is kept within the event type",
+			event:    user.NewUserPasswordChangedEvent("user-1", "hash", 2),
+			expected: "dcart.user.user.passwordChanged",
+		},
+		{
+			name: "wildcards and empty tokens are replaced",
+			event: &user.UserErasedEvent{BaseEvent: shared.BaseEvent{
+				AggregateType: "USER",
+				EventType:     "user..erased>*",
+			}},
+			expected: "dcart.user.user._.erased__",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subject("dcart", tt.event); got != tt.expected {
+				t.Errorf("subject() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeMessage(t *testing.T) {
+	registry := newTestRegistry()
+	event := user.NewUserRegisteredEvent("user-1", "alice", "hash")
+
+	msg, err := encodeMessage("dcart", event, registry)
+	if err != nil {
+		t.Fatalf("encodeMessage() error = %v", err)
+	}
+	if msg.Header.Get(headerEventType) != string(user.EventTypeUserRegistered) {
+		t.Errorf("event type header = %q", msg.Header.Get(headerEventType))
+	}
+
+	decoded, err := decodeMessage(&fakeMsg{subject: msg.Subject, data: msg.Data, headers: msg.Header}, registry)
+	if err != nil {
+		t.Fatalf("decodeMessage() error = %v", err)
+	}
+	registered, ok := decoded.(*user.UserRegisteredEvent)
+	if !ok {
+		t.Fatalf("decoded %T, expected *user.UserRegisteredEvent", decoded)
+	}
+	if registered.GetAggregateID() != "user-1" || registered.Username != "alice" {
+		t.Errorf("decoded %+v", registered)
+	}
+}
@@ -0,0 +1,112 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type PublisherConfig struct {
+	URL string
+	StreamConfig
+	Timeout time.Duration
+	// the client reconnects every ReconnectDelay for as long as it takes
+	ReconnectDelay time.Duration
+}
+
+type Publisher struct {
+	config   PublisherConfig
+	registry shared.EventRegistry
+	conn     *natsgo.Conn
+	js       jetstream.JetStream
+}
+
+func NewPublisher(config PublisherConfig, registry shared.EventRegistry) (*Publisher, error) {
+	conn, err := connect(config.URL, config.ReconnectDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("jetstream initialization failed: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	if err := ensureStreams(ctx, js, config.StreamConfig); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Publisher{
+		config:   config,
+		registry: registry,
+		conn:     conn,
+		js:       js,
+	}, nil
+}
+
+func connect(url string, reconnectDelay time.Duration) (*natsgo.Conn, error) {
+	conn, err := natsgo.Connect(
+		url,
+		natsgo.Name("dcart-auth"),
+		natsgo.MaxReconnects(-1),
+		natsgo.ReconnectWait(reconnectDelay),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connection establishment failed: %w", err)
+	}
+	return conn, nil
+}
+
+func (p *Publisher) PublishEvent(ctx context.Context, event shared.Event) error {
+	return p.PublishEvents(ctx, []shared.Event{event})
+}
+
+// PublishEvents sends events without waiting in between and then waits for
+// the stream to acknowledge each. Events are stored in the order given, and
+// an event published twice within the duplicate window is stored once.
+func (p *Publisher) PublishEvents(ctx context.Context, events []shared.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	futures := make([]jetstream.PubAckFuture, 0, len(events))
+	var errs []error
+	for _, event := range events {
+		msg, err := encodeMessage(p.config.SubjectPrefix, event, p.registry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		future, err := p.js.PublishMsgAsync(msg, jetstream.WithMsgID(messageID(event)), jetstream.WithExpectStream(p.config.Stream))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to publish %s: %w", messageID(event), err))
+			continue
+		}
+		futures = append(futures, future)
+	}
+
+	for _, future := range futures {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			errs = append(errs, fmt.Errorf("failed to publish %s: %w", future.Msg().Header.Get(jetstream.MsgIDHeader), err))
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("failed to publish %s: %w", future.Msg().Header.Get(jetstream.MsgIDHeader), ctx.Err()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Publisher) Close() error {
+	return p.conn.Drain()
+}
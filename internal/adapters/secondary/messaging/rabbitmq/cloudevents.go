@@ -0,0 +1,272 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	cloudEventsSpecVersion = "1.0"
+	// binary mode carries attributes as application properties, see the
+	// CloudEvents AMQP protocol binding
+	cloudEventsHeaderPrefix = "cloudEvents:"
+)
+
+// extension attributes carrying what the protobuf envelope has fields for
+const (
+	extensionAggregateType    = "aggregatetype"
+	extensionAggregateVersion = "aggregateversion"
+	extensionSchemaVersion    = "schemaversion"
+)
+
+// metadataExtensions names the well-known metadata keys as extensions, which
+// may only use lowercase letters and digits.
+var metadataExtensions = map[string]string{
+	shared.MetadataCorrelationID: "correlationid",
+	shared.MetadataCausationID:   "causationid",
+	shared.MetadataActorID:       "actorid",
+	shared.MetadataClientIP:      "clientip",
+	shared.MetadataUserAgent:     "useragent",
+}
+
+// cloudEvent is a CloudEvents 1.0 event whose data is the JSON form of a
+// shared.Event, as stored in the event store.
+type cloudEvent struct {
+	ID              string
+	Source          string
+	Type            string
+	Subject         string
+	Time            time.Time
+	DataContentType string
+	Data            json.RawMessage
+	Extensions      map[string]interface{}
+}
+
+// newCloudEvent derives id, source, type, subject and time from event; the id
+// matches the event store id so consumers can deduplicate on it.
+func newCloudEvent(event shared.Event, source string, registry shared.EventRegistry) (cloudEvent, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return cloudEvent{}, fmt.Errorf("event data serialization failed: %w", err)
+	}
+
+	extensions := map[string]interface{}{
+		extensionAggregateType:    event.GetAggregateType(),
+		extensionAggregateVersion: int32(event.GetVersion()),
+		extensionSchemaVersion:    int32(registry.SchemaVersion(shared.EventType(event.GetEventType()))),
+	}
+	for key, value := range event.GetMetadata() {
+		extensions[extensionName(key)] = value
+	}
+
+	return cloudEvent{
+		ID:              fmt.Sprintf("%s-%d", event.GetAggregateID(), event.GetVersion()),
+		Source:          source,
+		Type:            event.GetEventType(),
+		Subject:         event.GetAggregateID(),
+		Time:            event.GetTimestamp(),
+		DataContentType: contentTypeJSON,
+		Data:            data,
+		Extensions:      extensions,
+	}, nil
+}
+
+// structured renders the event as an application/cloudevents+json body.
+func (ce cloudEvent) structured() ([]byte, error) {
+	document := map[string]interface{}{}
+	for name, value := range ce.Extensions {
+		document[name] = value
+	}
+	document["specversion"] = cloudEventsSpecVersion
+	document["id"] = ce.ID
+	document["source"] = ce.Source
+	document["type"] = ce.Type
+	document["subject"] = ce.Subject
+	document["time"] = ce.Time.UTC().Format(time.RFC3339Nano)
+	document["datacontenttype"] = ce.DataContentType
+	document["data"] = ce.Data
+
+	return json.Marshal(document)
+}
+
+// binaryHeaders renders the attributes as application properties; data and
+// its content type travel as the body and content-type property.
+func (ce cloudEvent) binaryHeaders() amqp.Table {
+	headers := amqp.Table{}
+	for name, value := range ce.Extensions {
+		headers[cloudEventsHeaderPrefix+name] = value
+	}
+	headers[cloudEventsHeaderPrefix+"specversion"] = cloudEventsSpecVersion
+	headers[cloudEventsHeaderPrefix+"id"] = ce.ID
+	headers[cloudEventsHeaderPrefix+"source"] = ce.Source
+	headers[cloudEventsHeaderPrefix+"type"] = ce.Type
+	headers[cloudEventsHeaderPrefix+"subject"] = ce.Subject
+	headers[cloudEventsHeaderPrefix+"time"] = ce.Time.UTC().Format(time.RFC3339Nano)
+	return headers
+}
+
+func parseStructuredCloudEvent(body []byte) (cloudEvent, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return cloudEvent{}, fmt.Errorf("invalid cloudevent: %w", err)
+	}
+
+	// keep data as sent instead of the decoded interface{}
+	var raw struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return cloudEvent{}, fmt.Errorf("invalid cloudevent: %w", err)
+	}
+
+	ce, err := cloudEventFromAttributes(document)
+	if err != nil {
+		return cloudEvent{}, err
+	}
+	ce.Data = raw.Data
+	return ce, nil
+}
+
+func parseBinaryCloudEvent(delivery amqp.Delivery) (cloudEvent, error) {
+	attributes := map[string]interface{}{}
+	for key, value := range delivery.Headers {
+		if name, ok := strings.CutPrefix(key, cloudEventsHeaderPrefix); ok {
+			attributes[name] = value
+		}
+	}
+	attributes["datacontenttype"] = delivery.ContentType
+
+	ce, err := cloudEventFromAttributes(attributes)
+	if err != nil {
+		return cloudEvent{}, err
+	}
+	ce.Data = delivery.Body
+	return ce, nil
+}
+
+func cloudEventFromAttributes(attributes map[string]interface{}) (cloudEvent, error) {
+	if specVersion, _ := attributes["specversion"].(string); specVersion != cloudEventsSpecVersion {
+		return cloudEvent{}, fmt.Errorf("unsupported cloudevents specversion: %v", attributes["specversion"])
+	}
+
+	ce := cloudEvent{Extensions: map[string]interface{}{}}
+	ce.ID, _ = attributes["id"].(string)
+	ce.Source, _ = attributes["source"].(string)
+	ce.Type, _ = attributes["type"].(string)
+	ce.Subject, _ = attributes["subject"].(string)
+	ce.DataContentType, _ = attributes["datacontenttype"].(string)
+	if ce.ID == "" || ce.Source == "" || ce.Type == "" {
+		return cloudEvent{}, fmt.Errorf("invalid cloudevent: id, source and type are required")
+	}
+
+	if raw, ok := attributes["time"].(string); ok {
+		timestamp, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return cloudEvent{}, fmt.Errorf("invalid cloudevent time: %w", err)
+		}
+		ce.Time = timestamp
+	}
+
+	for name, value := range attributes {
+		switch name {
+		case "specversion", "id", "source", "type", "subject", "time", "datacontenttype", "data":
+		default:
+			ce.Extensions[name] = value
+		}
+	}
+
+	return ce, nil
+}
+
+// event rebuilds the domain event, upcasting data published under an older
+// schema version.
+func (ce cloudEvent) event(registry shared.EventRegistry) (shared.Event, error) {
+	if ce.DataContentType != "" && mediaType(ce.DataContentType) != contentTypeJSON {
+		return nil, fmt.Errorf("unsupported cloudevent datacontenttype: %s", ce.DataContentType)
+	}
+
+	eventType := shared.EventType(ce.Type)
+	event, ok := registry.CreateEvent(eventType)
+	if !ok {
+		return nil, fmt.Errorf("unknown event type: %s", ce.Type)
+	}
+
+	schemaVersion, ok := intAttribute(ce.Extensions[extensionSchemaVersion])
+	if !ok || schemaVersion < shared.InitialSchemaVersion {
+		schemaVersion = shared.InitialSchemaVersion
+	}
+	data, err := registry.Upcast(eventType, schemaVersion, ce.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upcast event: %w", err)
+	}
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cloudevent data: %w", err)
+	}
+
+	metadata := shared.Metadata{}
+	for name, value := range ce.Extensions {
+		switch name {
+		case extensionAggregateType, extensionAggregateVersion, extensionSchemaVersion:
+			continue
+		}
+		if text, ok := value.(string); ok {
+			metadata[metadataKey(name)] = text
+		}
+	}
+	if len(metadata) > 0 {
+		event.SetMetadata(metadata)
+	}
+
+	return event, nil
+}
+
+func extensionName(metadataKey string) string {
+	if name, ok := metadataExtensions[metadataKey]; ok {
+		return name
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return -1
+		}
+	}, metadataKey)
+}
+
+func metadataKey(extensionName string) string {
+	for key, name := range metadataExtensions {
+		if name == extensionName {
+			return key
+		}
+	}
+	return extensionName
+}
+
+// intAttribute reads an integer attribute, which arrives as a JSON number in
+// structured mode and as an AMQP integer or string in binary mode.
+func intAttribute(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
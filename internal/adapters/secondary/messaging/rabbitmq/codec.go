@@ -0,0 +1,114 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"mime"
+
+	pb "github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/proto"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// MessageFormat is the wire format events are published in. Consumers accept
+// every format and tell them apart by content type.
+type MessageFormat string
+
+const (
+	FormatProtobuf              MessageFormat = "protobuf"
+	FormatCloudEventsStructured MessageFormat = "cloudevents-structured"
+	FormatCloudEventsBinary     MessageFormat = "cloudevents-binary"
+)
+
+const (
+	contentTypeProtobuf    = "application/protobuf"
+	contentTypeCloudEvents = "application/cloudevents+json"
+	contentTypeJSON        = "application/json"
+)
+
+// encodeEvent renders event in format. Headers are left to the caller apart
+// from the CloudEvents attributes of binary mode.
+func encodeEvent(event shared.Event, format MessageFormat, source string, registry shared.EventRegistry) (amqp.Publishing, error) {
+	switch format {
+	case FormatCloudEventsStructured, FormatCloudEventsBinary:
+		ce, err := newCloudEvent(event, source, registry)
+		if err != nil {
+			return amqp.Publishing{}, err
+		}
+
+		if format == FormatCloudEventsBinary {
+			return amqp.Publishing{
+				ContentType: ce.DataContentType,
+				Headers:     ce.binaryHeaders(),
+				Body:        ce.Data,
+			}, nil
+		}
+
+		body, err := ce.structured()
+		if err != nil {
+			return amqp.Publishing{}, fmt.Errorf("cloudevent serialization failed: %w", err)
+		}
+		return amqp.Publishing{
+			ContentType: contentTypeCloudEvents,
+			Headers:     amqp.Table{},
+			Body:        body,
+		}, nil
+	case FormatProtobuf, "":
+		eventMsg, err := SerializeEvent(event, registry)
+		if err != nil {
+			return amqp.Publishing{}, fmt.Errorf("event serialization failed: %w", err)
+		}
+
+		body, err := proto.Marshal(eventMsg)
+		if err != nil {
+			return amqp.Publishing{}, fmt.Errorf("event message serialization failed: %w", err)
+		}
+		return amqp.Publishing{
+			ContentType: contentTypeProtobuf,
+			Headers:     amqp.Table{},
+			Body:        body,
+		}, nil
+	default:
+		return amqp.Publishing{}, fmt.Errorf("unsupported message format: %s", format)
+	}
+}
+
+// decodeDelivery turns a delivery in any supported format back into a
+// domain event. Binary CloudEvents are recognised by their specversion
+// property whatever the content type of their data.
+func decodeDelivery(delivery amqp.Delivery, registry shared.EventRegistry) (shared.Event, error) {
+	if _, ok := delivery.Headers[cloudEventsHeaderPrefix+"specversion"]; ok {
+		ce, err := parseBinaryCloudEvent(delivery)
+		if err != nil {
+			return nil, err
+		}
+		return ce.event(registry)
+	}
+
+	switch mediaType(delivery.ContentType) {
+	case contentTypeProtobuf:
+		var eventMsg pb.EventMessage
+		if err := proto.Unmarshal(delivery.Body, &eventMsg); err != nil {
+			return nil, fmt.Errorf("payload deserialization failed: %w", err)
+		}
+		return DeserializeEvent(&eventMsg, registry)
+	case contentTypeCloudEvents:
+		ce, err := parseStructuredCloudEvent(delivery.Body)
+		if err != nil {
+			return nil, err
+		}
+		return ce.event(registry)
+	default:
+		return nil, fmt.Errorf("unexpected content type: %s", delivery.ContentType)
+	}
+}
+
+// mediaType drops parameters such as charset from a content type.
+func mediaType(contentType string) string {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return parsed
+}
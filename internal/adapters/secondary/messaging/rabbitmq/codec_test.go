@@ -0,0 +1,198 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// delivered turns a publishing into the delivery a consumer would receive.
+func delivered(msg amqp.Publishing) amqp.Delivery {
+	return amqp.Delivery{
+		ContentType: msg.ContentType,
+		Headers:     msg.Headers,
+		Body:        msg.Body,
+	}
+}
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	formats := []MessageFormat{FormatProtobuf, FormatCloudEventsStructured, FormatCloudEventsBinary}
+
+	for _, format := range formats {
+		t.Run(string(format), func(t *testing.T) {
+			registry := newUpcastingRegistry()
+			metadata := shared.Metadata{
+				shared.MetadataCorrelationID: "req-1",
+				shared.MetadataActorID:       "user-1",
+			}
+			original := user.NewUserRegisteredEvent("user-1", "Alice", "hash")
+			original.Timestamp = time.Now().UTC()
+			original.SetMetadata(metadata)
+
+			msg, err := encodeEvent(original, format, "/dcart/auth", registry)
+			if err != nil {
+				t.Fatalf("encodeEvent() error = %v", err)
+			}
+
+			event, err := decodeDelivery(delivered(msg), registry)
+			if err != nil {
+				t.Fatalf("decodeDelivery() error = %v", err)
+			}
+
+			registered, ok := event.(*user.UserRegisteredEvent)
+			if !ok {
+				t.Fatalf("decodeDelivery() returned %T", event)
+			}
+			if registered.Username != original.Username || registered.PasswordHash != original.PasswordHash {
+				t.Errorf("decoded %+v, expected %+v", registered, original)
+			}
+			if registered.GetAggregateID() != "user-1" || registered.GetVersion() != 1 {
+				t.Errorf("decoded %s v%d, expected user-1 v1", registered.GetAggregateID(), registered.GetVersion())
+			}
+			if !registered.GetTimestamp().Equal(original.GetTimestamp()) {
+				t.Errorf("Timestamp = %v, expected %v", registered.GetTimestamp(), original.GetTimestamp())
+			}
+			for key, expected := range metadata {
+				if actual := registered.GetMetadata()[key]; actual != expected {
+					t.Errorf("Metadata[%s] = %q, expected %q", key, actual, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeEvent_CloudEventsStructured(t *testing.T) {
+	registry := newUpcastingRegistry()
+	original := user.NewUserRegisteredEvent("user-1", "Alice", "hash")
+	original.SetMetadata(shared.Metadata{shared.MetadataCorrelationID: "req-1"})
+
+	msg, err := encodeEvent(original, FormatCloudEventsStructured, "/dcart/auth", registry)
+	if err != nil {
+		t.Fatalf("encodeEvent() error = %v", err)
+	}
+	if msg.ContentType != "application/cloudevents+json" {
+		t.Errorf("ContentType = %s, expected application/cloudevents+json", msg.ContentType)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(msg.Body, &document); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              "user-1-1",
+		"source":          "/dcart/auth",
+		"type":            "user.registered",
+		"subject":         "user-1",
+		"datacontenttype": "application/json",
+		"correlationid":   "req-1",
+		"schemaversion":   float64(2),
+	}
+	for attribute, value := range expected {
+		if document[attribute] != value {
+			t.Errorf("%s = %v, expected %v", attribute, document[attribute], value)
+		}
+	}
+	if data, ok := document["data"].(map[string]interface{}); !ok || data["username"] != "Alice" {
+		t.Errorf("data = %v, expected the event payload", document["data"])
+	}
+}
+
+func TestEncodeEvent_CloudEventsBinary(t *testing.T) {
+	registry := newUpcastingRegistry()
+
+	msg, err := encodeEvent(user.NewUserRegisteredEvent("user-1", "Alice", "hash"), FormatCloudEventsBinary, "/dcart/auth", registry)
+	if err != nil {
+		t.Fatalf("encodeEvent() error = %v", err)
+	}
+	if msg.ContentType != "application/json" {
+		t.Errorf("ContentType = %s, expected application/json", msg.ContentType)
+	}
+
+	expected := map[string]interface{}{
+		"cloudEvents:specversion": "1.0",
+		"cloudEvents:id":          "user-1-1",
+		"cloudEvents:type":        "user.registered",
+		"cloudEvents:subject":     "user-1",
+	}
+	for header, value := range expected {
+		if msg.Headers[header] != value {
+			t.Errorf("%s = %v, expected %v", header, msg.Headers[header], value)
+		}
+	}
+	if err := msg.Headers.Validate(); err != nil {
+		t.Errorf("headers are not valid AMQP: %v", err)
+	}
+}
+
+func TestDecodeDelivery_CloudEventsUpcasting(t *testing.T) {
+	registry := newUpcastingRegistry()
+	body := []byte(`{
+		"specversion": "1.0",
+		"id": "user-1-1",
+		"source": "/other/service",
+		"type": "user.registered",
+		"time": "2026-10-19T09:00:00Z",
+		"datacontenttype": "application/json",
+		"schemaversion": 1,
+		"data": {"aggregate_id": "user-1", "version": 1, "username": "Alice"}
+	}`)
+
+	event, err := decodeDelivery(amqp.Delivery{
+		ContentType: "application/cloudevents+json; charset=utf-8",
+		Body:        body,
+	}, registry)
+	if err != nil {
+		t.Fatalf("decodeDelivery() error = %v", err)
+	}
+
+	if registered := event.(*user.UserRegisteredEvent); registered.Username != "alice" {
+		t.Errorf("Username = %s, expected the upcasted alice", registered.Username)
+	}
+}
+
+func TestDecodeDelivery_Rejects(t *testing.T) {
+	registry := newUpcastingRegistry()
+	tests := []struct {
+		name     string
+		delivery amqp.Delivery
+	}{
+		{
+			name:     "unknown content type",
+			delivery: amqp.Delivery{ContentType: "text/plain", Body: []byte("hello")},
+		},
+		{
+			name:     "malformed protobuf",
+			delivery: amqp.Delivery{ContentType: "application/protobuf", Body: []byte{0xff}},
+		},
+		{
+			name: "unsupported specversion",
+			delivery: amqp.Delivery{
+				ContentType: "application/cloudevents+json",
+				Body:        []byte(`{"specversion": "0.3", "id": "1", "source": "/s", "type": "user.registered"}`),
+			},
+		},
+		{
+			name: "missing required attribute",
+			delivery: amqp.Delivery{
+				ContentType: "application/json",
+				Headers:     amqp.Table{"cloudEvents:specversion": "1.0", "cloudEvents:id": "1"},
+				Body:        []byte(`{}`),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeDelivery(tt.delivery, registry); err == nil {
+				t.Error("decodeDelivery() succeeded, expected an error")
+			}
+		})
+	}
+}
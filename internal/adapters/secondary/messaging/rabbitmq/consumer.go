@@ -3,15 +3,14 @@ package rabbitmq
 import (
 	"context"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
-	pb "github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/proto"
-	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/mongodb"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
 	"github.com/ncfex/dcart-auth/internal/domain/shared"
 
 	amqp "github.com/rabbitmq/amqp091-go"
-	"google.golang.org/protobuf/proto"
 )
 
 type ConsumerConfig struct {
@@ -21,25 +20,31 @@ type ConsumerConfig struct {
 	Queue             string
 	RoutingKey        string
 	PrefetchCount     int
+	Workers           int
 	ReconnectDelay    time.Duration
 	ProcessingTimeout time.Duration
+	// a failed delivery is retried MaxRetries times, waiting RetryBaseDelay
+	// and doubling the wait each attempt, before it is dead-lettered
+	MaxRetries     int
+	RetryBaseDelay time.Duration
 }
 
 type Consumer struct {
 	config    ConsumerConfig
 	conn      *amqp.Connection
 	channel   *amqp.Channel
+	forwarder amqpPublisher
 	mu        sync.RWMutex
 	connected bool
 	stopChan  chan struct{}
 	done      chan struct{}
 
 	// app
-	projector     *mongodb.MongoProjector // use port
+	projector     secondary.EventProjector
 	eventRegistry shared.EventRegistry
 }
 
-func NewConsumer(config ConsumerConfig, projector *mongodb.MongoProjector, eventRegistry shared.EventRegistry) (*Consumer, error) {
+func NewConsumer(config ConsumerConfig, projector secondary.EventProjector, eventRegistry shared.EventRegistry) (*Consumer, error) {
 	consumer := &Consumer{
 		config:        config,
 		stopChan:      make(chan struct{}),
@@ -70,91 +75,13 @@ func (c *Consumer) initialize() error {
 		return fmt.Errorf("channel creation failed: %w", err)
 	}
 
-	// topic
-	if err := ch.ExchangeDeclare(
-		c.config.Exchange,
-		c.config.ExchangeType,
-		true, // durable
-		false,
-		false,
-		false,
-		nil,
-	); err != nil {
-		ch.Close()
-		conn.Close()
-		return fmt.Errorf("exchange declaration failed: %w", err)
-	}
-
-	// dlx
-	dlxName := c.config.Exchange + ".dlx"
-	if err := ch.ExchangeDeclare(
-		dlxName,
-		"fanout",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	); err != nil {
-		ch.Close()
-		conn.Close()
-		return fmt.Errorf("DLX declaration failed: %w", err)
-	}
-
-	args := amqp.Table{
-		"x-dead-letter-exchange": dlxName,
-		"x-message-ttl":          259200000, // 72hrs
-	}
-	if _, err := ch.QueueDeclare(
-		c.config.Queue,
-		true,
-		false,
-		false,
-		false,
-		args,
-	); err != nil {
-		ch.Close()
-		conn.Close()
-		return fmt.Errorf("queue declaration failed: %w", err)
-	}
-
-	// dlq
-	dlqName := c.config.Queue + ".dlq"
-	if _, err := ch.QueueDeclare(
-		dlqName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	); err != nil {
-		ch.Close()
-		conn.Close()
-		return fmt.Errorf("DLQ declaration failed: %w", err)
-	}
-
-	if err := ch.QueueBind(
-		c.config.Queue,
-		c.config.RoutingKey,
-		c.config.Exchange,
-		false,
-		nil,
-	); err != nil {
-		ch.Close()
-		conn.Close()
-		return fmt.Errorf("queue binding failed: %w", err)
-	}
-
-	if err := ch.QueueBind(
-		dlqName,
-		c.config.RoutingKey,
-		dlxName,
-		false,
-		nil,
-	); err != nil {
+	if err := declareConsumerTopology(ch, c.config); err != nil {
 		ch.Close()
 		conn.Close()
-		return fmt.Errorf("DLQ binding failed: %w", err)
+		if isPreconditionFailed(err) {
+			return fmt.Errorf("%w (queue %s predates its own dead-letter exchange; stop the service and run: auth dlq -queue %s migrate)", err, c.config.Queue, c.config.Queue)
+		}
+		return err
 	}
 
 	// qos
@@ -170,6 +97,7 @@ func (c *Consumer) initialize() error {
 
 	c.conn = conn
 	c.channel = ch
+	c.forwarder = ch
 	c.connected = true
 
 	return nil
@@ -202,71 +130,127 @@ func (c *Consumer) Start(ctx context.Context) error {
 }
 
 func (c *Consumer) consume(ctx context.Context, deliveries <-chan amqp.Delivery) {
+	// stopCtx ends retry waits once the consumer stops or loses its channel;
+	// in-flight projections still finish rather than being abandoned
+	stopCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	pool := newWorkerPool(c.config.Workers, c.workerBuffer(), func(delivery amqp.Delivery) {
+		c.processDelivery(stopCtx, delivery)
+	})
+
 	for {
 		select {
 		case <-ctx.Done():
+			stop()
+			pool.drain()
 			c.shutdown()
 			return
 		case <-c.stopChan:
+			stop()
+			pool.drain()
 			c.shutdown()
 			return
 		case delivery, ok := <-deliveries:
 			if !ok {
+				stop()
+				pool.drain()
 				c.reconnect(ctx)
 				return
 			}
-			c.processDelivery(ctx, delivery)
+			pool.dispatch(delivery)
 		}
 	}
 }
 
-func (c *Consumer) processDelivery(ctx context.Context, delivery amqp.Delivery) {
-	processCtx, cancel := context.WithTimeout(ctx, c.config.ProcessingTimeout)
-	defer cancel()
-
-	// todo add genetic unmarshaler
-	if delivery.ContentType != "application/protobuf" {
-		c.handleProcessingError(delivery, fmt.Errorf("unexpected content type: %s", delivery.ContentType), "invalid message format")
-		return
+// workerBuffer spreads the prefetched deliveries across the workers.
+func (c *Consumer) workerBuffer() int {
+	workers := c.config.Workers
+	if workers < 1 {
+		workers = 1
 	}
-
-	var eventMsg pb.EventMessage
-	if err := proto.Unmarshal(delivery.Body, &eventMsg); err != nil {
-		c.handleProcessingError(delivery, fmt.Errorf("payload deserialization failed: %w", err), "invalid message format")
-		return
+	if buffer := c.config.PrefetchCount / workers; buffer > 0 {
+		return buffer
 	}
+	return 1
+}
 
-	event, err := DeserializeEvent(&eventMsg, c.eventRegistry)
+// processDelivery projects delivery and acks it. A failed projection is
+// retried in place, up to MaxRetries times with a doubling wait, so later
+// events of the same aggregate wait in this worker instead of overtaking it
+// and having the retried one dropped as stale. Once retries run out the
+// delivery is dead-lettered. A delivery whose retry is cut short by stopCtx
+// is requeued.
+func (c *Consumer) processDelivery(stopCtx context.Context, delivery amqp.Delivery) {
+	ctx := context.WithoutCancel(stopCtx)
+
+	// malformed messages would fail every retry, so they skip straight to the DLQ
+	event, err := decodeDelivery(delivery, c.eventRegistry)
 	if err != nil {
-		c.handleProcessingError(delivery, fmt.Errorf("event deserialization failed: %w", err), "invalid event format")
+		c.deadLetter(ctx, delivery, 0, fmt.Errorf("invalid message format: %w", err))
 		return
 	}
 
-	if err := c.projector.ProjectEvent(processCtx, event); err != nil {
-		c.handleProcessingError(delivery, fmt.Errorf("projection failed: %w", err), "projection error")
-		return
+	for attempt := 1; ; attempt++ {
+		err := c.project(ctx, event)
+		if err == nil {
+			break
+		}
+		if attempt > c.config.MaxRetries {
+			c.deadLetter(ctx, delivery, attempt-1, fmt.Errorf("projection failed: %w", err))
+			return
+		}
+
+		log.Printf("retrying message %s, attempt %d: %v", delivery.MessageId, attempt, err)
+		if !sleep(stopCtx, retryDelay(c.config.RetryBaseDelay, attempt)) {
+			if err := delivery.Nack(false, true); err != nil {
+				log.Printf("failed to requeue message: %v", err)
+			}
+			return
+		}
 	}
 
 	if err := delivery.Ack(false); err != nil {
-		fmt.Printf("failed to ack message: %v\n", err)
+		log.Printf("failed to ack message: %v", err)
 	}
 }
 
-func (c *Consumer) handleProcessingError(delivery amqp.Delivery, err error, msg string) {
-	fmt.Printf("%s: %v\n", msg, err)
+func (c *Consumer) project(ctx context.Context, event shared.Event) error {
+	processCtx, cancel := context.WithTimeout(ctx, c.config.ProcessingTimeout)
+	defer cancel()
+
+	return c.projector.ProjectEvent(processCtx, event)
+}
 
-	headers := delivery.Headers
-	if headers == nil {
-		headers = amqp.Table{}
+// deadLetter publishes delivery to the queue's own DLX with the reason it
+// failed and the retries made, where the DLQ tooling can show them.
+func (c *Consumer) deadLetter(ctx context.Context, delivery amqp.Delivery, retries int, err error) {
+	log.Printf("dead-lettering message: %v", err)
+
+	headers := failureHeaders(delivery, err)
+	headers[retryCountHeader] = int32(retryCount(delivery.Headers) + retries)
+	c.forward(ctx, delivery, deadLetterExchangeName(c.config.Queue), originalRoutingKey(delivery), headers)
+}
+
+// forward republishes delivery and acks it. When the publish fails the
+// delivery is requeued rather than lost.
+func (c *Consumer) forward(ctx context.Context, delivery amqp.Delivery, exchange, routingKey string, headers amqp.Table) {
+	if err := c.forwarder.PublishWithContext(
+		ctx,
+		exchange,
+		routingKey,
+		false,
+		false,
+		republishing(delivery, headers),
+	); err != nil {
+		log.Printf("failed to forward message: %v", err)
+		if err := delivery.Nack(false, true); err != nil {
+			log.Printf("failed to requeue message: %v", err)
+		}
+		return
 	}
 
-	retryCount, _ := headers["x-retry-count"].(int32)
-	if retryCount < 3 {
-		headers["x-retry-count"] = retryCount + 1
-		delivery.Headers = headers
-		delivery.Nack(false, true) // requeue
-	} else {
-		delivery.Nack(false, false) // DLQ
+	if err := delivery.Ack(false); err != nil {
+		log.Printf("failed to ack message: %v", err)
 	}
 }
 
@@ -279,11 +263,11 @@ func (c *Consumer) reconnect(ctx context.Context) {
 			return
 		case <-time.After(c.config.ReconnectDelay):
 			if err := c.initialize(); err != nil {
-				fmt.Printf("failed to reconnect: %v\n", err)
+				log.Printf("failed to reconnect: %v", err)
 				continue
 			}
 			if err := c.Start(ctx); err != nil {
-				fmt.Printf("failed to restart consumer: %v\n", err)
+				log.Printf("failed to restart consumer: %v", err)
 				continue
 			}
 			return
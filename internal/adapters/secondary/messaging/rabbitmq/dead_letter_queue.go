@@ -0,0 +1,273 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type DeadLetterConfig struct {
+	URI string
+	// Queue is the main queue; its dead letters live in Queue + ".dlq"
+	Queue string
+}
+
+// DeadLetterQueue inspects, replays and purges the consumer's DLQ. Every
+// operation runs on its own channel; messages fetched but not acked go back
+// to the queue when that channel closes.
+type DeadLetterQueue struct {
+	config   DeadLetterConfig
+	conn     *amqp.Connection
+	registry shared.EventRegistry
+}
+
+func NewDeadLetterQueue(config DeadLetterConfig, registry shared.EventRegistry) (*DeadLetterQueue, error) {
+	conn, err := amqp.Dial(config.URI)
+	if err != nil {
+		return nil, fmt.Errorf("connection establishment failed: %w", err)
+	}
+
+	return &DeadLetterQueue{
+		config:   config,
+		conn:     conn,
+		registry: registry,
+	}, nil
+}
+
+func (q *DeadLetterQueue) List(ctx context.Context, limit int) ([]types.DeadLetter, error) {
+	ch, err := q.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("channel creation failed: %w", err)
+	}
+	defer ch.Close()
+
+	letters := []types.DeadLetter{}
+	for limit <= 0 || len(letters) < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		delivery, ok, err := ch.Get(q.dlqName(), false)
+		if err != nil {
+			return nil, fmt.Errorf("dead letter fetch failed: %w", err)
+		}
+		if !ok {
+			break
+		}
+		letters = append(letters, describeDeadLetter(delivery, q.registry))
+	}
+
+	return letters, nil
+}
+
+func (q *DeadLetterQueue) Replay(ctx context.Context, ids []string) (int, error) {
+	ch, err := q.conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("channel creation failed: %w", err)
+	}
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return 0, fmt.Errorf("confirm mode activation failed: %w", err)
+	}
+	confirms := ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	replayed := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+
+		delivery, ok, err := ch.Get(q.dlqName(), false)
+		if err != nil {
+			return replayed, fmt.Errorf("dead letter fetch failed: %w", err)
+		}
+		if !ok {
+			return replayed, nil
+		}
+		if len(selected) > 0 {
+			event, err := decodeDelivery(delivery, q.registry)
+			if err != nil {
+				event = nil
+			}
+			if !selected[deadLetterID(delivery, event)] {
+				continue
+			}
+		}
+
+		if err := q.replay(ctx, ch, delivery); err != nil {
+			return replayed, fmt.Errorf("replay publishing failed: %w", err)
+		}
+
+		select {
+		case confirm := <-confirms:
+			if !confirm.Ack {
+				return replayed, fmt.Errorf("replay unconfirmed by broker")
+			}
+		case <-ctx.Done():
+			return replayed, ctx.Err()
+		}
+
+		if err := delivery.Ack(false); err != nil {
+			return replayed, fmt.Errorf("failed to ack dead letter: %w", err)
+		}
+		replayed++
+	}
+}
+
+// replay publishes delivery through the default exchange, so it reaches the
+// queue it was dead-lettered from and no other feed sees it again.
+func (q *DeadLetterQueue) replay(ctx context.Context, publisher amqpPublisher, delivery amqp.Delivery) error {
+	return publisher.PublishWithContext(
+		ctx,
+		"",
+		q.config.Queue,
+		false,
+		false,
+		republishing(delivery, replayHeaders(delivery)),
+	)
+}
+
+func (q *DeadLetterQueue) Purge(ctx context.Context) (int, error) {
+	ch, err := q.conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("channel creation failed: %w", err)
+	}
+	defer ch.Close()
+
+	purged, err := ch.QueuePurge(q.dlqName(), false)
+	if err != nil {
+		return 0, fmt.Errorf("dead letter purge failed: %w", err)
+	}
+	return purged, nil
+}
+
+func (q *DeadLetterQueue) Close() error {
+	return q.conn.Close()
+}
+
+func (q *DeadLetterQueue) dlqName() string {
+	return deadLetterQueueName(q.config.Queue)
+}
+
+func describeDeadLetter(delivery amqp.Delivery, registry shared.EventRegistry) types.DeadLetter {
+	event, err := decodeDelivery(delivery, registry)
+	if err != nil {
+		event = nil
+	}
+
+	letter := types.DeadLetter{
+		ID:      deadLetterID(delivery, event),
+		Reason:  failureReason(delivery.Headers),
+		Retries: retryCount(delivery.Headers),
+	}
+	if event == nil {
+		return letter
+	}
+
+	letter.AggregateID = event.GetAggregateID()
+	letter.EventType = event.GetEventType()
+	letter.Version = event.GetVersion()
+	letter.SchemaVersion, _ = intAttribute(delivery.Headers["schema_version"])
+	letter.Timestamp = event.GetTimestamp()
+	letter.Metadata = redactPersonalMetadata(event.GetMetadata())
+
+	if payload, err := json.Marshal(event); err == nil {
+		letter.Payload = redactPersonalData(payload, registry.PersonalDataFields(shared.EventType(event.GetEventType())))
+	}
+	return letter
+}
+
+// deadLetterID matches the event store id of the message, or falls back to
+// a digest of the body for messages that cannot be identified.
+func deadLetterID(delivery amqp.Delivery, event shared.Event) string {
+	if event != nil {
+		return fmt.Sprintf("%s-%d", event.GetAggregateID(), event.GetVersion())
+	}
+	if delivery.MessageId != "" {
+		return delivery.MessageId
+	}
+	sum := sha256.Sum256(delivery.Body)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// failureReason prefers the error recorded by the consumer and falls back to
+// the broker's dead-lettering reason.
+func failureReason(headers amqp.Table) string {
+	if reason, ok := headers[lastErrorHeader].(string); ok {
+		return reason
+	}
+	if deaths, ok := headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			if reason, ok := death["reason"].(string); ok {
+				return reason
+			}
+		}
+	}
+	return "unknown"
+}
+
+// replayHeaders strips the failure bookkeeping so a replayed message starts
+// over with a fresh retry budget. The original routing key is kept, since
+// the replay itself is addressed to the queue rather than the event type.
+func replayHeaders(delivery amqp.Delivery) amqp.Table {
+	replayed := amqp.Table{}
+	for key, value := range delivery.Headers {
+		switch {
+		case key == retryCountHeader, key == lastErrorHeader, key == originalRoutingKeyHeader:
+		case key == "x-death", strings.HasPrefix(key, "x-first-death-"), strings.HasPrefix(key, "x-last-death-"):
+		default:
+			replayed[key] = value
+		}
+	}
+	replayed[originalRoutingKeyHeader] = originalRoutingKey(delivery)
+	return replayed
+}
+
+func redactPersonalData(payload json.RawMessage, fields []string) json.RawMessage {
+	if len(fields) == 0 {
+		return payload
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &document); err != nil {
+		return payload
+	}
+	for _, field := range fields {
+		if _, exists := document[field]; exists {
+			document[field] = json.RawMessage(`"[redacted]"`)
+		}
+	}
+
+	redacted, err := json.Marshal(document)
+	if err != nil {
+		return payload
+	}
+	return redacted
+}
+
+func redactPersonalMetadata(metadata shared.Metadata) shared.Metadata {
+	redacted := make(shared.Metadata, len(metadata))
+	for key, value := range metadata {
+		redacted[key] = value
+	}
+	for _, key := range shared.PersonalMetadata {
+		if _, exists := redacted[key]; exists {
+			redacted[key] = "[redacted]"
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,129 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/protobuf/proto"
+)
+
+func deadLetteredDelivery(t *testing.T, event shared.Event, registry shared.EventRegistry, headers amqp.Table) amqp.Delivery {
+	t.Helper()
+
+	eventMsg, err := SerializeEvent(event, registry)
+	if err != nil {
+		t.Fatalf("SerializeEvent() error = %v", err)
+	}
+	body, err := proto.Marshal(eventMsg)
+	if err != nil {
+		t.Fatalf("proto.Marshal() error = %v", err)
+	}
+
+	return amqp.Delivery{
+		ContentType: "application/protobuf",
+		RoutingKey:  "auth.events",
+		Headers:     headers,
+		Body:        body,
+	}
+}
+
+func TestDescribeDeadLetter(t *testing.T) {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+
+	event := user.NewUserRegisteredEvent("user-1", "alice", "hash")
+	event.SetMetadata(shared.Metadata{
+		shared.MetadataCorrelationID: "req-1",
+		shared.MetadataClientIP:      "10.0.0.1",
+	})
+	delivery := deadLetteredDelivery(t, event, registry, amqp.Table{
+		retryCountHeader: int32(5),
+		lastErrorHeader:  "projection failed",
+	})
+
+	letter := describeDeadLetter(delivery, registry)
+
+	if letter.ID != "user-1-1" {
+		t.Errorf("ID = %s, expected user-1-1", letter.ID)
+	}
+	if letter.EventType != string(user.EventTypeUserRegistered) {
+		t.Errorf("EventType = %s, expected %s", letter.EventType, user.EventTypeUserRegistered)
+	}
+	if letter.Reason != "projection failed" {
+		t.Errorf("Reason = %s, expected projection failed", letter.Reason)
+	}
+	if letter.Retries != 5 {
+		t.Errorf("Retries = %d, expected 5", letter.Retries)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(letter.Payload, &payload); err != nil {
+		t.Fatalf("Payload is not JSON: %v", err)
+	}
+	for _, field := range []string{"username", "password_hash"} {
+		if payload[field] != "[redacted]" {
+			t.Errorf("payload[%s] = %v, expected [redacted]", field, payload[field])
+		}
+	}
+	if payload["aggregate_id"] != "user-1" {
+		t.Errorf("payload[aggregate_id] = %v, expected user-1", payload["aggregate_id"])
+	}
+	if letter.Metadata[shared.MetadataClientIP] != "[redacted]" {
+		t.Errorf("Metadata[client_ip] = %s, expected [redacted]", letter.Metadata[shared.MetadataClientIP])
+	}
+	if letter.Metadata[shared.MetadataCorrelationID] != "req-1" {
+		t.Errorf("Metadata[correlation_id] = %s, expected req-1", letter.Metadata[shared.MetadataCorrelationID])
+	}
+}
+
+func TestDescribeDeadLetter_Undecodable(t *testing.T) {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+
+	delivery := amqp.Delivery{
+		ContentType: "text/plain",
+		Body:        []byte("garbage"),
+		Headers: amqp.Table{
+			"x-death": []interface{}{amqp.Table{"reason": "rejected"}},
+		},
+	}
+
+	letter := describeDeadLetter(delivery, registry)
+
+	if !strings.HasPrefix(letter.ID, "sha256:") {
+		t.Errorf("ID = %s, expected a body digest", letter.ID)
+	}
+	if letter.Reason != "rejected" {
+		t.Errorf("Reason = %s, expected rejected", letter.Reason)
+	}
+	if letter.Payload != nil {
+		t.Errorf("Payload = %s, expected none", letter.Payload)
+	}
+}
+
+func TestReplayHeaders(t *testing.T) {
+	headers := replayHeaders(amqp.Delivery{
+		RoutingKey: "auth.events.dlx",
+		Headers: amqp.Table{
+			"aggregate_id":           "user-1",
+			retryCountHeader:         int32(5),
+			lastErrorHeader:          "projection failed",
+			originalRoutingKeyHeader: "user.registered",
+			"x-death":                []interface{}{},
+			"x-first-death-reason":   "rejected",
+			"x-last-death-queue":     "auth.events",
+		},
+	})
+
+	if len(headers) != 2 || headers["aggregate_id"] != "user-1" {
+		t.Errorf("replayHeaders() = %v, expected aggregate_id and the routing key", headers)
+	}
+	if headers[originalRoutingKeyHeader] != "user.registered" {
+		t.Errorf("routing key header = %v, expected user.registered", headers[originalRoutingKeyHeader])
+	}
+}
@@ -1,6 +1,7 @@
 package rabbitmq
 
 import (
+	"encoding/json"
 	"fmt"
 
 	pb "github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/proto"
@@ -11,7 +12,7 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-func SerializeEvent(event shared.Event) (*pb.EventMessage, error) {
+func SerializeEvent(event shared.Event, registry shared.EventRegistry) (*pb.EventMessage, error) {
 	var payload []byte
 	var err error
 
@@ -41,6 +42,129 @@ func SerializeEvent(event shared.Event) (*pb.EventMessage, error) {
 			NewPasswordHash: e.NewPasswordHash,
 		}
 		payload, err = proto.Marshal(protoEvent)
+	case *user.UserPasswordRehashedEvent:
+		protoEvent := &pb.UserPasswordRehashedEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+			NewPasswordHash: e.NewPasswordHash,
+		}
+		payload, err = proto.Marshal(protoEvent)
+	case *user.UserErasedEvent:
+		protoEvent := &pb.UserErasedEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+		}
+		payload, err = proto.Marshal(protoEvent)
+	case *user.UserLoggedInEvent:
+		protoEvent := &pb.UserLoggedInEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+			IpAddress: e.IPAddress,
+			UserAgent: e.UserAgent,
+		}
+		payload, err = proto.Marshal(protoEvent)
+	case *user.UserLoginFailedEvent:
+		protoEvent := &pb.UserLoginFailedEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+			IpAddress: e.IPAddress,
+			UserAgent: e.UserAgent,
+			Reason:    e.Reason,
+		}
+		payload, err = proto.Marshal(protoEvent)
+	case *user.UserProfileUpdatedEvent:
+		protoEvent := &pb.UserProfileUpdatedEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+			DisplayName: e.DisplayName,
+			AvatarUrl:   e.AvatarURL,
+			Locale:      e.Locale,
+			Timezone:    e.Timezone,
+		}
+		payload, err = proto.Marshal(protoEvent)
+	case *user.UserUsernameChangedEvent:
+		protoEvent := &pb.UserUsernameChangedEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+			Username: e.Username,
+		}
+		payload, err = proto.Marshal(protoEvent)
+	case *user.UserEmailChangedEvent:
+		protoEvent := &pb.UserEmailChangedEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+			Email: e.Email,
+		}
+		payload, err = proto.Marshal(protoEvent)
+	case *user.UserLockedEvent:
+		protoEvent := &pb.UserLockedEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+		}
+		payload, err = proto.Marshal(protoEvent)
+	case *user.UserUnlockedEvent:
+		protoEvent := &pb.UserUnlockedEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+		}
+		payload, err = proto.Marshal(protoEvent)
+	case *user.UserRoleAssignedEvent:
+		protoEvent := &pb.UserRoleAssignedEvent{
+			Base: &pb.BaseEvent{
+				AggregateId:   e.GetAggregateID(),
+				AggregateType: e.GetAggregateType(),
+				EventType:     e.GetEventType(),
+				Version:       int32(e.GetVersion()),
+				Timestamp:     timestamppb.New(e.GetTimestamp()),
+			},
+			Role: e.Role,
+		}
+		payload, err = proto.Marshal(protoEvent)
 	default:
 		return nil, fmt.Errorf("unknown event type: %T", event)
 	}
@@ -49,6 +173,11 @@ func SerializeEvent(event shared.Event) (*pb.EventMessage, error) {
 		return nil, fmt.Errorf("failed to marshal event: %w", err)
 	}
 
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
 	return &pb.EventMessage{
 		AggregateId:   event.GetAggregateID(),
 		AggregateType: event.GetAggregateType(),
@@ -56,16 +185,61 @@ func SerializeEvent(event shared.Event) (*pb.EventMessage, error) {
 		Version:       int32(event.GetVersion()),
 		Timestamp:     timestamppb.New(event.GetTimestamp()),
 		Payload:       payload,
+		SchemaVersion: int32(registry.SchemaVersion(shared.EventType(event.GetEventType()))),
+		Metadata:      event.GetMetadata(),
+		Data:          data,
 	}, nil
 }
 
+// DeserializeEvent rebuilds the domain event from its JSON data, upcasting
+// data published under an older schema version before decoding it.
 func DeserializeEvent(msg *pb.EventMessage, registry shared.EventRegistry) (shared.Event, error) {
+	if len(msg.Data) == 0 {
+		return decodeLegacyEvent(msg, registry)
+	}
+
+	eventType := shared.EventType(msg.EventType)
+	event, ok := registry.CreateEvent(eventType)
+	if !ok {
+		return nil, fmt.Errorf("unknown event type: %s", msg.EventType)
+	}
+
+	data, err := registry.Upcast(eventType, int(msg.SchemaVersion), msg.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upcast event: %w", err)
+	}
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event data: %w", err)
+	}
+	event.SetMetadata(msg.Metadata)
+
+	return event, nil
+}
+
+// decodeLegacyEvent decodes a message published before messages carried
+// their JSON data. Proto payloads are decoded field by number into the
+// current struct, so one published under an older schema version cannot be
+// upcast and is refused rather than decoded with fields missing.
+func decodeLegacyEvent(msg *pb.EventMessage, registry shared.EventRegistry) (shared.Event, error) {
+	schemaVersion := int(msg.SchemaVersion)
+	if schemaVersion < shared.InitialSchemaVersion {
+		schemaVersion = shared.InitialSchemaVersion
+	}
+	if current := registry.SchemaVersion(shared.EventType(msg.EventType)); schemaVersion != current {
+		return nil, fmt.Errorf("%w: %s v%d without data, expected v%d",
+			shared.ErrUnsupportedSchemaVersion, msg.EventType, schemaVersion, current)
+	}
+	return decodeEvent(msg)
+}
+
+func decodeEvent(msg *pb.EventMessage) (shared.Event, error) {
 	baseEvent := shared.BaseEvent{
 		AggregateID:   msg.AggregateId,
 		AggregateType: msg.AggregateType,
 		EventType:     msg.EventType,
 		Version:       int(msg.Version),
 		Timestamp:     msg.Timestamp.AsTime(),
+		Metadata:      msg.Metadata,
 	}
 
 	switch shared.EventType(msg.EventType) {
@@ -88,6 +262,99 @@ func DeserializeEvent(msg *pb.EventMessage, registry shared.EventRegistry) (shar
 			BaseEvent:       baseEvent,
 			NewPasswordHash: protoEvent.NewPasswordHash,
 		}, nil
+	case user.EventTypeUserPasswordRehashed:
+		var protoEvent pb.UserPasswordRehashedEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserPasswordRehashedEvent: %w", err)
+		}
+		return &user.UserPasswordRehashedEvent{
+			BaseEvent:       baseEvent,
+			NewPasswordHash: protoEvent.NewPasswordHash,
+		}, nil
+	case user.EventTypeUserErased:
+		var protoEvent pb.UserErasedEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserErasedEvent: %w", err)
+		}
+		return &user.UserErasedEvent{
+			BaseEvent: baseEvent,
+		}, nil
+	case user.EventTypeUserLoggedIn:
+		var protoEvent pb.UserLoggedInEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserLoggedInEvent: %w", err)
+		}
+		return &user.UserLoggedInEvent{
+			BaseEvent: baseEvent,
+			IPAddress: protoEvent.IpAddress,
+			UserAgent: protoEvent.UserAgent,
+		}, nil
+	case user.EventTypeUserLoginFailed:
+		var protoEvent pb.UserLoginFailedEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserLoginFailedEvent: %w", err)
+		}
+		return &user.UserLoginFailedEvent{
+			BaseEvent: baseEvent,
+			IPAddress: protoEvent.IpAddress,
+			UserAgent: protoEvent.UserAgent,
+			Reason:    protoEvent.Reason,
+		}, nil
+	case user.EventTypeUserProfileUpdated:
+		var protoEvent pb.UserProfileUpdatedEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserProfileUpdatedEvent: %w", err)
+		}
+		return &user.UserProfileUpdatedEvent{
+			BaseEvent:   baseEvent,
+			DisplayName: protoEvent.DisplayName,
+			AvatarURL:   protoEvent.AvatarUrl,
+			Locale:      protoEvent.Locale,
+			Timezone:    protoEvent.Timezone,
+		}, nil
+	case user.EventTypeUserUsernameChanged:
+		var protoEvent pb.UserUsernameChangedEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserUsernameChangedEvent: %w", err)
+		}
+		return &user.UserUsernameChangedEvent{
+			BaseEvent: baseEvent,
+			Username:  protoEvent.Username,
+		}, nil
+	case user.EventTypeUserEmailChanged:
+		var protoEvent pb.UserEmailChangedEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserEmailChangedEvent: %w", err)
+		}
+		return &user.UserEmailChangedEvent{
+			BaseEvent: baseEvent,
+			Email:     protoEvent.Email,
+		}, nil
+	case user.EventTypeUserLocked:
+		var protoEvent pb.UserLockedEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserLockedEvent: %w", err)
+		}
+		return &user.UserLockedEvent{
+			BaseEvent: baseEvent,
+		}, nil
+	case user.EventTypeUserUnlocked:
+		var protoEvent pb.UserUnlockedEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserUnlockedEvent: %w", err)
+		}
+		return &user.UserUnlockedEvent{
+			BaseEvent: baseEvent,
+		}, nil
+	case user.EventTypeUserRoleAssigned:
+		var protoEvent pb.UserRoleAssignedEvent
+		if err := proto.Unmarshal(msg.Payload, &protoEvent); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal UserRoleAssignedEvent: %w", err)
+		}
+		return &user.UserRoleAssignedEvent{
+			BaseEvent: baseEvent,
+			Role:      protoEvent.Role,
+		}, nil
 	default:
 		return nil, fmt.Errorf("unknown event type: %s", msg.EventType)
 	}
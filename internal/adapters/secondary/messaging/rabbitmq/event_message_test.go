@@ -0,0 +1,262 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/proto"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+func newUpcastingRegistry() shared.EventRegistry {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	registry.RegisterUpcaster(shared.Upcaster{
+		EventType:   user.EventTypeUserRegistered,
+		FromVersion: 1,
+		Upcast: func(payload map[string]interface{}) (map[string]interface{}, error) {
+			username, _ := payload["username"].(string)
+			payload["username"] = strings.ToLower(username)
+			return payload, nil
+		},
+	})
+	return registry
+}
+
+func TestDeserializeEvent_Upcasting(t *testing.T) {
+	tests := []struct {
+		name             string
+		schemaVersion    int32
+		expectedUsername string
+		expectedError    error
+	}{
+		{
+			name:             "current schema version",
+			schemaVersion:    2,
+			expectedUsername: "Alice",
+		},
+		{
+			name:             "older schema version is upcasted",
+			schemaVersion:    1,
+			expectedUsername: "alice",
+		},
+		{
+			name:             "missing schema version is upcasted",
+			schemaVersion:    0,
+			expectedUsername: "alice",
+		},
+		{
+			name:          "newer schema version",
+			schemaVersion: 3,
+			expectedError: shared.ErrUnsupportedSchemaVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := newUpcastingRegistry()
+			original := user.NewUserRegisteredEvent("user-1", "Alice", "hash")
+			original.Timestamp = time.Now().UTC()
+
+			msg, err := SerializeEvent(original, registry)
+			if err != nil {
+				t.Fatalf("Failed to serialize event: %v", err)
+			}
+			if msg.SchemaVersion != 2 {
+				t.Fatalf("SerializeEvent() schema version = %v, expected 2", msg.SchemaVersion)
+			}
+			msg.SchemaVersion = tt.schemaVersion
+
+			event, err := DeserializeEvent(msg, registry)
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("DeserializeEvent() error = %v, expected error %v", err, tt.expectedError)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			registered, ok := event.(*user.UserRegisteredEvent)
+			if !ok {
+				t.Fatalf("DeserializeEvent() returned %T", event)
+			}
+			if registered.Username != tt.expectedUsername {
+				t.Errorf("Username = %v, expected %v", registered.Username, tt.expectedUsername)
+			}
+			if registered.PasswordHash != original.PasswordHash {
+				t.Errorf("PasswordHash = %v, expected %v", registered.PasswordHash, original.PasswordHash)
+			}
+			if registered.GetVersion() != original.GetVersion() {
+				t.Errorf("Version = %v, expected %v", registered.GetVersion(), original.GetVersion())
+			}
+			if !registered.GetTimestamp().Equal(original.GetTimestamp()) {
+				t.Errorf("Timestamp = %v, expected %v", registered.GetTimestamp(), original.GetTimestamp())
+			}
+		})
+	}
+}
+
+func TestDeserializeEvent_Metadata(t *testing.T) {
+	tests := []struct {
+		name          string
+		schemaVersion int32
+	}{
+		{
+			name:          "current schema version",
+			schemaVersion: 2,
+		},
+		{
+			name:          "upcasted schema version",
+			schemaVersion: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := newUpcastingRegistry()
+			metadata := shared.Metadata{
+				shared.MetadataCorrelationID: "req-1",
+				shared.MetadataCausationID:   "req-1",
+				shared.MetadataActorID:       "user-1",
+				shared.MetadataClientIP:      "10.0.0.1",
+				shared.MetadataUserAgent:     "curl/8.0",
+			}
+			original := user.NewUserRegisteredEvent("user-1", "Alice", "hash")
+			original.SetMetadata(metadata)
+
+			msg, err := SerializeEvent(original, registry)
+			if err != nil {
+				t.Fatalf("Failed to serialize event: %v", err)
+			}
+			msg.SchemaVersion = tt.schemaVersion
+
+			event, err := DeserializeEvent(msg, registry)
+			if err != nil {
+				t.Fatalf("DeserializeEvent() error = %v", err)
+			}
+
+			for key, expected := range metadata {
+				if actual := event.GetMetadata()[key]; actual != expected {
+					t.Errorf("Metadata[%s] = %v, expected %v", key, actual, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSerializeEvent_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		event shared.Event
+	}{
+		{name: "registered", event: user.NewUserRegisteredEvent("user-1", "alice", "hash")},
+		{name: "password changed", event: user.NewUserPasswordChangedEvent("user-1", "hash-2", 2)},
+		{name: "erased", event: user.NewUserErasedEvent("user-1", 3)},
+		{name: "logged in", event: user.NewUserLoggedInEvent("user-1", "203.0.113.7", "curl/8.0", 2)},
+		{name: "login failed", event: user.NewUserLoginFailedEvent("user-1", "203.0.113.7", "curl/8.0", user.LoginFailureInvalidPassword, 2)},
+		{name: "profile updated", event: user.NewUserProfileUpdatedEvent("user-1", user.Profile{
+			DisplayName: "Alice",
+			AvatarURL:   "https://cdn.example.com/a.png",
+			Locale:      "en-GB",
+			Timezone:    "Europe/London",
+		}, 2)},
+		{name: "username changed", event: user.NewUserUsernameChangedEvent("user-1", "alicia", 2)},
+		{name: "password rehashed", event: user.NewUserPasswordRehashedEvent("user-1", "$argon2id$v=19$m=65536,t=3,p=4$c2FsdA$aGFzaA", 2)},
+		{name: "email changed", event: user.NewUserEmailChangedEvent("user-1", "alice@example.com", 2)},
+		{name: "locked", event: user.NewUserLockedEvent("user-1", 2)},
+		{name: "unlocked", event: user.NewUserUnlockedEvent("user-1", 3)},
+		{name: "role assigned", event: user.NewUserRoleAssignedEvent("user-1", user.RoleAdmin, 2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := shared.NewEventRegistry()
+			user.RegisterEvents(registry)
+
+			msg, err := SerializeEvent(tt.event, registry)
+			if err != nil {
+				t.Fatalf("Failed to serialize event: %v", err)
+			}
+			event, err := DeserializeEvent(msg, registry)
+			if err != nil {
+				t.Fatalf("DeserializeEvent() error = %v", err)
+			}
+
+			if !event.GetTimestamp().Equal(tt.event.GetTimestamp()) {
+				t.Errorf("Timestamp = %v, expected %v", event.GetTimestamp(), tt.event.GetTimestamp())
+			}
+			// timestamps come back in UTC, so compare the rest of the payload
+			if actual, expected := payloadWithoutTimestamp(t, event), payloadWithoutTimestamp(t, tt.event); !reflect.DeepEqual(actual, expected) {
+				t.Errorf("DeserializeEvent() = %v, expected %v", actual, expected)
+			}
+
+			// messages published before they carried data decode the proto payload
+			msg.Data = nil
+			event, err = DeserializeEvent(msg, registry)
+			if err != nil {
+				t.Fatalf("DeserializeEvent() without data error = %v", err)
+			}
+			if actual, expected := payloadWithoutTimestamp(t, event), payloadWithoutTimestamp(t, tt.event); !reflect.DeepEqual(actual, expected) {
+				t.Errorf("DeserializeEvent() without data = %v, expected %v", actual, expected)
+			}
+		})
+	}
+}
+
+func TestDeserializeEvent_UpcastsRenamedField(t *testing.T) {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	registry.RegisterUpcaster(shared.Upcaster{
+		EventType:   user.EventTypeUserRegistered,
+		FromVersion: 1,
+		Upcast: func(payload map[string]interface{}) (map[string]interface{}, error) {
+			payload["username"] = payload["name"]
+			delete(payload, "name")
+			return payload, nil
+		},
+	})
+
+	msg := &pb.EventMessage{
+		AggregateId:   "user-1",
+		AggregateType: "USER",
+		EventType:     string(user.EventTypeUserRegistered),
+		Version:       1,
+		SchemaVersion: 1,
+		Data:          []byte(`{"aggregate_id": "user-1", "version": 1, "name": "alice", "password_hash": "hash"}`),
+	}
+
+	event, err := DeserializeEvent(msg, registry)
+	if err != nil {
+		t.Fatalf("DeserializeEvent() error = %v", err)
+	}
+	registered := event.(*user.UserRegisteredEvent)
+	if registered.Username != "alice" || registered.PasswordHash != "hash" {
+		t.Errorf("DeserializeEvent() = %s/%s, expected the renamed field to carry alice", registered.Username, registered.PasswordHash)
+	}
+
+	// without data the old field names are lost, so the message is refused
+	msg.Data = nil
+	if _, err := DeserializeEvent(msg, registry); !errors.Is(err, shared.ErrUnsupportedSchemaVersion) {
+		t.Errorf("DeserializeEvent() without data error = %v, expected %v", err, shared.ErrUnsupportedSchemaVersion)
+	}
+}
+
+func payloadWithoutTimestamp(t *testing.T, event shared.Event) map[string]interface{} {
+	t.Helper()
+
+	raw, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("Failed to marshal event: %v", err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		t.Fatalf("Failed to unmarshal event: %v", err)
+	}
+	delete(payload, "timestamp")
+	return payload
+}
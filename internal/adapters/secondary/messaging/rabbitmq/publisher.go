@@ -2,13 +2,29 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
 	"github.com/ncfex/dcart-auth/internal/domain/shared"
 	amqp "github.com/rabbitmq/amqp091-go"
-	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrMessageUnroutable is returned when the broker hands a mandatory
+	// message back because no queue is bound to receive it.
+	ErrMessageUnroutable = errors.New("message unroutable")
+	// ErrMessageNacked is returned when the broker refuses a message it
+	// received, as opposed to a confirm that never arrived.
+	ErrMessageNacked = errors.New("message nacked by broker")
+	// ErrPublishBufferFull is returned while reconnecting once BufferSize
+	// messages are already waiting.
+	ErrPublishBufferFull = errors.New("publish buffer full")
+
+	// errAdapterClosed stops a reconnect that lost the race with Close.
+	errAdapterClosed = errors.New("rabbitmq publisher closed")
 )
 
 type RabbitMQConfig struct {
@@ -17,88 +33,237 @@ type RabbitMQConfig struct {
 	ExchangeType string
 	RoutingKey   string
 	Timeout      time.Duration
+	// while the connection is down up to BufferSize publishes are held in
+	// memory and sent once a reconnect, retried every ReconnectDelay, succeeds
+	ReconnectDelay time.Duration
+	BufferSize     int
+	// Format defaults to protobuf; Source is the CloudEvents source attribute
+	Format MessageFormat
+	Source string
 }
 
 type RabbitMQAdapter struct {
 	config    RabbitMQConfig
+	registry  shared.EventRegistry
 	conn      *amqp.Connection
 	channel   *amqp.Channel
-	confirms  chan amqp.Confirmation
+	returns   chan amqp.Return
 	mu        sync.RWMutex
 	connected bool
+	closing   bool
+	stopChan  chan struct{}
+
+	// publishes accepted while disconnected, oldest first
+	buffer  []amqp.Publishing
+	flushMu sync.Mutex
+
+	// returns read while looking for another publish's, keyed by message id
+	returnsMu sync.Mutex
+	returned  map[string]amqp.Return
 }
 
-func NewRabbitMQAdapter(config RabbitMQConfig) (*RabbitMQAdapter, error) {
+func NewRabbitMQAdapter(config RabbitMQConfig, registry shared.EventRegistry) (*RabbitMQAdapter, error) {
 	adapter := &RabbitMQAdapter{
-		config: config,
+		config:   config,
+		registry: registry,
+		stopChan: make(chan struct{}),
+		returned: make(map[string]amqp.Return),
 	}
 
 	if err := adapter.initialize(); err != nil {
 		return nil, fmt.Errorf("adapter initialization failed: %w", err)
 	}
+	// nothing is buffered yet; this only marks the adapter connected
+	if err := adapter.flush(); err != nil {
+		return nil, fmt.Errorf("adapter initialization failed: %w", err)
+	}
 
 	return adapter, nil
 }
 
+// PublishEvent sends event and waits for the broker to confirm it. While the
+// connection is being re-established the message is buffered instead and
+// PublishEvent returns nil; buffered messages are lost if the process exits
+// before the broker comes back.
 func (a *RabbitMQAdapter) PublishEvent(ctx context.Context, event shared.Event) error {
-	a.mu.RLock()
-	if !a.connected {
-		a.mu.RUnlock()
-		return fmt.Errorf("rabbitmq connection unavailable")
+	return a.PublishEvents(ctx, []shared.Event{event})
+}
+
+// PublishEvents sends every event before waiting for any confirm, and reports
+// the events the broker did not accept. Like PublishEvent it buffers while
+// reconnecting, all events or none.
+func (a *RabbitMQAdapter) PublishEvents(ctx context.Context, events []shared.Event) error {
+	msgs := make([]amqp.Publishing, 0, len(events))
+	for _, event := range events {
+		msg, err := a.publishing(event)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
 	}
-	a.mu.RUnlock()
 
-	// todo add generic marshaler
-	eventMsg, err := SerializeEvent(event)
-	if err != nil {
-		return fmt.Errorf("event serialization failed: %w", err)
+	buffered, err := a.bufferIfDisconnected(msgs)
+	if err != nil || buffered {
+		return err
 	}
 
-	payload, err := proto.Marshal(eventMsg)
+	return a.publish(ctx, msgs...)
+}
+
+func (a *RabbitMQAdapter) publishing(event shared.Event) (amqp.Publishing, error) {
+	msg, err := encodeEvent(event, a.config.Format, a.config.Source, a.registry)
 	if err != nil {
-		return fmt.Errorf("event message serialization failed: %w", err)
+		return amqp.Publishing{}, err
 	}
 
-	msg := amqp.Publishing{
-		ContentType: "application/protobuf",
-		Body:        payload,
-		Timestamp:   event.GetTimestamp(),
-		Headers: amqp.Table{
-			"aggregate_id":   event.GetAggregateID(),
-			"aggregate_type": event.GetAggregateType(),
-			"event_type":     event.GetEventType(),
-			"version":        event.GetVersion(),
-		},
-		DeliveryMode: amqp.Persistent,
+	// routing headers are set in every format; consumers shard on aggregate_id
+	msg.Headers["aggregate_id"] = event.GetAggregateID()
+	msg.Headers["aggregate_type"] = event.GetAggregateType()
+	msg.Headers["event_type"] = event.GetEventType()
+	msg.Headers["version"] = event.GetVersion()
+	msg.Headers["schema_version"] = int32(a.registry.SchemaVersion(shared.EventType(event.GetEventType())))
+	for key, value := range event.GetMetadata() {
+		msg.Headers[key] = value
 	}
 
-	if err := a.channel.PublishWithContext(
-		ctx,
-		a.config.Exchange,
-		a.config.RoutingKey,
-		true,
-		false,
-		msg,
-	); err != nil {
-		return fmt.Errorf("message publishing failed: %w", err)
+	msg.Timestamp = event.GetTimestamp()
+	msg.MessageId = fmt.Sprintf("%s-%d", event.GetAggregateID(), event.GetVersion())
+	msg.CorrelationId = event.GetMetadata()[shared.MetadataCorrelationID]
+	msg.DeliveryMode = amqp.Persistent
+	return msg, nil
+}
+
+// bufferIfDisconnected holds msgs for the reconnect flush when the
+// connection is down. It reports whether msgs were taken.
+func (a *RabbitMQAdapter) bufferIfDisconnected(msgs []amqp.Publishing) (bool, error) {
+	a.mu.RLock()
+	connected := a.connected
+	a.mu.RUnlock()
+	if connected {
+		return false, nil
 	}
 
-	select {
-	case confirm := <-a.confirms:
-		if !confirm.Ack {
-			return fmt.Errorf("message delivery unconfirmed by broker")
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.connected {
+		return false, nil
+	}
+	if a.closing {
+		return false, fmt.Errorf("rabbitmq connection unavailable")
+	}
+	if len(a.buffer)+len(msgs) > a.config.BufferSize {
+		return false, ErrPublishBufferFull
+	}
+	a.buffer = append(a.buffer, msgs...)
+	return true, nil
+}
+
+// publish sends msgs and then waits for each one's own confirm, matched by
+// delivery tag, so concurrent callers never see each other's outcome.
+func (a *RabbitMQAdapter) publish(ctx context.Context, msgs ...amqp.Publishing) error {
+	a.mu.RLock()
+	channel := a.channel
+	a.mu.RUnlock()
+
+	type pending struct {
+		msg          amqp.Publishing
+		confirmation *amqp.DeferredConfirmation
+	}
+
+	var errs []error
+	sent := make([]pending, 0, len(msgs))
+	for _, msg := range msgs {
+		// mandatory, so unroutable messages come back through NotifyReturn
+		confirmation, err := channel.PublishWithDeferredConfirmWithContext(
+			ctx,
+			a.config.Exchange,
+			a.config.RoutingKey,
+			true,
+			false,
+			msg,
+		)
+		if err != nil {
+			// later messages would overtake this one
+			errs = append(errs, fmt.Errorf("message %s publishing failed: %w", msg.MessageId, err))
+			break
+		}
+		sent = append(sent, pending{msg: msg, confirmation: confirmation})
+	}
+
+	for _, p := range sent {
+		if err := a.awaitConfirm(ctx, channel, p.msg, p.confirmation); err != nil {
+			errs = append(errs, fmt.Errorf("message %s: %w", p.msg.MessageId, err))
 		}
-	case <-ctx.Done():
-		return fmt.Errorf("publish confirmation timeout: %w", ctx.Err())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *RabbitMQAdapter) awaitConfirm(
+	ctx context.Context,
+	channel *amqp.Channel,
+	msg amqp.Publishing,
+	confirmation *amqp.DeferredConfirmation,
+) error {
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("publish confirmation timeout: %w", err)
+	}
+	if !acked {
+		// pending confirms are nacked when the channel goes away
+		if channel.IsClosed() {
+			return fmt.Errorf("message publishing failed: %w", amqp.ErrClosed)
+		}
+		return ErrMessageNacked
+	}
+
+	// the broker sends a return before the ack of the same message
+	if ret, ok := a.takeReturn(msg.MessageId); ok {
+		return fmt.Errorf("%w: %s (%d)", ErrMessageUnroutable, ret.ReplyText, ret.ReplyCode)
 	}
 
 	return nil
 }
 
+// takeReturn reports whether the broker returned the message with messageID.
+// Returns of other in-flight publishes are kept for their owners.
+func (a *RabbitMQAdapter) takeReturn(messageID string) (amqp.Return, bool) {
+	a.mu.RLock()
+	returns := a.returns
+	a.mu.RUnlock()
+
+	a.returnsMu.Lock()
+	defer a.returnsMu.Unlock()
+
+	for drained := false; !drained; {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				drained = true
+				continue
+			}
+			a.returned[ret.MessageId] = ret
+		default:
+			drained = true
+		}
+	}
+
+	ret, ok := a.returned[messageID]
+	delete(a.returned, messageID)
+	return ret, ok
+}
+
+// initialize connects and declares the exchange. It refuses once Close has
+// begun, since Close would not see a connection opened after it.
 func (a *RabbitMQAdapter) initialize() error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	if a.closing {
+		return errAdapterClosed
+	}
+
 	conn, err := amqp.Dial(a.config.URI)
 	if err != nil {
 		return fmt.Errorf("connection establishment failed: %w", err)
@@ -130,27 +295,149 @@ func (a *RabbitMQAdapter) initialize() error {
 		return fmt.Errorf("exchange declaration failed: %w", err)
 	}
 
-	a.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 100))
+	a.returns = ch.NotifyReturn(make(chan amqp.Return, 100))
+
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	channelClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	go a.watch(conn, connClosed, channelClosed)
 
 	a.conn = conn
 	a.channel = ch
-	a.connected = true
 
 	return nil
 }
 
+// watch waits for the connection or its channel to go away and reconnects,
+// unless the adapter is being closed.
+func (a *RabbitMQAdapter) watch(conn *amqp.Connection, connClosed, channelClosed chan *amqp.Error) {
+	var reason *amqp.Error
+	select {
+	case reason = <-connClosed:
+	case reason = <-channelClosed:
+	case <-a.stopChan:
+		return
+	}
+
+	a.mu.Lock()
+	if a.closing {
+		a.mu.Unlock()
+		return
+	}
+	a.connected = false
+	a.mu.Unlock()
+
+	log.Printf("rabbitmq publisher connection lost: %v", reason)
+	// a channel exception leaves the connection open
+	conn.Close()
+
+	a.reconnect()
+}
+
+func (a *RabbitMQAdapter) reconnect() {
+	for {
+		select {
+		case <-a.stopChan:
+			return
+		case <-time.After(a.config.ReconnectDelay):
+			err := a.initialize()
+			if errors.Is(err, errAdapterClosed) {
+				return
+			}
+			if err != nil {
+				log.Printf("failed to reconnect publisher: %v", err)
+				continue
+			}
+			err = a.flush()
+			if err != nil && !errors.Is(err, errAdapterClosed) {
+				// the new connection's watcher takes over
+				log.Printf("failed to flush publish buffer: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// flush sends the buffered messages in order and only then marks the adapter
+// connected, so new publishes cannot overtake buffered ones.
+func (a *RabbitMQAdapter) flush() error {
+	return a.drain(func(ctx context.Context, msg amqp.Publishing) error {
+		return a.publish(ctx, msg)
+	})
+}
+
+// drain sends the buffered messages one at a time. A message leaves the
+// buffer once it is confirmed or the broker refused it; a timeout says
+// nothing about whether the broker has it, so the message is kept and sent
+// again and may be delivered twice. Nothing deduplicates by message id: the
+// projections skip events at or below the version they already hold.
+// Draining stops once Close has begun.
+func (a *RabbitMQAdapter) drain(send func(ctx context.Context, msg amqp.Publishing) error) error {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	for {
+		a.mu.Lock()
+		if a.closing {
+			a.mu.Unlock()
+			return errAdapterClosed
+		}
+		if len(a.buffer) == 0 {
+			a.connected = true
+			a.mu.Unlock()
+			return nil
+		}
+		msg := a.buffer[0]
+		a.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), a.config.Timeout)
+		err := send(ctx, msg)
+		cancel()
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrMessageNacked), errors.Is(err, ErrMessageUnroutable):
+			// retrying will not change the broker's mind
+			log.Printf("dropping buffered message %s: %v", msg.MessageId, err)
+		case errors.Is(err, amqp.ErrClosed):
+			return err
+		default:
+			log.Printf("retrying buffered message %s: %v", msg.MessageId, err)
+			select {
+			case <-a.stopChan:
+				return err
+			case <-time.After(a.config.ReconnectDelay):
+			}
+			continue
+		}
+
+		a.mu.Lock()
+		a.buffer = a.buffer[1:]
+		a.mu.Unlock()
+	}
+}
+
 func (a *RabbitMQAdapter) Close() error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	if a.closing {
+		return nil
+	}
+	a.closing = true
+	a.connected = false
+	close(a.stopChan)
+
+	if len(a.buffer) > 0 {
+		log.Printf("discarding %d buffered message(s)", len(a.buffer))
+	}
+
 	if a.channel != nil {
-		if err := a.channel.Close(); err != nil {
+		if err := a.channel.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
 			return fmt.Errorf("channel closure failed: %w", err)
 		}
 	}
 
 	if a.conn != nil {
-		if err := a.conn.Close(); err != nil {
+		if err := a.conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
 			return fmt.Errorf("connection closure failed: %w", err)
 		}
 	}
@@ -0,0 +1,200 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// disconnectedAdapter is an adapter whose connection dropped and has not come
+// back yet.
+func disconnectedAdapter(bufferSize int) *RabbitMQAdapter {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+
+	return &RabbitMQAdapter{
+		config:   RabbitMQConfig{BufferSize: bufferSize},
+		registry: registry,
+		stopChan: make(chan struct{}),
+		returned: make(map[string]amqp.Return),
+	}
+}
+
+func TestRabbitMQAdapter_BuffersWhileDisconnected(t *testing.T) {
+	adapter := disconnectedAdapter(2)
+	ctx := context.Background()
+
+	for i, aggregateID := range []string{"user-1", "user-2"} {
+		if err := adapter.PublishEvent(ctx, user.NewUserRegisteredEvent(aggregateID, "alice", "hash")); err != nil {
+			t.Fatalf("PublishEvent() #%d error = %v", i, err)
+		}
+	}
+
+	err := adapter.PublishEvent(ctx, user.NewUserRegisteredEvent("user-3", "alice", "hash"))
+	if !errors.Is(err, ErrPublishBufferFull) {
+		t.Fatalf("PublishEvent() error = %v, expected ErrPublishBufferFull", err)
+	}
+
+	if len(adapter.buffer) != 2 {
+		t.Fatalf("buffered %d messages, expected 2", len(adapter.buffer))
+	}
+	if adapter.buffer[0].MessageId != "user-1-1" || adapter.buffer[1].MessageId != "user-2-1" {
+		t.Errorf("buffer order = [%s %s], expected [user-1-1 user-2-1]", adapter.buffer[0].MessageId, adapter.buffer[1].MessageId)
+	}
+}
+
+func TestRabbitMQAdapter_RejectsAfterClose(t *testing.T) {
+	adapter := disconnectedAdapter(10)
+	if err := adapter.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := adapter.PublishEvent(context.Background(), user.NewUserRegisteredEvent("user-1", "alice", "hash")); err == nil {
+		t.Fatal("PublishEvent() after Close() succeeded, expected an error")
+	}
+	if len(adapter.buffer) != 0 {
+		t.Errorf("buffered %d messages after Close(), expected none", len(adapter.buffer))
+	}
+}
+
+func TestRabbitMQAdapter_TakeReturn(t *testing.T) {
+	adapter := disconnectedAdapter(0)
+	adapter.returns = make(chan amqp.Return, 2)
+	adapter.returns <- amqp.Return{MessageId: "user-2-1", ReplyCode: 312}
+	adapter.returns <- amqp.Return{MessageId: "user-1-1", ReplyCode: 312}
+
+	if _, ok := adapter.takeReturn("user-1-1"); !ok {
+		t.Error("takeReturn(user-1-1) found nothing, expected its return")
+	}
+	if _, ok := adapter.takeReturn("user-3-1"); ok {
+		t.Error("takeReturn(user-3-1) found a return, expected none")
+	}
+	// read by the first call, kept for its owner
+	if _, ok := adapter.takeReturn("user-2-1"); !ok {
+		t.Error("takeReturn(user-2-1) found nothing, expected its return")
+	}
+	if _, ok := adapter.takeReturn("user-2-1"); ok {
+		t.Error("takeReturn(user-2-1) found a return twice")
+	}
+}
+
+func TestRabbitMQAdapter_BuffersBatchWhole(t *testing.T) {
+	adapter := disconnectedAdapter(2)
+	events := []shared.Event{
+		user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+		user.NewUserPasswordChangedEvent("user-1", "new-hash", 2),
+		user.NewUserErasedEvent("user-1", 3),
+	}
+
+	if err := adapter.PublishEvents(context.Background(), events); !errors.Is(err, ErrPublishBufferFull) {
+		t.Fatalf("PublishEvents() error = %v, expected ErrPublishBufferFull", err)
+	}
+	if len(adapter.buffer) != 0 {
+		t.Fatalf("buffered %d messages, expected a rejected batch to buffer none", len(adapter.buffer))
+	}
+
+	if err := adapter.PublishEvents(context.Background(), events[:2]); err != nil {
+		t.Fatalf("PublishEvents() error = %v", err)
+	}
+	if len(adapter.buffer) != 2 {
+		t.Errorf("buffered %d messages, expected 2", len(adapter.buffer))
+	}
+}
+
+func TestRabbitMQAdapter_DrainKeepsUnconfirmedMessages(t *testing.T) {
+	tests := []struct {
+		name           string
+		outcomes       []error
+		expectedSent   []string
+		expectedBuffer int
+		expectedErr    error
+	}{
+		{
+			name:         "confirm timeout is retried",
+			outcomes:     []error{context.DeadlineExceeded, nil, nil},
+			expectedSent: []string{"user-1-1", "user-1-1", "user-2-1"},
+		},
+		{
+			name:         "nack drops the message",
+			outcomes:     []error{ErrMessageNacked, nil},
+			expectedSent: []string{"user-1-1", "user-2-1"},
+		},
+		{
+			name:         "mandatory return drops the message",
+			outcomes:     []error{ErrMessageUnroutable, nil},
+			expectedSent: []string{"user-1-1", "user-2-1"},
+		},
+		{
+			name:           "lost channel keeps the message for the reconnect",
+			outcomes:       []error{amqp.ErrClosed},
+			expectedSent:   []string{"user-1-1"},
+			expectedBuffer: 2,
+			expectedErr:    amqp.ErrClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := disconnectedAdapter(2)
+			for _, aggregateID := range []string{"user-1", "user-2"} {
+				if err := adapter.PublishEvent(context.Background(), user.NewUserRegisteredEvent(aggregateID, "alice", "hash")); err != nil {
+					t.Fatalf("PublishEvent() error = %v", err)
+				}
+			}
+
+			var sent []string
+			err := adapter.drain(func(_ context.Context, msg amqp.Publishing) error {
+				sent = append(sent, msg.MessageId)
+				return tt.outcomes[len(sent)-1]
+			})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("drain() error = %v, expected %v", err, tt.expectedErr)
+			}
+
+			if !reflect.DeepEqual(sent, tt.expectedSent) {
+				t.Errorf("sent %v, expected %v", sent, tt.expectedSent)
+			}
+			if len(adapter.buffer) != tt.expectedBuffer {
+				t.Errorf("buffer holds %d messages, expected %d", len(adapter.buffer), tt.expectedBuffer)
+			}
+			if adapter.connected != (tt.expectedErr == nil) {
+				t.Errorf("connected = %v after drain() error %v", adapter.connected, err)
+			}
+		})
+	}
+}
+
+func TestRabbitMQAdapter_ReconnectAfterCloseGivesUp(t *testing.T) {
+	adapter := disconnectedAdapter(2)
+	if err := adapter.PublishEvent(context.Background(), user.NewUserRegisteredEvent("user-1", "alice", "hash")); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+	if err := adapter.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// no URI is configured, so only the closed check keeps this from dialing
+	if err := adapter.initialize(); !errors.Is(err, errAdapterClosed) {
+		t.Errorf("initialize() after Close() error = %v, expected errAdapterClosed", err)
+	}
+	if adapter.conn != nil {
+		t.Error("initialize() after Close() opened a connection")
+	}
+
+	err := adapter.drain(func(context.Context, amqp.Publishing) error {
+		t.Error("drain() after Close() sent a message")
+		return nil
+	})
+	if !errors.Is(err, errAdapterClosed) {
+		t.Errorf("drain() after Close() error = %v, expected errAdapterClosed", err)
+	}
+	if adapter.connected {
+		t.Error("drain() after Close() marked the adapter connected")
+	}
+}
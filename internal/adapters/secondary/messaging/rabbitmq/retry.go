@@ -0,0 +1,80 @@
+package rabbitmq
+
+import (
+	"context"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	retryCountHeader         = "x-retry-count"
+	lastErrorHeader          = "x-last-error"
+	originalRoutingKeyHeader = "x-original-routing-key"
+)
+
+// retryDelay is the wait before the given retry attempt, doubling from base.
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	return base << (attempt - 1)
+}
+
+// sleep waits for d and reports false if ctx ends first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// retryCount reads the retries recorded on a delivery, set when it is
+// dead-lettered and by the retry queues of earlier releases; header integers
+// come back from the broker in whichever width they were sent.
+func retryCount(headers map[string]interface{}) int {
+	switch count := headers[retryCountHeader].(type) {
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	case int:
+		return count
+	default:
+		return 0
+	}
+}
+
+// failureHeaders copies the delivery headers and records why it failed and
+// where it was first routed, which a replay of the dead letter restores.
+func failureHeaders(delivery amqp.Delivery, err error) amqp.Table {
+	headers := amqp.Table{}
+	for key, value := range delivery.Headers {
+		headers[key] = value
+	}
+	headers[lastErrorHeader] = err.Error()
+	headers[originalRoutingKeyHeader] = originalRoutingKey(delivery)
+	return headers
+}
+
+func originalRoutingKey(delivery amqp.Delivery) string {
+	if routingKey, ok := delivery.Headers[originalRoutingKeyHeader].(string); ok {
+		return routingKey
+	}
+	return delivery.RoutingKey
+}
+
+func republishing(delivery amqp.Delivery, headers amqp.Table) amqp.Publishing {
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   delivery.ContentType,
+		CorrelationId: delivery.CorrelationId,
+		MessageId:     delivery.MessageId,
+		Timestamp:     delivery.Timestamp,
+		Type:          delivery.Type,
+		DeliveryMode:  amqp.Persistent,
+		Body:          delivery.Body,
+	}
+}
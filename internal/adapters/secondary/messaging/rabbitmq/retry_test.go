@@ -0,0 +1,136 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: time.Second},
+		{attempt: 2, expected: 2 * time.Second},
+		{attempt: 5, expected: 16 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if delay := retryDelay(time.Second, tt.attempt); delay != tt.expected {
+			t.Errorf("retryDelay(1s, %d) = %v, expected %v", tt.attempt, delay, tt.expected)
+		}
+	}
+}
+
+func TestRetryCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  amqp.Table
+		expected int
+	}{
+		{name: "first delivery", headers: nil, expected: 0},
+		{name: "int32 header", headers: amqp.Table{retryCountHeader: int32(2)}, expected: 2},
+		{name: "int64 header", headers: amqp.Table{retryCountHeader: int64(3)}, expected: 3},
+		{name: "unexpected type", headers: amqp.Table{retryCountHeader: "4"}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if count := retryCount(tt.headers); count != tt.expected {
+				t.Errorf("retryCount() = %d, expected %d", count, tt.expected)
+			}
+		})
+	}
+}
+
+// flakyProjector fails the first failures projections.
+type flakyProjector struct {
+	failures int
+	calls    int
+}
+
+func (p *flakyProjector) ProjectEvent(context.Context, shared.Event) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("read model unavailable")
+	}
+	return nil
+}
+
+func TestConsumer_RetriesInPlace(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int
+		stopped          bool
+		expectedCalls    int
+		expectedAcked    bool
+		expectedRequeued bool
+		expectedLetters  int
+	}{
+		{name: "recovers on retry", failures: 1, expectedCalls: 2, expectedAcked: true},
+		{name: "dead-lettered after the last retry", failures: 10, expectedCalls: 3, expectedAcked: true, expectedLetters: 1},
+		{name: "requeued when stopped while waiting", failures: 1, stopped: true, expectedCalls: 1, expectedRequeued: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := newUpcastingRegistry()
+			msg, err := encodeEvent(user.NewUserUsernameChangedEvent("user-1", "alicia", 2), FormatProtobuf, "/dcart/auth", registry)
+			if err != nil {
+				t.Fatalf("encodeEvent() error = %v", err)
+			}
+
+			broker := newFakeBroker()
+			config := ConsumerConfig{
+				Exchange:          "domain.events",
+				ExchangeType:      "topic",
+				Queue:             "auth.events",
+				RoutingKey:        "#",
+				ProcessingTimeout: time.Second,
+				MaxRetries:        2,
+				RetryBaseDelay:    time.Millisecond,
+			}
+			if err := declareConsumerTopology(broker, config); err != nil {
+				t.Fatalf("declareConsumerTopology() error = %v", err)
+			}
+			projector := &flakyProjector{failures: tt.failures}
+			consumer := &Consumer{config: config, forwarder: broker, projector: projector, eventRegistry: registry}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.stopped {
+				cancel()
+			}
+			defer cancel()
+
+			acknowledger := &fakeAcknowledger{}
+			delivery := delivered(msg)
+			delivery.Acknowledger = acknowledger
+			delivery.RoutingKey = "user.usernameChanged"
+			consumer.processDelivery(ctx, delivery)
+
+			if projector.calls != tt.expectedCalls {
+				t.Errorf("projected %d times, expected %d", projector.calls, tt.expectedCalls)
+			}
+			if acknowledger.acked != tt.expectedAcked || acknowledger.requeued != tt.expectedRequeued {
+				t.Errorf("acked = %v requeued = %v, expected %v %v", acknowledger.acked, acknowledger.requeued, tt.expectedAcked, tt.expectedRequeued)
+			}
+			letters := broker.queues[deadLetterQueueName(config.Queue)]
+			if len(letters) != tt.expectedLetters {
+				t.Fatalf("DLQ holds %d messages, expected %d", len(letters), tt.expectedLetters)
+			}
+			if len(letters) > 0 && retryCount(letters[0].Headers) != config.MaxRetries {
+				t.Errorf("dead letter retry count = %d, expected %d", retryCount(letters[0].Headers), config.MaxRetries)
+			}
+			if len(broker.queues[config.Queue]) != 0 {
+				t.Errorf("the retried delivery was republished to %s", config.Queue)
+			}
+		})
+	}
+}
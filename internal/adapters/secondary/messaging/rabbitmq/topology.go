@@ -0,0 +1,283 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// amqpPublisher is the part of a channel that republishes messages.
+type amqpPublisher interface {
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// topologyDeclarer is the part of a channel that declares the topology.
+type topologyDeclarer interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+}
+
+// deadLetterExchangeName is the DLX of a single queue. Feeds share the event
+// exchange but never their DLX, so a message one feed gives up on lands in
+// that feed's DLQ only.
+func deadLetterExchangeName(queue string) string {
+	return queue + ".dlx"
+}
+
+func deadLetterQueueName(queue string) string {
+	return queue + ".dlq"
+}
+
+// legacyDeadLetterExchangeName is the fanout DLX every queue on exchange
+// shared before each queue got its own; see migrateConsumerTopology.
+func legacyDeadLetterExchangeName(exchange string) string {
+	return exchange + ".dlx"
+}
+
+// declareConsumerTopology declares the event exchange and the consumer's
+// queue with its own DLX and DLQ. Failed deliveries are retried by the
+// consumer itself, so there are no retry queues.
+func declareConsumerTopology(ch topologyDeclarer, config ConsumerConfig) error {
+	if err := declareConsumerQueues(ch, config); err != nil {
+		return err
+	}
+	return bindConsumerQueue(ch, config)
+}
+
+// declareConsumerQueues declares everything declareConsumerTopology does
+// except the binding that feeds the queue from the event exchange.
+func declareConsumerQueues(ch topologyDeclarer, config ConsumerConfig) error {
+	// topic
+	if err := ch.ExchangeDeclare(
+		config.Exchange,
+		config.ExchangeType,
+		true, // durable
+		false,
+		false,
+		false,
+		nil,
+	); err != nil {
+		return fmt.Errorf("exchange declaration failed: %w", err)
+	}
+
+	// dlx
+	dlxName := deadLetterExchangeName(config.Queue)
+	if err := ch.ExchangeDeclare(
+		dlxName,
+		"fanout",
+		true,
+		false,
+		false,
+		false,
+		nil,
+	); err != nil {
+		return fmt.Errorf("DLX declaration failed: %w", err)
+	}
+
+	args := amqp.Table{
+		"x-dead-letter-exchange": dlxName,
+		"x-message-ttl":          259200000, // 72hrs
+	}
+	if _, err := ch.QueueDeclare(
+		config.Queue,
+		true,
+		false,
+		false,
+		false,
+		args,
+	); err != nil {
+		return fmt.Errorf("queue declaration failed: %w", err)
+	}
+
+	// dlq
+	dlqName := deadLetterQueueName(config.Queue)
+	if _, err := ch.QueueDeclare(
+		dlqName,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	); err != nil {
+		return fmt.Errorf("DLQ declaration failed: %w", err)
+	}
+
+	if err := ch.QueueBind(
+		dlqName,
+		"",
+		dlxName,
+		false,
+		nil,
+	); err != nil {
+		return fmt.Errorf("DLQ binding failed: %w", err)
+	}
+
+	return nil
+}
+
+func bindConsumerQueue(ch topologyDeclarer, config ConsumerConfig) error {
+	if err := ch.QueueBind(
+		config.Queue,
+		config.RoutingKey,
+		config.Exchange,
+		false,
+		nil,
+	); err != nil {
+		return fmt.Errorf("queue binding failed: %w", err)
+	}
+	return nil
+}
+
+// isPreconditionFailed reports whether the broker refused a declaration
+// because the entity already exists with other arguments.
+func isPreconditionFailed(err error) bool {
+	var amqpErr *amqp.Error
+	return errors.As(err, &amqpErr) && amqpErr.Code == amqp.PreconditionFailed
+}
+
+// topologyMigrator is the part of a channel that rebuilds a queue.
+type topologyMigrator interface {
+	topologyDeclarer
+	amqpPublisher
+	QueueUnbind(name, key, exchange string, args amqp.Table) error
+	QueueDelete(name string, ifUnused, ifEmpty, noWait bool) (int, error)
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
+}
+
+// MigrateConsumerTopology moves a queue declared with the shared legacy DLX
+// onto its own DLX and DLQ, keeping every message it holds. Queue arguments
+// cannot change in place, so the queue is emptied, deleted and declared
+// again; run it while no consumer is attached. It reports false when the
+// queue is already current, after finishing any migration that was cut
+// short.
+func MigrateConsumerTopology(ctx context.Context, config ConsumerConfig) (bool, error) {
+	conn, err := amqp.Dial(config.URI)
+	if err != nil {
+		return false, fmt.Errorf("connection establishment failed: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, fmt.Errorf("channel creation failed: %w", err)
+	}
+	err = declareConsumerTopology(ch, config)
+	ch.Close()
+	if err != nil && !isPreconditionFailed(err) {
+		return false, err
+	}
+	legacy := err != nil
+
+	// a refused declaration closes the channel
+	ch, err = conn.Channel()
+	if err != nil {
+		return false, fmt.Errorf("channel creation failed: %w", err)
+	}
+	defer ch.Close()
+
+	if err := migrateConsumerTopology(ctx, ch, config, legacy); err != nil {
+		return false, err
+	}
+	return legacy, nil
+}
+
+// migrateConsumerTopology rebuilds the queue through two durable holding
+// queues: <queue>.backlog takes what the old queue held, and
+// <queue>.migrating, bound like the queue, takes whatever is published in
+// the meantime. Both are drained back in that order, so messages keep
+// their order; each step can be repeated, so a migration that stopped
+// halfway is finished by running it again with legacy false.
+func migrateConsumerTopology(ctx context.Context, ch topologyMigrator, config ConsumerConfig, legacy bool) error {
+	backlog := config.Queue + ".backlog"
+	holding := config.Queue + ".migrating"
+
+	for _, name := range []string{backlog, holding} {
+		if _, err := ch.QueueDeclare(name, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("holding queue declaration failed: %w", err)
+		}
+	}
+
+	if legacy {
+		if err := ch.QueueBind(holding, config.RoutingKey, config.Exchange, false, nil); err != nil {
+			return fmt.Errorf("holding queue binding failed: %w", err)
+		}
+		if err := ch.QueueUnbind(config.Queue, config.RoutingKey, config.Exchange, nil); err != nil {
+			return fmt.Errorf("queue unbinding failed: %w", err)
+		}
+		if err := moveMessages(ctx, ch, config.Queue, backlog); err != nil {
+			return err
+		}
+		// fails while a consumer is still attached or a message slipped in
+		if _, err := ch.QueueDelete(config.Queue, true, true, false); err != nil {
+			return fmt.Errorf("queue deletion failed: %w", err)
+		}
+
+		// the shared DLX would keep copying other queues' dead letters here
+		if err := ch.QueueUnbind(
+			deadLetterQueueName(config.Queue),
+			config.RoutingKey,
+			legacyDeadLetterExchangeName(config.Exchange),
+			nil,
+		); err != nil {
+			return fmt.Errorf("DLQ unbinding failed: %w", err)
+		}
+
+		if err := declareConsumerQueues(ch, config); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range []string{backlog, holding} {
+		if err := moveMessages(ctx, ch, name, config.Queue); err != nil {
+			return err
+		}
+	}
+	if err := bindConsumerQueue(ch, config); err != nil {
+		return err
+	}
+	if err := ch.QueueUnbind(holding, config.RoutingKey, config.Exchange, nil); err != nil {
+		return fmt.Errorf("holding queue unbinding failed: %w", err)
+	}
+	// published between the last drain and the bind above
+	if err := moveMessages(ctx, ch, holding, config.Queue); err != nil {
+		return err
+	}
+
+	for _, name := range []string{backlog, holding} {
+		if _, err := ch.QueueDelete(name, false, true, false); err != nil {
+			return fmt.Errorf("holding queue deletion failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// moveMessages republishes everything in from to the queue to, acking each
+// message only once it has been published.
+func moveMessages(ctx context.Context, ch topologyMigrator, from, to string) error {
+	for {
+		delivery, ok, err := ch.Get(from, false)
+		if err != nil {
+			return fmt.Errorf("failed to get message from %s: %w", from, err)
+		}
+		if !ok {
+			return nil
+		}
+
+		if err := ch.PublishWithContext(
+			ctx,
+			"",
+			to,
+			false,
+			false,
+			republishing(delivery, delivery.Headers),
+		); err != nil {
+			return fmt.Errorf("failed to move message to %s: %w", to, err)
+		}
+		if err := delivery.Ack(false); err != nil {
+			return fmt.Errorf("failed to ack moved message: %w", err)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeBroker routes messages the way rabbitmq does for the exchange kinds
+// the consumers declare, and keeps whatever reaches a queue.
+type fakeBroker struct {
+	exchanges map[string]string
+	bindings  map[string][]fakeBinding
+	queueArgs map[string]amqp.Table
+	queues    map[string][]amqp.Publishing
+}
+
+type fakeBinding struct {
+	queue string
+	key   string
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{
+		exchanges: map[string]string{},
+		bindings:  map[string][]fakeBinding{},
+		queueArgs: map[string]amqp.Table{},
+		queues:    map[string][]amqp.Publishing{},
+	}
+}
+
+func (b *fakeBroker) ExchangeDeclare(name, kind string, _, _, _, _ bool, _ amqp.Table) error {
+	if declared, ok := b.exchanges[name]; ok && declared != kind {
+		return errors.New("exchange redeclared with another kind")
+	}
+	b.exchanges[name] = kind
+	return nil
+}
+
+func (b *fakeBroker) QueueDeclare(name string, _, _, _, _ bool, args amqp.Table) (amqp.Queue, error) {
+	if declared, ok := b.queueArgs[name]; ok && !reflect.DeepEqual(declared, args) {
+		return amqp.Queue{}, &amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED - inequivalent arg"}
+	}
+	b.queueArgs[name] = args
+	return amqp.Queue{Name: name, Messages: len(b.queues[name])}, nil
+}
+
+func (b *fakeBroker) QueueBind(name, key, exchange string, _ bool, _ amqp.Table) error {
+	if _, ok := b.exchanges[exchange]; !ok {
+		return errors.New("no exchange " + exchange)
+	}
+	for _, binding := range b.bindings[exchange] {
+		if binding.queue == name && binding.key == key {
+			return nil
+		}
+	}
+	b.bindings[exchange] = append(b.bindings[exchange], fakeBinding{queue: name, key: key})
+	return nil
+}
+
+func (b *fakeBroker) QueueUnbind(name, key, exchange string, _ amqp.Table) error {
+	var kept []fakeBinding
+	for _, binding := range b.bindings[exchange] {
+		if binding.queue != name || binding.key != key {
+			kept = append(kept, binding)
+		}
+	}
+	b.bindings[exchange] = kept
+	return nil
+}
+
+func (b *fakeBroker) QueueDelete(name string, _, ifEmpty, _ bool) (int, error) {
+	if ifEmpty && len(b.queues[name]) > 0 {
+		return 0, &amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED - queue not empty"}
+	}
+	purged := len(b.queues[name])
+	delete(b.queueArgs, name)
+	delete(b.queues, name)
+	for exchange := range b.bindings {
+		var kept []fakeBinding
+		for _, binding := range b.bindings[exchange] {
+			if binding.queue != name {
+				kept = append(kept, binding)
+			}
+		}
+		b.bindings[exchange] = kept
+	}
+	return purged, nil
+}
+
+func (b *fakeBroker) Get(queue string, _ bool) (amqp.Delivery, bool, error) {
+	if len(b.queues[queue]) == 0 {
+		return amqp.Delivery{}, false, nil
+	}
+	msg := b.queues[queue][0]
+	b.queues[queue] = b.queues[queue][1:]
+	return amqp.Delivery{
+		Acknowledger: &fakeAcknowledger{},
+		Headers:      msg.Headers,
+		MessageId:    msg.MessageId,
+		Body:         msg.Body,
+	}, true, nil
+}
+
+func (b *fakeBroker) PublishWithContext(_ context.Context, exchange, key string, _, _ bool, msg amqp.Publishing) error {
+	if exchange == "" {
+		b.queues[key] = append(b.queues[key], msg)
+		return nil
+	}
+
+	kind, ok := b.exchanges[exchange]
+	if !ok {
+		return errors.New("no exchange " + exchange)
+	}
+	for _, binding := range b.bindings[exchange] {
+		if kind == "fanout" || binding.key == "#" || binding.key == key {
+			b.queues[binding.queue] = append(b.queues[binding.queue], msg)
+		}
+	}
+	return nil
+}
+
+type fakeAcknowledger struct {
+	acked    bool
+	requeued bool
+}
+
+func (a *fakeAcknowledger) Ack(uint64, bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	a.requeued = requeue
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(uint64, bool) error { return nil }
+
+func TestConsumer_DeadLetterReachesOnlyItsOwnQueue(t *testing.T) {
+	broker := newFakeBroker()
+
+	consumers := map[string]*Consumer{}
+	for _, queue := range []string{"auth.events", "auth.webhooks"} {
+		config := ConsumerConfig{
+			Exchange:     "domain.events",
+			ExchangeType: "topic",
+			Queue:        queue,
+			RoutingKey:   "#",
+			MaxRetries:   2,
+		}
+		if err := declareConsumerTopology(broker, config); err != nil {
+			t.Fatalf("declareConsumerTopology(%s) error = %v", queue, err)
+		}
+		consumers[queue] = &Consumer{config: config, forwarder: broker}
+	}
+
+	for queue, consumer := range consumers {
+		acknowledger := &fakeAcknowledger{}
+		consumer.deadLetter(context.Background(), amqp.Delivery{
+			Acknowledger: acknowledger,
+			RoutingKey:   "user.registered",
+			MessageId:    queue,
+		}, 2, errors.New("projection failed"))
+
+		if !acknowledger.acked {
+			t.Errorf("%s: dead-lettered delivery was not acked", queue)
+		}
+	}
+
+	// the broker dead-letters expired messages through the queue argument
+	for queue := range consumers {
+		dlx, _ := broker.queueArgs[queue]["x-dead-letter-exchange"].(string)
+		if err := broker.PublishWithContext(context.Background(), dlx, "user.registered", false, false, amqp.Publishing{
+			MessageId: queue + ".expired",
+		}); err != nil {
+			t.Fatalf("%s: expiring message error = %v", queue, err)
+		}
+	}
+
+	for queue := range consumers {
+		letters := broker.queues[deadLetterQueueName(queue)]
+		if len(letters) != 2 {
+			t.Fatalf("%s DLQ holds %d messages, expected 2", queue, len(letters))
+		}
+		for _, letter := range letters {
+			if letter.MessageId != queue && letter.MessageId != queue+".expired" {
+				t.Errorf("%s DLQ holds %s, expected only its own dead letters", queue, letter.MessageId)
+			}
+		}
+		if routingKey := letters[0].Headers[originalRoutingKeyHeader]; routingKey != "user.registered" {
+			t.Errorf("%s dead letter routing key = %v, expected user.registered", queue, routingKey)
+		}
+	}
+	if len(broker.queues["auth.events"]) != 0 || len(broker.queues["auth.webhooks"]) != 0 {
+		t.Errorf("dead letters reached a main queue: %v", broker.queues)
+	}
+}
+
+func TestDeadLetterQueue_ReplayReachesOnlyTheOriginQueue(t *testing.T) {
+	broker := newFakeBroker()
+	for _, queue := range []string{"auth.events", "auth.webhooks"} {
+		if err := declareConsumerTopology(broker, ConsumerConfig{
+			Exchange:     "domain.events",
+			ExchangeType: "topic",
+			Queue:        queue,
+			RoutingKey:   "#",
+		}); err != nil {
+			t.Fatalf("declareConsumerTopology(%s) error = %v", queue, err)
+		}
+	}
+
+	deadLetters := &DeadLetterQueue{config: DeadLetterConfig{Queue: "auth.webhooks"}}
+	if err := deadLetters.replay(context.Background(), broker, amqp.Delivery{
+		RoutingKey: "auth.webhooks.dlx",
+		Headers:    amqp.Table{originalRoutingKeyHeader: "user.registered"},
+	}); err != nil {
+		t.Fatalf("replay() error = %v", err)
+	}
+
+	if len(broker.queues["auth.webhooks"]) != 1 {
+		t.Errorf("auth.webhooks holds %d messages, expected 1", len(broker.queues["auth.webhooks"]))
+	}
+	if len(broker.queues["auth.events"]) != 0 {
+		t.Errorf("auth.events holds %d messages, expected none", len(broker.queues["auth.events"]))
+	}
+}
+
+// declareLegacyTopology declares a queue the way consumers did while every
+// queue on the exchange dead-lettered through one shared fanout DLX.
+func declareLegacyTopology(t *testing.T, broker *fakeBroker, config ConsumerConfig) {
+	t.Helper()
+
+	legacyDLX := legacyDeadLetterExchangeName(config.Exchange)
+	steps := []error{
+		broker.ExchangeDeclare(config.Exchange, config.ExchangeType, true, false, false, false, nil),
+		broker.ExchangeDeclare(legacyDLX, "fanout", true, false, false, false, nil),
+	}
+	_, err := broker.QueueDeclare(config.Queue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange": legacyDLX,
+		"x-message-ttl":          259200000,
+	})
+	steps = append(steps, err)
+	_, err = broker.QueueDeclare(deadLetterQueueName(config.Queue), true, false, false, false, nil)
+	steps = append(steps,
+		err,
+		broker.QueueBind(config.Queue, config.RoutingKey, config.Exchange, false, nil),
+		broker.QueueBind(deadLetterQueueName(config.Queue), config.RoutingKey, legacyDLX, false, nil),
+	)
+	for _, err := range steps {
+		if err != nil {
+			t.Fatalf("declaring legacy topology: %v", err)
+		}
+	}
+}
+
+func TestMigrateConsumerTopology_RebuildsLegacyQueue(t *testing.T) {
+	ctx := context.Background()
+	broker := newFakeBroker()
+
+	configs := map[string]ConsumerConfig{}
+	for _, queue := range []string{"auth.events", "auth.webhooks"} {
+		configs[queue] = ConsumerConfig{
+			Exchange:     "domain.events",
+			ExchangeType: "topic",
+			Queue:        queue,
+			RoutingKey:   "#",
+		}
+		declareLegacyTopology(t, broker, configs[queue])
+	}
+
+	for _, id := range []string{"first", "second"} {
+		if err := broker.PublishWithContext(ctx, "domain.events", "user.registered", false, false, amqp.Publishing{MessageId: id}); err != nil {
+			t.Fatalf("publish %s error = %v", id, err)
+		}
+	}
+	broker.queues["auth.events.dlq"] = append(broker.queues["auth.events.dlq"], amqp.Publishing{MessageId: "dead"})
+
+	config := configs["auth.events"]
+	if err := declareConsumerTopology(broker, config); !isPreconditionFailed(err) {
+		t.Fatalf("declareConsumerTopology() on a legacy queue error = %v, expected precondition failed", err)
+	}
+	if err := migrateConsumerTopology(ctx, broker, config, true); err != nil {
+		t.Fatalf("migrateConsumerTopology() error = %v", err)
+	}
+
+	if err := declareConsumerTopology(broker, config); err != nil {
+		t.Errorf("declareConsumerTopology() after migrating error = %v", err)
+	}
+	for _, name := range []string{"auth.events.backlog", "auth.events.migrating"} {
+		if _, ok := broker.queueArgs[name]; ok {
+			t.Errorf("holding queue %s was left behind", name)
+		}
+	}
+
+	if err := broker.PublishWithContext(ctx, "domain.events", "user.registered", false, false, amqp.Publishing{MessageId: "third"}); err != nil {
+		t.Fatalf("publish third error = %v", err)
+	}
+	var ids []string
+	for _, msg := range broker.queues["auth.events"] {
+		ids = append(ids, msg.MessageId)
+	}
+	if !reflect.DeepEqual(ids, []string{"first", "second", "third"}) {
+		t.Errorf("auth.events holds %v, expected [first second third]", ids)
+	}
+
+	// the webhooks queue is not migrated yet; its expired messages must no
+	// longer be copied into the events DLQ
+	if err := broker.PublishWithContext(ctx, legacyDeadLetterExchangeName("domain.events"), "user.registered", false, false, amqp.Publishing{MessageId: "expired"}); err != nil {
+		t.Fatalf("expiring message error = %v", err)
+	}
+	if dlq := broker.queues["auth.events.dlq"]; len(dlq) != 1 || dlq[0].MessageId != "dead" {
+		t.Errorf("auth.events.dlq holds %v, expected only its earlier dead letter", dlq)
+	}
+	if len(broker.queues["auth.webhooks.dlq"]) != 1 {
+		t.Errorf("auth.webhooks.dlq holds %d messages, expected 1", len(broker.queues["auth.webhooks.dlq"]))
+	}
+}
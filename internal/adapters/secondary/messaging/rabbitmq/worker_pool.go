@@ -0,0 +1,61 @@
+package rabbitmq
+
+import (
+	"hash/fnv"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// workerPool fans deliveries out to a fixed set of workers. Deliveries are
+// sharded by their aggregate_id header, so events of one aggregate are
+// handled strictly in order while different aggregates run in parallel.
+type workerPool struct {
+	workers []chan amqp.Delivery
+	wg      sync.WaitGroup
+}
+
+func newWorkerPool(size, buffer int, handle func(amqp.Delivery)) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+
+	pool := &workerPool{
+		workers: make([]chan amqp.Delivery, size),
+	}
+	for i := range pool.workers {
+		deliveries := make(chan amqp.Delivery, buffer)
+		pool.workers[i] = deliveries
+
+		pool.wg.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			for delivery := range deliveries {
+				handle(delivery)
+			}
+		}()
+	}
+	return pool
+}
+
+// dispatch hands delivery to the worker owning its aggregate, blocking while
+// that worker is busy; the channel prefetch bounds how much piles up.
+func (p *workerPool) dispatch(delivery amqp.Delivery) {
+	aggregateID, _ := delivery.Headers["aggregate_id"].(string)
+	p.workers[workerIndex(aggregateID, len(p.workers))] <- delivery
+}
+
+// drain stops accepting deliveries and waits until every dispatched one has
+// been handled and acknowledged.
+func (p *workerPool) drain() {
+	for _, deliveries := range p.workers {
+		close(deliveries)
+	}
+	p.wg.Wait()
+}
+
+func workerIndex(aggregateID string, size int) int {
+	h := fnv.New32a()
+	h.Write([]byte(aggregateID))
+	return int(h.Sum32() % uint32(size))
+}
@@ -0,0 +1,109 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func delivery(aggregateID string, version int) amqp.Delivery {
+	return amqp.Delivery{
+		Headers: amqp.Table{
+			"aggregate_id": aggregateID,
+			"version":      int32(version),
+		},
+	}
+}
+
+func TestWorkerPool_KeepsAggregateOrder(t *testing.T) {
+	var mu sync.Mutex
+	handled := make(map[string][]int32)
+
+	pool := newWorkerPool(4, 2, func(d amqp.Delivery) {
+		// uneven work so unordered handling would show up
+		time.Sleep(time.Duration(d.Headers["version"].(int32)%3) * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		aggregateID := d.Headers["aggregate_id"].(string)
+		handled[aggregateID] = append(handled[aggregateID], d.Headers["version"].(int32))
+	})
+
+	const aggregates, versions = 10, 20
+	for v := 1; v <= versions; v++ {
+		for a := 0; a < aggregates; a++ {
+			pool.dispatch(delivery(fmt.Sprintf("user-%d", a), v))
+		}
+	}
+	pool.drain()
+
+	if len(handled) != aggregates {
+		t.Fatalf("handled %d aggregates, expected %d", len(handled), aggregates)
+	}
+	for aggregateID, got := range handled {
+		if len(got) != versions {
+			t.Errorf("%s: handled %d deliveries, expected %d", aggregateID, len(got), versions)
+			continue
+		}
+		for i, version := range got {
+			if version != int32(i+1) {
+				t.Errorf("%s: handled order = %v, expected ascending versions", aggregateID, got)
+				break
+			}
+		}
+	}
+}
+
+func TestWorkerPool_RunsAggregatesInParallel(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string, 2)
+
+	pool := newWorkerPool(8, 1, func(d amqp.Delivery) {
+		started <- d.Headers["aggregate_id"].(string)
+		<-release
+	})
+
+	// pick two aggregates owned by different workers
+	first, second := "user-0", ""
+	for i := 1; second == ""; i++ {
+		candidate := fmt.Sprintf("user-%d", i)
+		if workerIndex(candidate, 8) != workerIndex(first, 8) {
+			second = candidate
+		}
+	}
+	pool.dispatch(delivery(first, 1))
+	pool.dispatch(delivery(second, 1))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("second aggregate waited for the first one")
+		}
+	}
+	close(release)
+	pool.drain()
+}
+
+func TestWorkerPool_DrainWaitsForInFlight(t *testing.T) {
+	var handled int
+	var mu sync.Mutex
+
+	pool := newWorkerPool(2, 4, func(d amqp.Delivery) {
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	})
+	for i := 0; i < 6; i++ {
+		pool.dispatch(delivery("user-1", i+1))
+	}
+	pool.drain()
+
+	if handled != 6 {
+		t.Errorf("drain() returned after %d deliveries, expected 6", handled)
+	}
+}
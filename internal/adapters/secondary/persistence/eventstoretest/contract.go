@@ -0,0 +1,589 @@
+// Package eventstoretest holds the behaviour every secondary.EventStore
+// implementation must share. Adapter tests call Run with a factory for their
+// store so the backends cannot drift apart.
+package eventstoretest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/encryption"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+// Factory returns an empty store that resolves events through registry and
+// keeps personal data encrypted with cipher.
+type Factory func(t *testing.T, registry shared.EventRegistry, cipher secondary.PayloadCipher) secondary.EventStore
+
+func Run(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, newStore Factory)
+	}{
+		{"saves and loads a stream in version order", testSaveAndLoad},
+		{"returns no events for an unknown aggregate", testUnknownAggregate},
+		{"rejects a stale version", testStaleVersion},
+		{"rejects a version gap", testVersionGap},
+		{"rejects a whole batch when one event conflicts", testAtomicBatch},
+		{"lets exactly one concurrent writer win", testConcurrentWriters},
+		{"filters by event type in timestamp order", testGetEventsByType},
+		{"round trips metadata", testMetadata},
+		{"upcasts payloads stored under an older schema", testUpcasting},
+		{"shreds personal data once the data key is deleted", testShredding},
+		{"keeps a reserved value with a single aggregate", testReservations},
+		{"reserves values outside of an append", testReserve},
+		{"lets exactly one concurrent registration take a value", testConcurrentReservations},
+		{"keeps the latest snapshot and the events after it", testSnapshots},
+		{"shreds snapshots once the data key is deleted", testSnapshotShredding},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStore)
+		})
+	}
+}
+
+func newRegistry() shared.EventRegistry {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	return registry
+}
+
+// keyRing is a DataKeyRepository kept in the test; the shredding case deletes
+// from it directly.
+type keyRing struct {
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+func (k *keyRing) CreateKey(ctx context.Context, subjectID string, key []byte) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, exists := k.keys[subjectID]; exists {
+		return secondary.ErrDataKeyExists
+	}
+	k.keys[subjectID] = key
+	return nil
+}
+
+func (k *keyRing) GetKey(ctx context.Context, subjectID string) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, exists := k.keys[subjectID]
+	if !exists {
+		return nil, secondary.ErrDataKeyNotFound
+	}
+	return key, nil
+}
+
+func (k *keyRing) DeleteKey(ctx context.Context, subjectID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.keys, subjectID)
+	return nil
+}
+
+func openStore(t *testing.T, newStore Factory, registry shared.EventRegistry) (secondary.EventStore, *keyRing) {
+	keys := &keyRing{keys: make(map[string][]byte)}
+	return newStore(t, registry, encryption.NewFieldCipher(keys, registry)), keys
+}
+
+func mustSave(t *testing.T, store secondary.EventStore, aggregateID string, events ...shared.Event) {
+	t.Helper()
+	if err := store.SaveEvents(context.Background(), aggregateID, events); err != nil {
+		t.Fatalf("SaveEvents() error = %v", err)
+	}
+}
+
+func assertConflict(t *testing.T, err error, expectedVersion, actualVersion int) {
+	t.Helper()
+	if !errors.Is(err, secondary.ErrConcurrencyConflict) {
+		t.Fatalf("SaveEvents() error = %v, expected a concurrency conflict", err)
+	}
+
+	var conflict *secondary.ConcurrencyConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("SaveEvents() error is %T, expected *secondary.ConcurrencyConflictError", err)
+	}
+	if conflict.ExpectedVersion != expectedVersion || conflict.ActualVersion != actualVersion {
+		t.Errorf("conflict versions = (%d, %d), expected (%d, %d)",
+			conflict.ExpectedVersion, conflict.ActualVersion, expectedVersion, actualVersion)
+	}
+}
+
+func mustLoad(t *testing.T, store secondary.EventStore, aggregateID string) []shared.Event {
+	t.Helper()
+	events, err := store.GetEvents(context.Background(), aggregateID)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	return events
+}
+
+func testSaveAndLoad(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+
+	registered := user.NewUserRegisteredEvent("user-1", "alice", "hash-1")
+	mustSave(t, store, "user-1", registered)
+	mustSave(t, store, "user-1",
+		user.NewUserPasswordChangedEvent("user-1", "hash-2", 2),
+		user.NewUserPasswordChangedEvent("user-1", "hash-3", 3),
+	)
+
+	events := mustLoad(t, store, "user-1")
+	if len(events) != 3 {
+		t.Fatalf("GetEvents() returned %d events, expected 3", len(events))
+	}
+	if version, err := store.GetVersion(context.Background(), "user-1"); err != nil || version != 3 {
+		t.Errorf("GetVersion() = (%d, %v), expected 3", version, err)
+	}
+	for i, event := range events {
+		if event.GetVersion() != i+1 {
+			t.Errorf("events[%d] version = %d, expected %d", i, event.GetVersion(), i+1)
+		}
+		if event.GetAggregateID() != "user-1" {
+			t.Errorf("events[%d] aggregate id = %s, expected user-1", i, event.GetAggregateID())
+		}
+	}
+
+	loaded, ok := events[0].(*user.UserRegisteredEvent)
+	if !ok {
+		t.Fatalf("events[0] is %T, expected *user.UserRegisteredEvent", events[0])
+	}
+	if loaded == registered {
+		t.Error("GetEvents() returned the saved instance, expected a fresh one")
+	}
+	if loaded.Username != "alice" || loaded.PasswordHash != "hash-1" {
+		t.Errorf("events[0] = %+v, expected saved payload", loaded)
+	}
+	if !loaded.GetTimestamp().Equal(registered.GetTimestamp()) {
+		t.Errorf("events[0] timestamp = %v, expected %v", loaded.GetTimestamp(), registered.GetTimestamp())
+	}
+
+	changed, ok := events[2].(*user.UserPasswordChangedEvent)
+	if !ok {
+		t.Fatalf("events[2] is %T, expected *user.UserPasswordChangedEvent", events[2])
+	}
+	if changed.NewPasswordHash != "hash-3" {
+		t.Errorf("events[2] hash = %s, expected hash-3", changed.NewPasswordHash)
+	}
+}
+
+func testUnknownAggregate(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+
+	if events := mustLoad(t, store, "missing"); len(events) != 0 {
+		t.Errorf("GetEvents() returned %d events, expected none", len(events))
+	}
+	if version, err := store.GetVersion(context.Background(), "missing"); err != nil || version != 0 {
+		t.Errorf("GetVersion() = (%d, %v), expected 0", version, err)
+	}
+}
+
+func testStaleVersion(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+	mustSave(t, store, "user-1", user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+
+	err := store.SaveEvents(context.Background(), "user-1", []shared.Event{
+		user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+	})
+	assertConflict(t, err, 0, 1)
+
+	if events := mustLoad(t, store, "user-1"); len(events) != 1 {
+		t.Errorf("GetEvents() returned %d events, expected 1", len(events))
+	}
+}
+
+func testVersionGap(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+
+	err := store.SaveEvents(context.Background(), "user-1", []shared.Event{
+		user.NewUserPasswordChangedEvent("user-1", "hash", 2),
+	})
+	assertConflict(t, err, 1, 0)
+
+	if events := mustLoad(t, store, "user-1"); len(events) != 0 {
+		t.Errorf("GetEvents() returned %d events, expected none", len(events))
+	}
+}
+
+func testAtomicBatch(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+	mustSave(t, store, "user-1", user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+
+	err := store.SaveEvents(context.Background(), "user-1", []shared.Event{
+		user.NewUserPasswordChangedEvent("user-1", "hash-2", 2),
+		user.NewUserPasswordChangedEvent("user-1", "hash-4", 4),
+	})
+	if err == nil {
+		t.Fatal("SaveEvents() expected error for conflicting batch")
+	}
+
+	if events := mustLoad(t, store, "user-1"); len(events) != 1 {
+		t.Errorf("GetEvents() returned %d events, expected the batch to be discarded", len(events))
+	}
+}
+
+func testConcurrentWriters(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+	mustSave(t, store, "user-1", user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+
+	const writers = 8
+	var wg sync.WaitGroup
+	results := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- store.SaveEvents(context.Background(), "user-1", []shared.Event{
+				user.NewUserPasswordChangedEvent("user-1", "hash-2", 2),
+			})
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	succeeded := 0
+	for err := range results {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		if !errors.Is(err, secondary.ErrConcurrencyConflict) {
+			t.Errorf("SaveEvents() error = %v, expected a concurrency conflict", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d writers succeeded, expected exactly 1", succeeded)
+	}
+
+	if events := mustLoad(t, store, "user-1"); len(events) != 2 {
+		t.Errorf("GetEvents() returned %d events, expected 2", len(events))
+	}
+}
+
+func testConcurrentReservations(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+
+	const writers = 8
+	var wg sync.WaitGroup
+	results := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(aggregateID string) {
+			defer wg.Done()
+			results <- store.SaveEvents(context.Background(), aggregateID, []shared.Event{
+				user.NewUserRegisteredEvent(aggregateID, "alice", "hash"),
+			})
+		}(fmt.Sprintf("user-%d", i))
+	}
+	wg.Wait()
+	close(results)
+
+	succeeded := 0
+	for err := range results {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		if !errors.Is(err, secondary.ErrValueReserved) {
+			t.Errorf("SaveEvents() error = %v, expected the value to be reserved", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d writers succeeded, expected exactly 1", succeeded)
+	}
+}
+
+func testGetEventsByType(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+
+	base := time.Now().UTC().Truncate(time.Millisecond)
+	second := user.NewUserRegisteredEvent("user-2", "bob", "hash")
+	second.Timestamp = base.Add(time.Second)
+	first := user.NewUserRegisteredEvent("user-1", "alice", "hash")
+	first.Timestamp = base
+	changed := user.NewUserPasswordChangedEvent("user-1", "hash-2", 2)
+	changed.Timestamp = base.Add(2 * time.Second)
+
+	mustSave(t, store, "user-2", second)
+	mustSave(t, store, "user-1", first, changed)
+
+	events, err := store.GetEventsByType(context.Background(), string(user.EventTypeUserRegistered))
+	if err != nil {
+		t.Fatalf("GetEventsByType() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("GetEventsByType() returned %d events, expected 2", len(events))
+	}
+	if events[0].GetAggregateID() != "user-1" || events[1].GetAggregateID() != "user-2" {
+		t.Errorf("GetEventsByType() order = [%s %s], expected [user-1 user-2]",
+			events[0].GetAggregateID(), events[1].GetAggregateID())
+	}
+}
+
+func testMetadata(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+
+	metadata := shared.Metadata{
+		shared.MetadataCorrelationID: "req-1",
+		shared.MetadataCausationID:   "req-1",
+		shared.MetadataActorID:       "admin-1",
+		shared.MetadataClientIP:      "10.0.0.1",
+		shared.MetadataUserAgent:     "curl/8.0",
+	}
+	registered := user.NewUserRegisteredEvent("user-1", "alice", "hash")
+	registered.SetMetadata(metadata)
+	mustSave(t, store, "user-1", registered, user.NewUserPasswordChangedEvent("user-1", "hash-2", 2))
+
+	events := mustLoad(t, store, "user-1")
+	for key, expected := range metadata {
+		if actual := events[0].GetMetadata()[key]; actual != expected {
+			t.Errorf("Metadata[%s] = %s, expected %s", key, actual, expected)
+		}
+	}
+	if len(events[1].GetMetadata()) != 0 {
+		t.Errorf("Metadata = %v, expected none for event saved without metadata", events[1].GetMetadata())
+	}
+}
+
+func testUpcasting(t *testing.T, newStore Factory) {
+	registry := newRegistry()
+	store, _ := openStore(t, newStore, registry)
+	mustSave(t, store, "user-1", user.NewUserRegisteredEvent("user-1", "Alice", "hash"))
+
+	// the payload above was stored at v1; bump the schema afterwards
+	registry.RegisterUpcaster(shared.Upcaster{
+		EventType:   user.EventTypeUserRegistered,
+		FromVersion: shared.InitialSchemaVersion,
+		Upcast: func(payload map[string]interface{}) (map[string]interface{}, error) {
+			username, _ := payload["username"].(string)
+			payload["username"] = strings.ToLower(username)
+			return payload, nil
+		},
+	})
+
+	events := mustLoad(t, store, "user-1")
+	if username := events[0].(*user.UserRegisteredEvent).Username; username != "alice" {
+		t.Errorf("Username = %s, expected upcasted alice", username)
+	}
+
+	mustSave(t, store, "user-2", user.NewUserRegisteredEvent("user-2", "Bob", "hash"))
+	events = mustLoad(t, store, "user-2")
+	if username := events[0].(*user.UserRegisteredEvent).Username; username != "Bob" {
+		t.Errorf("Username = %s, expected current schema payload to be left alone", username)
+	}
+}
+
+func testShredding(t *testing.T, newStore Factory) {
+	store, keys := openStore(t, newStore, newRegistry())
+	registered := user.NewUserRegisteredEvent("user-1", "alice", "hash-1")
+	registered.SetMetadata(shared.Metadata{
+		shared.MetadataCorrelationID: "req-1",
+		shared.MetadataClientIP:      "10.0.0.1",
+	})
+	mustSave(t, store, "user-1", registered, user.NewUserPasswordChangedEvent("user-1", "hash-2", 2))
+	mustSave(t, store, "user-2", user.NewUserRegisteredEvent("user-2", "bob", "hash"))
+
+	if err := keys.DeleteKey(context.Background(), "user-1"); err != nil {
+		t.Fatalf("DeleteKey() error = %v", err)
+	}
+
+	events := mustLoad(t, store, "user-1")
+	if len(events) != 2 {
+		t.Fatalf("GetEvents() returned %d events, expected the history to survive", len(events))
+	}
+	registered = events[0].(*user.UserRegisteredEvent)
+	if registered.Username != "" || registered.PasswordHash != "" {
+		t.Errorf("events[0] = %+v, expected personal data to be shredded", registered)
+	}
+	metadata := registered.GetMetadata()
+	if _, exists := metadata[shared.MetadataClientIP]; exists {
+		t.Errorf("Metadata = %v, expected the client address to be shredded", metadata)
+	}
+	if metadata[shared.MetadataCorrelationID] != "req-1" {
+		t.Errorf("Metadata = %v, expected other metadata to survive", metadata)
+	}
+	if changed := events[1].(*user.UserPasswordChangedEvent); changed.NewPasswordHash != "" {
+		t.Errorf("events[1] hash = %s, expected it to be shredded", changed.NewPasswordHash)
+	}
+
+	events = mustLoad(t, store, "user-2")
+	if username := events[0].(*user.UserRegisteredEvent).Username; username != "bob" {
+		t.Errorf("Username = %s, expected other users to stay readable", username)
+	}
+}
+
+func assertHolder(t *testing.T, store secondary.EventStore, value, expected string) {
+	t.Helper()
+	holder, err := store.GetReservationHolder(context.Background(), user.UsernameReservationScope, value)
+	if expected == "" {
+		if !errors.Is(err, secondary.ErrReservationNotFound) {
+			t.Errorf("GetReservationHolder(%s) = (%s, %v), expected it to be free", value, holder, err)
+		}
+		return
+	}
+	if err != nil || holder != expected {
+		t.Errorf("GetReservationHolder(%s) = (%s, %v), expected %s", value, holder, err, expected)
+	}
+}
+
+func testReservations(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+	mustSave(t, store, "user-1", user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+	assertHolder(t, store, "alice", "user-1")
+
+	err := store.SaveEvents(context.Background(), "user-2", []shared.Event{
+		user.NewUserRegisteredEvent("user-2", "alice", "hash"),
+	})
+	if !errors.Is(err, secondary.ErrValueReserved) {
+		t.Fatalf("SaveEvents() error = %v, expected %v", err, secondary.ErrValueReserved)
+	}
+	if events := mustLoad(t, store, "user-2"); len(events) != 0 {
+		t.Errorf("GetEvents() returned %d events, expected the append to be discarded", len(events))
+	}
+
+	// a stale append reports the conflict and reserves nothing
+	err = store.SaveEvents(context.Background(), "user-1", []shared.Event{
+		user.NewUserUsernameChangedEvent("user-1", "alicia", 1),
+	})
+	assertConflict(t, err, 0, 1)
+	assertHolder(t, store, "alicia", "")
+
+	mustSave(t, store, "user-1", user.NewUserUsernameChangedEvent("user-1", "alicia", 2))
+	assertHolder(t, store, "alicia", "user-1")
+	assertHolder(t, store, "alice", "")
+
+	mustSave(t, store, "user-2", user.NewUserRegisteredEvent("user-2", "alice", "hash"))
+	assertHolder(t, store, "alice", "user-2")
+
+	mustSave(t, store, "user-2", user.NewUserErasedEvent("user-2", 2))
+	assertHolder(t, store, "alice", "")
+	assertHolder(t, store, "alicia", "user-1")
+}
+
+func testReserve(t *testing.T, newStore Factory) {
+	store, _ := openStore(t, newStore, newRegistry())
+	mustSave(t, store, "user-1", user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+
+	reservation := []shared.Reservation{{Scope: user.UsernameReservationScope, Value: "bob"}}
+	if err := store.Reserve(context.Background(), "user-2", reservation); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	// reserving what the aggregate already holds is a no-op
+	if err := store.Reserve(context.Background(), "user-2", reservation); err != nil {
+		t.Fatalf("Reserve() again error = %v", err)
+	}
+	assertHolder(t, store, "bob", "user-2")
+
+	err := store.Reserve(context.Background(), "user-3", []shared.Reservation{
+		{Scope: user.UsernameReservationScope, Value: "alice"},
+	})
+	if !errors.Is(err, secondary.ErrValueReserved) {
+		t.Errorf("Reserve() error = %v, expected %v", err, secondary.ErrValueReserved)
+	}
+	assertHolder(t, store, "alice", "user-1")
+}
+
+func snapshotStore(t *testing.T, store secondary.EventStore) secondary.SnapshotStore {
+	t.Helper()
+	snapshots, ok := store.(secondary.SnapshotStore)
+	if !ok {
+		t.Fatalf("%T does not implement secondary.SnapshotStore", store)
+	}
+	return snapshots
+}
+
+func mustSnapshot(t *testing.T, snapshots secondary.SnapshotStore, events ...shared.Event) {
+	t.Helper()
+	aggregate, err := user.ReconstructFromEvents(events)
+	if err != nil {
+		t.Fatalf("ReconstructFromEvents() error = %v", err)
+	}
+	snapshot, err := aggregate.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if err := snapshots.SaveSnapshot(context.Background(), snapshot); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+}
+
+func testSnapshots(t *testing.T, newStore Factory) {
+	ctx := context.Background()
+	store, _ := openStore(t, newStore, newRegistry())
+	snapshots := snapshotStore(t, store)
+
+	if _, err := snapshots.GetSnapshot(ctx, "user-1"); !errors.Is(err, secondary.ErrSnapshotNotFound) {
+		t.Fatalf("GetSnapshot() error = %v, expected %v", err, secondary.ErrSnapshotNotFound)
+	}
+
+	events := []shared.Event{
+		user.NewUserRegisteredEvent("user-1", "alice", "hash-1"),
+		user.NewUserPasswordChangedEvent("user-1", "hash-2", 2),
+		user.NewUserUsernameChangedEvent("user-1", "alicia", 3),
+	}
+	mustSave(t, store, "user-1", events...)
+	mustSnapshot(t, snapshots, events[:2]...)
+	// an older snapshot never replaces a newer one
+	mustSnapshot(t, snapshots, events[:1]...)
+
+	snapshot, err := snapshots.GetSnapshot(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetSnapshot() error = %v", err)
+	}
+	if snapshot.Version != 2 || snapshot.Type != user.SnapshotTypeUser {
+		t.Errorf("GetSnapshot() = %s at version %d, expected %s at version 2", snapshot.Type, snapshot.Version, user.SnapshotTypeUser)
+	}
+
+	after, err := snapshots.GetEventsAfterVersion(ctx, "user-1", snapshot.Version)
+	if err != nil {
+		t.Fatalf("GetEventsAfterVersion() error = %v", err)
+	}
+	if len(after) != 1 || after[0].GetVersion() != 3 {
+		t.Fatalf("GetEventsAfterVersion() returned %d events, expected only version 3", len(after))
+	}
+
+	restored, err := user.RestoreFromSnapshot(snapshot, after)
+	if err != nil {
+		t.Fatalf("RestoreFromSnapshot() error = %v", err)
+	}
+	if restored.Username != "alicia" || restored.PasswordHash != "hash-2" || restored.Version != 3 {
+		t.Errorf("restored = %s/%s at version %d, expected alicia/hash-2 at version 3",
+			restored.Username, restored.PasswordHash, restored.Version)
+	}
+}
+
+func testSnapshotShredding(t *testing.T, newStore Factory) {
+	ctx := context.Background()
+	store, keys := openStore(t, newStore, newRegistry())
+	snapshots := snapshotStore(t, store)
+
+	registered := user.NewUserRegisteredEvent("user-1", "alice", "hash-1")
+	mustSave(t, store, "user-1", registered)
+	mustSnapshot(t, snapshots, registered)
+
+	if err := keys.DeleteKey(ctx, "user-1"); err != nil {
+		t.Fatalf("DeleteKey() error = %v", err)
+	}
+
+	snapshot, err := snapshots.GetSnapshot(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetSnapshot() error = %v", err)
+	}
+	restored, err := user.RestoreFromSnapshot(snapshot, nil)
+	if err != nil {
+		t.Fatalf("RestoreFromSnapshot() error = %v", err)
+	}
+	if restored.Username != "" || restored.PasswordHash != "" {
+		t.Errorf("restored = %s/%s, expected personal data to be shredded", restored.Username, restored.PasswordHash)
+	}
+}
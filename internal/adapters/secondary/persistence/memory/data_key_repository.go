@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+)
+
+type dataKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string][]byte
+}
+
+func NewDataKeyRepository() secondary.DataKeyRepository {
+	return &dataKeyRepository{
+		keys: make(map[string][]byte),
+	}
+}
+
+func (r *dataKeyRepository) CreateKey(ctx context.Context, subjectID string, key []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.keys[subjectID]; exists {
+		return secondary.ErrDataKeyExists
+	}
+	r.keys[subjectID] = append([]byte(nil), key...)
+	return nil
+}
+
+func (r *dataKeyRepository) GetKey(ctx context.Context, subjectID string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, exists := r.keys[subjectID]
+	if !exists {
+		return nil, secondary.ErrDataKeyNotFound
+	}
+	return append([]byte(nil), key...), nil
+}
+
+func (r *dataKeyRepository) DeleteKey(ctx context.Context, subjectID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.keys, subjectID)
+	return nil
+}
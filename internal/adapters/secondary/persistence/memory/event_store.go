@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+// eventRecord mirrors a row of the postgres events table. Events are kept
+// serialized so readers always get fresh instances, exactly as they would
+// from a database.
+type eventRecord struct {
+	AggregateID   string
+	AggregateType string
+	EventType     string
+	Version       int
+	SchemaVersion int
+	Timestamp     time.Time
+	Payload       json.RawMessage
+	Metadata      shared.Metadata
+}
+
+type EventStore struct {
+	mu            sync.RWMutex
+	streams       map[string][]eventRecord
+	log           []eventRecord
+	reservations  map[shared.Reservation]string
+	snapshots     map[string]shared.Snapshot
+	eventRegistry shared.EventRegistry
+	cipher        secondary.PayloadCipher
+}
+
+func NewEventStore(registry shared.EventRegistry, cipher secondary.PayloadCipher) *EventStore {
+	return &EventStore{
+		streams:       make(map[string][]eventRecord),
+		reservations:  make(map[shared.Reservation]string),
+		snapshots:     make(map[string]shared.Snapshot),
+		eventRegistry: registry,
+		cipher:        cipher,
+	}
+}
+
+func (s *EventStore) SaveEvents(ctx context.Context, aggregateID string, events []shared.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	payloads := make([]json.RawMessage, len(events))
+	metadata := make([]shared.Metadata, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal event: %w", err)
+		}
+
+		payloads[i], err = s.cipher.EncryptPayload(ctx, aggregateID, shared.EventType(event.GetEventType()), payload)
+		if err != nil {
+			return fmt.Errorf("encrypt event: %w", err)
+		}
+
+		metadata[i], err = s.cipher.EncryptMetadata(ctx, aggregateID, event.GetMetadata())
+		if err != nil {
+			return fmt.Errorf("encrypt event metadata: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream := s.streams[aggregateID]
+	latestVersion := len(stream)
+
+	records := make([]eventRecord, 0, len(events))
+	for i, event := range events {
+		expectedVersion := latestVersion + 1
+		if event.GetVersion() != expectedVersion {
+			return &secondary.ConcurrencyConflictError{
+				AggregateID:     aggregateID,
+				ExpectedVersion: event.GetVersion() - 1,
+				ActualVersion:   latestVersion,
+			}
+		}
+
+		records = append(records, eventRecord{
+			AggregateID:   event.GetAggregateID(),
+			AggregateType: event.GetAggregateType(),
+			EventType:     event.GetEventType(),
+			Version:       event.GetVersion(),
+			SchemaVersion: s.eventRegistry.SchemaVersion(shared.EventType(event.GetEventType())),
+			Timestamp:     event.GetTimestamp(),
+			Payload:       payloads[i],
+			Metadata:      copyMetadata(metadata[i]),
+		})
+
+		latestVersion = event.GetVersion()
+	}
+
+	if reservations := shared.ReservationsOf(events); len(reservations) > 0 {
+		// reserve on a copy so a taken value leaves nothing half applied
+		held := maps.Clone(s.reservations)
+		if err := reserve(held, aggregateID, reservations); err != nil {
+			return err
+		}
+		s.reservations = held
+	}
+
+	s.streams[aggregateID] = append(stream, records...)
+	s.log = append(s.log, records...)
+	return nil
+}
+
+func (s *EventStore) GetEvents(ctx context.Context, aggregateID string) ([]shared.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	records := append([]eventRecord(nil), s.streams[aggregateID]...)
+	s.mu.RUnlock()
+
+	return s.loadEvents(ctx, records)
+}
+
+func (s *EventStore) GetVersion(ctx context.Context, aggregateID string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stream := s.streams[aggregateID]
+	if len(stream) == 0 {
+		return 0, nil
+	}
+	return stream[len(stream)-1].Version, nil
+}
+
+func (s *EventStore) GetEventsAfterVersion(ctx context.Context, aggregateID string, version int) ([]shared.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	var records []eventRecord
+	for _, record := range s.streams[aggregateID] {
+		if record.Version > version {
+			records = append(records, record)
+		}
+	}
+	s.mu.RUnlock()
+
+	return s.loadEvents(ctx, records)
+}
+
+func (s *EventStore) GetEventsByType(ctx context.Context, eventType string) ([]shared.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	var records []eventRecord
+	for _, record := range s.log {
+		if record.EventType == eventType {
+			records = append(records, record)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+
+	return s.loadEvents(ctx, records)
+}
+
+func (s *EventStore) SaveSnapshot(ctx context.Context, snapshot shared.Snapshot) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	state, err := s.cipher.EncryptPayload(ctx, snapshot.AggregateID, snapshot.Type, snapshot.State)
+	if err != nil {
+		return fmt.Errorf("encrypt snapshot: %w", err)
+	}
+	snapshot.State = state
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, exists := s.snapshots[snapshot.AggregateID]; exists && stored.Version >= snapshot.Version {
+		return nil
+	}
+	s.snapshots[snapshot.AggregateID] = snapshot
+	return nil
+}
+
+func (s *EventStore) GetSnapshot(ctx context.Context, aggregateID string) (*shared.Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	snapshot, exists := s.snapshots[aggregateID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, secondary.ErrSnapshotNotFound
+	}
+
+	state, err := s.cipher.DecryptPayload(ctx, aggregateID, snapshot.Type, snapshot.State)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt snapshot: %w", err)
+	}
+	snapshot.State = state
+	return &snapshot, nil
+}
+
+func (s *EventStore) GetReservationHolder(ctx context.Context, scope, value string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	holder, exists := s.reservations[shared.Reservation{Scope: scope, Value: value}]
+	if !exists {
+		return "", secondary.ErrReservationNotFound
+	}
+	return holder, nil
+}
+
+func (s *EventStore) Reserve(ctx context.Context, aggregateID string, reservations []shared.Reservation) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	held := maps.Clone(s.reservations)
+	if err := reserve(held, aggregateID, reservations); err != nil {
+		return err
+	}
+	s.reservations = held
+	return nil
+}
+
+// reserve applies reservations for aggregateID to held, which maps every
+// reserved value to the aggregate holding it.
+func reserve(held map[shared.Reservation]string, aggregateID string, reservations []shared.Reservation) error {
+	for _, reservation := range reservations {
+		if holder, taken := held[reservation]; taken && holder != aggregateID && reservation.Value != "" {
+			return fmt.Errorf("%w in scope %s", secondary.ErrValueReserved, reservation.Scope)
+		}
+
+		for existing, holder := range held {
+			if holder == aggregateID && existing.Scope == reservation.Scope {
+				delete(held, existing)
+			}
+		}
+		if reservation.Value != "" {
+			held[reservation] = aggregateID
+		}
+	}
+	return nil
+}
+
+func (s *EventStore) loadEvents(ctx context.Context, records []eventRecord) ([]shared.Event, error) {
+	var events []shared.Event
+	for _, record := range records {
+		event, ok := s.eventRegistry.CreateEvent(shared.EventType(record.EventType))
+		if !ok {
+			return nil, fmt.Errorf("unknown event type: %s", record.EventType)
+		}
+
+		payload, err := s.cipher.DecryptPayload(ctx, record.AggregateID, shared.EventType(record.EventType), record.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt event: %w", err)
+		}
+
+		payload, err = s.eventRegistry.Upcast(
+			shared.EventType(record.EventType),
+			record.SchemaVersion,
+			payload,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("upcast event: %w", err)
+		}
+
+		if err := json.Unmarshal(payload, event); err != nil {
+			return nil, fmt.Errorf("unmarshal event data: %w", err)
+		}
+		metadata, err := s.cipher.DecryptMetadata(ctx, record.AggregateID, copyMetadata(record.Metadata))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt event metadata: %w", err)
+		}
+		event.SetMetadata(metadata)
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func copyMetadata(metadata shared.Metadata) shared.Metadata {
+	copied := make(shared.Metadata, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	return copied
+}
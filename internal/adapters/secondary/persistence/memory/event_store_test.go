@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/eventstoretest"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+func TestEventStore_Contract(t *testing.T) {
+	eventstoretest.Run(t, func(t *testing.T, registry shared.EventRegistry, cipher secondary.PayloadCipher) secondary.EventStore {
+		return NewEventStore(registry, cipher)
+	})
+}
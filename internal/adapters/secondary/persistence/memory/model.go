@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type UserReadModel struct {
+	ID           string
+	Username     string
+	PasswordHash string
+	DisplayName  string
+	AvatarURL    string
+	Locale       string
+	Timezone     string
+	Email        string
+	Role         string
+	Locked       bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Version      int
+	Erased       bool
+}
+
+// LoginRecord is one entry of a user's login history; Version is that of
+// the event it was projected from.
+type LoginRecord struct {
+	Version    int
+	Outcome    string
+	Reason     string
+	IPAddress  string
+	UserAgent  string
+	OccurredAt time.Time
+}
+
+// UserReadModelStore plays the role of the mongo users collection; the
+// projector writes to it and the query handler reads from it.
+type UserReadModelStore struct {
+	mu     sync.RWMutex
+	users  map[string]UserReadModel
+	logins map[string][]LoginRecord
+}
+
+func NewUserReadModelStore() *UserReadModelStore {
+	return &UserReadModelStore{
+		users:  make(map[string]UserReadModel),
+		logins: make(map[string][]LoginRecord),
+	}
+}
+
+func (s *UserReadModelStore) findByID(id string) (UserReadModel, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[id]
+	if user.Erased {
+		return UserReadModel{}, false
+	}
+	return user, exists
+}
+
+func (s *UserReadModelStore) findByUsername(username string) (UserReadModel, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if !user.Erased && user.Username == username {
+			return user, true
+		}
+	}
+	return UserReadModel{}, false
+}
+
+func (s *UserReadModelStore) list() []UserReadModel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]UserReadModel, 0, len(s.users))
+	for _, user := range s.users {
+		if !user.Erased {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// update applies fn to the stored document for id, starting from an empty
+// document when none exists, like an upsert.
+func (s *UserReadModelStore) update(id string, fn func(user *UserReadModel, exists bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[id]
+	if !exists {
+		user = UserReadModel{ID: id}
+	}
+	fn(&user, exists)
+	s.users[id] = user
+}
+
+// addLogin inserts record into the user's history, newest first, ignoring a
+// version already present and dropping whatever falls beyond capacity.
+func (s *UserReadModelStore) addLogin(userID string, record LoginRecord, capacity int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.logins[userID]
+	i := sort.Search(len(history), func(i int) bool { return history[i].Version <= record.Version })
+	if i < len(history) && history[i].Version == record.Version {
+		return
+	}
+	history = append(history, LoginRecord{})
+	copy(history[i+1:], history[i:])
+	history[i] = record
+
+	if len(history) > capacity {
+		history = history[:capacity]
+	}
+	s.logins[userID] = history
+}
+
+func (s *UserReadModelStore) loginHistory(userID string) []LoginRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]LoginRecord(nil), s.logins[userID]...)
+}
+
+func (s *UserReadModelStore) deleteLogins(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.logins, userID)
+}
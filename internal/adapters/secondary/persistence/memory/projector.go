@@ -0,0 +1,174 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+type MemoryProjector struct {
+	store *UserReadModelStore
+}
+
+func NewMemoryProjector(store *UserReadModelStore) *MemoryProjector {
+	return &MemoryProjector{
+		store: store,
+	}
+}
+
+func (p *MemoryProjector) ProjectEvent(ctx context.Context, event shared.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	switch e := event.(type) {
+	case *user.UserRegisteredEvent:
+		p.projectUserRegistered(e)
+	case *user.UserPasswordChangedEvent:
+		p.projectUserPasswordChanged(e)
+	case *user.UserPasswordRehashedEvent:
+		p.projectUserPasswordRehashed(e)
+	case *user.UserUsernameChangedEvent:
+		p.projectUserUsernameChanged(e)
+	case *user.UserProfileUpdatedEvent:
+		p.projectUserProfileUpdated(e)
+	case *user.UserEmailChangedEvent:
+		p.applyNewer(e, func(rm *UserReadModel) { rm.Email = e.Email })
+	case *user.UserLockedEvent:
+		p.applyNewer(e, func(rm *UserReadModel) { rm.Locked = true })
+	case *user.UserUnlockedEvent:
+		p.applyNewer(e, func(rm *UserReadModel) { rm.Locked = false })
+	case *user.UserRoleAssignedEvent:
+		p.applyNewer(e, func(rm *UserReadModel) { rm.Role = e.Role })
+	case *user.UserErasedEvent:
+		p.projectUserErased(e)
+	case *user.UserLoggedInEvent:
+		p.store.addLogin(e.GetAggregateID(), LoginRecord{
+			Version:    e.GetVersion(),
+			Outcome:    query.LoginOutcomeSucceeded,
+			IPAddress:  e.IPAddress,
+			UserAgent:  e.UserAgent,
+			OccurredAt: e.GetTimestamp(),
+		}, query.LoginHistorySize)
+		p.advanceVersion(e)
+	case *user.UserLoginFailedEvent:
+		p.store.addLogin(e.GetAggregateID(), LoginRecord{
+			Version:    e.GetVersion(),
+			Outcome:    query.LoginOutcomeFailed,
+			Reason:     e.Reason,
+			IPAddress:  e.IPAddress,
+			UserAgent:  e.UserAgent,
+			OccurredAt: e.GetTimestamp(),
+		}, query.LoginHistorySize)
+		p.advanceVersion(e)
+	default:
+		return fmt.Errorf("unsupported event type: %s", event.GetEventType())
+	}
+	return nil
+}
+
+// projectUserRegistered creates the read model, or completes one a later
+// event created first with what only the registration knows.
+func (p *MemoryProjector) projectUserRegistered(event *user.UserRegisteredEvent) {
+	p.store.update(event.GetAggregateID(), func(rm *UserReadModel, exists bool) {
+		if rm.Erased {
+			return
+		}
+		if rm.Username == "" {
+			rm.Username = event.Username
+		}
+		if rm.PasswordHash == "" {
+			rm.PasswordHash = event.PasswordHash
+		}
+		if rm.Role == "" {
+			rm.Role = string(user.DefaultRole)
+		}
+		rm.CreatedAt = event.GetTimestamp()
+		if !exists {
+			rm.UpdatedAt = event.GetTimestamp()
+			rm.Version = 1
+		}
+	})
+}
+
+func (p *MemoryProjector) projectUserPasswordChanged(event *user.UserPasswordChangedEvent) {
+	p.applyNewer(event, func(rm *UserReadModel) {
+		rm.PasswordHash = event.NewPasswordHash
+	})
+}
+
+func (p *MemoryProjector) projectUserPasswordRehashed(event *user.UserPasswordRehashedEvent) {
+	p.applyNewer(event, func(rm *UserReadModel) {
+		rm.PasswordHash = event.NewPasswordHash
+	})
+}
+
+func (p *MemoryProjector) projectUserUsernameChanged(event *user.UserUsernameChangedEvent) {
+	p.applyNewer(event, func(rm *UserReadModel) {
+		rm.Username = event.Username
+	})
+}
+
+func (p *MemoryProjector) projectUserProfileUpdated(event *user.UserProfileUpdatedEvent) {
+	p.applyNewer(event, func(rm *UserReadModel) {
+		rm.DisplayName = event.DisplayName
+		rm.AvatarURL = event.AvatarURL
+		rm.Locale = event.Locale
+		rm.Timezone = event.Timezone
+	})
+}
+
+// applyNewer changes the user's read model unless it already reflects a
+// later version or is a tombstone, since an erased user's data must stay
+// gone. An event that arrives before the registration creates the model.
+func (p *MemoryProjector) applyNewer(event shared.Event, apply func(rm *UserReadModel)) {
+	p.store.update(event.GetAggregateID(), func(rm *UserReadModel, exists bool) {
+		if exists && (rm.Erased || rm.Version >= event.GetVersion()) {
+			return
+		}
+		if !exists {
+			rm.CreatedAt = event.GetTimestamp()
+		}
+		apply(rm)
+		rm.UpdatedAt = event.GetTimestamp()
+		rm.Version = event.GetVersion()
+	})
+}
+
+// advanceVersion moves the read model to the version of an event that
+// changes none of its fields, so the version keeps matching the stream's and
+// a reader waiting for a later write is not held up by a login. UpdatedAt is
+// left alone.
+func (p *MemoryProjector) advanceVersion(event shared.Event) {
+	p.store.update(event.GetAggregateID(), func(rm *UserReadModel, exists bool) {
+		if exists && (rm.Erased || rm.Version >= event.GetVersion()) {
+			return
+		}
+		if !exists {
+			rm.CreatedAt = event.GetTimestamp()
+			rm.UpdatedAt = event.GetTimestamp()
+		}
+		rm.Version = event.GetVersion()
+	})
+}
+
+// projectUserErased also drops the login history, which holds the client
+// addresses and user agents of the erased user.
+func (p *MemoryProjector) projectUserErased(event *user.UserErasedEvent) {
+	p.store.deleteLogins(event.GetAggregateID())
+	p.store.update(event.GetAggregateID(), func(rm *UserReadModel, _ bool) {
+		rm.Username = ""
+		rm.PasswordHash = ""
+		rm.DisplayName = ""
+		rm.AvatarURL = ""
+		rm.Locale = ""
+		rm.Timezone = ""
+		rm.Email = ""
+		rm.Erased = true
+		rm.UpdatedAt = event.GetTimestamp()
+		rm.Version = event.GetVersion()
+	})
+}
@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+func TestMemoryProjector_IgnoresStaleEvents(t *testing.T) {
+	tests := []struct {
+		name             string
+		events           []shared.Event
+		expectedUsername string
+		expectedHash     string
+		expectedVersion  int
+		expectedErased   bool
+	}{
+		{
+			name: "older password change after a newer one",
+			events: []shared.Event{
+				userDomain.NewUserRegisteredEvent("user-1", "alice", "hash-1"),
+				userDomain.NewUserPasswordChangedEvent("user-1", "hash-3", 3),
+				userDomain.NewUserPasswordRehashedEvent("user-1", "hash-2", 2),
+			},
+			expectedUsername: "alice",
+			expectedHash:     "hash-3",
+			expectedVersion:  3,
+		},
+		{
+			name: "redelivered username change",
+			events: []shared.Event{
+				userDomain.NewUserRegisteredEvent("user-1", "alice", "hash-1"),
+				userDomain.NewUserUsernameChangedEvent("user-1", "bob", 2),
+				userDomain.NewUserUsernameChangedEvent("user-1", "carol", 3),
+				userDomain.NewUserUsernameChangedEvent("user-1", "bob", 2),
+			},
+			expectedUsername: "carol",
+			expectedHash:     "hash-1",
+			expectedVersion:  3,
+		},
+		{
+			name: "password change after erasure",
+			events: []shared.Event{
+				userDomain.NewUserRegisteredEvent("user-1", "alice", "hash-1"),
+				userDomain.NewUserErasedEvent("user-1", 3),
+				userDomain.NewUserPasswordChangedEvent("user-1", "hash-4", 4),
+			},
+			expectedVersion: 3,
+			expectedErased:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewUserReadModelStore()
+			projector := NewMemoryProjector(store)
+			for _, event := range tt.events {
+				if err := projector.ProjectEvent(context.Background(), event); err != nil {
+					t.Fatalf("ProjectEvent(%s) error = %v", event.GetEventType(), err)
+				}
+			}
+
+			rm := store.users["user-1"]
+			if rm.Username != tt.expectedUsername || rm.PasswordHash != tt.expectedHash {
+				t.Errorf("read model = %s/%s, expected %s/%s", rm.Username, rm.PasswordHash, tt.expectedUsername, tt.expectedHash)
+			}
+			if rm.Version != tt.expectedVersion || rm.Erased != tt.expectedErased {
+				t.Errorf("version = %d erased = %v, expected %d %v", rm.Version, rm.Erased, tt.expectedVersion, tt.expectedErased)
+			}
+		})
+	}
+}
+
+func TestMemoryProjector_RegistrationArrivingLate(t *testing.T) {
+	store := NewUserReadModelStore()
+	projector := NewMemoryProjector(store)
+
+	registered := userDomain.NewUserRegisteredEvent("user-1", "alice", "hash-1")
+	registered.Timestamp = registered.Timestamp.Add(-time.Hour)
+	for _, event := range []shared.Event{
+		userDomain.NewUserPasswordChangedEvent("user-1", "hash-2", 2),
+		registered,
+	} {
+		if err := projector.ProjectEvent(context.Background(), event); err != nil {
+			t.Fatalf("ProjectEvent(%s) error = %v", event.GetEventType(), err)
+		}
+	}
+
+	rm := store.users["user-1"]
+	if rm.Username != "alice" || rm.PasswordHash != "hash-2" || rm.Version != 2 {
+		t.Errorf("read model = %s/%s at version %d, expected alice/hash-2 at version 2", rm.Username, rm.PasswordHash, rm.Version)
+	}
+	if !rm.CreatedAt.Equal(registered.Timestamp) {
+		t.Errorf("CreatedAt = %v, expected the registration time %v", rm.CreatedAt, registered.Timestamp)
+	}
+}
+
+func TestMemoryProjector_EmailLockAndRole(t *testing.T) {
+	store := NewUserReadModelStore()
+	projector := NewMemoryProjector(store)
+
+	for _, event := range []shared.Event{
+		userDomain.NewUserRegisteredEvent("user-1", "alice", "hash-1"),
+		userDomain.NewUserEmailChangedEvent("user-1", "alice@example.com", 2),
+		userDomain.NewUserRoleAssignedEvent("user-1", userDomain.RoleSupport, 3),
+		userDomain.NewUserLockedEvent("user-1", 4),
+		userDomain.NewUserUnlockedEvent("user-1", 5),
+		// redelivered
+		userDomain.NewUserLockedEvent("user-1", 4),
+	} {
+		if err := projector.ProjectEvent(context.Background(), event); err != nil {
+			t.Fatalf("ProjectEvent(%s) error = %v", event.GetEventType(), err)
+		}
+	}
+
+	rm := store.users["user-1"]
+	if rm.Email != "alice@example.com" || rm.Role != "support" || rm.Locked || rm.Version != 5 {
+		t.Errorf("read model = %s/%s locked %v at version %d, expected alice@example.com/support unlocked at version 5", rm.Email, rm.Role, rm.Locked, rm.Version)
+	}
+
+	if err := projector.ProjectEvent(context.Background(), userDomain.NewUserErasedEvent("user-1", 6)); err != nil {
+		t.Fatalf("ProjectEvent(user.erased) error = %v", err)
+	}
+	if rm := store.users["user-1"]; rm.Email != "" {
+		t.Errorf("erased read model kept email %s", rm.Email)
+	}
+}
+
+func TestMemoryProjector_LoginsAdvanceVersion(t *testing.T) {
+	store := NewUserReadModelStore()
+	projector := NewMemoryProjector(store)
+
+	registered := userDomain.NewUserRegisteredEvent("user-1", "alice", "hash-1")
+	for _, event := range []shared.Event{
+		registered,
+		userDomain.NewUserLoggedInEvent("user-1", "10.0.0.1", "curl/8.0", 2),
+		userDomain.NewUserLoginFailedEvent("user-1", "10.0.0.1", "curl/8.0", userDomain.LoginFailureInvalidPassword, 3),
+	} {
+		if err := projector.ProjectEvent(context.Background(), event); err != nil {
+			t.Fatalf("ProjectEvent(%s) error = %v", event.GetEventType(), err)
+		}
+	}
+
+	rm := store.users["user-1"]
+	if rm.Version != 3 {
+		t.Errorf("version = %d, expected sign-ins to advance it to 3", rm.Version)
+	}
+	if !rm.UpdatedAt.Equal(registered.Timestamp) {
+		t.Errorf("UpdatedAt = %v, expected sign-ins to leave it at %v", rm.UpdatedAt, registered.Timestamp)
+	}
+	if len(store.loginHistory("user-1")) != 2 {
+		t.Errorf("login history holds %d entries, expected 2", len(store.loginHistory("user-1")))
+	}
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	tokenDomain "github.com/ncfex/dcart-auth/internal/domain/token"
+)
+
+var (
+	ErrDuplicateToken = errors.New("token already exists")
+)
+
+type tokenRepository struct {
+	mu        sync.RWMutex
+	tokens    map[string]tokenDomain.RefreshToken
+	expiresIn time.Duration
+}
+
+func NewTokenRepository(expiresIn time.Duration) secondary.TokenRepository {
+	return &tokenRepository{
+		tokens:    make(map[string]tokenDomain.RefreshToken),
+		expiresIn: expiresIn,
+	}
+}
+
+func (r *tokenRepository) Add(ctx context.Context, token *tokenDomain.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tokens[token.Token]; exists {
+		return ErrDuplicateToken
+	}
+
+	now := time.Now().UTC()
+	r.tokens[token.Token] = tokenDomain.RefreshToken{
+		Token:     token.Token,
+		UserID:    token.UserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(r.expiresIn),
+	}
+	return nil
+}
+
+// GetByToken only returns active tokens, matching GetTokenByTokenString.
+func (r *tokenRepository) GetByToken(ctx context.Context, tokenString string) (*tokenDomain.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, exists := r.tokens[tokenString]
+	if !exists || !isActive(token) {
+		return nil, tokenDomain.ErrTokenNotFound
+	}
+	return &token, nil
+}
+
+func (r *tokenRepository) Revoke(ctx context.Context, tokenString string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, exists := r.tokens[tokenString]
+	if !exists || !isActive(token) {
+		return tokenDomain.ErrTokenNotFound
+	}
+
+	now := time.Now().UTC()
+	token.RevokedAt = now
+	token.UpdatedAt = now
+	r.tokens[tokenString] = token
+	return nil
+}
+
+func (r *tokenRepository) Save(ctx context.Context, token *tokenDomain.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tokens[token.Token]; !exists {
+		return nil
+	}
+	r.tokens[token.Token] = *token
+	return nil
+}
+
+func isActive(token tokenDomain.RefreshToken) bool {
+	return token.RevokedAt.IsZero() && token.ExpiresAt.After(time.Now())
+}
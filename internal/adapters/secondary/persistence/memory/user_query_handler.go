@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+type UserQueryHandler struct {
+	store *UserReadModelStore
+}
+
+func NewUserQueryHandler(store *UserReadModelStore) *UserQueryHandler {
+	return &UserQueryHandler{
+		store: store,
+	}
+}
+
+func (h *UserQueryHandler) GetUserByID(ctx context.Context, query query.GetUserByIDQuery) (*types.UserResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userRM, exists := h.store.findByID(query.UserID)
+	if !exists {
+		return nil, userDomain.ErrUserNotFound
+	}
+
+	return &types.UserResponse{
+		ID:       userRM.ID,
+		Username: userRM.Username,
+		Email:    userRM.Email,
+		Role:     userRM.Role,
+		Locked:   userRM.Locked,
+		Version:  userRM.Version,
+	}, nil
+}
+
+func (h *UserQueryHandler) GetUserByUsername(ctx context.Context, query query.GetUserByUsernameQuery) (*types.UserResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userRM, exists := h.store.findByUsername(query.Username)
+	if !exists {
+		return nil, userDomain.ErrUserNotFound
+	}
+
+	return &types.UserResponse{
+		ID:       userRM.ID,
+		Username: userRM.Username,
+		Email:    userRM.Email,
+		Role:     userRM.Role,
+		Locked:   userRM.Locked,
+		Version:  userRM.Version,
+	}, nil
+}
+
+func (h *UserQueryHandler) GetProfile(ctx context.Context, query query.GetProfileQuery) (*types.ProfileResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	userRM, exists := h.store.findByID(query.UserID)
+	if !exists {
+		return nil, userDomain.ErrUserNotFound
+	}
+
+	return &types.ProfileResponse{
+		ID:          userRM.ID,
+		Username:    userRM.Username,
+		DisplayName: userRM.DisplayName,
+		AvatarURL:   userRM.AvatarURL,
+		Locale:      userRM.Locale,
+		Timezone:    userRM.Timezone,
+		CreatedAt:   userRM.CreatedAt,
+		UpdatedAt:   userRM.UpdatedAt,
+		Version:     userRM.Version,
+	}, nil
+}
+
+func (h *UserQueryHandler) ListUsers(ctx context.Context, q query.ListUsersQuery) (*types.UserListResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	q, cursor, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	less := func(a, b UserReadModel) bool {
+		if q.SortBy == query.UserSortUsername && a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		if q.SortBy == query.UserSortCreatedAt && !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	}
+	if q.Descending {
+		ascending := less
+		less = func(a, b UserReadModel) bool { return ascending(b, a) }
+	}
+
+	var after UserReadModel
+	if cursor != nil {
+		after = UserReadModel{ID: cursor.ID, Username: cursor.Username, CreatedAt: cursor.CreatedAt}
+	}
+
+	users := []UserReadModel{}
+	for _, userRM := range h.store.list() {
+		if !strings.HasPrefix(userRM.Username, q.UsernamePrefix) || !strings.HasPrefix(userRM.Email, q.EmailPrefix) {
+			continue
+		}
+		if q.Locked != nil && userRM.Locked != *q.Locked {
+			continue
+		}
+		if q.Role != "" && userRM.Role != q.Role {
+			continue
+		}
+		if !q.CreatedFrom.IsZero() && userRM.CreatedAt.Before(q.CreatedFrom) {
+			continue
+		}
+		if !q.CreatedTo.IsZero() && !userRM.CreatedAt.Before(q.CreatedTo) {
+			continue
+		}
+		if cursor != nil && !less(after, userRM) {
+			continue
+		}
+		users = append(users, userRM)
+	}
+	sort.Slice(users, func(i, j int) bool { return less(users[i], users[j]) })
+
+	response := &types.UserListResponse{Users: []types.UserSummaryResponse{}}
+	if len(users) > q.Limit {
+		users = users[:q.Limit]
+		last := users[len(users)-1]
+		response.NextCursor = query.EncodeUserCursor(query.UserCursor{
+			SortBy:     q.SortBy,
+			Descending: q.Descending,
+			Username:   last.Username,
+			CreatedAt:  last.CreatedAt,
+			ID:         last.ID,
+		})
+	}
+	for _, userRM := range users {
+		response.Users = append(response.Users, types.UserSummaryResponse{
+			ID:        userRM.ID,
+			Username:  userRM.Username,
+			Email:     userRM.Email,
+			Role:      userRM.Role,
+			Locked:    userRM.Locked,
+			CreatedAt: userRM.CreatedAt,
+			UpdatedAt: userRM.UpdatedAt,
+			Version:   userRM.Version,
+		})
+	}
+	return response, nil
+}
+
+func (h *UserQueryHandler) GetLoginHistory(ctx context.Context, q query.GetLoginHistoryQuery) (*types.LoginHistoryResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	q, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	history := h.store.loginHistory(q.UserID)
+	if len(history) > q.Limit {
+		history = history[:q.Limit]
+	}
+
+	response := &types.LoginHistoryResponse{Logins: []types.LoginHistoryEntry{}}
+	for _, record := range history {
+		response.Logins = append(response.Logins, types.LoginHistoryEntry{
+			Outcome:    record.Outcome,
+			Reason:     record.Reason,
+			IPAddress:  record.IPAddress,
+			UserAgent:  record.UserAgent,
+			OccurredAt: record.OccurredAt,
+		})
+	}
+	return response, nil
+}
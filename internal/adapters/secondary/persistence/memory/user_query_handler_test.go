@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+func newListStore() *UserReadModelStore {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewUserReadModelStore()
+	for _, u := range []UserReadModel{
+		{ID: "u1", Username: "alice", Email: "alice@example.com", Role: "admin", CreatedAt: base},
+		{ID: "u2", Username: "albert", Email: "albert@example.org", Role: "user", Locked: true, CreatedAt: base.Add(time.Hour)},
+		{ID: "u3", Username: "bob", Email: "bob@example.com", Role: "user", CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "u4", Username: "alfred", Role: "support", Locked: true, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "u5", Username: "alma", Role: "admin", CreatedAt: base.Add(3 * time.Hour), Erased: true},
+	} {
+		store.users[u.ID] = u
+	}
+	return store
+}
+
+func TestUserQueryHandler_ListUsers(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	locked, unlocked := true, false
+
+	tests := []struct {
+		name        string
+		query       query.ListUsersQuery
+		expectedIDs []string
+		expectedErr error
+	}{
+		{
+			name:        "defaults to creation order without erased users",
+			expectedIDs: []string{"u1", "u2", "u3", "u4"},
+		},
+		{
+			name:        "username prefix",
+			query:       query.ListUsersQuery{UsernamePrefix: "al"},
+			expectedIDs: []string{"u1", "u2", "u4"},
+		},
+		{
+			name:        "email prefix ignores case",
+			query:       query.ListUsersQuery{EmailPrefix: "AL"},
+			expectedIDs: []string{"u1", "u2"},
+		},
+		{
+			name:        "locked users",
+			query:       query.ListUsersQuery{Locked: &locked},
+			expectedIDs: []string{"u2", "u4"},
+		},
+		{
+			name:        "unlocked users with a role",
+			query:       query.ListUsersQuery{Locked: &unlocked, Role: "user"},
+			expectedIDs: []string{"u3"},
+		},
+		{
+			name:        "unknown role",
+			query:       query.ListUsersQuery{Role: "owner"},
+			expectedErr: query.ErrInvalidUserQuery,
+		},
+		{
+			name:        "created range is half open",
+			query:       query.ListUsersQuery{CreatedFrom: base.Add(time.Hour), CreatedTo: base.Add(2 * time.Hour)},
+			expectedIDs: []string{"u2"},
+		},
+		{
+			name:        "username descending",
+			query:       query.ListUsersQuery{SortBy: query.UserSortUsername, Descending: true},
+			expectedIDs: []string{"u3", "u1", "u4", "u2"},
+		},
+		{
+			name:        "unknown sort",
+			query:       query.ListUsersQuery{SortBy: "email"},
+			expectedErr: query.ErrInvalidUserQuery,
+		},
+		{
+			name:        "limit above maximum",
+			query:       query.ListUsersQuery{Limit: query.MaxUserListLimit + 1},
+			expectedErr: query.ErrInvalidUserQuery,
+		},
+		{
+			name:        "malformed cursor",
+			query:       query.ListUsersQuery{Cursor: "%%%"},
+			expectedErr: query.ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewUserQueryHandler(newListStore())
+
+			result, err := handler.ListUsers(context.Background(), tt.query)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("ListUsers() error = %v, expected %v", err, tt.expectedErr)
+			}
+			if tt.expectedErr != nil {
+				return
+			}
+
+			var ids []string
+			for _, u := range result.Users {
+				ids = append(ids, u.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedIDs) {
+				t.Errorf("ListUsers() ids = %v, expected %v", ids, tt.expectedIDs)
+			}
+			if result.NextCursor != "" {
+				t.Errorf("ListUsers() next cursor = %q on the last page", result.NextCursor)
+			}
+		})
+	}
+}
+
+func TestUserQueryHandler_ListUsers_Pagination(t *testing.T) {
+	handler := NewUserQueryHandler(newListStore())
+
+	// u3 and u4 share a creation time, so the second page relies on the id
+	// tie-break in the cursor
+	q := query.ListUsersQuery{Limit: 3, Descending: true}
+	var ids []string
+	for page := 0; ; page++ {
+		if page > 2 {
+			t.Fatal("pagination did not terminate")
+		}
+		result, err := handler.ListUsers(context.Background(), q)
+		if err != nil {
+			t.Fatalf("ListUsers() error = %v", err)
+		}
+		for _, u := range result.Users {
+			ids = append(ids, u.ID)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		q.Cursor = result.NextCursor
+	}
+
+	if expected := []string{"u4", "u3", "u2", "u1"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("paged ids = %v, expected %v", ids, expected)
+	}
+
+	q.Descending = false
+	if _, err := handler.ListUsers(context.Background(), q); !errors.Is(err, query.ErrInvalidCursor) {
+		t.Errorf("cursor reused with another sort: error = %v, expected %v", err, query.ErrInvalidCursor)
+	}
+}
+
+func TestUserQueryHandler_GetLoginHistory(t *testing.T) {
+	store := NewUserReadModelStore()
+	projector := NewMemoryProjector(store)
+	handler := NewUserQueryHandler(store)
+	ctx := context.Background()
+
+	// one more attempt than is kept, projected out of order and with a
+	// redelivery
+	versions := []int{3, 2}
+	for version := 4; version <= query.LoginHistorySize+2; version++ {
+		versions = append(versions, version)
+	}
+	versions = append(versions, 3)
+	for _, version := range versions {
+		if err := projector.ProjectEvent(ctx, userDomain.NewUserLoggedInEvent("user-1", "203.0.113.7", "curl/8.0", version)); err != nil {
+			t.Fatalf("ProjectEvent() error = %v", err)
+		}
+	}
+
+	history, err := handler.GetLoginHistory(ctx, query.GetLoginHistoryQuery{UserID: "user-1"})
+	if err != nil {
+		t.Fatalf("GetLoginHistory() error = %v", err)
+	}
+	if len(history.Logins) != query.LoginHistorySize {
+		t.Fatalf("GetLoginHistory() returned %d logins, expected %d", len(history.Logins), query.LoginHistorySize)
+	}
+	if kept := store.loginHistory("user-1"); kept[0].Version != query.LoginHistorySize+2 || kept[len(kept)-1].Version != 3 {
+		t.Errorf("kept versions %d..%d, expected %d..3", kept[0].Version, kept[len(kept)-1].Version, query.LoginHistorySize+2)
+	}
+
+	limited, err := handler.GetLoginHistory(ctx, query.GetLoginHistoryQuery{UserID: "user-1", Limit: 5})
+	if err != nil {
+		t.Fatalf("GetLoginHistory() error = %v", err)
+	}
+	if len(limited.Logins) != 5 {
+		t.Errorf("GetLoginHistory() with limit returned %d logins, expected 5", len(limited.Logins))
+	}
+
+	if _, err := handler.GetLoginHistory(ctx, query.GetLoginHistoryQuery{UserID: "user-1", Limit: query.LoginHistorySize + 1}); !errors.Is(err, query.ErrInvalidUserQuery) {
+		t.Errorf("GetLoginHistory() error = %v, expected %v", err, query.ErrInvalidUserQuery)
+	}
+}
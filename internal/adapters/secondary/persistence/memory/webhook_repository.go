@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+type webhookRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[string]types.WebhookSubscription
+	deliveries    map[string]types.WebhookDelivery
+}
+
+func NewWebhookRepository() secondary.WebhookRepository {
+	return &webhookRepository{
+		subscriptions: make(map[string]types.WebhookSubscription),
+		deliveries:    make(map[string]types.WebhookDelivery),
+	}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription types.WebhookSubscription) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription.EventTypes = append([]string(nil), subscription.EventTypes...)
+	r.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id string) (*types.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscription, exists := r.subscriptions[id]
+	if !exists {
+		return nil, secondary.ErrWebhookNotFound
+	}
+	subscription.EventTypes = append([]string(nil), subscription.EventTypes...)
+	return &subscription, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]types.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscriptions := make([]types.WebhookSubscription, 0, len(r.subscriptions))
+	for _, subscription := range r.subscriptions {
+		subscription.EventTypes = append([]string(nil), subscription.EventTypes...)
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.subscriptions[id]; !exists {
+		return secondary.ErrWebhookNotFound
+	}
+	delete(r.subscriptions, id)
+	for deliveryID, delivery := range r.deliveries {
+		if delivery.SubscriptionID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery types.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.subscriptions[delivery.SubscriptionID]; !exists {
+		return secondary.ErrWebhookNotFound
+	}
+	if _, exists := r.deliveries[delivery.ID]; exists {
+		return secondary.ErrWebhookDeliveryExists
+	}
+	r.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id string) (*types.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	delivery, exists := r.deliveries[id]
+	if !exists {
+		return nil, secondary.ErrWebhookDeliveryNotFound
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery types.WebhookDelivery) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.deliveries[delivery.ID]; !exists {
+		return secondary.ErrWebhookDeliveryNotFound
+	}
+	r.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]types.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := []types.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.SubscriptionID != subscriptionID {
+			continue
+		}
+		if status != "" && delivery.Status != status {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]types.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := []types.WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if delivery.Status == types.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
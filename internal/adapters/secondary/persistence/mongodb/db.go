@@ -66,6 +66,10 @@ func (c *Client) Connect(ctx context.Context) error {
 
 	c.mongo = client
 	c.db = client.Database(c.config.Database)
+
+	if err := c.ensureIndexes(ctx); err != nil {
+		return err
+	}
 	return nil
 }
 
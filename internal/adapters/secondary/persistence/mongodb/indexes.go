@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	usersCollection        = "users"
+	loginHistoryCollection = "login_history"
+)
+
+// userIndexes serve the username lookup and the admin listing, whose keyset
+// pagination sorts on a field and _id. email_id serves the email prefix
+// search.
+var userIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "username", Value: 1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName("username_id"),
+	},
+	{
+		Keys:    bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName("created_at_id"),
+	},
+	{
+		Keys:    bson.D{{Key: "email", Value: 1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName("email_id"),
+	},
+}
+
+// loginHistoryIndexes serve reading and trimming a user's history, newest
+// first.
+var loginHistoryIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetName("user_id_version"),
+	},
+}
+
+// ensureIndexes creates missing indexes; existing ones with the same keys
+// and options are left as they are.
+func (c *Client) ensureIndexes(ctx context.Context) error {
+	for collection, indexes := range map[string][]mongo.IndexModel{
+		usersCollection:        userIndexes,
+		loginHistoryCollection: loginHistoryIndexes,
+	} {
+		if _, err := c.db.Collection(collection).Indexes().CreateMany(ctx, indexes); err != nil {
+			return fmt.Errorf("failed to create %s indexes: %w", collection, err)
+		}
+	}
+	return nil
+}
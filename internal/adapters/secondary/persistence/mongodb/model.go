@@ -2,13 +2,44 @@ package mongodb
 
 import (
 	"time"
+
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
 )
 
 type UserReadModel struct {
 	ID           string    `bson:"_id"`
 	Username     string    `bson:"username"`
 	PasswordHash string    `bson:"password_hash"`
+	DisplayName  string    `bson:"display_name,omitempty"`
+	AvatarURL    string    `bson:"avatar_url,omitempty"`
+	Locale       string    `bson:"locale,omitempty"`
+	Timezone     string    `bson:"timezone,omitempty"`
+	Email        string    `bson:"email,omitempty"`
+	Role         string    `bson:"role,omitempty"`
+	Locked       bool      `bson:"locked,omitempty"`
 	CreatedAt    time.Time `bson:"created_at"`
 	UpdatedAt    time.Time `bson:"updated_at"`
 	Version      int       `bson:"version"`
+	Erased       bool      `bson:"erased,omitempty"`
+}
+
+// role reads documents projected before roles existed as the default role.
+func (rm UserReadModel) role() string {
+	if rm.Role == "" {
+		return string(userDomain.DefaultRole)
+	}
+	return rm.Role
+}
+
+// LoginRecord is one login_history document; its id is the user id and
+// event version, so a redelivered event maps onto the same document.
+type LoginRecord struct {
+	ID         string    `bson:"_id"`
+	UserID     string    `bson:"user_id"`
+	Version    int       `bson:"version"`
+	Outcome    string    `bson:"outcome"`
+	Reason     string    `bson:"reason,omitempty"`
+	IPAddress  string    `bson:"ip_address"`
+	UserAgent  string    `bson:"user_agent"`
+	OccurredAt time.Time `bson:"occurred_at"`
 }
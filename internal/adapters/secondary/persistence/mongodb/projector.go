@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
 	"github.com/ncfex/dcart-auth/internal/domain/shared"
 	"github.com/ncfex/dcart-auth/internal/domain/user"
 	"go.mongodb.org/mongo-driver/bson"
@@ -29,44 +30,216 @@ func (p *MongoProjector) ProjectEvent(ctx context.Context, event shared.Event) e
 		return p.projectUserRegistered(ctx, e)
 	case *user.UserPasswordChangedEvent:
 		return p.projectUserPasswordChanged(ctx, e)
+	case *user.UserPasswordRehashedEvent:
+		return p.projectUserPasswordRehashed(ctx, e)
+	case *user.UserUsernameChangedEvent:
+		return p.projectUserUsernameChanged(ctx, e)
+	case *user.UserProfileUpdatedEvent:
+		return p.projectUserProfileUpdated(ctx, e)
+	case *user.UserEmailChangedEvent:
+		return p.applyNewer(ctx, e, bson.M{"email": e.Email})
+	case *user.UserLockedEvent:
+		return p.applyNewer(ctx, e, bson.M{"locked": true})
+	case *user.UserUnlockedEvent:
+		return p.applyNewer(ctx, e, bson.M{"locked": false})
+	case *user.UserRoleAssignedEvent:
+		return p.applyNewer(ctx, e, bson.M{"role": e.Role})
+	case *user.UserErasedEvent:
+		return p.projectUserErased(ctx, e)
+	case *user.UserLoggedInEvent:
+		return p.projectLogin(ctx, LoginRecord{
+			UserID:    e.GetAggregateID(),
+			Version:   e.GetVersion(),
+			Outcome:   query.LoginOutcomeSucceeded,
+			IPAddress: e.IPAddress,
+			UserAgent: e.UserAgent,
+		}, e)
+	case *user.UserLoginFailedEvent:
+		return p.projectLogin(ctx, LoginRecord{
+			UserID:    e.GetAggregateID(),
+			Version:   e.GetVersion(),
+			Outcome:   query.LoginOutcomeFailed,
+			Reason:    e.Reason,
+			IPAddress: e.IPAddress,
+			UserAgent: e.UserAgent,
+		}, e)
 	default:
 		return fmt.Errorf("unsupported event type: %s", event.GetEventType())
 	}
 }
 
+// projectUserRegistered creates the document, or completes one a later event
+// created first with what only the registration knows. Tombstones are left
+// alone.
 func (p *MongoProjector) projectUserRegistered(ctx context.Context, event *user.UserRegisteredEvent) error {
 	collection := p.db.Collection(p.collectionName)
 
-	filter := bson.M{"_id": event.GetAggregateID()}
-	update := bson.M{
-		"$setOnInsert": bson.M{
-			"_id":           event.GetAggregateID(),
-			"username":      event.Username,
-			"password_hash": event.PasswordHash,
+	// values are wrapped in $literal since hashes start with $, which a
+	// pipeline would read as a field path
+	filter := bson.M{"_id": event.GetAggregateID(), "erased": bson.M{"$ne": true}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"username":      bson.M{"$ifNull": bson.A{"$username", bson.M{"$literal": event.Username}}},
+			"password_hash": bson.M{"$ifNull": bson.A{"$password_hash", bson.M{"$literal": event.PasswordHash}}},
+			"role":          bson.M{"$ifNull": bson.A{"$role", string(user.DefaultRole)}},
 			"created_at":    event.GetTimestamp(),
-			"updated_at":    event.GetTimestamp(),
-			"version":       1,
-		},
+			"updated_at":    bson.M{"$ifNull": bson.A{"$updated_at", event.GetTimestamp()}},
+			"version":       bson.M{"$ifNull": bson.A{"$version", event.GetVersion()}},
+		}}},
 	}
 
 	opts := options.Update().SetUpsert(true)
 	_, err := collection.UpdateOne(ctx, filter, update, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
 	return err
 }
 
 func (p *MongoProjector) projectUserPasswordChanged(ctx context.Context, event *user.UserPasswordChangedEvent) error {
+	return p.applyNewer(ctx, event, bson.M{
+		"password_hash": event.NewPasswordHash,
+	})
+}
+
+func (p *MongoProjector) projectUserPasswordRehashed(ctx context.Context, event *user.UserPasswordRehashedEvent) error {
+	return p.applyNewer(ctx, event, bson.M{
+		"password_hash": event.NewPasswordHash,
+	})
+}
+
+func (p *MongoProjector) projectUserUsernameChanged(ctx context.Context, event *user.UserUsernameChangedEvent) error {
+	return p.applyNewer(ctx, event, bson.M{
+		"username": event.Username,
+	})
+}
+
+func (p *MongoProjector) projectUserProfileUpdated(ctx context.Context, event *user.UserProfileUpdatedEvent) error {
+	return p.applyNewer(ctx, event, bson.M{
+		"display_name": event.DisplayName,
+		"avatar_url":   event.AvatarURL,
+		"locale":       event.Locale,
+		"timezone":     event.Timezone,
+	})
+}
+
+// applyNewer sets fields on the user's document unless it already reflects
+// a later version or is a tombstone, since an erased user's data must stay
+// gone. An event that arrives before the registration creates the document.
+func (p *MongoProjector) applyNewer(ctx context.Context, event shared.Event, fields bson.M) error {
+	collection := p.db.Collection(p.collectionName)
+
+	fields["updated_at"] = event.GetTimestamp()
+	fields["version"] = event.GetVersion()
+
+	filter := bson.M{
+		"_id":     event.GetAggregateID(),
+		"version": bson.M{"$lt": event.GetVersion()},
+		"erased":  bson.M{"$ne": true},
+	}
+	update := bson.M{
+		"$set":         fields,
+		"$setOnInsert": bson.M{"created_at": event.GetTimestamp()},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := collection.UpdateOne(ctx, filter, update, opts)
+	// the upsert collides with a document the filter skipped, which is newer
+	// or erased
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// projectUserErased leaves a tombstone so the document is not recreated by a
+// late event, while dropping every personal data field and the login history.
+func (p *MongoProjector) projectUserErased(ctx context.Context, event *user.UserErasedEvent) error {
+	if _, err := p.db.Collection(loginHistoryCollection).DeleteMany(ctx, bson.M{"user_id": event.GetAggregateID()}); err != nil {
+		return err
+	}
+
 	collection := p.db.Collection(p.collectionName)
 
 	filter := bson.M{"_id": event.GetAggregateID()}
 	update := bson.M{
 		"$set": bson.M{
-			"password_hash": event.NewPasswordHash,
-			"updated_at":    event.GetTimestamp(),
-			"version":       event.GetVersion(),
+			"erased":     true,
+			"updated_at": event.GetTimestamp(),
+			"version":    event.GetVersion(),
+		},
+		"$unset": bson.M{
+			"username":      "",
+			"password_hash": "",
+			"display_name":  "",
+			"avatar_url":    "",
+			"locale":        "",
+			"timezone":      "",
+			"email":         "",
+		},
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := collection.UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
+// projectLogin stores the attempt, trims the user's history to
+// query.LoginHistorySize entries, dropping the oldest, and advances the
+// user's version.
+func (p *MongoProjector) projectLogin(ctx context.Context, record LoginRecord, event shared.Event) error {
+	collection := p.db.Collection(loginHistoryCollection)
+
+	record.ID = fmt.Sprintf("%s-%d", record.UserID, record.Version)
+	record.OccurredAt = event.GetTimestamp()
+
+	opts := options.Update().SetUpsert(true)
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": record.ID}, bson.M{"$setOnInsert": record}, opts); err != nil {
+		return err
+	}
+
+	var oldest LoginRecord
+	findOpts := options.FindOne().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetSkip(query.LoginHistorySize).
+		SetProjection(bson.M{"version": 1})
+	err := collection.FindOne(ctx, bson.M{"user_id": record.UserID}, findOpts).Decode(&oldest)
+	switch {
+	case err == nil:
+		if _, err := collection.DeleteMany(ctx, bson.M{"user_id": record.UserID, "version": bson.M{"$lte": oldest.Version}}); err != nil {
+			return err
+		}
+	case err != mongo.ErrNoDocuments:
+		return err
+	}
+
+	return p.advanceVersion(ctx, event)
+}
+
+// advanceVersion moves the user's document to the version of an event that
+// changes none of its fields, so the version keeps matching the stream's and
+// a reader waiting for a later write is not held up by a login. updated_at is
+// left alone.
+func (p *MongoProjector) advanceVersion(ctx context.Context, event shared.Event) error {
+	collection := p.db.Collection(p.collectionName)
+
+	filter := bson.M{
+		"_id":     event.GetAggregateID(),
+		"version": bson.M{"$lt": event.GetVersion()},
+		"erased":  bson.M{"$ne": true},
+	}
+	update := bson.M{
+		"$set": bson.M{"version": event.GetVersion()},
+		"$setOnInsert": bson.M{
+			"created_at": event.GetTimestamp(),
+			"updated_at": event.GetTimestamp(),
 		},
 	}
 
 	opts := options.Update().SetUpsert(true)
 	_, err := collection.UpdateOne(ctx, filter, update, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
 	return err
 }
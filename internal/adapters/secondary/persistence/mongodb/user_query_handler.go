@@ -3,6 +3,7 @@ package mongodb
 import (
 	"context"
 	"fmt"
+	"regexp"
 
 	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
 	"github.com/ncfex/dcart-auth/internal/application/ports/types"
@@ -10,6 +11,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // todo move this to internal/application/query/user_query_handler.go
@@ -21,13 +23,13 @@ type UserQueryHandler struct {
 func NewUserQueryHandler(db *mongo.Database) *UserQueryHandler {
 	return &UserQueryHandler{
 		db:         db,
-		collection: "users",
+		collection: usersCollection,
 	}
 }
 
 func (h *UserQueryHandler) GetUserByID(ctx context.Context, query query.GetUserByIDQuery) (*types.UserResponse, error) {
 	var userRM UserReadModel
-	err := h.db.Collection(h.collection).FindOne(ctx, bson.M{"_id": query.UserID}).Decode(&userRM)
+	err := h.db.Collection(h.collection).FindOne(ctx, bson.M{"_id": query.UserID, "erased": bson.M{"$ne": true}}).Decode(&userRM)
 	if err == mongo.ErrNoDocuments {
 		return nil, userDomain.ErrUserNotFound
 	}
@@ -38,12 +40,16 @@ func (h *UserQueryHandler) GetUserByID(ctx context.Context, query query.GetUserB
 	return &types.UserResponse{
 		ID:       userRM.ID,
 		Username: userRM.Username,
+		Email:    userRM.Email,
+		Role:     userRM.role(),
+		Locked:   userRM.Locked,
+		Version:  userRM.Version,
 	}, nil
 }
 
 func (h *UserQueryHandler) GetUserByUsername(ctx context.Context, query query.GetUserByUsernameQuery) (*types.UserResponse, error) {
 	var userRM UserReadModel
-	err := h.db.Collection(h.collection).FindOne(ctx, bson.M{"username": query.Username}).Decode(&userRM)
+	err := h.db.Collection(h.collection).FindOne(ctx, bson.M{"username": query.Username, "erased": bson.M{"$ne": true}}).Decode(&userRM)
 	if err == mongo.ErrNoDocuments {
 		return nil, userDomain.ErrUserNotFound
 	}
@@ -54,5 +60,163 @@ func (h *UserQueryHandler) GetUserByUsername(ctx context.Context, query query.Ge
 	return &types.UserResponse{
 		ID:       userRM.ID,
 		Username: userRM.Username,
+		Email:    userRM.Email,
+		Role:     userRM.role(),
+		Locked:   userRM.Locked,
+		Version:  userRM.Version,
 	}, nil
 }
+
+func (h *UserQueryHandler) GetProfile(ctx context.Context, query query.GetProfileQuery) (*types.ProfileResponse, error) {
+	var userRM UserReadModel
+	err := h.db.Collection(h.collection).FindOne(ctx, bson.M{"_id": query.UserID, "erased": bson.M{"$ne": true}}).Decode(&userRM)
+	if err == mongo.ErrNoDocuments {
+		return nil, userDomain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+
+	return &types.ProfileResponse{
+		ID:          userRM.ID,
+		Username:    userRM.Username,
+		DisplayName: userRM.DisplayName,
+		AvatarURL:   userRM.AvatarURL,
+		Locale:      userRM.Locale,
+		Timezone:    userRM.Timezone,
+		CreatedAt:   userRM.CreatedAt,
+		UpdatedAt:   userRM.UpdatedAt,
+		Version:     userRM.Version,
+	}, nil
+}
+
+// ListUsers pages by keyset on the sort field and _id, which the indexes
+// created by Client.Connect serve without an in-memory sort.
+func (h *UserQueryHandler) ListUsers(ctx context.Context, q query.ListUsersQuery) (*types.UserListResponse, error) {
+	q, cursor, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	filters := bson.A{bson.M{"erased": bson.M{"$ne": true}}}
+	if q.UsernamePrefix != "" {
+		// an anchored, case-sensitive regex is a range scan on the index
+		filters = append(filters, bson.M{"username": bson.M{"$regex": "^" + regexp.QuoteMeta(q.UsernamePrefix)}})
+	}
+	if q.EmailPrefix != "" {
+		filters = append(filters, bson.M{"email": bson.M{"$regex": "^" + regexp.QuoteMeta(q.EmailPrefix)}})
+	}
+	if q.Locked != nil {
+		// unlocked documents may have no locked field at all
+		if *q.Locked {
+			filters = append(filters, bson.M{"locked": true})
+		} else {
+			filters = append(filters, bson.M{"locked": bson.M{"$ne": true}})
+		}
+	}
+	if q.Role != "" {
+		roles := bson.A{q.Role}
+		if q.Role == string(userDomain.DefaultRole) {
+			// documents projected before roles existed have none
+			roles = append(roles, nil)
+		}
+		filters = append(filters, bson.M{"role": bson.M{"$in": roles}})
+	}
+	createdAt := bson.M{}
+	if !q.CreatedFrom.IsZero() {
+		createdAt["$gte"] = q.CreatedFrom
+	}
+	if !q.CreatedTo.IsZero() {
+		createdAt["$lt"] = q.CreatedTo
+	}
+	if len(createdAt) > 0 {
+		filters = append(filters, bson.M{"created_at": createdAt})
+	}
+
+	direction, after := 1, "$gt"
+	if q.Descending {
+		direction, after = -1, "$lt"
+	}
+	if cursor != nil {
+		var value interface{} = cursor.CreatedAt
+		if q.SortBy == query.UserSortUsername {
+			value = cursor.Username
+		}
+		filters = append(filters, bson.M{"$or": bson.A{
+			bson.M{q.SortBy: bson.M{after: value}},
+			bson.M{q.SortBy: value, "_id": bson.M{after: cursor.ID}},
+		}})
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: q.SortBy, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(q.Limit + 1)).
+		SetProjection(bson.M{"password_hash": 0})
+
+	cur, err := h.db.Collection(h.collection).Find(ctx, bson.M{"$and": filters}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	var users []UserReadModel
+	if err := cur.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	response := &types.UserListResponse{Users: []types.UserSummaryResponse{}}
+	if len(users) > q.Limit {
+		users = users[:q.Limit]
+		last := users[len(users)-1]
+		response.NextCursor = query.EncodeUserCursor(query.UserCursor{
+			SortBy:     q.SortBy,
+			Descending: q.Descending,
+			Username:   last.Username,
+			CreatedAt:  last.CreatedAt,
+			ID:         last.ID,
+		})
+	}
+	for _, userRM := range users {
+		response.Users = append(response.Users, types.UserSummaryResponse{
+			ID:        userRM.ID,
+			Username:  userRM.Username,
+			Email:     userRM.Email,
+			Role:      userRM.role(),
+			Locked:    userRM.Locked,
+			CreatedAt: userRM.CreatedAt,
+			UpdatedAt: userRM.UpdatedAt,
+			Version:   userRM.Version,
+		})
+	}
+	return response, nil
+}
+
+func (h *UserQueryHandler) GetLoginHistory(ctx context.Context, q query.GetLoginHistoryQuery) (*types.LoginHistoryResponse, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetLimit(int64(q.Limit))
+	cursor, err := h.db.Collection(loginHistoryCollection).Find(ctx, bson.M{"user_id": q.UserID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+
+	var records []LoginRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode login history: %w", err)
+	}
+
+	response := &types.LoginHistoryResponse{Logins: []types.LoginHistoryEntry{}}
+	for _, record := range records {
+		response.Logins = append(response.Logins, types.LoginHistoryEntry{
+			Outcome:    record.Outcome,
+			Reason:     record.Reason,
+			IPAddress:  record.IPAddress,
+			UserAgent:  record.UserAgent,
+			OccurredAt: record.OccurredAt,
+		})
+	}
+	return response, nil
+}
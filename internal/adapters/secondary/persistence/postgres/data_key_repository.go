@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/postgres/db"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+)
+
+type dataKeyRepository struct {
+	queries *db.Queries
+}
+
+func NewDataKeyRepository(database *database) secondary.DataKeyRepository {
+	return &dataKeyRepository{
+		queries: db.New(database.DB),
+	}
+}
+
+func (r *dataKeyRepository) CreateKey(ctx context.Context, subjectID string, key []byte) error {
+	err := r.queries.CreateDataKey(ctx, db.CreateDataKeyParams{
+		SubjectID: subjectID,
+		Key:       key,
+	})
+	if isUniqueViolation(err) {
+		return secondary.ErrDataKeyExists
+	}
+	if err != nil {
+		return fmt.Errorf("create data key: %w", err)
+	}
+	return nil
+}
+
+func (r *dataKeyRepository) GetKey(ctx context.Context, subjectID string) ([]byte, error) {
+	key, err := r.queries.GetDataKey(ctx, subjectID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, secondary.ErrDataKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get data key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *dataKeyRepository) DeleteKey(ctx context.Context, subjectID string) error {
+	if err := r.queries.DeleteDataKey(ctx, subjectID); err != nil {
+		return fmt.Errorf("delete data key: %w", err)
+	}
+	return nil
+}
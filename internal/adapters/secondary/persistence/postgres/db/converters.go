@@ -3,6 +3,7 @@ package db
 import (
 	"time"
 
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
 	tokenDomain "github.com/ncfex/dcart-auth/internal/domain/token"
 )
 
@@ -22,3 +23,30 @@ func ToRefreshTokenDomain(dbToken *RefreshToken) *tokenDomain.RefreshToken {
 		RevokedAt: revokedAt,
 	}
 }
+
+func ToWebhookDelivery(dbDelivery *WebhookDelivery) types.WebhookDelivery {
+	return types.WebhookDelivery{
+		ID:             dbDelivery.ID,
+		SubscriptionID: dbDelivery.SubscriptionID,
+		EventID:        dbDelivery.EventID,
+		EventType:      dbDelivery.EventType,
+		Payload:        dbDelivery.Payload,
+		Status:         dbDelivery.Status,
+		Attempts:       int(dbDelivery.Attempts),
+		LastError:      dbDelivery.LastError,
+		ResponseStatus: int(dbDelivery.ResponseStatus),
+		NextAttemptAt:  dbDelivery.NextAttemptAt,
+		CreatedAt:      dbDelivery.CreatedAt,
+		UpdatedAt:      dbDelivery.UpdatedAt,
+	}
+}
+
+func ToWebhookSubscription(dbSubscription *WebhookSubscription) types.WebhookSubscription {
+	return types.WebhookSubscription{
+		ID:         dbSubscription.ID,
+		URL:        dbSubscription.Url,
+		EventTypes: dbSubscription.EventTypes,
+		Secret:     dbSubscription.Secret,
+		CreatedAt:  dbSubscription.CreatedAt,
+	}
+}
@@ -0,0 +1,56 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: data_key.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createDataKey = `-- name: CreateDataKey :exec
+INSERT INTO data_keys (
+  subject_id,
+  key,
+  created_at
+)
+VALUES (
+    $1,
+    $2,
+    NOW() AT TIME ZONE 'UTC'
+)
+`
+
+type CreateDataKeyParams struct {
+	SubjectID string `json:"subject_id"`
+	Key       []byte `json:"key"`
+}
+
+func (q *Queries) CreateDataKey(ctx context.Context, arg CreateDataKeyParams) error {
+	_, err := q.db.ExecContext(ctx, createDataKey, arg.SubjectID, arg.Key)
+	return err
+}
+
+const deleteDataKey = `-- name: DeleteDataKey :exec
+DELETE FROM data_keys
+WHERE subject_id = $1
+`
+
+func (q *Queries) DeleteDataKey(ctx context.Context, subjectID string) error {
+	_, err := q.db.ExecContext(ctx, deleteDataKey, subjectID)
+	return err
+}
+
+const getDataKey = `-- name: GetDataKey :one
+SELECT key
+FROM data_keys
+WHERE subject_id = $1
+`
+
+func (q *Queries) GetDataKey(ctx context.Context, subjectID string) ([]byte, error) {
+	row := q.db.QueryRowContext(ctx, getDataKey, subjectID)
+	var key []byte
+	err := row.Scan(&key)
+	return key, err
+}
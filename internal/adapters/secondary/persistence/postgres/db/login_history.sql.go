@@ -0,0 +1,133 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: login_history.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const addLoginHistory = `-- name: AddLoginHistory :exec
+INSERT INTO login_history (
+  user_id,
+  version,
+  outcome,
+  reason,
+  ip_address,
+  user_agent,
+  occurred_at
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT (user_id, version) DO NOTHING
+`
+
+type AddLoginHistoryParams struct {
+	UserID     string    `json:"user_id"`
+	Version    int32     `json:"version"`
+	Outcome    string    `json:"outcome"`
+	Reason     string    `json:"reason"`
+	IpAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (q *Queries) AddLoginHistory(ctx context.Context, arg AddLoginHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, addLoginHistory,
+		arg.UserID,
+		arg.Version,
+		arg.Outcome,
+		arg.Reason,
+		arg.IpAddress,
+		arg.UserAgent,
+		arg.OccurredAt,
+	)
+	return err
+}
+
+const deleteLoginHistory = `-- name: DeleteLoginHistory :exec
+DELETE FROM login_history
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteLoginHistory(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteLoginHistory, userID)
+	return err
+}
+
+const listLoginHistory = `-- name: ListLoginHistory :many
+SELECT user_id, version, outcome, reason, ip_address, user_agent, occurred_at
+FROM login_history
+WHERE user_id = $1
+ORDER BY version DESC
+LIMIT $2
+`
+
+type ListLoginHistoryParams struct {
+	UserID     string `json:"user_id"`
+	MaxResults int32  `json:"max_results"`
+}
+
+func (q *Queries) ListLoginHistory(ctx context.Context, arg ListLoginHistoryParams) ([]LoginHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listLoginHistory, arg.UserID, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoginHistory
+	for rows.Next() {
+		var i LoginHistory
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Version,
+			&i.Outcome,
+			&i.Reason,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const trimLoginHistory = `-- name: TrimLoginHistory :exec
+DELETE FROM login_history
+WHERE login_history.user_id = $1
+    AND login_history.version <= (
+        SELECT kept.version
+        FROM login_history kept
+        WHERE kept.user_id = $1
+        ORDER BY kept.version DESC
+        OFFSET $2
+        LIMIT 1
+    )
+`
+
+type TrimLoginHistoryParams struct {
+	UserID string `json:"user_id"`
+	Keep   int32  `json:"keep"`
+}
+
+func (q *Queries) TrimLoginHistory(ctx context.Context, arg TrimLoginHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, trimLoginHistory, arg.UserID, arg.Keep)
+	return err
+}
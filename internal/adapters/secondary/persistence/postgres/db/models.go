@@ -10,6 +10,12 @@ import (
 	"time"
 )
 
+type DataKey struct {
+	SubjectID string    `json:"subject_id"`
+	Key       []byte    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Event struct {
 	ID            string          `json:"id"`
 	AggregateID   string          `json:"aggregate_id"`
@@ -18,6 +24,19 @@ type Event struct {
 	Version       int32           `json:"version"`
 	Timestamp     time.Time       `json:"timestamp"`
 	Payload       json.RawMessage `json:"payload"`
+	SchemaVersion int32           `json:"schema_version"`
+	Metadata      json.RawMessage `json:"metadata"`
+	Position      int64           `json:"position"`
+}
+
+type LoginHistory struct {
+	UserID     string    `json:"user_id"`
+	Version    int32     `json:"version"`
+	Outcome    string    `json:"outcome"`
+	Reason     string    `json:"reason"`
+	IpAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	OccurredAt time.Time `json:"occurred_at"`
 }
 
 type RefreshToken struct {
@@ -28,3 +47,64 @@ type RefreshToken struct {
 	ExpiresAt time.Time    `json:"expires_at"`
 	RevokedAt sql.NullTime `json:"revoked_at"`
 }
+
+type Reservation struct {
+	Scope       string `json:"scope"`
+	Value       string `json:"value"`
+	AggregateID string `json:"aggregate_id"`
+}
+
+type Snapshot struct {
+	AggregateID   string          `json:"aggregate_id"`
+	AggregateType string          `json:"aggregate_type"`
+	SnapshotType  string          `json:"snapshot_type"`
+	Version       int32           `json:"version"`
+	State         json.RawMessage `json:"state"`
+	Timestamp     time.Time       `json:"timestamp"`
+}
+
+type SubscriptionCheckpoint struct {
+	Name      string    `json:"name"`
+	Position  int64     `json:"position"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type UserReadModel struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Version      int32     `json:"version"`
+	Erased       bool      `json:"erased"`
+	DisplayName  string    `json:"display_name"`
+	AvatarUrl    string    `json:"avatar_url"`
+	Locale       string    `json:"locale"`
+	Timezone     string    `json:"timezone"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	Locked       bool      `json:"locked"`
+}
+
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	LastError      string          `json:"last_error"`
+	ResponseStatus int32           `json:"response_status"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret"`
+	CreatedAt  time.Time `json:"created_at"`
+}
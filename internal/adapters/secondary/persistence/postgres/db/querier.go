@@ -9,10 +9,45 @@ import (
 )
 
 type Querier interface {
+	AddLoginHistory(ctx context.Context, arg AddLoginHistoryParams) error
+	CreateDataKey(ctx context.Context, arg CreateDataKeyParams) error
 	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) error
+	DeleteDataKey(ctx context.Context, subjectID string) error
+	DeleteLoginHistory(ctx context.Context, userID string) error
+	DeleteWebhookSubscription(ctx context.Context, id string) (int64, error)
+	DueWebhookDeliveries(ctx context.Context, arg DueWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	GetDataKey(ctx context.Context, subjectID string) ([]byte, error)
 	GetTokenByTokenString(ctx context.Context, token string) (RefreshToken, error)
+	GetUserReadModelByID(ctx context.Context, id string) (UserReadModel, error)
+	GetUserReadModelByUsername(ctx context.Context, username string) (UserReadModel, error)
+	GetWebhookDelivery(ctx context.Context, id string) (WebhookDelivery, error)
+	GetWebhookSubscription(ctx context.Context, id string) (WebhookSubscription, error)
+	ListLoginHistory(ctx context.Context, arg ListLoginHistoryParams) ([]LoginHistory, error)
+	ListUsersByCreatedAtAsc(ctx context.Context, arg ListUsersByCreatedAtAscParams) ([]UserReadModel, error)
+	ListUsersByCreatedAtDesc(ctx context.Context, arg ListUsersByCreatedAtDescParams) ([]UserReadModel, error)
+	ListUsersByUsernameAsc(ctx context.Context, arg ListUsersByUsernameAscParams) ([]UserReadModel, error)
+	ListUsersByUsernameDesc(ctx context.Context, arg ListUsersByUsernameDescParams) ([]UserReadModel, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	ProjectUserEmailChanged(ctx context.Context, arg ProjectUserEmailChangedParams) error
+	ProjectUserErased(ctx context.Context, arg ProjectUserErasedParams) error
+	ProjectUserLockChanged(ctx context.Context, arg ProjectUserLockChangedParams) error
+	ProjectUserPasswordChanged(ctx context.Context, arg ProjectUserPasswordChangedParams) error
+	ProjectUserProfileUpdated(ctx context.Context, arg ProjectUserProfileUpdatedParams) error
+	// a later event may have created the row first; the registration then only
+	// fills in what that event could not know
+	ProjectUserRegistered(ctx context.Context, arg ProjectUserRegisteredParams) error
+	ProjectUserRoleAssigned(ctx context.Context, arg ProjectUserRoleAssignedParams) error
+	ProjectUserUsernameChanged(ctx context.Context, arg ProjectUserUsernameChangedParams) error
+	// login events change no column; the version still follows the stream so
+	// readers waiting for a later write are not held up
+	ProjectUserVersion(ctx context.Context, arg ProjectUserVersionParams) error
 	RevokeRefreshToken(ctx context.Context, token string) (RefreshToken, error)
 	SaveToken(ctx context.Context, arg SaveTokenParams) error
+	TrimLoginHistory(ctx context.Context, arg TrimLoginHistoryParams) error
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,751 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: user_read_model.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const getUserReadModelByID = `-- name: GetUserReadModelByID :one
+SELECT id, username, password_hash, created_at, updated_at, version, erased, display_name, avatar_url, locale, timezone, email, role, locked
+FROM user_read_model
+WHERE id = $1
+    AND NOT erased
+`
+
+func (q *Queries) GetUserReadModelByID(ctx context.Context, id string) (UserReadModel, error) {
+	row := q.db.QueryRowContext(ctx, getUserReadModelByID, id)
+	var i UserReadModel
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.Erased,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.Timezone,
+		&i.Email,
+		&i.Role,
+		&i.Locked,
+	)
+	return i, err
+}
+
+const getUserReadModelByUsername = `-- name: GetUserReadModelByUsername :one
+SELECT id, username, password_hash, created_at, updated_at, version, erased, display_name, avatar_url, locale, timezone, email, role, locked
+FROM user_read_model
+WHERE username = $1
+    AND NOT erased
+LIMIT 1
+`
+
+func (q *Queries) GetUserReadModelByUsername(ctx context.Context, username string) (UserReadModel, error) {
+	row := q.db.QueryRowContext(ctx, getUserReadModelByUsername, username)
+	var i UserReadModel
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PasswordHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.Erased,
+		&i.DisplayName,
+		&i.AvatarUrl,
+		&i.Locale,
+		&i.Timezone,
+		&i.Email,
+		&i.Role,
+		&i.Locked,
+	)
+	return i, err
+}
+
+const listUsersByCreatedAtAsc = `-- name: ListUsersByCreatedAtAsc :many
+SELECT id, username, password_hash, created_at, updated_at, version, erased, display_name, avatar_url, locale, timezone, email, role, locked
+FROM user_read_model
+WHERE NOT erased
+    AND username LIKE $1::TEXT
+    AND email LIKE $2::TEXT
+    AND ($3::BOOLEAN IS NULL OR locked = $3)
+    AND ($4::TEXT IS NULL OR role = $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+    AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+    AND ($7::TEXT IS NULL OR (created_at, id) > ($8::TIMESTAMPTZ, $7))
+ORDER BY created_at ASC, id ASC
+LIMIT $9
+`
+
+type ListUsersByCreatedAtAscParams struct {
+	UsernamePattern string         `json:"username_pattern"`
+	EmailPattern    string         `json:"email_pattern"`
+	Locked          sql.NullBool   `json:"locked"`
+	Role            sql.NullString `json:"role"`
+	CreatedFrom     sql.NullTime   `json:"created_from"`
+	CreatedTo       sql.NullTime   `json:"created_to"`
+	AfterID         sql.NullString `json:"after_id"`
+	AfterCreatedAt  time.Time      `json:"after_created_at"`
+	MaxResults      int32          `json:"max_results"`
+}
+
+func (q *Queries) ListUsersByCreatedAtAsc(ctx context.Context, arg ListUsersByCreatedAtAscParams) ([]UserReadModel, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByCreatedAtAsc,
+		arg.UsernamePattern,
+		arg.EmailPattern,
+		arg.Locked,
+		arg.Role,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterID,
+		arg.AfterCreatedAt,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserReadModel
+	for rows.Next() {
+		var i UserReadModel
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.Erased,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.Locale,
+			&i.Timezone,
+			&i.Email,
+			&i.Role,
+			&i.Locked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByCreatedAtDesc = `-- name: ListUsersByCreatedAtDesc :many
+SELECT id, username, password_hash, created_at, updated_at, version, erased, display_name, avatar_url, locale, timezone, email, role, locked
+FROM user_read_model
+WHERE NOT erased
+    AND username LIKE $1::TEXT
+    AND email LIKE $2::TEXT
+    AND ($3::BOOLEAN IS NULL OR locked = $3)
+    AND ($4::TEXT IS NULL OR role = $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+    AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+    AND ($7::TEXT IS NULL OR (created_at, id) < ($8::TIMESTAMPTZ, $7))
+ORDER BY created_at DESC, id DESC
+LIMIT $9
+`
+
+type ListUsersByCreatedAtDescParams struct {
+	UsernamePattern string         `json:"username_pattern"`
+	EmailPattern    string         `json:"email_pattern"`
+	Locked          sql.NullBool   `json:"locked"`
+	Role            sql.NullString `json:"role"`
+	CreatedFrom     sql.NullTime   `json:"created_from"`
+	CreatedTo       sql.NullTime   `json:"created_to"`
+	AfterID         sql.NullString `json:"after_id"`
+	AfterCreatedAt  time.Time      `json:"after_created_at"`
+	MaxResults      int32          `json:"max_results"`
+}
+
+func (q *Queries) ListUsersByCreatedAtDesc(ctx context.Context, arg ListUsersByCreatedAtDescParams) ([]UserReadModel, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByCreatedAtDesc,
+		arg.UsernamePattern,
+		arg.EmailPattern,
+		arg.Locked,
+		arg.Role,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterID,
+		arg.AfterCreatedAt,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserReadModel
+	for rows.Next() {
+		var i UserReadModel
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.Erased,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.Locale,
+			&i.Timezone,
+			&i.Email,
+			&i.Role,
+			&i.Locked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByUsernameAsc = `-- name: ListUsersByUsernameAsc :many
+SELECT id, username, password_hash, created_at, updated_at, version, erased, display_name, avatar_url, locale, timezone, email, role, locked
+FROM user_read_model
+WHERE NOT erased
+    AND username LIKE $1::TEXT
+    AND email LIKE $2::TEXT
+    AND ($3::BOOLEAN IS NULL OR locked = $3)
+    AND ($4::TEXT IS NULL OR role = $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+    AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+    AND ($7::TEXT IS NULL OR (username, id) > ($8::TEXT, $7))
+ORDER BY username ASC, id ASC
+LIMIT $9
+`
+
+type ListUsersByUsernameAscParams struct {
+	UsernamePattern string         `json:"username_pattern"`
+	EmailPattern    string         `json:"email_pattern"`
+	Locked          sql.NullBool   `json:"locked"`
+	Role            sql.NullString `json:"role"`
+	CreatedFrom     sql.NullTime   `json:"created_from"`
+	CreatedTo       sql.NullTime   `json:"created_to"`
+	AfterID         sql.NullString `json:"after_id"`
+	AfterUsername   string         `json:"after_username"`
+	MaxResults      int32          `json:"max_results"`
+}
+
+func (q *Queries) ListUsersByUsernameAsc(ctx context.Context, arg ListUsersByUsernameAscParams) ([]UserReadModel, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByUsernameAsc,
+		arg.UsernamePattern,
+		arg.EmailPattern,
+		arg.Locked,
+		arg.Role,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterID,
+		arg.AfterUsername,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserReadModel
+	for rows.Next() {
+		var i UserReadModel
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.Erased,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.Locale,
+			&i.Timezone,
+			&i.Email,
+			&i.Role,
+			&i.Locked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByUsernameDesc = `-- name: ListUsersByUsernameDesc :many
+SELECT id, username, password_hash, created_at, updated_at, version, erased, display_name, avatar_url, locale, timezone, email, role, locked
+FROM user_read_model
+WHERE NOT erased
+    AND username LIKE $1::TEXT
+    AND email LIKE $2::TEXT
+    AND ($3::BOOLEAN IS NULL OR locked = $3)
+    AND ($4::TEXT IS NULL OR role = $4)
+    AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+    AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+    AND ($7::TEXT IS NULL OR (username, id) < ($8::TEXT, $7))
+ORDER BY username DESC, id DESC
+LIMIT $9
+`
+
+type ListUsersByUsernameDescParams struct {
+	UsernamePattern string         `json:"username_pattern"`
+	EmailPattern    string         `json:"email_pattern"`
+	Locked          sql.NullBool   `json:"locked"`
+	Role            sql.NullString `json:"role"`
+	CreatedFrom     sql.NullTime   `json:"created_from"`
+	CreatedTo       sql.NullTime   `json:"created_to"`
+	AfterID         sql.NullString `json:"after_id"`
+	AfterUsername   string         `json:"after_username"`
+	MaxResults      int32          `json:"max_results"`
+}
+
+func (q *Queries) ListUsersByUsernameDesc(ctx context.Context, arg ListUsersByUsernameDescParams) ([]UserReadModel, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersByUsernameDesc,
+		arg.UsernamePattern,
+		arg.EmailPattern,
+		arg.Locked,
+		arg.Role,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterID,
+		arg.AfterUsername,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserReadModel
+	for rows.Next() {
+		var i UserReadModel
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.PasswordHash,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.Erased,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.Locale,
+			&i.Timezone,
+			&i.Email,
+			&i.Role,
+			&i.Locked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const projectUserEmailChanged = `-- name: ProjectUserEmailChanged :exec
+INSERT INTO user_read_model (
+  id,
+  email,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    email = EXCLUDED.email,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased
+`
+
+type ProjectUserEmailChangedParams struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int32     `json:"version"`
+}
+
+func (q *Queries) ProjectUserEmailChanged(ctx context.Context, arg ProjectUserEmailChangedParams) error {
+	_, err := q.db.ExecContext(ctx, projectUserEmailChanged,
+		arg.ID,
+		arg.Email,
+		arg.CreatedAt,
+		arg.Version,
+	)
+	return err
+}
+
+const projectUserErased = `-- name: ProjectUserErased :exec
+INSERT INTO user_read_model (
+  id,
+  created_at,
+  updated_at,
+  version,
+  erased
+)
+VALUES (
+    $1,
+    $2,
+    $2,
+    $3,
+    TRUE
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    username = '',
+    password_hash = '',
+    display_name = '',
+    avatar_url = '',
+    locale = '',
+    timezone = '',
+    email = '',
+    erased = TRUE,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+`
+
+type ProjectUserErasedParams struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int32     `json:"version"`
+}
+
+func (q *Queries) ProjectUserErased(ctx context.Context, arg ProjectUserErasedParams) error {
+	_, err := q.db.ExecContext(ctx, projectUserErased, arg.ID, arg.CreatedAt, arg.Version)
+	return err
+}
+
+const projectUserLockChanged = `-- name: ProjectUserLockChanged :exec
+INSERT INTO user_read_model (
+  id,
+  locked,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    locked = EXCLUDED.locked,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased
+`
+
+type ProjectUserLockChangedParams struct {
+	ID        string    `json:"id"`
+	Locked    bool      `json:"locked"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int32     `json:"version"`
+}
+
+func (q *Queries) ProjectUserLockChanged(ctx context.Context, arg ProjectUserLockChangedParams) error {
+	_, err := q.db.ExecContext(ctx, projectUserLockChanged,
+		arg.ID,
+		arg.Locked,
+		arg.CreatedAt,
+		arg.Version,
+	)
+	return err
+}
+
+const projectUserPasswordChanged = `-- name: ProjectUserPasswordChanged :exec
+INSERT INTO user_read_model (
+  id,
+  password_hash,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    password_hash = EXCLUDED.password_hash,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased
+`
+
+type ProjectUserPasswordChangedParams struct {
+	ID           string    `json:"id"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+	Version      int32     `json:"version"`
+}
+
+func (q *Queries) ProjectUserPasswordChanged(ctx context.Context, arg ProjectUserPasswordChangedParams) error {
+	_, err := q.db.ExecContext(ctx, projectUserPasswordChanged,
+		arg.ID,
+		arg.PasswordHash,
+		arg.CreatedAt,
+		arg.Version,
+	)
+	return err
+}
+
+const projectUserProfileUpdated = `-- name: ProjectUserProfileUpdated :exec
+INSERT INTO user_read_model (
+  id,
+  display_name,
+  avatar_url,
+  locale,
+  timezone,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $6,
+    $7
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    display_name = EXCLUDED.display_name,
+    avatar_url = EXCLUDED.avatar_url,
+    locale = EXCLUDED.locale,
+    timezone = EXCLUDED.timezone,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased
+`
+
+type ProjectUserProfileUpdatedParams struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	AvatarUrl   string    `json:"avatar_url"`
+	Locale      string    `json:"locale"`
+	Timezone    string    `json:"timezone"`
+	CreatedAt   time.Time `json:"created_at"`
+	Version     int32     `json:"version"`
+}
+
+func (q *Queries) ProjectUserProfileUpdated(ctx context.Context, arg ProjectUserProfileUpdatedParams) error {
+	_, err := q.db.ExecContext(ctx, projectUserProfileUpdated,
+		arg.ID,
+		arg.DisplayName,
+		arg.AvatarUrl,
+		arg.Locale,
+		arg.Timezone,
+		arg.CreatedAt,
+		arg.Version,
+	)
+	return err
+}
+
+const projectUserRegistered = `-- name: ProjectUserRegistered :exec
+INSERT INTO user_read_model (
+  id,
+  username,
+  password_hash,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $4,
+    1
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    username = CASE WHEN user_read_model.username = '' THEN EXCLUDED.username ELSE user_read_model.username END,
+    password_hash = CASE WHEN user_read_model.password_hash = '' THEN EXCLUDED.password_hash ELSE user_read_model.password_hash END,
+    created_at = EXCLUDED.created_at
+WHERE user_read_model.version > EXCLUDED.version
+    AND NOT user_read_model.erased
+`
+
+type ProjectUserRegisteredParams struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// a later event may have created the row first; the registration then only
+// fills in what that event could not know
+func (q *Queries) ProjectUserRegistered(ctx context.Context, arg ProjectUserRegisteredParams) error {
+	_, err := q.db.ExecContext(ctx, projectUserRegistered,
+		arg.ID,
+		arg.Username,
+		arg.PasswordHash,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const projectUserRoleAssigned = `-- name: ProjectUserRoleAssigned :exec
+INSERT INTO user_read_model (
+  id,
+  role,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    role = EXCLUDED.role,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased
+`
+
+type ProjectUserRoleAssignedParams struct {
+	ID        string    `json:"id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int32     `json:"version"`
+}
+
+func (q *Queries) ProjectUserRoleAssigned(ctx context.Context, arg ProjectUserRoleAssignedParams) error {
+	_, err := q.db.ExecContext(ctx, projectUserRoleAssigned,
+		arg.ID,
+		arg.Role,
+		arg.CreatedAt,
+		arg.Version,
+	)
+	return err
+}
+
+const projectUserUsernameChanged = `-- name: ProjectUserUsernameChanged :exec
+INSERT INTO user_read_model (
+  id,
+  username,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    username = EXCLUDED.username,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased
+`
+
+type ProjectUserUsernameChangedParams struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int32     `json:"version"`
+}
+
+func (q *Queries) ProjectUserUsernameChanged(ctx context.Context, arg ProjectUserUsernameChangedParams) error {
+	_, err := q.db.ExecContext(ctx, projectUserUsernameChanged,
+		arg.ID,
+		arg.Username,
+		arg.CreatedAt,
+		arg.Version,
+	)
+	return err
+}
+
+const projectUserVersion = `-- name: ProjectUserVersion :exec
+INSERT INTO user_read_model (
+  id,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $2,
+    $3
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased
+`
+
+type ProjectUserVersionParams struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int32     `json:"version"`
+}
+
+// login events change no column; the version still follows the stream so
+// readers waiting for a later write are not held up
+func (q *Queries) ProjectUserVersion(ctx context.Context, arg ProjectUserVersionParams) error {
+	_, err := q.db.ExecContext(ctx, projectUserVersion, arg.ID, arg.CreatedAt, arg.Version)
+	return err
+}
@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+)
+
+type ListenerConfig struct {
+	// Name identifies the checkpoint; listeners sharing a name resume from
+	// the same position.
+	Name                 string
+	BatchSize            int
+	PollInterval         time.Duration
+	MinReconnectInterval time.Duration
+	MaxReconnectInterval time.Duration
+	ProcessingTimeout    time.Duration
+	// an event that fails is retried MaxRetries times, RetryBaseDelay apart
+	// and doubling, and then parked so the events after it are not held up
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+}
+
+// EventListener catches up from its last checkpoint whenever
+// PostgresEventStore.SaveEvents signals EventsChannel, and dispatches the new
+// events to a projector in position order. The store must have listeners
+// enabled. Polling on PollInterval covers
+// notifications lost while the connection was down. An event the projector
+// keeps failing on is parked in parked_events, where ParkedEvents can replay
+// it, and the listener moves on.
+type EventListener struct {
+	config    ListenerConfig
+	dsn       string
+	store     *PostgresEventStore
+	projector secondary.EventProjector
+
+	listener *pq.Listener
+	position int64
+	stopChan chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewEventListener(dsn string, store *PostgresEventStore, config ListenerConfig, projector secondary.EventProjector) *EventListener {
+	return &EventListener{
+		config:    config,
+		dsn:       dsn,
+		store:     store,
+		projector: projector,
+		stopChan:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (l *EventListener) Start(ctx context.Context) error {
+	position, err := l.loadCheckpoint(ctx)
+	if err != nil {
+		return err
+	}
+	l.position = position
+
+	l.listener = pq.NewListener(
+		l.dsn,
+		l.config.MinReconnectInterval,
+		l.config.MaxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("event listener connection: %v", err)
+			}
+		},
+	)
+	if err := l.listener.Listen(EventsChannel); err != nil {
+		l.listener.Close()
+		return fmt.Errorf("listen on %s: %w", EventsChannel, err)
+	}
+
+	go l.run(ctx)
+
+	return nil
+}
+
+func (l *EventListener) run(ctx context.Context) {
+	defer close(l.done)
+	defer l.listener.Close()
+
+	ticker := time.NewTicker(l.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// everything committed before LISTEN took effect is picked up here too
+		if err := l.catchUp(ctx); err != nil {
+			log.Printf("event listener catch up: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.stopChan:
+			return
+		case <-l.listener.Notify:
+			// a nil notification means the connection was re-established
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *EventListener) catchUp(ctx context.Context) error {
+	for {
+		events, err := l.store.getEventsAfter(ctx, l.position, l.config.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		projected := l.position
+		for _, metadata := range events {
+			retries, err := l.projectWithRetries(ctx, metadata)
+			if err != nil && !l.stopping(ctx) {
+				err = l.park(ctx, metadata, retries, err)
+			}
+			if err != nil {
+				// keep what was projected; the rest is retried on the next wake-up
+				if saveErr := l.saveCheckpoint(ctx, projected); saveErr != nil {
+					return saveErr
+				}
+				return fmt.Errorf("project event at position %d: %w", metadata.Position, err)
+			}
+			projected = metadata.Position
+		}
+
+		if err := l.saveCheckpoint(ctx, projected); err != nil {
+			return err
+		}
+
+		if len(events) < l.config.BatchSize {
+			return nil
+		}
+	}
+}
+
+// projectWithRetries projects the event, retrying in place so the events
+// after it keep their order, and returns how many retries it took. It gives
+// up early when the listener is stopped.
+func (l *EventListener) projectWithRetries(ctx context.Context, metadata EventMetadata) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := l.project(ctx, metadata)
+		if err == nil || attempt > l.config.MaxRetries {
+			return attempt - 1, err
+		}
+
+		log.Printf("retrying event at position %d, attempt %d: %v", metadata.Position, attempt, err)
+		if !l.wait(ctx, l.config.RetryBaseDelay<<(attempt-1)) {
+			return attempt - 1, err
+		}
+	}
+}
+
+// park records an event the projector gave up on, so the checkpoint can move
+// past it.
+func (l *EventListener) park(ctx context.Context, metadata EventMetadata, retries int, reason error) error {
+	log.Printf("parking event at position %d after %d retries: %v", metadata.Position, retries, reason)
+
+	_, err := l.store.db.ExecContext(ctx, `
+		INSERT INTO parked_events (subscription, position, reason, retries, parked_at) 
+		VALUES ($1, $2, $3, $4, $5) 
+		ON CONFLICT (subscription, position) DO UPDATE 
+		SET reason = EXCLUDED.reason, retries = EXCLUDED.retries, parked_at = EXCLUDED.parked_at`,
+		l.config.Name, metadata.Position, reason.Error(), retries, time.Now())
+	if err != nil {
+		return fmt.Errorf("park event: %w (projecting: %v)", err, reason)
+	}
+	return nil
+}
+
+// wait sleeps for d and reports false if the listener is stopped first.
+func (l *EventListener) wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-l.stopChan:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (l *EventListener) stopping(ctx context.Context) bool {
+	select {
+	case <-l.stopChan:
+		return true
+	default:
+		return ctx.Err() != nil
+	}
+}
+
+func (l *EventListener) project(ctx context.Context, metadata EventMetadata) error {
+	event, err := l.store.decodeEvent(ctx, metadata)
+	if err != nil {
+		return err
+	}
+
+	processCtx, cancel := context.WithTimeout(ctx, l.config.ProcessingTimeout)
+	defer cancel()
+
+	return l.projector.ProjectEvent(processCtx, event)
+}
+
+func (l *EventListener) loadCheckpoint(ctx context.Context) (int64, error) {
+	var position int64
+	err := l.store.db.QueryRowContext(ctx, `
+		SELECT position 
+		FROM subscription_checkpoints 
+		WHERE name = $1`,
+		l.config.Name).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("load checkpoint: %w", err)
+	}
+	return position, nil
+}
+
+func (l *EventListener) saveCheckpoint(ctx context.Context, position int64) error {
+	if position == l.position {
+		return nil
+	}
+
+	_, err := l.store.db.ExecContext(ctx, `
+		INSERT INTO subscription_checkpoints (name, position, updated_at) 
+		VALUES ($1, $2, $3) 
+		ON CONFLICT (name) DO UPDATE 
+		SET position = EXCLUDED.position, updated_at = EXCLUDED.updated_at`,
+		l.config.Name, position, time.Now())
+	if err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+
+	l.position = position
+	return nil
+}
+
+func (l *EventListener) Stop() error {
+	l.stopOnce.Do(func() {
+		close(l.stopChan)
+	})
+	<-l.done
+	return nil
+}
@@ -0,0 +1,291 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/encryption"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/memory"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+type recordingProjector struct {
+	mu     sync.Mutex
+	events []shared.Event
+}
+
+func (p *recordingProjector) ProjectEvent(ctx context.Context, event shared.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingProjector) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.events)
+}
+
+// TestEventListener_CatchesUpAndFollows needs a migrated database; point
+// POSTGRES_TEST_DSN at it to run.
+func TestEventListener_CatchesUpAndFollows(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := NewDatabase(dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`TRUNCATE events, subscription_checkpoints, parked_events`); err != nil {
+		t.Fatalf("Failed to truncate: %v", err)
+	}
+
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	store := NewPostgresEventStore(db.DB, registry, encryption.NewFieldCipher(NewDataKeyRepository(db), registry))
+	store.EnableListeners()
+
+	ctx := context.Background()
+	// committed before the listener starts, so only catch up can see it
+	if err := store.SaveEvents(ctx, "user-1", []shared.Event{
+		user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+	}); err != nil {
+		t.Fatalf("SaveEvents() error = %v", err)
+	}
+
+	config := ListenerConfig{
+		Name:                 "test",
+		BatchSize:            10,
+		PollInterval:         time.Hour,
+		MinReconnectInterval: time.Second,
+		MaxReconnectInterval: time.Second,
+		ProcessingTimeout:    time.Second,
+	}
+	projector := &recordingProjector{}
+	listener := NewEventListener(dsn, store, config, projector)
+	if err := listener.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Stop() })
+
+	waitFor(t, func() bool { return projector.count() == 1 })
+
+	// with polling effectively off, only the notification wakes the listener
+	if err := store.SaveEvents(ctx, "user-1", []shared.Event{
+		user.NewUserPasswordChangedEvent("user-1", "hash-2", 2),
+	}); err != nil {
+		t.Fatalf("SaveEvents() error = %v", err)
+	}
+	waitFor(t, func() bool { return projector.count() == 2 })
+
+	position, err := listener.loadCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("loadCheckpoint() error = %v", err)
+	}
+	if position == 0 {
+		t.Error("checkpoint was not advanced")
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type flakyProjector struct {
+	failures int
+	calls    int
+}
+
+func (p *flakyProjector) ProjectEvent(ctx context.Context, event shared.Event) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("read model unavailable")
+	}
+	return nil
+}
+
+func TestEventListener_ProjectWithRetries(t *testing.T) {
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	store := &PostgresEventStore{
+		eventRegistry: registry,
+		cipher:        encryption.NewFieldCipher(memory.NewDataKeyRepository(), registry),
+	}
+	payload, err := json.Marshal(user.NewUserRegisteredEvent("user-1", "alice", "hash"))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	metadata := EventMetadata{
+		Position:      1,
+		AggregateID:   "user-1",
+		AggregateType: "USER",
+		EventType:     string(user.EventTypeUserRegistered),
+		Version:       1,
+		SchemaVersion: shared.InitialSchemaVersion,
+		Payload:       payload,
+		Metadata:      json.RawMessage(`{}`),
+	}
+
+	tests := []struct {
+		name            string
+		failures        int
+		stopped         bool
+		expectedCalls   int
+		expectedRetries int
+		expectErr       bool
+	}{
+		{
+			name:            "recovers on a retry",
+			failures:        2,
+			expectedCalls:   3,
+			expectedRetries: 2,
+		},
+		{
+			name:            "gives up after the last retry",
+			failures:        10,
+			expectedCalls:   4,
+			expectedRetries: 3,
+			expectErr:       true,
+		},
+		{
+			name:            "stops retrying once stopped",
+			failures:        10,
+			stopped:         true,
+			expectedCalls:   1,
+			expectedRetries: 0,
+			expectErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projector := &flakyProjector{failures: tt.failures}
+			listener := NewEventListener("", store, ListenerConfig{
+				ProcessingTimeout: time.Second,
+				MaxRetries:        3,
+				RetryBaseDelay:    time.Millisecond,
+			}, projector)
+			if tt.stopped {
+				close(listener.stopChan)
+			}
+
+			retries, err := listener.projectWithRetries(context.Background(), metadata)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("projectWithRetries() error = %v, expected error %v", err, tt.expectErr)
+			}
+			if retries != tt.expectedRetries || projector.calls != tt.expectedCalls {
+				t.Errorf("retries = %d after %d calls, expected %d after %d", retries, projector.calls, tt.expectedRetries, tt.expectedCalls)
+			}
+			if listener.stopping(context.Background()) != tt.stopped {
+				t.Errorf("stopping() = %v, expected %v", !tt.stopped, tt.stopped)
+			}
+		})
+	}
+}
+
+// poisonProjector fails on the first version of every stream until healed.
+type poisonProjector struct {
+	recordingProjector
+	healed bool
+}
+
+func (p *poisonProjector) ProjectEvent(ctx context.Context, event shared.Event) error {
+	p.mu.Lock()
+	healed := p.healed
+	p.mu.Unlock()
+	if event.GetVersion() == 1 && !healed {
+		return errors.New("cannot project registration")
+	}
+	return p.recordingProjector.ProjectEvent(ctx, event)
+}
+
+func TestEventListener_ParksPoisonEvents(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := NewDatabase(dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`TRUNCATE events, subscription_checkpoints, parked_events`); err != nil {
+		t.Fatalf("Failed to truncate: %v", err)
+	}
+
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	store := NewPostgresEventStore(db.DB, registry, encryption.NewFieldCipher(NewDataKeyRepository(db), registry))
+	store.EnableListeners()
+
+	ctx := context.Background()
+	if err := store.SaveEvents(ctx, "user-1", []shared.Event{
+		user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+		user.NewUserPasswordChangedEvent("user-1", "hash-2", 2),
+	}); err != nil {
+		t.Fatalf("SaveEvents() error = %v", err)
+	}
+
+	config := ListenerConfig{
+		Name:                 "test-parking",
+		BatchSize:            10,
+		PollInterval:         time.Hour,
+		MinReconnectInterval: time.Second,
+		MaxReconnectInterval: time.Second,
+		ProcessingTimeout:    time.Second,
+		MaxRetries:           1,
+		RetryBaseDelay:       time.Millisecond,
+	}
+	projector := &poisonProjector{}
+	listener := NewEventListener(dsn, store, config, projector)
+	if err := listener.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { listener.Stop() })
+
+	// the registration is parked and the password change behind it projected
+	waitFor(t, func() bool { return projector.count() == 1 })
+
+	parked := NewParkedEvents(store, config.Name, projector)
+	letters, err := parked.List(ctx, 0)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(letters) != 1 || letters[0].ID != "user-1-1" || letters[0].Retries != 1 {
+		t.Fatalf("List() = %+v, expected user-1-1 parked after 1 retry", letters)
+	}
+
+	projector.mu.Lock()
+	projector.healed = true
+	projector.mu.Unlock()
+	replayed, err := parked.Replay(ctx, nil)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if replayed != 1 || projector.count() != 2 {
+		t.Errorf("Replay() = %d with %d events projected, expected 1 and 2", replayed, projector.count())
+	}
+	if letters, _ := parked.List(ctx, 0); len(letters) != 0 {
+		t.Errorf("List() after Replay() = %+v, expected none", letters)
+	}
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE events
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_events_correlation_id ON events((metadata->>'correlation_id'));
+
+-- +goose Down
+DROP INDEX idx_events_correlation_id;
+
+ALTER TABLE events
+    DROP COLUMN metadata;
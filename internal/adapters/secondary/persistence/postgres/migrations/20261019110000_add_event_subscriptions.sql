@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE events
+    ADD COLUMN position BIGSERIAL NOT NULL;
+
+CREATE UNIQUE INDEX idx_events_position ON events(position);
+
+CREATE TABLE subscription_checkpoints (
+    name VARCHAR(255) PRIMARY KEY,
+    position BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE subscription_checkpoints;
+
+DROP INDEX idx_events_position;
+
+ALTER TABLE events
+    DROP COLUMN position;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE data_keys (
+    subject_id VARCHAR(255) PRIMARY KEY,
+    key BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE data_keys;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- user_read_model is the postgres alternative to the mongodb users
+-- collection; erased users stay as tombstones without personal data
+CREATE TABLE user_read_model (
+    id VARCHAR(255) PRIMARY KEY,
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    password_hash TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    version INTEGER NOT NULL,
+    erased BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX idx_user_read_model_username ON user_read_model (username, id);
+CREATE INDEX idx_user_read_model_created_at ON user_read_model (created_at, id);
+
+-- +goose Down
+DROP TABLE user_read_model;
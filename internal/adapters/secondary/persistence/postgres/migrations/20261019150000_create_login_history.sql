@@ -0,0 +1,16 @@
+-- +goose Up
+-- login_history keeps the most recent sign-in attempts per user, one row
+-- per user.loggedIn or user.loginFailed event
+CREATE TABLE login_history (
+    user_id VARCHAR(255) NOT NULL,
+    version INTEGER NOT NULL,
+    outcome VARCHAR(16) NOT NULL,
+    reason VARCHAR(64) NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, version)
+);
+
+-- +goose Down
+DROP TABLE login_history;
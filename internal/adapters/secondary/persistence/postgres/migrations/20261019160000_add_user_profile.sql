@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE user_read_model
+    ADD COLUMN display_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '',
+    ADD COLUMN locale VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE user_read_model
+    DROP COLUMN display_name,
+    DROP COLUMN avatar_url,
+    DROP COLUMN locale,
+    DROP COLUMN timezone;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- reservations hold values only one aggregate may have at a time, such as
+-- usernames; the event store maintains them in the transaction that appends
+-- the event claiming or releasing a value. Streams written before this
+-- table existed are reserved by the reserve-usernames command.
+CREATE TABLE reservations (
+    scope VARCHAR(64) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+
+    PRIMARY KEY (scope, value),
+    UNIQUE (scope, aggregate_id)
+);
+
+-- +goose Down
+DROP TABLE reservations;
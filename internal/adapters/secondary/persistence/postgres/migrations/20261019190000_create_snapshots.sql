@@ -0,0 +1,14 @@
+-- +goose Up
+-- snapshots keeps the latest state of each aggregate, so loading one replays
+-- only the events after it; personal data fields are encrypted like payloads
+CREATE TABLE snapshots (
+    aggregate_id VARCHAR(255) PRIMARY KEY,
+    aggregate_type VARCHAR(255) NOT NULL,
+    snapshot_type VARCHAR(255) NOT NULL,
+    version INTEGER NOT NULL,
+    state JSONB NOT NULL,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE snapshots;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- existing users take the default role and stay unlocked
+ALTER TABLE user_read_model
+    ADD COLUMN email VARCHAR(254) NOT NULL DEFAULT '',
+    ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'user',
+    ADD COLUMN locked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_user_read_model_email ON user_read_model (email, id);
+
+-- +goose Down
+DROP INDEX idx_user_read_model_email;
+
+ALTER TABLE user_read_model
+    DROP COLUMN email,
+    DROP COLUMN role,
+    DROP COLUMN locked;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- parked_events holds the events a listener gave up projecting, so the
+-- checkpoint can move past them; the event itself stays in events
+CREATE TABLE parked_events (
+    subscription VARCHAR(255) NOT NULL,
+    position BIGINT NOT NULL,
+    reason TEXT NOT NULL,
+    retries INTEGER NOT NULL,
+    parked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (subscription, position)
+);
+
+-- +goose Down
+DROP TABLE parked_events;
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+// ParkedEvents is the dead-letter queue of an EventListener: the events it
+// parked under its subscription name. Payloads are listed as stored, with
+// personal data still encrypted; replaying projects an event again and
+// unparks it once that succeeds.
+type ParkedEvents struct {
+	store        *PostgresEventStore
+	subscription string
+	projector    secondary.EventProjector
+}
+
+func NewParkedEvents(store *PostgresEventStore, subscription string, projector secondary.EventProjector) *ParkedEvents {
+	return &ParkedEvents{
+		store:        store,
+		subscription: subscription,
+		projector:    projector,
+	}
+}
+
+type parkedEvent struct {
+	EventMetadata
+	Reason  string
+	Retries int
+}
+
+func (p *ParkedEvents) List(ctx context.Context, limit int) ([]types.DeadLetter, error) {
+	parked, err := p.parked(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]types.DeadLetter, 0, len(parked))
+	for _, event := range parked {
+		var metadata map[string]string
+		if err := json.Unmarshal(event.Metadata, &metadata); err != nil {
+			return nil, fmt.Errorf("unmarshal event metadata: %w", err)
+		}
+		letters = append(letters, types.DeadLetter{
+			ID:            event.ID,
+			AggregateID:   event.AggregateID,
+			EventType:     event.EventType,
+			Version:       event.Version,
+			SchemaVersion: event.SchemaVersion,
+			Timestamp:     event.Timestamp,
+			Payload:       event.Payload,
+			Metadata:      metadata,
+			Reason:        event.Reason,
+			Retries:       event.Retries,
+		})
+	}
+	return letters, nil
+}
+
+// Replay projects the selected events in position order and stops at the
+// first that fails again, leaving it parked.
+func (p *ParkedEvents) Replay(ctx context.Context, ids []string) (int, error) {
+	parked, err := p.parked(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+
+	replayed := 0
+	for _, event := range parked {
+		if len(selected) > 0 && !selected[event.ID] {
+			continue
+		}
+
+		decoded, err := p.store.decodeEvent(ctx, event.EventMetadata)
+		if err != nil {
+			return replayed, fmt.Errorf("decode event %s: %w", event.ID, err)
+		}
+		if err := p.projector.ProjectEvent(ctx, decoded); err != nil {
+			return replayed, fmt.Errorf("project event %s: %w", event.ID, err)
+		}
+
+		if _, err := p.store.db.ExecContext(ctx, `
+			DELETE FROM parked_events
+			WHERE subscription = $1 AND position = $2`,
+			p.subscription, event.Position); err != nil {
+			return replayed, fmt.Errorf("unpark event %s: %w", event.ID, err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+func (p *ParkedEvents) Purge(ctx context.Context) (int, error) {
+	result, err := p.store.db.ExecContext(ctx, `
+		DELETE FROM parked_events
+		WHERE subscription = $1`,
+		p.subscription)
+	if err != nil {
+		return 0, fmt.Errorf("purge parked events: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("purge parked events: %w", err)
+	}
+	return int(purged), nil
+}
+
+// parked returns up to limit parked events with their stored rows, oldest
+// first; a limit of 0 returns all of them.
+func (p *ParkedEvents) parked(ctx context.Context, limit int) ([]parkedEvent, error) {
+	var maxResults interface{}
+	if limit > 0 {
+		maxResults = limit
+	}
+
+	rows, err := p.store.db.QueryContext(ctx, `
+		SELECT
+			e.id,
+			e.position,
+			e.aggregate_id,
+			e.aggregate_type,
+			e.event_type,
+			e.version,
+			e.schema_version,
+			e.timestamp,
+			e.payload,
+			e.metadata,
+			p.reason,
+			p.retries
+		FROM parked_events p
+		JOIN events e ON e.position = p.position
+		WHERE p.subscription = $1
+		ORDER BY p.position ASC
+		LIMIT $2`,
+		p.subscription, maxResults)
+	if err != nil {
+		return nil, fmt.Errorf("query parked events: %w", err)
+	}
+	defer rows.Close()
+
+	var parked []parkedEvent
+	for rows.Next() {
+		var event parkedEvent
+		if err := rows.Scan(
+			&event.ID,
+			&event.Position,
+			&event.AggregateID,
+			&event.AggregateType,
+			&event.EventType,
+			&event.Version,
+			&event.SchemaVersion,
+			&event.Timestamp,
+			&event.Payload,
+			&event.Metadata,
+			&event.Reason,
+			&event.Retries,
+		); err != nil {
+			return nil, fmt.Errorf("scan parked event: %w", err)
+		}
+		parked = append(parked, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate parked events: %w", err)
+	}
+	return parked, nil
+}
@@ -4,41 +4,103 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
+	"github.com/lib/pq"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
 	"github.com/ncfex/dcart-auth/internal/domain/shared"
 )
 
+// EventsChannel is the LISTEN/NOTIFY channel SaveEvents signals once its
+// transaction commits, when listeners are enabled.
+const EventsChannel = "events"
+
+// eventsAppendLock is the advisory lock key serializing appends while
+// listeners are enabled, so event positions become visible in commit order
+// and a listener that checkpoints on position never skips a write that was
+// still in flight.
+const eventsAppendLock = 0x6463617274
+
 type EventMetadata struct {
 	ID            string          `json:"id"`
+	Position      int64           `json:"position"`
 	AggregateID   string          `json:"aggregate_id"`
 	AggregateType string          `json:"aggregate_type"`
 	EventType     string          `json:"event_type"`
 	Version       int             `json:"version"`
+	SchemaVersion int             `json:"schema_version"`
 	Timestamp     time.Time       `json:"timestamp"`
 	Payload       json.RawMessage `json:"payload"`
+	Metadata      json.RawMessage `json:"metadata"`
 }
 
 type PostgresEventStore struct {
 	db            *sql.DB
 	eventRegistry shared.EventRegistry
+	cipher        secondary.PayloadCipher
+	listeners     atomic.Bool
 }
 
-func NewPostgresEventStore(db *sql.DB, registry shared.EventRegistry) *PostgresEventStore {
+func NewPostgresEventStore(db *sql.DB, registry shared.EventRegistry, cipher secondary.PayloadCipher) *PostgresEventStore {
 	return &PostgresEventStore{
 		db:            db,
 		eventRegistry: registry,
+		cipher:        cipher,
 	}
 }
 
+// EnableListeners makes SaveEvents serialize appends and signal
+// EventsChannel, which EventListener relies on. Call it before the first
+// append; other transports leave it off so unrelated streams append in
+// parallel.
+func (s *PostgresEventStore) EnableListeners() {
+	s.listeners.Store(true)
+}
+
 func (s *PostgresEventStore) SaveEvents(ctx context.Context, aggregateID string, events []shared.Event) error {
+	// encrypt up front; key creation must not run under the append lock
+	payloads := make([]json.RawMessage, len(events))
+	metadata := make([]json.RawMessage, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal event: %w", err)
+		}
+
+		payloads[i], err = s.cipher.EncryptPayload(ctx, aggregateID, shared.EventType(event.GetEventType()), payload)
+		if err != nil {
+			return fmt.Errorf("encrypt event: %w", err)
+		}
+
+		eventMetadata, err := s.cipher.EncryptMetadata(ctx, aggregateID, event.GetMetadata())
+		if err != nil {
+			return fmt.Errorf("encrypt event metadata: %w", err)
+		}
+		if eventMetadata == nil {
+			eventMetadata = shared.Metadata{}
+		}
+		if metadata[i], err = json.Marshal(eventMetadata); err != nil {
+			return fmt.Errorf("marshal event metadata: %w", err)
+		}
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	listeners := s.listeners.Load()
+	if listeners {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, eventsAppendLock); err != nil {
+			return fmt.Errorf("acquire append lock: %w", err)
+		}
+	}
+
 	_, err = tx.ExecContext(ctx, `
 		SELECT 1 FROM events 
 		WHERE aggregate_id = $1 
@@ -65,24 +127,24 @@ func (s *PostgresEventStore) SaveEvents(ctx context.Context, aggregateID string,
 			aggregate_type, 
 			event_type, 
 			version, 
+			schema_version, 
 			timestamp, 
-			payload
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+			payload, 
+			metadata
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`)
 	if err != nil {
 		return fmt.Errorf("prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	for _, event := range events {
+	for i, event := range events {
 		expectedVersion := latestVersion + 1
 		if event.GetVersion() != expectedVersion {
-			return fmt.Errorf("concurrent modification detected: expected version %d, got %d",
-				expectedVersion, event.GetVersion())
-		}
-
-		payload, err := json.Marshal(event)
-		if err != nil {
-			return fmt.Errorf("marshal event: %w", err)
+			return &secondary.ConcurrencyConflictError{
+				AggregateID:     aggregateID,
+				ExpectedVersion: event.GetVersion() - 1,
+				ActualVersion:   latestVersion,
+			}
 		}
 
 		_, err = stmt.ExecContext(ctx,
@@ -91,18 +153,153 @@ func (s *PostgresEventStore) SaveEvents(ctx context.Context, aggregateID string,
 			event.GetAggregateType(),
 			event.GetEventType(),
 			event.GetVersion(),
+			s.eventRegistry.SchemaVersion(shared.EventType(event.GetEventType())),
 			event.GetTimestamp(),
-			payload)
+			payloads[i],
+			metadata[i])
 		if err != nil {
+			// a writer that raced us on a new stream holds no row lock
+			if isUniqueViolation(err) {
+				return s.conflictError(ctx, aggregateID, event.GetVersion()-1)
+			}
 			return fmt.Errorf("insert event: %w", err)
 		}
 
 		latestVersion = event.GetVersion()
 	}
 
+	if err := reserve(ctx, tx, aggregateID, shared.ReservationsOf(events)); err != nil {
+		return err
+	}
+
+	// delivered to listeners only when the transaction commits
+	if listeners {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, EventsChannel, aggregateID); err != nil {
+			return fmt.Errorf("notify listeners: %w", err)
+		}
+	}
+
 	return tx.Commit()
 }
 
+func (s *PostgresEventStore) conflictError(ctx context.Context, aggregateID string, expectedVersion int) error {
+	var actualVersion int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version), 0) 
+		FROM events 
+		WHERE aggregate_id = $1`,
+		aggregateID).Scan(&actualVersion)
+	if err != nil {
+		return fmt.Errorf("get latest version: %w", err)
+	}
+
+	return &secondary.ConcurrencyConflictError{
+		AggregateID:     aggregateID,
+		ExpectedVersion: expectedVersion,
+		ActualVersion:   actualVersion,
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (s *PostgresEventStore) GetReservationHolder(ctx context.Context, scope, value string) (string, error) {
+	var holder string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT aggregate_id 
+		FROM reservations 
+		WHERE scope = $1 AND value = $2`,
+		scope, value).Scan(&holder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", secondary.ErrReservationNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get reservation: %w", err)
+	}
+	return holder, nil
+}
+
+func (s *PostgresEventStore) Reserve(ctx context.Context, aggregateID string, reservations []shared.Reservation) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	listeners := s.listeners.Load()
+	if listeners {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, eventsAppendLock); err != nil {
+			return fmt.Errorf("acquire append lock: %w", err)
+		}
+	}
+
+	if err := reserve(ctx, tx, aggregateID, reservations); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// reserve applies reservations for aggregateID within tx. The holder check
+// takes no lock, so two writers can both pass it for the same value; the
+// second then fails on the (scope, value) primary key, which is reported as
+// ErrValueReserved just the same.
+func reserve(ctx context.Context, tx *sql.Tx, aggregateID string, reservations []shared.Reservation) error {
+	for _, reservation := range reservations {
+		if reservation.Value != "" {
+			var holder string
+			err := tx.QueryRowContext(ctx, `
+				SELECT aggregate_id 
+				FROM reservations 
+				WHERE scope = $1 AND value = $2`,
+				reservation.Scope, reservation.Value).Scan(&holder)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("check reservation: %w", err)
+			}
+			if err == nil && holder != aggregateID {
+				return fmt.Errorf("%w in scope %s", secondary.ErrValueReserved, reservation.Scope)
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM reservations 
+			WHERE scope = $1 AND aggregate_id = $2`,
+			reservation.Scope, aggregateID)
+		if err != nil {
+			return fmt.Errorf("release reservation: %w", err)
+		}
+		if reservation.Value == "" {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO reservations (scope, value, aggregate_id) 
+			VALUES ($1, $2, $3)`,
+			reservation.Scope, reservation.Value, aggregateID)
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w in scope %s", secondary.ErrValueReserved, reservation.Scope)
+		}
+		if err != nil {
+			return fmt.Errorf("insert reservation: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PostgresEventStore) GetVersion(ctx context.Context, aggregateID string) (int, error) {
+	var version int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version), 0) 
+		FROM events 
+		WHERE aggregate_id = $1`,
+		aggregateID).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("get latest version: %w", err)
+	}
+	return version, nil
+}
+
 func (s *PostgresEventStore) GetEvents(ctx context.Context, aggregateID string) ([]shared.Event, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT 
@@ -110,8 +307,10 @@ func (s *PostgresEventStore) GetEvents(ctx context.Context, aggregateID string)
 			aggregate_type, 
 			event_type, 
 			version, 
+			schema_version, 
 			timestamp, 
-			payload 
+			payload, 
+			metadata 
 		FROM events 
 		WHERE aggregate_id = $1 
 		ORDER BY version ASC`,
@@ -121,7 +320,30 @@ func (s *PostgresEventStore) GetEvents(ctx context.Context, aggregateID string)
 	}
 	defer rows.Close()
 
-	return s.scanEvents(rows)
+	return s.scanEvents(ctx, rows)
+}
+
+func (s *PostgresEventStore) GetEventsAfterVersion(ctx context.Context, aggregateID string, version int) ([]shared.Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT 
+			aggregate_id, 
+			aggregate_type, 
+			event_type, 
+			version, 
+			schema_version, 
+			timestamp, 
+			payload, 
+			metadata 
+		FROM events 
+		WHERE aggregate_id = $1 AND version > $2 
+		ORDER BY version ASC`,
+		aggregateID, version)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanEvents(ctx, rows)
 }
 
 func (s *PostgresEventStore) GetEventsByType(ctx context.Context, eventType string) ([]shared.Event, error) {
@@ -131,8 +353,10 @@ func (s *PostgresEventStore) GetEventsByType(ctx context.Context, eventType stri
 			aggregate_type, 
 			event_type, 
 			version, 
+			schema_version, 
 			timestamp, 
-			payload 
+			payload, 
+			metadata 
 		FROM events 
 		WHERE event_type = $1 
 		ORDER BY timestamp ASC`,
@@ -142,31 +366,147 @@ func (s *PostgresEventStore) GetEventsByType(ctx context.Context, eventType stri
 	}
 	defer rows.Close()
 
-	return s.scanEvents(rows)
+	return s.scanEvents(ctx, rows)
 }
 
-func (s *PostgresEventStore) scanEvents(rows *sql.Rows) ([]shared.Event, error) {
-	var events []shared.Event
+func (s *PostgresEventStore) SaveSnapshot(ctx context.Context, snapshot shared.Snapshot) error {
+	state, err := s.cipher.EncryptPayload(ctx, snapshot.AggregateID, snapshot.Type, snapshot.State)
+	if err != nil {
+		return fmt.Errorf("encrypt snapshot: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO snapshots (
+			aggregate_id, 
+			aggregate_type, 
+			snapshot_type, 
+			version, 
+			state, 
+			timestamp
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (aggregate_id) DO UPDATE
+		SET
+			aggregate_type = EXCLUDED.aggregate_type,
+			snapshot_type = EXCLUDED.snapshot_type,
+			version = EXCLUDED.version,
+			state = EXCLUDED.state,
+			timestamp = EXCLUDED.timestamp
+		WHERE snapshots.version < EXCLUDED.version`,
+		snapshot.AggregateID,
+		snapshot.AggregateType,
+		string(snapshot.Type),
+		snapshot.Version,
+		state,
+		snapshot.Timestamp)
+	if err != nil {
+		return fmt.Errorf("save snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresEventStore) GetSnapshot(ctx context.Context, aggregateID string) (*shared.Snapshot, error) {
+	var (
+		snapshot     shared.Snapshot
+		snapshotType string
+		state        json.RawMessage
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT aggregate_id, aggregate_type, snapshot_type, version, state, timestamp 
+		FROM snapshots 
+		WHERE aggregate_id = $1`,
+		aggregateID).Scan(
+		&snapshot.AggregateID,
+		&snapshot.AggregateType,
+		&snapshotType,
+		&snapshot.Version,
+		&state,
+		&snapshot.Timestamp,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, secondary.ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot: %w", err)
+	}
+
+	snapshot.Type = shared.EventType(snapshotType)
+	snapshot.State, err = s.cipher.DecryptPayload(ctx, aggregateID, snapshot.Type, state)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// getEventsAfter returns up to limit raw events past position, in the order
+// they were committed.
+func (s *PostgresEventStore) getEventsAfter(ctx context.Context, position int64, limit int) ([]EventMetadata, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT 
+			position, 
+			aggregate_id, 
+			aggregate_type, 
+			event_type, 
+			version, 
+			schema_version, 
+			timestamp, 
+			payload, 
+			metadata 
+		FROM events 
+		WHERE position > $1 
+		ORDER BY position ASC 
+		LIMIT $2`,
+		position, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query events after position: %w", err)
+	}
+	defer rows.Close()
+
+	var events []EventMetadata
 	for rows.Next() {
 		var metadata EventMetadata
 		if err := rows.Scan(
+			&metadata.Position,
 			&metadata.AggregateID,
 			&metadata.AggregateType,
 			&metadata.EventType,
 			&metadata.Version,
+			&metadata.SchemaVersion,
 			&metadata.Timestamp,
 			&metadata.Payload,
+			&metadata.Metadata,
 		); err != nil {
 			return nil, fmt.Errorf("scan event: %w", err)
 		}
+		events = append(events, metadata)
+	}
 
-		event, ok := s.eventRegistry.CreateEvent(shared.EventType(metadata.EventType))
-		if !ok {
-			return nil, fmt.Errorf("unknown event type: %s", metadata.EventType)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *PostgresEventStore) scanEvents(ctx context.Context, rows *sql.Rows) ([]shared.Event, error) {
+	var events []shared.Event
+	for rows.Next() {
+		var metadata EventMetadata
+		if err := rows.Scan(
+			&metadata.AggregateID,
+			&metadata.AggregateType,
+			&metadata.EventType,
+			&metadata.Version,
+			&metadata.SchemaVersion,
+			&metadata.Timestamp,
+			&metadata.Payload,
+			&metadata.Metadata,
+		); err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
 		}
 
-		if err := json.Unmarshal(metadata.Payload, event); err != nil {
-			return nil, fmt.Errorf("unmarshal event data: %w", err)
+		event, err := s.decodeEvent(ctx, metadata)
+		if err != nil {
+			return nil, err
 		}
 		events = append(events, event)
 	}
@@ -177,3 +517,40 @@ func (s *PostgresEventStore) scanEvents(rows *sql.Rows) ([]shared.Event, error)
 
 	return events, nil
 }
+
+func (s *PostgresEventStore) decodeEvent(ctx context.Context, metadata EventMetadata) (shared.Event, error) {
+	event, ok := s.eventRegistry.CreateEvent(shared.EventType(metadata.EventType))
+	if !ok {
+		return nil, fmt.Errorf("unknown event type: %s", metadata.EventType)
+	}
+
+	payload, err := s.cipher.DecryptPayload(ctx, metadata.AggregateID, shared.EventType(metadata.EventType), metadata.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt event: %w", err)
+	}
+
+	payload, err = s.eventRegistry.Upcast(
+		shared.EventType(metadata.EventType),
+		metadata.SchemaVersion,
+		payload,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("upcast event: %w", err)
+	}
+
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("unmarshal event data: %w", err)
+	}
+
+	var eventMetadata shared.Metadata
+	if err := json.Unmarshal(metadata.Metadata, &eventMetadata); err != nil {
+		return nil, fmt.Errorf("unmarshal event metadata: %w", err)
+	}
+	eventMetadata, err = s.cipher.DecryptMetadata(ctx, metadata.AggregateID, eventMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt event metadata: %w", err)
+	}
+	event.SetMetadata(eventMetadata)
+
+	return event, nil
+}
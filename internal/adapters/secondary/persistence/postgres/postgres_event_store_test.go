@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/eventstoretest"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+// TestPostgresEventStore_Contract needs a migrated database; point
+// POSTGRES_TEST_DSN at it to run. The events and reservations tables are
+// truncated per case.
+func TestPostgresEventStore_Contract(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	db, err := NewDatabase(dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	eventstoretest.Run(t, func(t *testing.T, registry shared.EventRegistry, cipher secondary.PayloadCipher) secondary.EventStore {
+		if _, err := db.Exec(`TRUNCATE events, reservations, snapshots`); err != nil {
+			t.Fatalf("Failed to truncate events: %v", err)
+		}
+		return NewPostgresEventStore(db.DB, registry, cipher)
+	})
+}
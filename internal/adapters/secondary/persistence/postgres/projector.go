@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/postgres/db"
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+// PostgresProjector maintains user_read_model and login_history. Every write
+// is an upsert guarded by the row version, so a redelivered or stale event
+// changes nothing and an erased user is never brought back.
+type PostgresProjector struct {
+	queries *db.Queries
+}
+
+func NewPostgresProjector(database *database) *PostgresProjector {
+	return &PostgresProjector{
+		queries: db.New(database.DB),
+	}
+}
+
+func (p *PostgresProjector) ProjectEvent(ctx context.Context, event shared.Event) error {
+	var err error
+	switch e := event.(type) {
+	case *user.UserRegisteredEvent:
+		err = p.queries.ProjectUserRegistered(ctx, db.ProjectUserRegisteredParams{
+			ID:           e.GetAggregateID(),
+			Username:     e.Username,
+			PasswordHash: e.PasswordHash,
+			CreatedAt:    e.GetTimestamp(),
+		})
+	case *user.UserPasswordChangedEvent:
+		err = p.queries.ProjectUserPasswordChanged(ctx, db.ProjectUserPasswordChangedParams{
+			ID:           e.GetAggregateID(),
+			PasswordHash: e.NewPasswordHash,
+			CreatedAt:    e.GetTimestamp(),
+			Version:      int32(e.GetVersion()),
+		})
+	case *user.UserPasswordRehashedEvent:
+		// the read model only keeps the hash, so a rehash lands like a change
+		err = p.queries.ProjectUserPasswordChanged(ctx, db.ProjectUserPasswordChangedParams{
+			ID:           e.GetAggregateID(),
+			PasswordHash: e.NewPasswordHash,
+			CreatedAt:    e.GetTimestamp(),
+			Version:      int32(e.GetVersion()),
+		})
+	case *user.UserUsernameChangedEvent:
+		err = p.queries.ProjectUserUsernameChanged(ctx, db.ProjectUserUsernameChangedParams{
+			ID:        e.GetAggregateID(),
+			Username:  e.Username,
+			CreatedAt: e.GetTimestamp(),
+			Version:   int32(e.GetVersion()),
+		})
+	case *user.UserProfileUpdatedEvent:
+		err = p.queries.ProjectUserProfileUpdated(ctx, db.ProjectUserProfileUpdatedParams{
+			ID:          e.GetAggregateID(),
+			DisplayName: e.DisplayName,
+			AvatarUrl:   e.AvatarURL,
+			Locale:      e.Locale,
+			Timezone:    e.Timezone,
+			CreatedAt:   e.GetTimestamp(),
+			Version:     int32(e.GetVersion()),
+		})
+	case *user.UserEmailChangedEvent:
+		err = p.queries.ProjectUserEmailChanged(ctx, db.ProjectUserEmailChangedParams{
+			ID:        e.GetAggregateID(),
+			Email:     e.Email,
+			CreatedAt: e.GetTimestamp(),
+			Version:   int32(e.GetVersion()),
+		})
+	case *user.UserLockedEvent:
+		err = p.queries.ProjectUserLockChanged(ctx, db.ProjectUserLockChangedParams{
+			ID:        e.GetAggregateID(),
+			Locked:    true,
+			CreatedAt: e.GetTimestamp(),
+			Version:   int32(e.GetVersion()),
+		})
+	case *user.UserUnlockedEvent:
+		err = p.queries.ProjectUserLockChanged(ctx, db.ProjectUserLockChangedParams{
+			ID:        e.GetAggregateID(),
+			Locked:    false,
+			CreatedAt: e.GetTimestamp(),
+			Version:   int32(e.GetVersion()),
+		})
+	case *user.UserRoleAssignedEvent:
+		err = p.queries.ProjectUserRoleAssigned(ctx, db.ProjectUserRoleAssignedParams{
+			ID:        e.GetAggregateID(),
+			Role:      e.Role,
+			CreatedAt: e.GetTimestamp(),
+			Version:   int32(e.GetVersion()),
+		})
+	case *user.UserErasedEvent:
+		// the history holds client addresses and user agents
+		if err = p.queries.DeleteLoginHistory(ctx, e.GetAggregateID()); err != nil {
+			break
+		}
+		err = p.queries.ProjectUserErased(ctx, db.ProjectUserErasedParams{
+			ID:        e.GetAggregateID(),
+			CreatedAt: e.GetTimestamp(),
+			Version:   int32(e.GetVersion()),
+		})
+	case *user.UserLoggedInEvent:
+		err = p.projectLogin(ctx, db.AddLoginHistoryParams{
+			Outcome:   query.LoginOutcomeSucceeded,
+			IpAddress: e.IPAddress,
+			UserAgent: e.UserAgent,
+		}, e)
+	case *user.UserLoginFailedEvent:
+		err = p.projectLogin(ctx, db.AddLoginHistoryParams{
+			Outcome:   query.LoginOutcomeFailed,
+			Reason:    e.Reason,
+			IpAddress: e.IPAddress,
+			UserAgent: e.UserAgent,
+		}, e)
+	default:
+		return fmt.Errorf("unsupported event type: %s", event.GetEventType())
+	}
+	if err != nil {
+		return fmt.Errorf("project %s: %w", event.GetEventType(), err)
+	}
+	return nil
+}
+
+// projectLogin stores the attempt, trims the user's history to
+// query.LoginHistorySize entries, dropping the oldest, and advances the
+// user's version.
+func (p *PostgresProjector) projectLogin(ctx context.Context, record db.AddLoginHistoryParams, event shared.Event) error {
+	record.UserID = event.GetAggregateID()
+	record.Version = int32(event.GetVersion())
+	record.OccurredAt = event.GetTimestamp()
+	if err := p.queries.AddLoginHistory(ctx, record); err != nil {
+		return err
+	}
+
+	err := p.queries.TrimLoginHistory(ctx, db.TrimLoginHistoryParams{
+		UserID: record.UserID,
+		Keep:   query.LoginHistorySize,
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.queries.ProjectUserVersion(ctx, db.ProjectUserVersionParams{
+		ID:        record.UserID,
+		CreatedAt: event.GetTimestamp(),
+		Version:   record.Version,
+	})
+}
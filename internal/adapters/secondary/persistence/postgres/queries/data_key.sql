@@ -0,0 +1,20 @@
+-- name: CreateDataKey :exec
+INSERT INTO data_keys (
+  subject_id,
+  key,
+  created_at
+)
+VALUES (
+    $1,
+    $2,
+    NOW() AT TIME ZONE 'UTC'
+);
+
+-- name: GetDataKey :one
+SELECT key
+FROM data_keys
+WHERE subject_id = $1;
+
+-- name: DeleteDataKey :exec
+DELETE FROM data_keys
+WHERE subject_id = $1;
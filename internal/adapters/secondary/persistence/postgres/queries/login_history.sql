@@ -0,0 +1,43 @@
+-- name: AddLoginHistory :exec
+INSERT INTO login_history (
+  user_id,
+  version,
+  outcome,
+  reason,
+  ip_address,
+  user_agent,
+  occurred_at
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT (user_id, version) DO NOTHING;
+
+-- name: TrimLoginHistory :exec
+DELETE FROM login_history
+WHERE login_history.user_id = sqlc.arg(user_id)
+    AND login_history.version <= (
+        SELECT kept.version
+        FROM login_history kept
+        WHERE kept.user_id = sqlc.arg(user_id)
+        ORDER BY kept.version DESC
+        OFFSET sqlc.arg(keep)
+        LIMIT 1
+    );
+
+-- name: DeleteLoginHistory :exec
+DELETE FROM login_history
+WHERE user_id = $1;
+
+-- name: ListLoginHistory :many
+SELECT *
+FROM login_history
+WHERE user_id = sqlc.arg(user_id)
+ORDER BY version DESC
+LIMIT sqlc.arg(max_results);
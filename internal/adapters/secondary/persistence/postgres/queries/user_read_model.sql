@@ -0,0 +1,292 @@
+-- name: ProjectUserRegistered :exec
+-- a later event may have created the row first; the registration then only
+-- fills in what that event could not know
+INSERT INTO user_read_model (
+  id,
+  username,
+  password_hash,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $4,
+    1
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    username = CASE WHEN user_read_model.username = '' THEN EXCLUDED.username ELSE user_read_model.username END,
+    password_hash = CASE WHEN user_read_model.password_hash = '' THEN EXCLUDED.password_hash ELSE user_read_model.password_hash END,
+    created_at = EXCLUDED.created_at
+WHERE user_read_model.version > EXCLUDED.version
+    AND NOT user_read_model.erased;
+
+-- name: ProjectUserPasswordChanged :exec
+INSERT INTO user_read_model (
+  id,
+  password_hash,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    password_hash = EXCLUDED.password_hash,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased;
+
+-- name: ProjectUserUsernameChanged :exec
+INSERT INTO user_read_model (
+  id,
+  username,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    username = EXCLUDED.username,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased;
+
+-- name: ProjectUserProfileUpdated :exec
+INSERT INTO user_read_model (
+  id,
+  display_name,
+  avatar_url,
+  locale,
+  timezone,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $6,
+    $7
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    display_name = EXCLUDED.display_name,
+    avatar_url = EXCLUDED.avatar_url,
+    locale = EXCLUDED.locale,
+    timezone = EXCLUDED.timezone,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased;
+
+-- name: ProjectUserEmailChanged :exec
+INSERT INTO user_read_model (
+  id,
+  email,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    email = EXCLUDED.email,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased;
+
+-- name: ProjectUserLockChanged :exec
+INSERT INTO user_read_model (
+  id,
+  locked,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    locked = EXCLUDED.locked,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased;
+
+-- name: ProjectUserRoleAssigned :exec
+INSERT INTO user_read_model (
+  id,
+  role,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    role = EXCLUDED.role,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased;
+
+-- name: ProjectUserVersion :exec
+-- login events change no column; the version still follows the stream so
+-- readers waiting for a later write are not held up
+INSERT INTO user_read_model (
+  id,
+  created_at,
+  updated_at,
+  version
+)
+VALUES (
+    $1,
+    $2,
+    $2,
+    $3
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version
+    AND NOT user_read_model.erased;
+
+-- name: ProjectUserErased :exec
+INSERT INTO user_read_model (
+  id,
+  created_at,
+  updated_at,
+  version,
+  erased
+)
+VALUES (
+    $1,
+    $2,
+    $2,
+    $3,
+    TRUE
+)
+ON CONFLICT (id) DO UPDATE
+SET
+    username = '',
+    password_hash = '',
+    display_name = '',
+    avatar_url = '',
+    locale = '',
+    timezone = '',
+    email = '',
+    erased = TRUE,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_read_model.version < EXCLUDED.version;
+
+-- name: GetUserReadModelByID :one
+SELECT *
+FROM user_read_model
+WHERE id = $1
+    AND NOT erased;
+
+-- name: GetUserReadModelByUsername :one
+SELECT *
+FROM user_read_model
+WHERE username = $1
+    AND NOT erased
+LIMIT 1;
+
+-- name: ListUsersByCreatedAtAsc :many
+SELECT *
+FROM user_read_model
+WHERE NOT erased
+    AND username LIKE sqlc.arg(username_pattern)::TEXT
+    AND email LIKE sqlc.arg(email_pattern)::TEXT
+    AND (sqlc.narg(locked)::BOOLEAN IS NULL OR locked = sqlc.narg(locked))
+    AND (sqlc.narg(role)::TEXT IS NULL OR role = sqlc.narg(role))
+    AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(created_from))
+    AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(created_to))
+    AND (sqlc.narg(after_id)::TEXT IS NULL OR (created_at, id) > (sqlc.arg(after_created_at)::TIMESTAMPTZ, sqlc.narg(after_id)))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(max_results);
+
+-- name: ListUsersByCreatedAtDesc :many
+SELECT *
+FROM user_read_model
+WHERE NOT erased
+    AND username LIKE sqlc.arg(username_pattern)::TEXT
+    AND email LIKE sqlc.arg(email_pattern)::TEXT
+    AND (sqlc.narg(locked)::BOOLEAN IS NULL OR locked = sqlc.narg(locked))
+    AND (sqlc.narg(role)::TEXT IS NULL OR role = sqlc.narg(role))
+    AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(created_from))
+    AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(created_to))
+    AND (sqlc.narg(after_id)::TEXT IS NULL OR (created_at, id) < (sqlc.arg(after_created_at)::TIMESTAMPTZ, sqlc.narg(after_id)))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: ListUsersByUsernameAsc :many
+SELECT *
+FROM user_read_model
+WHERE NOT erased
+    AND username LIKE sqlc.arg(username_pattern)::TEXT
+    AND email LIKE sqlc.arg(email_pattern)::TEXT
+    AND (sqlc.narg(locked)::BOOLEAN IS NULL OR locked = sqlc.narg(locked))
+    AND (sqlc.narg(role)::TEXT IS NULL OR role = sqlc.narg(role))
+    AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(created_from))
+    AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(created_to))
+    AND (sqlc.narg(after_id)::TEXT IS NULL OR (username, id) > (sqlc.arg(after_username)::TEXT, sqlc.narg(after_id)))
+ORDER BY username ASC, id ASC
+LIMIT sqlc.arg(max_results);
+
+-- name: ListUsersByUsernameDesc :many
+SELECT *
+FROM user_read_model
+WHERE NOT erased
+    AND username LIKE sqlc.arg(username_pattern)::TEXT
+    AND email LIKE sqlc.arg(email_pattern)::TEXT
+    AND (sqlc.narg(locked)::BOOLEAN IS NULL OR locked = sqlc.narg(locked))
+    AND (sqlc.narg(role)::TEXT IS NULL OR role = sqlc.narg(role))
+    AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(created_from))
+    AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(created_to))
+    AND (sqlc.narg(after_id)::TEXT IS NULL OR (username, id) < (sqlc.arg(after_username)::TEXT, sqlc.narg(after_id)))
+ORDER BY username DESC, id DESC
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,91 @@
+-- name: CreateWebhookSubscription :exec
+INSERT INTO webhook_subscriptions (
+  id,
+  url,
+  event_types,
+  secret,
+  created_at
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: GetWebhookSubscription :one
+SELECT *
+FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: ListWebhookSubscriptions :many
+SELECT *
+FROM webhook_subscriptions
+ORDER BY created_at ASC;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (
+  id,
+  subscription_id,
+  event_id,
+  event_type,
+  payload,
+  status,
+  attempts,
+  last_error,
+  response_status,
+  next_attempt_at,
+  created_at,
+  updated_at
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12
+);
+
+-- name: GetWebhookDelivery :one
+SELECT *
+FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: UpdateWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET
+    status = $2,
+    attempts = $3,
+    last_error = $4,
+    response_status = $5,
+    next_attempt_at = $6,
+    updated_at = $7
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE subscription_id = sqlc.arg(subscription_id)
+    AND (sqlc.arg(status)::TEXT = '' OR status = sqlc.arg(status))
+ORDER BY created_at DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: DueWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE status = 'pending'
+    AND next_attempt_at <= sqlc.arg(now)
+ORDER BY next_attempt_at ASC
+LIMIT sqlc.arg(max_results);
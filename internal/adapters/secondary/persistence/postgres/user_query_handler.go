@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/postgres/db"
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+type UserQueryHandler struct {
+	queries *db.Queries
+}
+
+func NewUserQueryHandler(database *database) *UserQueryHandler {
+	return &UserQueryHandler{
+		queries: db.New(database.DB),
+	}
+}
+
+func (h *UserQueryHandler) GetUserByID(ctx context.Context, query query.GetUserByIDQuery) (*types.UserResponse, error) {
+	userRM, err := h.queries.GetUserReadModelByID(ctx, query.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, userDomain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &types.UserResponse{
+		ID:       userRM.ID,
+		Username: userRM.Username,
+		Email:    userRM.Email,
+		Role:     userRM.Role,
+		Locked:   userRM.Locked,
+		Version:  int(userRM.Version),
+	}, nil
+}
+
+func (h *UserQueryHandler) GetUserByUsername(ctx context.Context, query query.GetUserByUsernameQuery) (*types.UserResponse, error) {
+	userRM, err := h.queries.GetUserReadModelByUsername(ctx, query.Username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, userDomain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &types.UserResponse{
+		ID:       userRM.ID,
+		Username: userRM.Username,
+		Email:    userRM.Email,
+		Role:     userRM.Role,
+		Locked:   userRM.Locked,
+		Version:  int(userRM.Version),
+	}, nil
+}
+
+func (h *UserQueryHandler) GetProfile(ctx context.Context, query query.GetProfileQuery) (*types.ProfileResponse, error) {
+	userRM, err := h.queries.GetUserReadModelByID(ctx, query.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, userDomain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+
+	return &types.ProfileResponse{
+		ID:          userRM.ID,
+		Username:    userRM.Username,
+		DisplayName: userRM.DisplayName,
+		AvatarURL:   userRM.AvatarUrl,
+		Locale:      userRM.Locale,
+		Timezone:    userRM.Timezone,
+		CreatedAt:   userRM.CreatedAt,
+		UpdatedAt:   userRM.UpdatedAt,
+		Version:     int(userRM.Version),
+	}, nil
+}
+
+// ListUsers pages by keyset on the sort column and id. sqlc cannot vary
+// ORDER BY, so each sort order has its own query; they differ only in the
+// keyset predicate and direction.
+func (h *UserQueryHandler) ListUsers(ctx context.Context, q query.ListUsersQuery) (*types.UserListResponse, error) {
+	q, cursor, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pattern      = likePrefix(q.UsernamePrefix)
+		emailPattern = likePrefix(q.EmailPrefix)
+		locked       sql.NullBool
+		role         = sql.NullString{String: q.Role, Valid: q.Role != ""}
+		createdFrom  = sql.NullTime{Time: q.CreatedFrom, Valid: !q.CreatedFrom.IsZero()}
+		createdTo    = sql.NullTime{Time: q.CreatedTo, Valid: !q.CreatedTo.IsZero()}
+		afterID      sql.NullString
+		after        query.UserCursor
+		// one extra row tells whether another page follows
+		maxResults = int32(q.Limit + 1)
+		users      []db.UserReadModel
+	)
+	if q.Locked != nil {
+		locked = sql.NullBool{Bool: *q.Locked, Valid: true}
+	}
+	if cursor != nil {
+		after = *cursor
+		afterID = sql.NullString{String: cursor.ID, Valid: true}
+	}
+
+	switch {
+	case q.SortBy == query.UserSortUsername && q.Descending:
+		users, err = h.queries.ListUsersByUsernameDesc(ctx, db.ListUsersByUsernameDescParams{
+			UsernamePattern: pattern, EmailPattern: emailPattern, Locked: locked, Role: role,
+			CreatedFrom: createdFrom, CreatedTo: createdTo,
+			AfterID: afterID, AfterUsername: after.Username, MaxResults: maxResults,
+		})
+	case q.SortBy == query.UserSortUsername:
+		users, err = h.queries.ListUsersByUsernameAsc(ctx, db.ListUsersByUsernameAscParams{
+			UsernamePattern: pattern, EmailPattern: emailPattern, Locked: locked, Role: role,
+			CreatedFrom: createdFrom, CreatedTo: createdTo,
+			AfterID: afterID, AfterUsername: after.Username, MaxResults: maxResults,
+		})
+	case q.Descending:
+		users, err = h.queries.ListUsersByCreatedAtDesc(ctx, db.ListUsersByCreatedAtDescParams{
+			UsernamePattern: pattern, EmailPattern: emailPattern, Locked: locked, Role: role,
+			CreatedFrom: createdFrom, CreatedTo: createdTo,
+			AfterID: afterID, AfterCreatedAt: after.CreatedAt, MaxResults: maxResults,
+		})
+	default:
+		users, err = h.queries.ListUsersByCreatedAtAsc(ctx, db.ListUsersByCreatedAtAscParams{
+			UsernamePattern: pattern, EmailPattern: emailPattern, Locked: locked, Role: role,
+			CreatedFrom: createdFrom, CreatedTo: createdTo,
+			AfterID: afterID, AfterCreatedAt: after.CreatedAt, MaxResults: maxResults,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	response := &types.UserListResponse{Users: []types.UserSummaryResponse{}}
+	if len(users) > q.Limit {
+		users = users[:q.Limit]
+		last := users[len(users)-1]
+		response.NextCursor = query.EncodeUserCursor(query.UserCursor{
+			SortBy:     q.SortBy,
+			Descending: q.Descending,
+			Username:   last.Username,
+			CreatedAt:  last.CreatedAt,
+			ID:         last.ID,
+		})
+	}
+	for _, userRM := range users {
+		response.Users = append(response.Users, types.UserSummaryResponse{
+			ID:        userRM.ID,
+			Username:  userRM.Username,
+			Email:     userRM.Email,
+			Role:      userRM.Role,
+			Locked:    userRM.Locked,
+			CreatedAt: userRM.CreatedAt,
+			UpdatedAt: userRM.UpdatedAt,
+			Version:   int(userRM.Version),
+		})
+	}
+	return response, nil
+}
+
+func (h *UserQueryHandler) GetLoginHistory(ctx context.Context, q query.GetLoginHistoryQuery) (*types.LoginHistoryResponse, error) {
+	q, err := q.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := h.queries.ListLoginHistory(ctx, db.ListLoginHistoryParams{
+		UserID:     q.UserID,
+		MaxResults: int32(q.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+
+	response := &types.LoginHistoryResponse{Logins: []types.LoginHistoryEntry{}}
+	for _, record := range records {
+		response.Logins = append(response.Logins, types.LoginHistoryEntry{
+			Outcome:    record.Outcome,
+			Reason:     record.Reason,
+			IPAddress:  record.IpAddress,
+			UserAgent:  record.UserAgent,
+			OccurredAt: record.OccurredAt,
+		})
+	}
+	return response, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePrefix matches values starting with prefix, taken literally.
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
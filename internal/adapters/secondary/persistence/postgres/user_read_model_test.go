@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+func TestLikePrefix(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		expected string
+	}{
+		{prefix: "", expected: "%"},
+		{prefix: "alice", expected: "alice%"},
+		{prefix: "50%_off", expected: `50\%\_off%`},
+		{prefix: `back\slash`, expected: `back\\slash%`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := likePrefix(tt.prefix); got != tt.expected {
+				t.Errorf("likePrefix(%q) = %q, expected %q", tt.prefix, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestUserReadModel needs a migrated database; point POSTGRES_TEST_DSN at it
+// to run. The user_read_model table is truncated first.
+func TestUserReadModel(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	database, err := NewDatabase(dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if _, err := database.Exec(`TRUNCATE user_read_model, login_history`); err != nil {
+		t.Fatalf("Failed to truncate user_read_model: %v", err)
+	}
+
+	ctx := context.Background()
+	projector := NewPostgresProjector(database)
+	queries := NewUserQueryHandler(database)
+
+	project := func(events ...shared.Event) {
+		t.Helper()
+		for _, event := range events {
+			if err := projector.ProjectEvent(ctx, event); err != nil {
+				t.Fatalf("ProjectEvent(%s) error = %v", event.GetEventType(), err)
+			}
+		}
+	}
+
+	registered := user.NewUserRegisteredEvent("user-1", "alice", "hash-1")
+	changed := user.NewUserPasswordChangedEvent("user-1", "hash-2", 2)
+	// redelivery and a stale event change nothing
+	project(registered, changed, registered, user.NewUserPasswordChangedEvent("user-1", "hash-0", 2))
+	project(user.NewUserRegisteredEvent("user-2", "al_bert", "hash"), user.NewUserRegisteredEvent("user-3", "bob", "hash"))
+
+	got, err := queries.GetUserByID(ctx, query.GetUserByIDQuery{UserID: "user-1"})
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if got.Username != "alice" || got.Version != 2 {
+		t.Errorf("GetUserByID() = %+v, expected alice at version 2", got)
+	}
+
+	list, err := queries.ListUsers(ctx, query.ListUsersQuery{UsernamePrefix: "al_", SortBy: query.UserSortUsername})
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+	if len(list.Users) != 1 || list.Users[0].ID != "user-2" {
+		t.Errorf("ListUsers() = %+v, expected only user-2", list.Users)
+	}
+
+	page, err := queries.ListUsers(ctx, query.ListUsersQuery{Limit: 2, SortBy: query.UserSortUsername, Descending: true})
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+	next, err := queries.ListUsers(ctx, query.ListUsersQuery{Limit: 2, SortBy: query.UserSortUsername, Descending: true, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+	if len(page.Users) != 2 || page.Users[0].ID != "user-3" || len(next.Users) != 1 || next.Users[0].ID != "user-2" || next.NextCursor != "" {
+		t.Errorf("pages = %+v then %+v", page, next)
+	}
+
+	locked := true
+	project(
+		user.NewUserEmailChangedEvent("user-3", "bob@example.com", 2),
+		user.NewUserRoleAssignedEvent("user-3", user.RoleSupport, 3),
+		user.NewUserLockedEvent("user-3", 4),
+	)
+	filtered, err := queries.ListUsers(ctx, query.ListUsersQuery{EmailPrefix: "BOB@", Locked: &locked, Role: "support"})
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+	if len(filtered.Users) != 1 || filtered.Users[0].ID != "user-3" || filtered.Users[0].Email != "bob@example.com" {
+		t.Errorf("ListUsers() = %+v, expected only user-3", filtered.Users)
+	}
+	defaults, err := queries.ListUsers(ctx, query.ListUsersQuery{Locked: new(bool), Role: "user"})
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+	if len(defaults.Users) != 2 {
+		t.Errorf("ListUsers() = %+v, expected the unlocked users with the default role", defaults.Users)
+	}
+
+	// sign-ins change no column but still advance the version
+	project(user.NewUserLoggedInEvent("user-2", "10.0.0.1", "curl/8.0", 2))
+	signedIn, err := queries.GetUserByID(ctx, query.GetUserByIDQuery{UserID: "user-2"})
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if signedIn.Version != 2 {
+		t.Errorf("GetUserByID() = %+v, expected version 2 after a sign-in", signedIn)
+	}
+
+	// a registration that arrives after a later event fills in the row that
+	// event created without undoing it
+	late := user.NewUserRegisteredEvent("user-4", "dora", "hash-1")
+	late.Timestamp = late.Timestamp.Add(-time.Hour).Truncate(time.Microsecond)
+	project(user.NewUserPasswordChangedEvent("user-4", "hash-2", 2), late)
+	var (
+		username, passwordHash string
+		version                int
+		createdAt              time.Time
+	)
+	if err := database.QueryRow(
+		`SELECT username, password_hash, version, created_at FROM user_read_model WHERE id = 'user-4'`,
+	).Scan(&username, &passwordHash, &version, &createdAt); err != nil {
+		t.Fatalf("Failed to read user-4: %v", err)
+	}
+	if username != "dora" || passwordHash != "hash-2" || version != 2 || !createdAt.Equal(late.Timestamp) {
+		t.Errorf("user-4 = %s/%s at version %d created %v, expected dora/hash-2 at version 2 created %v",
+			username, passwordHash, version, createdAt, late.Timestamp)
+	}
+
+	// an erased user stays erased when its registration is redelivered
+	project(user.NewUserErasedEvent("user-1", 3), registered)
+	if _, err := queries.GetUserByID(ctx, query.GetUserByIDQuery{UserID: "user-1"}); !errors.Is(err, user.ErrUserNotFound) {
+		t.Errorf("GetUserByID() after erasure error = %v, expected %v", err, user.ErrUserNotFound)
+	}
+	if _, err := queries.GetUserByUsername(ctx, query.GetUserByUsernameQuery{Username: "alice"}); !errors.Is(err, user.ErrUserNotFound) {
+		t.Errorf("GetUserByUsername() after erasure error = %v, expected %v", err, user.ErrUserNotFound)
+	}
+}
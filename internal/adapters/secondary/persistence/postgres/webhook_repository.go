@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/postgres/db"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+type webhookRepository struct {
+	queries *db.Queries
+}
+
+func NewWebhookRepository(database *database) secondary.WebhookRepository {
+	return &webhookRepository{
+		queries: db.New(database.DB),
+	}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription types.WebhookSubscription) error {
+	eventTypes := subscription.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	err := r.queries.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		ID:         subscription.ID,
+		Url:        subscription.URL,
+		EventTypes: eventTypes,
+		Secret:     subscription.Secret,
+		CreatedAt:  subscription.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id string) (*types.WebhookSubscription, error) {
+	dbSubscription, err := r.queries.GetWebhookSubscription(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, secondary.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook subscription: %w", err)
+	}
+
+	subscription := db.ToWebhookSubscription(&dbSubscription)
+	return &subscription, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]types.WebhookSubscription, error) {
+	dbSubscriptions, err := r.queries.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+
+	subscriptions := make([]types.WebhookSubscription, 0, len(dbSubscriptions))
+	for i := range dbSubscriptions {
+		subscriptions = append(subscriptions, db.ToWebhookSubscription(&dbSubscriptions[i]))
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	deleted, err := r.queries.DeleteWebhookSubscription(ctx, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	if deleted == 0 {
+		return secondary.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery types.WebhookDelivery) error {
+	err := r.queries.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       int32(delivery.Attempts),
+		LastError:      delivery.LastError,
+		ResponseStatus: int32(delivery.ResponseStatus),
+		NextAttemptAt:  delivery.NextAttemptAt,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	})
+	switch {
+	case isUniqueViolation(err):
+		return secondary.ErrWebhookDeliveryExists
+	case isForeignKeyViolation(err):
+		return secondary.ErrWebhookNotFound
+	case err != nil:
+		return fmt.Errorf("create webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id string) (*types.WebhookDelivery, error) {
+	dbDelivery, err := r.queries.GetWebhookDelivery(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, secondary.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook delivery: %w", err)
+	}
+
+	delivery := db.ToWebhookDelivery(&dbDelivery)
+	return &delivery, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery types.WebhookDelivery) error {
+	updated, err := r.queries.UpdateWebhookDelivery(ctx, db.UpdateWebhookDeliveryParams{
+		ID:             delivery.ID,
+		Status:         delivery.Status,
+		Attempts:       int32(delivery.Attempts),
+		LastError:      delivery.LastError,
+		ResponseStatus: int32(delivery.ResponseStatus),
+		NextAttemptAt:  delivery.NextAttemptAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	if updated == 0 {
+		return secondary.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]types.WebhookDelivery, error) {
+	dbDeliveries, err := r.queries.ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		SubscriptionID: subscriptionID,
+		Status:         status,
+		MaxResults:     maxResults(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	return toWebhookDeliveries(dbDeliveries), nil
+}
+
+func (r *webhookRepository) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]types.WebhookDelivery, error) {
+	dbDeliveries, err := r.queries.DueWebhookDeliveries(ctx, db.DueWebhookDeliveriesParams{
+		Now:        now,
+		MaxResults: maxResults(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("due webhook deliveries: %w", err)
+	}
+	return toWebhookDeliveries(dbDeliveries), nil
+}
+
+func toWebhookDeliveries(dbDeliveries []db.WebhookDelivery) []types.WebhookDelivery {
+	deliveries := make([]types.WebhookDelivery, 0, len(dbDeliveries))
+	for i := range dbDeliveries {
+		deliveries = append(deliveries, db.ToWebhookDelivery(&dbDeliveries[i]))
+	}
+	return deliveries
+}
+
+// maxResults maps a non-positive limit to no limit.
+func maxResults(limit int) int32 {
+	if limit <= 0 || limit > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int32(limit)
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+)
+
+func TestDataKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDataKeyRepository(newTestDatabase(t))
+
+	key := bytes.Repeat([]byte{7}, 32)
+	if err := repo.CreateKey(ctx, "user-1", key); err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	if err := repo.CreateKey(ctx, "user-1", key); !errors.Is(err, secondary.ErrDataKeyExists) {
+		t.Errorf("CreateKey() error = %v, expected %v", err, secondary.ErrDataKeyExists)
+	}
+
+	loaded, err := repo.GetKey(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetKey() error = %v", err)
+	}
+	if !bytes.Equal(loaded, key) {
+		t.Errorf("GetKey() = %x, expected %x", loaded, key)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := repo.DeleteKey(ctx, "user-1"); err != nil {
+			t.Fatalf("DeleteKey() attempt %d error = %v", i+1, err)
+		}
+	}
+	if _, err := repo.GetKey(ctx, "user-1"); !errors.Is(err, secondary.ErrDataKeyNotFound) {
+		t.Errorf("GetKey() error = %v, expected %v", err, secondary.ErrDataKeyNotFound)
+	}
+}
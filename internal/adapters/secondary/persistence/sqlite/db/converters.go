@@ -0,0 +1,41 @@
+package db
+
+import (
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	tokenDomain "github.com/ncfex/dcart-auth/internal/domain/token"
+)
+
+func ToRefreshTokenDomain(dbToken *RefreshToken) *tokenDomain.RefreshToken {
+	var revokedAt time.Time
+	if dbToken.RevokedAt.Valid {
+		revokedAt = dbToken.RevokedAt.Time
+	}
+
+	return &tokenDomain.RefreshToken{
+		Token:     dbToken.Token,
+		UserID:    dbToken.UserID,
+		CreatedAt: dbToken.CreatedAt,
+		UpdatedAt: dbToken.UpdatedAt,
+		ExpiresAt: dbToken.ExpiresAt,
+		RevokedAt: revokedAt,
+	}
+}
+
+func ToWebhookDelivery(dbDelivery *WebhookDelivery) types.WebhookDelivery {
+	return types.WebhookDelivery{
+		ID:             dbDelivery.ID,
+		SubscriptionID: dbDelivery.SubscriptionID,
+		EventID:        dbDelivery.EventID,
+		EventType:      dbDelivery.EventType,
+		Payload:        dbDelivery.Payload,
+		Status:         dbDelivery.Status,
+		Attempts:       int(dbDelivery.Attempts),
+		LastError:      dbDelivery.LastError,
+		ResponseStatus: int(dbDelivery.ResponseStatus),
+		NextAttemptAt:  dbDelivery.NextAttemptAt,
+		CreatedAt:      dbDelivery.CreatedAt,
+		UpdatedAt:      dbDelivery.UpdatedAt,
+	}
+}
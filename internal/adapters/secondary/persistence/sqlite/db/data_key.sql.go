@@ -0,0 +1,58 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: data_key.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createDataKey = `-- name: CreateDataKey :exec
+INSERT INTO data_keys (
+  subject_id,
+  key,
+  created_at
+)
+VALUES (
+    ?1,
+    ?2,
+    ?3
+)
+`
+
+type CreateDataKeyParams struct {
+	SubjectID string    `json:"subject_id"`
+	Key       []byte    `json:"key"`
+	Now       time.Time `json:"now"`
+}
+
+func (q *Queries) CreateDataKey(ctx context.Context, arg CreateDataKeyParams) error {
+	_, err := q.db.ExecContext(ctx, createDataKey, arg.SubjectID, arg.Key, arg.Now)
+	return err
+}
+
+const deleteDataKey = `-- name: DeleteDataKey :exec
+DELETE FROM data_keys
+WHERE subject_id = ?1
+`
+
+func (q *Queries) DeleteDataKey(ctx context.Context, subjectID string) error {
+	_, err := q.db.ExecContext(ctx, deleteDataKey, subjectID)
+	return err
+}
+
+const getDataKey = `-- name: GetDataKey :one
+SELECT key
+FROM data_keys
+WHERE subject_id = ?1
+`
+
+func (q *Queries) GetDataKey(ctx context.Context, subjectID string) ([]byte, error) {
+	row := q.db.QueryRowContext(ctx, getDataKey, subjectID)
+	var key []byte
+	err := row.Scan(&key)
+	return key, err
+}
@@ -0,0 +1,75 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+type DataKey struct {
+	SubjectID string    `json:"subject_id"`
+	Key       []byte    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Event struct {
+	ID            string    `json:"id"`
+	AggregateID   string    `json:"aggregate_id"`
+	AggregateType string    `json:"aggregate_type"`
+	EventType     string    `json:"event_type"`
+	Version       int64     `json:"version"`
+	Timestamp     time.Time `json:"timestamp"`
+	Payload       string    `json:"payload"`
+	SchemaVersion int64     `json:"schema_version"`
+	Metadata      string    `json:"metadata"`
+}
+
+type RefreshToken struct {
+	Token     string       `json:"token"`
+	UserID    string       `json:"user_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+}
+
+type Reservation struct {
+	Scope       string `json:"scope"`
+	Value       string `json:"value"`
+	AggregateID string `json:"aggregate_id"`
+}
+
+type Snapshot struct {
+	AggregateID   string    `json:"aggregate_id"`
+	AggregateType string    `json:"aggregate_type"`
+	SnapshotType  string    `json:"snapshot_type"`
+	Version       int64     `json:"version"`
+	State         string    `json:"state"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+type WebhookDelivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Payload        []byte    `json:"payload"`
+	Status         string    `json:"status"`
+	Attempts       int64     `json:"attempts"`
+	LastError      string    `json:"last_error"`
+	ResponseStatus int64     `json:"response_status"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes string    `json:"event_types"`
+	Secret     string    `json:"secret"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+
+package db
+
+import (
+	"context"
+)
+
+type Querier interface {
+	CreateDataKey(ctx context.Context, arg CreateDataKeyParams) error
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) error
+	DeleteDataKey(ctx context.Context, subjectID string) error
+	DeleteWebhookSubscription(ctx context.Context, id string) (int64, error)
+	DueWebhookDeliveries(ctx context.Context, arg DueWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	GetDataKey(ctx context.Context, subjectID string) ([]byte, error)
+	GetTokenByTokenString(ctx context.Context, arg GetTokenByTokenStringParams) (RefreshToken, error)
+	GetWebhookDelivery(ctx context.Context, id string) (WebhookDelivery, error)
+	GetWebhookSubscription(ctx context.Context, id string) (WebhookSubscription, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	RevokeRefreshToken(ctx context.Context, arg RevokeRefreshTokenParams) (RefreshToken, error)
+	SaveToken(ctx context.Context, arg SaveTokenParams) error
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (int64, error)
+}
+
+var _ Querier = (*Queries)(nil)
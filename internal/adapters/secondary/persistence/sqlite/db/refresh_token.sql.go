@@ -0,0 +1,145 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: refresh_token.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const createRefreshToken = `-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (
+  token,
+  created_at,
+  updated_at,
+  user_id,
+  expires_at
+)
+VALUES (
+    ?1,
+    ?2,
+    ?2,
+    ?3,
+    ?4
+)
+RETURNING token, user_id, created_at, updated_at, expires_at, revoked_at
+`
+
+type CreateRefreshTokenParams struct {
+	Token     string    `json:"token"`
+	Now       time.Time `json:"now"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.Token,
+		arg.Now,
+		arg.UserID,
+		arg.ExpiresAt,
+	)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getTokenByTokenString = `-- name: GetTokenByTokenString :one
+SELECT token, user_id, created_at, updated_at, expires_at, revoked_at
+FROM refresh_tokens
+WHERE token = ?1
+    AND revoked_at IS NULL
+    AND expires_at > ?2
+`
+
+type GetTokenByTokenStringParams struct {
+	Token string    `json:"token"`
+	Now   time.Time `json:"now"`
+}
+
+func (q *Queries) GetTokenByTokenString(ctx context.Context, arg GetTokenByTokenStringParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getTokenByTokenString, arg.Token, arg.Now)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const revokeRefreshToken = `-- name: RevokeRefreshToken :one
+UPDATE refresh_tokens
+SET
+    revoked_at = ?1,
+    updated_at = ?1
+WHERE token = ?2
+    AND revoked_at IS NULL
+    AND expires_at > ?1
+RETURNING token, user_id, created_at, updated_at, expires_at, revoked_at
+`
+
+type RevokeRefreshTokenParams struct {
+	Now   sql.NullTime `json:"now"`
+	Token string       `json:"token"`
+}
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, arg RevokeRefreshTokenParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, revokeRefreshToken, arg.Now, arg.Token)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const saveToken = `-- name: SaveToken :exec
+UPDATE refresh_tokens
+SET
+    user_id = ?2,
+    created_at = ?3,
+    updated_at = ?4,
+    expires_at = ?5,
+    revoked_at = ?6
+WHERE token = ?1
+`
+
+type SaveTokenParams struct {
+	Token     string       `json:"token"`
+	UserID    string       `json:"user_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+}
+
+func (q *Queries) SaveToken(ctx context.Context, arg SaveTokenParams) error {
+	_, err := q.db.ExecContext(ctx, saveToken,
+		arg.Token,
+		arg.UserID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.ExpiresAt,
+		arg.RevokedAt,
+	)
+	return err
+}
@@ -0,0 +1,343 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: webhook.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (
+  id,
+  subscription_id,
+  event_id,
+  event_type,
+  payload,
+  status,
+  attempts,
+  last_error,
+  response_status,
+  next_attempt_at,
+  created_at,
+  updated_at
+)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6,
+    ?7,
+    ?8,
+    ?9,
+    ?10,
+    ?11,
+    ?12
+)
+`
+
+type CreateWebhookDeliveryParams struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Payload        []byte    `json:"payload"`
+	Status         string    `json:"status"`
+	Attempts       int64     `json:"attempts"`
+	LastError      string    `json:"last_error"`
+	ResponseStatus int64     `json:"response_status"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, createWebhookDelivery,
+		arg.ID,
+		arg.SubscriptionID,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+		arg.Status,
+		arg.Attempts,
+		arg.LastError,
+		arg.ResponseStatus,
+		arg.NextAttemptAt,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	return err
+}
+
+const createWebhookSubscription = `-- name: CreateWebhookSubscription :exec
+INSERT INTO webhook_subscriptions (
+  id,
+  url,
+  event_types,
+  secret,
+  created_at
+)
+VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5
+)
+`
+
+type CreateWebhookSubscriptionParams struct {
+	ID         string    `json:"id"`
+	Url        string    `json:"url"`
+	EventTypes string    `json:"event_types"`
+	Secret     string    `json:"secret"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) error {
+	_, err := q.db.ExecContext(ctx, createWebhookSubscription,
+		arg.ID,
+		arg.Url,
+		arg.EventTypes,
+		arg.Secret,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const deleteWebhookSubscription = `-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = ?1
+`
+
+func (q *Queries) DeleteWebhookSubscription(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWebhookSubscription, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const dueWebhookDeliveries = `-- name: DueWebhookDeliveries :many
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, last_error, response_status, next_attempt_at, created_at, updated_at
+FROM webhook_deliveries
+WHERE status = 'pending'
+    AND next_attempt_at <= ?1
+ORDER BY next_attempt_at ASC
+LIMIT ?2
+`
+
+type DueWebhookDeliveriesParams struct {
+	Now        time.Time `json:"now"`
+	MaxResults int64     `json:"max_results"`
+}
+
+func (q *Queries) DueWebhookDeliveries(ctx context.Context, arg DueWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, dueWebhookDeliveries, arg.Now, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.ResponseStatus,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, last_error, response_status, next_attempt_at, created_at, updated_at
+FROM webhook_deliveries
+WHERE id = ?1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id string) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		&i.ResponseStatus,
+		&i.NextAttemptAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getWebhookSubscription = `-- name: GetWebhookSubscription :one
+SELECT id, url, event_types, secret, created_at
+FROM webhook_subscriptions
+WHERE id = ?1
+`
+
+func (q *Queries) GetWebhookSubscription(ctx context.Context, id string) (WebhookSubscription, error) {
+	row := q.db.QueryRowContext(ctx, getWebhookSubscription, id)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.EventTypes,
+		&i.Secret,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, last_error, response_status, next_attempt_at, created_at, updated_at
+FROM webhook_deliveries
+WHERE subscription_id = ?1
+    AND (CAST(?2 AS TEXT) = '' OR status = ?2)
+ORDER BY created_at DESC
+LIMIT ?3
+`
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID string `json:"subscription_id"`
+	Status         string `json:"status"`
+	MaxResults     int64  `json:"max_results"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries, arg.SubscriptionID, arg.Status, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.ResponseStatus,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookSubscriptions = `-- name: ListWebhookSubscriptions :many
+SELECT id, url, event_types, secret, created_at
+FROM webhook_subscriptions
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookSubscription
+	for rows.Next() {
+		var i WebhookSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.EventTypes,
+			&i.Secret,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateWebhookDelivery = `-- name: UpdateWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET
+    status = ?1,
+    attempts = ?2,
+    last_error = ?3,
+    response_status = ?4,
+    next_attempt_at = ?5,
+    updated_at = ?6
+WHERE id = ?7
+`
+
+type UpdateWebhookDeliveryParams struct {
+	Status         string    `json:"status"`
+	Attempts       int64     `json:"attempts"`
+	LastError      string    `json:"last_error"`
+	ResponseStatus int64     `json:"response_status"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	ID             string    `json:"id"`
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateWebhookDelivery,
+		arg.Status,
+		arg.Attempts,
+		arg.LastError,
+		arg.ResponseStatus,
+		arg.NextAttemptAt,
+		arg.UpdatedAt,
+		arg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE events (
+    id TEXT PRIMARY KEY,
+    aggregate_id TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    timestamp DATETIME NOT NULL,
+    payload TEXT NOT NULL,
+
+    UNIQUE(aggregate_id, version)
+);
+
+CREATE INDEX idx_events_aggregate_id ON events(aggregate_id);
+CREATE INDEX idx_events_event_type ON events(event_type);
+CREATE INDEX idx_events_timestamp ON events(timestamp);
+
+-- +goose Down
+DROP TABLE events;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE events
+    ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE events
+    DROP COLUMN schema_version;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE data_keys (
+    subject_id TEXT PRIMARY KEY,
+    key BLOB NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE data_keys;
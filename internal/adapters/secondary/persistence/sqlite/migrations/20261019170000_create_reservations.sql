@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE reservations (
+    scope TEXT NOT NULL,
+    value TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+
+    PRIMARY KEY (scope, value),
+    UNIQUE (scope, aggregate_id)
+);
+
+-- +goose Down
+DROP TABLE reservations;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- snapshots keeps the latest state of each aggregate, so loading one replays
+-- only the events after it; personal data fields are encrypted like payloads
+CREATE TABLE snapshots (
+    aggregate_id TEXT PRIMARY KEY,
+    aggregate_type TEXT NOT NULL,
+    snapshot_type TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    state TEXT NOT NULL,
+    timestamp DATETIME NOT NULL
+);
+
+-- +goose Down
+DROP TABLE snapshots;
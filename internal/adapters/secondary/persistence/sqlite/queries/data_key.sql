@@ -0,0 +1,20 @@
+-- name: CreateDataKey :exec
+INSERT INTO data_keys (
+  subject_id,
+  key,
+  created_at
+)
+VALUES (
+    sqlc.arg(subject_id),
+    sqlc.arg(key),
+    sqlc.arg(now)
+);
+
+-- name: GetDataKey :one
+SELECT key
+FROM data_keys
+WHERE subject_id = sqlc.arg(subject_id);
+
+-- name: DeleteDataKey :exec
+DELETE FROM data_keys
+WHERE subject_id = sqlc.arg(subject_id);
@@ -0,0 +1,43 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (
+  token,
+  created_at,
+  updated_at,
+  user_id,
+  expires_at
+)
+VALUES (
+    sqlc.arg(token),
+    sqlc.arg(now),
+    sqlc.arg(now),
+    sqlc.arg(user_id),
+    sqlc.arg(expires_at)
+)
+RETURNING *;
+
+-- name: RevokeRefreshToken :one
+UPDATE refresh_tokens
+SET
+    revoked_at = sqlc.arg(now),
+    updated_at = sqlc.arg(now)
+WHERE token = sqlc.arg(token)
+    AND revoked_at IS NULL
+    AND expires_at > sqlc.arg(now)
+RETURNING *;
+
+-- name: GetTokenByTokenString :one
+SELECT *
+FROM refresh_tokens
+WHERE token = sqlc.arg(token)
+    AND revoked_at IS NULL
+    AND expires_at > sqlc.arg(now);
+
+-- name: SaveToken :exec
+UPDATE refresh_tokens
+SET
+    user_id = ?2,
+    created_at = ?3,
+    updated_at = ?4,
+    expires_at = ?5,
+    revoked_at = ?6
+WHERE token = ?1;
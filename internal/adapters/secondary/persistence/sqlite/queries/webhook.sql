@@ -0,0 +1,91 @@
+-- name: CreateWebhookSubscription :exec
+INSERT INTO webhook_subscriptions (
+  id,
+  url,
+  event_types,
+  secret,
+  created_at
+)
+VALUES (
+    sqlc.arg(id),
+    sqlc.arg(url),
+    sqlc.arg(event_types),
+    sqlc.arg(secret),
+    sqlc.arg(created_at)
+);
+
+-- name: GetWebhookSubscription :one
+SELECT *
+FROM webhook_subscriptions
+WHERE id = sqlc.arg(id);
+
+-- name: ListWebhookSubscriptions :many
+SELECT *
+FROM webhook_subscriptions
+ORDER BY created_at ASC;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = sqlc.arg(id);
+
+-- name: CreateWebhookDelivery :exec
+INSERT INTO webhook_deliveries (
+  id,
+  subscription_id,
+  event_id,
+  event_type,
+  payload,
+  status,
+  attempts,
+  last_error,
+  response_status,
+  next_attempt_at,
+  created_at,
+  updated_at
+)
+VALUES (
+    sqlc.arg(id),
+    sqlc.arg(subscription_id),
+    sqlc.arg(event_id),
+    sqlc.arg(event_type),
+    sqlc.arg(payload),
+    sqlc.arg(status),
+    sqlc.arg(attempts),
+    sqlc.arg(last_error),
+    sqlc.arg(response_status),
+    sqlc.arg(next_attempt_at),
+    sqlc.arg(created_at),
+    sqlc.arg(updated_at)
+);
+
+-- name: GetWebhookDelivery :one
+SELECT *
+FROM webhook_deliveries
+WHERE id = sqlc.arg(id);
+
+-- name: UpdateWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET
+    status = sqlc.arg(status),
+    attempts = sqlc.arg(attempts),
+    last_error = sqlc.arg(last_error),
+    response_status = sqlc.arg(response_status),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    updated_at = sqlc.arg(updated_at)
+WHERE id = sqlc.arg(id);
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE subscription_id = sqlc.arg(subscription_id)
+    AND (CAST(sqlc.arg(status) AS TEXT) = '' OR status = sqlc.arg(status))
+ORDER BY created_at DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: DueWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE status = 'pending'
+    AND next_attempt_at <= sqlc.arg(now)
+ORDER BY next_attempt_at ASC
+LIMIT sqlc.arg(max_results);
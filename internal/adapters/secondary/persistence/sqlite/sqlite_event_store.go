@@ -0,0 +1,451 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+type eventRow struct {
+	AggregateID   string
+	AggregateType string
+	EventType     string
+	Version       int
+	SchemaVersion int
+	Timestamp     time.Time
+	Payload       string
+	Metadata      string
+}
+
+type SQLiteEventStore struct {
+	db            *sql.DB
+	eventRegistry shared.EventRegistry
+	cipher        secondary.PayloadCipher
+}
+
+func NewSQLiteEventStore(db *sql.DB, registry shared.EventRegistry, cipher secondary.PayloadCipher) *SQLiteEventStore {
+	return &SQLiteEventStore{
+		db:            db,
+		eventRegistry: registry,
+		cipher:        cipher,
+	}
+}
+
+// SaveEvents relies on the database opening write transactions with
+// BEGIN IMMEDIATE, which serializes writers the way SELECT ... FOR UPDATE
+// does in postgres.
+func (s *SQLiteEventStore) SaveEvents(ctx context.Context, aggregateID string, events []shared.Event) error {
+	// encrypt up front; key creation needs the write lock BEGIN IMMEDIATE takes
+	payloads := make([]json.RawMessage, len(events))
+	metadata := make([]json.RawMessage, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal event: %w", err)
+		}
+
+		payloads[i], err = s.cipher.EncryptPayload(ctx, aggregateID, shared.EventType(event.GetEventType()), payload)
+		if err != nil {
+			return fmt.Errorf("encrypt event: %w", err)
+		}
+
+		eventMetadata, err := s.cipher.EncryptMetadata(ctx, aggregateID, event.GetMetadata())
+		if err != nil {
+			return fmt.Errorf("encrypt event metadata: %w", err)
+		}
+		if eventMetadata == nil {
+			eventMetadata = shared.Metadata{}
+		}
+		if metadata[i], err = json.Marshal(eventMetadata); err != nil {
+			return fmt.Errorf("marshal event metadata: %w", err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var latestVersion int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version), 0)
+		FROM events
+		WHERE aggregate_id = ?`,
+		aggregateID).Scan(&latestVersion)
+	if err != nil {
+		return fmt.Errorf("get latest version: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO events (
+			id,
+			aggregate_id,
+			aggregate_type,
+			event_type,
+			version,
+			schema_version,
+			timestamp,
+			payload,
+			metadata
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, event := range events {
+		expectedVersion := latestVersion + 1
+		if event.GetVersion() != expectedVersion {
+			return &secondary.ConcurrencyConflictError{
+				AggregateID:     aggregateID,
+				ExpectedVersion: event.GetVersion() - 1,
+				ActualVersion:   latestVersion,
+			}
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			fmt.Sprintf("%s-%d", aggregateID, event.GetVersion()),
+			event.GetAggregateID(),
+			event.GetAggregateType(),
+			event.GetEventType(),
+			event.GetVersion(),
+			s.eventRegistry.SchemaVersion(shared.EventType(event.GetEventType())),
+			event.GetTimestamp().UTC(),
+			string(payloads[i]),
+			string(metadata[i]))
+		if err != nil {
+			if isUniqueViolation(err) {
+				return s.conflictError(ctx, aggregateID, event.GetVersion()-1)
+			}
+			return fmt.Errorf("insert event: %w", err)
+		}
+
+		latestVersion = event.GetVersion()
+	}
+
+	if err := reserve(ctx, tx, aggregateID, shared.ReservationsOf(events)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteEventStore) conflictError(ctx context.Context, aggregateID string, expectedVersion int) error {
+	var actualVersion int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version), 0)
+		FROM events
+		WHERE aggregate_id = ?`,
+		aggregateID).Scan(&actualVersion)
+	if err != nil {
+		return fmt.Errorf("get latest version: %w", err)
+	}
+
+	return &secondary.ConcurrencyConflictError{
+		AggregateID:     aggregateID,
+		ExpectedVersion: expectedVersion,
+		ActualVersion:   actualVersion,
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+func (s *SQLiteEventStore) GetReservationHolder(ctx context.Context, scope, value string) (string, error) {
+	var holder string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT aggregate_id
+		FROM reservations
+		WHERE scope = ? AND value = ?`,
+		scope, value).Scan(&holder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", secondary.ErrReservationNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get reservation: %w", err)
+	}
+	return holder, nil
+}
+
+func (s *SQLiteEventStore) Reserve(ctx context.Context, aggregateID string, reservations []shared.Reservation) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := reserve(ctx, tx, aggregateID, reservations); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// reserve applies reservations for aggregateID within tx; BEGIN IMMEDIATE
+// keeps the holder check from racing another writer, and a value taken
+// regardless fails on the (scope, value) primary key as ErrValueReserved.
+func reserve(ctx context.Context, tx *sql.Tx, aggregateID string, reservations []shared.Reservation) error {
+	for _, reservation := range reservations {
+		if reservation.Value != "" {
+			var holder string
+			err := tx.QueryRowContext(ctx, `
+				SELECT aggregate_id
+				FROM reservations
+				WHERE scope = ? AND value = ?`,
+				reservation.Scope, reservation.Value).Scan(&holder)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("check reservation: %w", err)
+			}
+			if err == nil && holder != aggregateID {
+				return fmt.Errorf("%w in scope %s", secondary.ErrValueReserved, reservation.Scope)
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM reservations
+			WHERE scope = ? AND aggregate_id = ?`,
+			reservation.Scope, aggregateID)
+		if err != nil {
+			return fmt.Errorf("release reservation: %w", err)
+		}
+		if reservation.Value == "" {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO reservations (scope, value, aggregate_id)
+			VALUES (?, ?, ?)`,
+			reservation.Scope, reservation.Value, aggregateID)
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w in scope %s", secondary.ErrValueReserved, reservation.Scope)
+		}
+		if err != nil {
+			return fmt.Errorf("insert reservation: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteEventStore) GetVersion(ctx context.Context, aggregateID string) (int, error) {
+	var version int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version), 0)
+		FROM events
+		WHERE aggregate_id = ?`,
+		aggregateID).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("get latest version: %w", err)
+	}
+	return version, nil
+}
+
+func (s *SQLiteEventStore) GetEvents(ctx context.Context, aggregateID string) ([]shared.Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			aggregate_id,
+			aggregate_type,
+			event_type,
+			version,
+			schema_version,
+			timestamp,
+			payload,
+			metadata
+		FROM events
+		WHERE aggregate_id = ?
+		ORDER BY version ASC`,
+		aggregateID)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanEvents(ctx, rows)
+}
+
+func (s *SQLiteEventStore) GetEventsAfterVersion(ctx context.Context, aggregateID string, version int) ([]shared.Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			aggregate_id,
+			aggregate_type,
+			event_type,
+			version,
+			schema_version,
+			timestamp,
+			payload,
+			metadata
+		FROM events
+		WHERE aggregate_id = ? AND version > ?
+		ORDER BY version ASC`,
+		aggregateID, version)
+	if err != nil {
+		return nil, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanEvents(ctx, rows)
+}
+
+func (s *SQLiteEventStore) GetEventsByType(ctx context.Context, eventType string) ([]shared.Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			aggregate_id,
+			aggregate_type,
+			event_type,
+			version,
+			schema_version,
+			timestamp,
+			payload,
+			metadata
+		FROM events
+		WHERE event_type = ?
+		ORDER BY timestamp ASC`,
+		eventType)
+	if err != nil {
+		return nil, fmt.Errorf("query events by type: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanEvents(ctx, rows)
+}
+
+func (s *SQLiteEventStore) SaveSnapshot(ctx context.Context, snapshot shared.Snapshot) error {
+	state, err := s.cipher.EncryptPayload(ctx, snapshot.AggregateID, snapshot.Type, snapshot.State)
+	if err != nil {
+		return fmt.Errorf("encrypt snapshot: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO snapshots (
+			aggregate_id,
+			aggregate_type,
+			snapshot_type,
+			version,
+			state,
+			timestamp
+		) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (aggregate_id) DO UPDATE
+		SET
+			aggregate_type = excluded.aggregate_type,
+			snapshot_type = excluded.snapshot_type,
+			version = excluded.version,
+			state = excluded.state,
+			timestamp = excluded.timestamp
+		WHERE snapshots.version < excluded.version`,
+		snapshot.AggregateID,
+		snapshot.AggregateType,
+		string(snapshot.Type),
+		snapshot.Version,
+		string(state),
+		snapshot.Timestamp.UTC())
+	if err != nil {
+		return fmt.Errorf("save snapshot: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteEventStore) GetSnapshot(ctx context.Context, aggregateID string) (*shared.Snapshot, error) {
+	var (
+		snapshot     shared.Snapshot
+		snapshotType string
+		state        string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT aggregate_id, aggregate_type, snapshot_type, version, state, timestamp
+		FROM snapshots
+		WHERE aggregate_id = ?`,
+		aggregateID).Scan(
+		&snapshot.AggregateID,
+		&snapshot.AggregateType,
+		&snapshotType,
+		&snapshot.Version,
+		&state,
+		&snapshot.Timestamp,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, secondary.ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot: %w", err)
+	}
+
+	snapshot.Type = shared.EventType(snapshotType)
+	snapshot.State, err = s.cipher.DecryptPayload(ctx, aggregateID, snapshot.Type, json.RawMessage(state))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+func (s *SQLiteEventStore) scanEvents(ctx context.Context, rows *sql.Rows) ([]shared.Event, error) {
+	var events []shared.Event
+	for rows.Next() {
+		var row eventRow
+		if err := rows.Scan(
+			&row.AggregateID,
+			&row.AggregateType,
+			&row.EventType,
+			&row.Version,
+			&row.SchemaVersion,
+			&row.Timestamp,
+			&row.Payload,
+			&row.Metadata,
+		); err != nil {
+			return nil, fmt.Errorf("scan event: %w", err)
+		}
+
+		event, ok := s.eventRegistry.CreateEvent(shared.EventType(row.EventType))
+		if !ok {
+			return nil, fmt.Errorf("unknown event type: %s", row.EventType)
+		}
+
+		payload, err := s.cipher.DecryptPayload(ctx, row.AggregateID, shared.EventType(row.EventType), json.RawMessage(row.Payload))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt event: %w", err)
+		}
+
+		payload, err = s.eventRegistry.Upcast(
+			shared.EventType(row.EventType),
+			row.SchemaVersion,
+			payload,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("upcast event: %w", err)
+		}
+
+		if err := json.Unmarshal(payload, event); err != nil {
+			return nil, fmt.Errorf("unmarshal event data: %w", err)
+		}
+
+		var eventMetadata shared.Metadata
+		if err := json.Unmarshal([]byte(row.Metadata), &eventMetadata); err != nil {
+			return nil, fmt.Errorf("unmarshal event metadata: %w", err)
+		}
+		eventMetadata, err = s.cipher.DecryptMetadata(ctx, row.AggregateID, eventMetadata)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt event metadata: %w", err)
+		}
+		event.SetMetadata(eventMetadata)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/encryption"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/eventstoretest"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+func newTestDatabase(t *testing.T) *database {
+	t.Helper()
+
+	db, err := NewDatabase(context.Background(), filepath.Join(t.TempDir(), "auth.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLiteEventStore_Contract(t *testing.T) {
+	eventstoretest.Run(t, func(t *testing.T, registry shared.EventRegistry, cipher secondary.PayloadCipher) secondary.EventStore {
+		return NewSQLiteEventStore(newTestDatabase(t).DB, registry, cipher)
+	})
+}
+
+func TestSQLiteEventStore_EncryptsPersonalDataAtRest(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+	store := NewSQLiteEventStore(db.DB, registry, encryption.NewFieldCipher(NewDataKeyRepository(db), registry))
+
+	if err := store.SaveEvents(ctx, "user-1", []shared.Event{
+		user.NewUserRegisteredEvent("user-1", "alice", "secret-hash"),
+	}); err != nil {
+		t.Fatalf("SaveEvents() error = %v", err)
+	}
+
+	var payload string
+	if err := db.QueryRowContext(ctx, `SELECT payload FROM events WHERE aggregate_id = ?`, "user-1").Scan(&payload); err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	if strings.Contains(payload, "alice") || strings.Contains(payload, "secret-hash") {
+		t.Errorf("payload = %s, expected personal data to be encrypted", payload)
+	}
+}
+
+func TestNewDatabase_MigratesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.db")
+	for i := 0; i < 2; i++ {
+		db, err := NewDatabase(context.Background(), path)
+		if err != nil {
+			t.Fatalf("NewDatabase() attempt %d error = %v", i+1, err)
+		}
+		db.Close()
+	}
+}
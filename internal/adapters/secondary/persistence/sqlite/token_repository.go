@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/sqlite/db"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	tokenDomain "github.com/ncfex/dcart-auth/internal/domain/token"
+)
+
+var (
+	ErrStoringToken = errors.New("error storing token")
+)
+
+type tokenRepository struct {
+	queries   *db.Queries
+	expiresIn time.Duration
+}
+
+func NewTokenRepository(database *database, expiresIn time.Duration) secondary.TokenRepository {
+	return &tokenRepository{
+		queries:   db.New(database.DB),
+		expiresIn: expiresIn,
+	}
+}
+
+func (r *tokenRepository) Add(ctx context.Context, token *tokenDomain.RefreshToken) error {
+	now := time.Now().UTC()
+	params := db.CreateRefreshTokenParams{
+		Token:     token.Token,
+		Now:       now,
+		UserID:    token.UserID,
+		ExpiresAt: now.Add(r.expiresIn),
+	}
+
+	_, err := r.queries.CreateRefreshToken(ctx, params)
+	if err != nil {
+		return errors.Join(ErrStoringToken, err)
+	}
+
+	return nil
+}
+
+func (r *tokenRepository) GetByToken(ctx context.Context, tokenString string) (*tokenDomain.RefreshToken, error) {
+	refreshToken, err := r.queries.GetTokenByTokenString(ctx, db.GetTokenByTokenStringParams{
+		Token: tokenString,
+		Now:   time.Now().UTC(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, tokenDomain.ErrTokenNotFound
+		}
+		return nil, err
+	}
+
+	return db.ToRefreshTokenDomain(&refreshToken), nil
+}
+
+func (r *tokenRepository) Revoke(ctx context.Context, tokenString string) error {
+	_, err := r.queries.RevokeRefreshToken(ctx, db.RevokeRefreshTokenParams{
+		Token: tokenString,
+		Now:   sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return tokenDomain.ErrTokenNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *tokenRepository) Save(ctx context.Context, token *tokenDomain.RefreshToken) error {
+	revokedAt := sql.NullTime{
+		Time:  token.RevokedAt.UTC(),
+		Valid: !token.RevokedAt.IsZero(),
+	}
+
+	params := db.SaveTokenParams{
+		Token:     token.Token,
+		UserID:    token.UserID,
+		CreatedAt: token.CreatedAt.UTC(),
+		UpdatedAt: token.UpdatedAt.UTC(),
+		ExpiresAt: token.ExpiresAt.UTC(),
+		RevokedAt: revokedAt,
+	}
+
+	if err := r.queries.SaveToken(ctx, params); err != nil {
+		return ErrStoringToken
+	}
+
+	return nil
+}
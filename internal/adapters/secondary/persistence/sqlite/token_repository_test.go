@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tokenDomain "github.com/ncfex/dcart-auth/internal/domain/token"
+)
+
+func TestTokenRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		expiresIn     time.Duration
+		revoke        bool
+		expectedError error
+	}{
+		{
+			name:      "active token",
+			expiresIn: time.Hour,
+		},
+		{
+			name:          "revoked token",
+			expiresIn:     time.Hour,
+			revoke:        true,
+			expectedError: tokenDomain.ErrTokenNotFound,
+		},
+		{
+			name:          "expired token",
+			expiresIn:     time.Millisecond,
+			expectedError: tokenDomain.ErrTokenNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewTokenRepository(newTestDatabase(t), tt.expiresIn)
+
+			token, err := tokenDomain.NewRefreshToken("dc_token", "user-1")
+			if err != nil {
+				t.Fatalf("Failed to create token: %v", err)
+			}
+			if err := repo.Add(ctx, token); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			if tt.revoke {
+				if err := repo.Revoke(ctx, token.Token); err != nil {
+					t.Fatalf("Revoke() error = %v", err)
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			stored, err := repo.GetByToken(ctx, token.Token)
+			if err != tt.expectedError {
+				t.Fatalf("GetByToken() error = %v, expected error %v", err, tt.expectedError)
+			}
+			if err != nil {
+				return
+			}
+
+			if stored.UserID != "user-1" {
+				t.Errorf("UserID = %v, expected user-1", stored.UserID)
+			}
+			if err := stored.IsValid(); err != nil {
+				t.Errorf("IsValid() error = %v", err)
+			}
+
+			stored.Revoke()
+			if err := repo.Save(ctx, stored); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if _, err := repo.GetByToken(ctx, token.Token); err != tokenDomain.ErrTokenNotFound {
+				t.Errorf("GetByToken() after save error = %v, expected %v", err, tokenDomain.ErrTokenNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewWebhookRepository(newTestDatabase(t))
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	subscription := types.WebhookSubscription{
+		ID:         "sub-1",
+		URL:        "https://partner.example/hooks",
+		EventTypes: []string{"user.registered"},
+		Secret:     "whsec_test",
+		CreatedAt:  now,
+	}
+	if err := repo.CreateSubscription(ctx, subscription); err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+
+	loaded, err := repo.GetSubscription(ctx, "sub-1")
+	if err != nil {
+		t.Fatalf("GetSubscription() error = %v", err)
+	}
+	if loaded.URL != subscription.URL || loaded.Secret != subscription.Secret || len(loaded.EventTypes) != 1 || loaded.EventTypes[0] != "user.registered" {
+		t.Errorf("GetSubscription() = %+v, expected %+v", loaded, subscription)
+	}
+
+	deliveries := []types.WebhookDelivery{
+		{ID: "d-1", Status: types.WebhookDeliveryPending, NextAttemptAt: now.Add(-time.Minute), CreatedAt: now},
+		{ID: "d-2", Status: types.WebhookDeliveryPending, NextAttemptAt: now.Add(-time.Hour), CreatedAt: now.Add(time.Second)},
+		{ID: "d-3", Status: types.WebhookDeliveryPending, NextAttemptAt: now.Add(time.Hour), CreatedAt: now.Add(2 * time.Second)},
+		{ID: "d-4", Status: types.WebhookDeliveryFailed, NextAttemptAt: now.Add(-time.Hour), CreatedAt: now.Add(3 * time.Second)},
+	}
+	for i := range deliveries {
+		deliveries[i].SubscriptionID = "sub-1"
+		deliveries[i].EventType = "user.registered"
+		deliveries[i].Payload = []byte(`{}`)
+		deliveries[i].UpdatedAt = now
+		if err := repo.CreateDelivery(ctx, deliveries[i]); err != nil {
+			t.Fatalf("CreateDelivery(%s) error = %v", deliveries[i].ID, err)
+		}
+	}
+
+	if err := repo.CreateDelivery(ctx, deliveries[0]); !errors.Is(err, secondary.ErrWebhookDeliveryExists) {
+		t.Errorf("CreateDelivery() error = %v, expected %v", err, secondary.ErrWebhookDeliveryExists)
+	}
+	orphan := types.WebhookDelivery{ID: "d-5", SubscriptionID: "sub-2", Payload: []byte(`{}`)}
+	if err := repo.CreateDelivery(ctx, orphan); !errors.Is(err, secondary.ErrWebhookNotFound) {
+		t.Errorf("CreateDelivery() error = %v, expected %v", err, secondary.ErrWebhookNotFound)
+	}
+
+	due, err := repo.DueDeliveries(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("DueDeliveries() error = %v", err)
+	}
+	if len(due) != 2 || due[0].ID != "d-2" || due[1].ID != "d-1" {
+		t.Errorf("DueDeliveries() = %v, expected d-2 then d-1", deliveryIDs(due))
+	}
+
+	failed, err := repo.ListDeliveries(ctx, "sub-1", types.WebhookDeliveryFailed, 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(failed) != 1 || failed[0].ID != "d-4" {
+		t.Errorf("ListDeliveries(failed) = %v, expected d-4", deliveryIDs(failed))
+	}
+
+	newest, err := repo.ListDeliveries(ctx, "sub-1", "", 2)
+	if err != nil {
+		t.Fatalf("ListDeliveries() error = %v", err)
+	}
+	if len(newest) != 2 || newest[0].ID != "d-4" || newest[1].ID != "d-3" {
+		t.Errorf("ListDeliveries() = %v, expected d-4 then d-3", deliveryIDs(newest))
+	}
+
+	if err := repo.DeleteSubscription(ctx, "sub-1"); err != nil {
+		t.Fatalf("DeleteSubscription() error = %v", err)
+	}
+	if _, err := repo.GetDelivery(ctx, "d-1"); !errors.Is(err, secondary.ErrWebhookDeliveryNotFound) {
+		t.Errorf("GetDelivery() error = %v, expected deliveries to be deleted with their subscription", err)
+	}
+	if err := repo.DeleteSubscription(ctx, "sub-1"); !errors.Is(err, secondary.ErrWebhookNotFound) {
+		t.Errorf("DeleteSubscription() error = %v, expected %v", err, secondary.ErrWebhookNotFound)
+	}
+}
+
+func deliveryIDs(deliveries []types.WebhookDelivery) []string {
+	ids := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+	return ids
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+const (
+	HeaderDeliveryID = "X-Dcart-Webhook-Id"
+	HeaderEventType  = "X-Dcart-Webhook-Event"
+	HeaderTimestamp  = "X-Dcart-Webhook-Timestamp"
+	HeaderSignature  = "X-Dcart-Webhook-Signature"
+
+	signatureVersion = "v1"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleTimestamp   = errors.New("webhook timestamp outside tolerance")
+)
+
+// HTTPSender posts deliveries as JSON, signed with the subscription secret
+// over the timestamp and body so receivers can reject replays.
+type HTTPSender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewHTTPSender(client *http.Client) *HTTPSender {
+	return &HTTPSender{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+func (s *HTTPSender) Send(ctx context.Context, subscription types.WebhookSubscription, delivery types.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build webhook request: %w", err)
+	}
+
+	timestamp := s.now().UTC()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dcart-auth-webhooks")
+	req.Header.Set(HeaderDeliveryID, delivery.ID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	// drain so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value for body sent at timestamp:
+// "v1=" and the hex HMAC-SHA256 of "<unix timestamp>.<body>".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a received delivery the way receivers are expected to: the
+// signature must match and the timestamp be within tolerance of now.
+func Verify(secret, timestampHeader, signatureHeader string, body []byte, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+	timestamp := time.Unix(seconds, 0)
+
+	if age := now.Sub(timestamp); age > tolerance || age < -tolerance {
+		return ErrStaleTimestamp
+	}
+
+	if !hmac.Equal([]byte(signatureHeader), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
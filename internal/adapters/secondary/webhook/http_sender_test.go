@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+func TestHTTPSender_Send(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		expectError bool
+	}{
+		{name: "accepted", status: http.StatusNoContent},
+		{name: "server error", status: http.StatusBadGateway, expectError: true},
+		{name: "redirect is not success", status: http.StatusNotModified, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verifyErr error
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				verifyErr = Verify(
+					"whsec_test",
+					r.Header.Get(HeaderTimestamp),
+					r.Header.Get(HeaderSignature),
+					body,
+					5*time.Minute,
+					time.Now(),
+				)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			sender := NewHTTPSender(server.Client())
+			status, err := sender.Send(context.Background(), types.WebhookSubscription{
+				URL:    server.URL,
+				Secret: "whsec_test",
+			}, types.WebhookDelivery{
+				ID:        "delivery-1",
+				EventType: "user.registered",
+				Payload:   []byte(`{"id":"user-1-1"}`),
+			})
+
+			if status != tt.status {
+				t.Errorf("status = %d, expected %d", status, tt.status)
+			}
+			if (err != nil) != tt.expectError {
+				t.Errorf("Send() error = %v, expectError %v", err, tt.expectError)
+			}
+			if verifyErr != nil {
+				t.Errorf("receiver could not verify the signature: %v", verifyErr)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"id":"user-1-1"}`)
+	signature := Sign("whsec_test", now, body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	tests := []struct {
+		name          string
+		secret        string
+		timestamp     string
+		body          []byte
+		now           time.Time
+		expectedError error
+	}{
+		{
+			name:      "valid",
+			secret:    "whsec_test",
+			timestamp: timestamp,
+			body:      body,
+			now:       now.Add(time.Minute),
+		},
+		{
+			name:          "wrong secret",
+			secret:        "whsec_other",
+			timestamp:     timestamp,
+			body:          body,
+			now:           now,
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "tampered body",
+			secret:        "whsec_test",
+			timestamp:     timestamp,
+			body:          []byte(`{"id":"user-2-1"}`),
+			now:           now,
+			expectedError: ErrInvalidSignature,
+		},
+		{
+			name:          "replayed later",
+			secret:        "whsec_test",
+			timestamp:     timestamp,
+			body:          body,
+			now:           now.Add(time.Hour),
+			expectedError: ErrStaleTimestamp,
+		},
+		{
+			name:          "malformed timestamp",
+			secret:        "whsec_test",
+			timestamp:     "yesterday",
+			body:          body,
+			now:           now,
+			expectedError: ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, signature, tt.body, 5*time.Minute, tt.now)
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("Verify() error = %v, expected %v", err, tt.expectedError)
+			}
+		})
+	}
+}
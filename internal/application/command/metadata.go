@@ -0,0 +1,42 @@
+package command
+
+import (
+	"context"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/pkg/httputil/request"
+)
+
+// metadataFromContext collects the request details the primary adapters put
+// in the context. A command issued directly by a request is caused by that
+// request, unless the caller named the event it is acting on in
+// X-Causation-ID.
+func metadataFromContext(ctx context.Context) shared.Metadata {
+	correlationID := request.GetStringFromContext(ctx, request.ContextRequestIDKey)
+
+	causationID := request.GetStringFromContext(ctx, request.ContextCausationIDKey)
+	if causationID == "" {
+		causationID = correlationID
+	}
+
+	metadata := shared.Metadata{}
+	for key, value := range map[string]string{
+		shared.MetadataCorrelationID: correlationID,
+		shared.MetadataCausationID:   causationID,
+		shared.MetadataActorID:       request.GetStringFromContext(ctx, request.ContextUserKey),
+		shared.MetadataClientIP:      request.GetStringFromContext(ctx, request.ContextClientIPKey),
+		shared.MetadataUserAgent:     request.GetStringFromContext(ctx, request.ContextUserAgentKey),
+	} {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+func stampMetadata(ctx context.Context, events []shared.Event) {
+	metadata := metadataFromContext(ctx)
+	for _, event := range events {
+		event.SetMetadata(metadata)
+	}
+}
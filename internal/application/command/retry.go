@@ -0,0 +1,30 @@
+package command
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+)
+
+// maxConflictAttempts bounds how often a command reloads the aggregate after
+// losing an optimistic concurrency race before giving up.
+const maxConflictAttempts = 3
+
+// retryOnConflict runs a load-decide-save cycle until it stops conflicting
+// with a concurrent writer. Any other error ends the loop immediately; the
+// last conflict is returned once the attempts are exhausted.
+func retryOnConflict(ctx context.Context, attempt func() error) error {
+	var err error
+	for i := 0; i < maxConflictAttempts; i++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		err = attempt()
+		if !errors.Is(err, secondary.ErrConcurrencyConflict) {
+			return err
+		}
+	}
+	return err
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 
@@ -9,110 +10,484 @@ import (
 	"github.com/ncfex/dcart-auth/internal/application/ports/primary/command"
 	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
 	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
 	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
 )
 
+// snapshotInterval is how many events may follow a user's latest snapshot
+// before it is snapshotted again. Every sign-in attempt appends to the
+// stream, so without snapshots each command would replay all of them.
+const snapshotInterval = 100
+
 type UserCommandHandler struct {
 	eventStore     secondary.EventStore
+	snapshots      secondary.SnapshotStore
 	eventPublisher secondary.EventPublisher
-	idGenerator    id.IDGenerator
+	dataKeys       secondary.DataKeyRepository
+	idGenerator    id.UniqueIDGenerator
+	usernamePolicy userDomain.UsernamePolicy
+	passwordPolicy userDomain.PasswordPolicy
+	passwordHasher userDomain.PasswordHasher
 }
 
+// NewUserCommandHandler takes snapshots from the event store's backend; nil
+// disables them and every load replays the whole stream.
 func NewUserCommandHandler(
 	eventStore secondary.EventStore,
+	snapshots secondary.SnapshotStore,
 	eventPublisher secondary.EventPublisher,
-	idGenerator id.IDGenerator,
+	dataKeys secondary.DataKeyRepository,
+	idGenerator id.UniqueIDGenerator,
+	usernamePolicy userDomain.UsernamePolicy,
+	passwordPolicy userDomain.PasswordPolicy,
+	passwordHasher userDomain.PasswordHasher,
 ) command.UserCommandPort {
 	return &UserCommandHandler{
 		eventStore:     eventStore,
+		snapshots:      snapshots,
 		eventPublisher: eventPublisher,
+		dataKeys:       dataKeys,
 		idGenerator:    idGenerator,
+		usernamePolicy: usernamePolicy,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
 	}
 }
 
+// RegisterUser gives the user a fresh id and stores the username in canonical
+// form; it is only known to be free once the event store has reserved it
+// along with the append.
 func (h *UserCommandHandler) RegisterUser(ctx context.Context, cmd command.RegisterUserCommand) (*types.UserResponse, error) {
-	userID := h.idGenerator.GenerateFromData([]byte(cmd.Username))
+	username, usernameErr := h.usernamePolicy.Parse(cmd.Username)
+	password, passwordErr := h.passwordPolicy.Parse(cmd.Password, username)
+	if err := shared.JoinValidationErrors(usernameErr, passwordErr); err != nil {
+		return nil, err
+	}
 
-	events, err := h.eventStore.GetEvents(ctx, userID)
+	newUser, err := userDomain.NewUser(h.idGenerator.Generate(), username, password, h.passwordHasher)
 	if err != nil {
-		return nil, fmt.Errorf("checking existing user: %w", err)
+		return nil, fmt.Errorf("creating user: %w", err)
 	}
-	if len(events) > 0 {
-		return nil, userDomain.ErrUserAlreadyExists
+
+	events := newUser.GetUncommittedChanges()
+	stampMetadata(ctx, events)
+	if err := h.eventStore.SaveEvents(ctx, newUser.ID, events); err != nil {
+		if errors.Is(err, secondary.ErrValueReserved) {
+			return nil, userDomain.ErrUserAlreadyExists
+		}
+		return nil, fmt.Errorf("saving events: %w", err)
 	}
 
-	newUser, err := userDomain.NewUser(userID, cmd.Username, cmd.Password)
+	h.publishEvents(ctx, events)
+
+	return userResponse(newUser), nil
+}
+
+// AuthenticateUser records the attempt as user.loggedIn or user.loginFailed,
+// followed by user.passwordRehashed when the stored hash is outdated, before
+// answering. A failed attempt that cannot be recorded is still
+// rejected; a successful one is not let through unrecorded.
+func (h *UserCommandHandler) AuthenticateUser(ctx context.Context, cmd command.AuthenticateUserCommand) (*types.UserResponse, error) {
+	username := userDomain.NormalizeUsername(cmd.Username)
+	userID, err := h.eventStore.GetReservationHolder(ctx, userDomain.UsernameReservationScope, username.String())
+	if errors.Is(err, secondary.ErrReservationNotFound) {
+		return nil, userDomain.ErrInvalidCredentials
+	}
 	if err != nil {
-		return nil, fmt.Errorf("creating user: %w", err)
+		return nil, fmt.Errorf("looking up username: %w", err)
 	}
 
-	events = newUser.GetUncommittedChanges()
-	if err := h.eventStore.SaveEvents(ctx, userID, events); err != nil {
-		return nil, fmt.Errorf("saving events: %w", err)
+	var (
+		currentUser     *userDomain.User
+		snapshotVersion int
+		loginErr        error
+		newEvents       []shared.Event
+	)
+	err = retryOnConflict(ctx, func() error {
+		newEvents = nil
+
+		currentUser, snapshotVersion, err = h.loadUser(ctx, userID)
+		if errors.Is(err, userDomain.ErrUserNotFound) {
+			loginErr = userDomain.ErrInvalidCredentials
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// renamed since the lookup
+		if userDomain.NormalizeUsername(currentUser.Username) != username {
+			loginErr = userDomain.ErrInvalidCredentials
+			return nil
+		}
+
+		loginErr = currentUser.Login(cmd.Password, cmd.IPAddress, cmd.UserAgent, h.passwordHasher)
+		if errors.Is(loginErr, userDomain.ErrUserErased) {
+			loginErr = userDomain.ErrInvalidCredentials
+		}
+
+		newEvents = currentUser.GetUncommittedChanges()
+		if len(newEvents) == 0 {
+			return nil
+		}
+		stampMetadata(ctx, newEvents)
+		if err := h.eventStore.SaveEvents(ctx, userID, newEvents); err != nil {
+			return fmt.Errorf("saving events: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if loginErr != nil {
+			log.Printf("error recording failed login of %s: %v", userID, err)
+			return nil, loginErr
+		}
+		return nil, err
+	}
+
+	h.publishEvents(ctx, newEvents)
+	if len(newEvents) > 0 {
+		h.snapshotIfDue(ctx, currentUser, snapshotVersion)
 	}
 
-	for _, event := range events {
-		if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
-			log.Printf("error publishing event: %v", err)
+	if loginErr != nil {
+		return nil, loginErr
+	}
+
+	return userResponse(currentUser), nil
+}
+
+// ChangePassword returns the version the change was committed at.
+func (h *UserCommandHandler) ChangePassword(ctx context.Context, cmd command.ChangePasswordCommand) (int, error) {
+	var (
+		currentUser     *userDomain.User
+		snapshotVersion int
+		newEvents       []shared.Event
+	)
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		currentUser, snapshotVersion, err = h.loadUser(ctx, cmd.UserID)
+		if err != nil {
+			return err
 		}
+
+		newPassword, err := h.passwordPolicy.Parse(cmd.NewPassword, userDomain.NormalizeUsername(currentUser.Username))
+		if err != nil {
+			return err
+		}
+
+		if err := currentUser.ChangePassword(cmd.OldPassword, newPassword, h.passwordHasher); err != nil {
+			return fmt.Errorf("changing password: %w", err)
+		}
+
+		newEvents = currentUser.GetUncommittedChanges()
+		stampMetadata(ctx, newEvents)
+		if err := h.eventStore.SaveEvents(ctx, cmd.UserID, newEvents); err != nil {
+			return fmt.Errorf("saving events: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return &types.UserResponse{
-		ID:       userID,
-		Username: cmd.Username,
+	h.publishEvents(ctx, newEvents)
+	h.snapshotIfDue(ctx, currentUser, snapshotVersion)
+
+	return newEvents[len(newEvents)-1].GetVersion(), nil
+}
+
+// ChangeUsername renames the user while keeping its id. A username held by
+// someone else is reported as ErrUsernameTaken.
+func (h *UserCommandHandler) ChangeUsername(ctx context.Context, cmd command.ChangeUsernameCommand) (*types.UserResponse, error) {
+	newUsername, err := h.usernamePolicy.Parse(cmd.NewUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	currentUser, err := h.changeUser(ctx, cmd.UserID, reservedAs(userDomain.ErrUsernameTaken), func(currentUser *userDomain.User) error {
+		if err := currentUser.ChangeUsername(newUsername); err != nil {
+			return fmt.Errorf("changing username: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userResponse(currentUser), nil
+}
+
+// UpdateProfile returns the profile as committed; an update that changes
+// nothing stores no event and returns the current profile.
+func (h *UserCommandHandler) UpdateProfile(ctx context.Context, cmd command.UpdateProfileCommand) (*types.ProfileResponse, error) {
+	currentUser, err := h.changeUser(ctx, cmd.UserID, nil, func(currentUser *userDomain.User) error {
+		err := currentUser.UpdateProfile(userDomain.ProfileChanges{
+			DisplayName: cmd.DisplayName,
+			AvatarURL:   cmd.AvatarURL,
+			Locale:      cmd.Locale,
+			Timezone:    cmd.Timezone,
+		})
+		if err != nil {
+			return fmt.Errorf("updating profile: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.ProfileResponse{
+		ID:          currentUser.ID,
+		Username:    currentUser.Username,
+		DisplayName: currentUser.Profile.DisplayName,
+		AvatarURL:   currentUser.Profile.AvatarURL,
+		Locale:      currentUser.Profile.Locale,
+		Timezone:    currentUser.Profile.Timezone,
+		CreatedAt:   currentUser.CreatedAt,
+		UpdatedAt:   currentUser.UpdatedAt,
+		Version:     currentUser.Version,
 	}, nil
 }
 
-func (h *UserCommandHandler) AuthenticateUser(ctx context.Context, cmd command.AuthenticateUserCommand) (*types.UserResponse, error) {
-	userID := h.idGenerator.GenerateFromData([]byte(cmd.Username))
+// ChangeEmail sets the user's email address. An address held by someone else
+// is reported as ErrEmailTaken.
+func (h *UserCommandHandler) ChangeEmail(ctx context.Context, cmd command.ChangeEmailCommand) (*types.UserResponse, error) {
+	email, err := userDomain.ParseEmail(cmd.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	currentUser, err := h.changeUser(ctx, cmd.UserID, reservedAs(userDomain.ErrEmailTaken), func(currentUser *userDomain.User) error {
+		if err := currentUser.ChangeEmail(email); err != nil {
+			return fmt.Errorf("changing email: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userResponse(currentUser), nil
+}
+
+// LockUser stops the user from signing in or refreshing a token until it is
+// unlocked. Locking a locked user changes nothing.
+func (h *UserCommandHandler) LockUser(ctx context.Context, cmd command.LockUserCommand) (*types.UserResponse, error) {
+	currentUser, err := h.changeUser(ctx, cmd.UserID, nil, func(currentUser *userDomain.User) error {
+		if err := currentUser.Lock(); err != nil {
+			return fmt.Errorf("locking user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userResponse(currentUser), nil
+}
+
+func (h *UserCommandHandler) UnlockUser(ctx context.Context, cmd command.UnlockUserCommand) (*types.UserResponse, error) {
+	currentUser, err := h.changeUser(ctx, cmd.UserID, nil, func(currentUser *userDomain.User) error {
+		if err := currentUser.Unlock(); err != nil {
+			return fmt.Errorf("unlocking user: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userResponse(currentUser), nil
+}
+
+// AssignRole replaces the user's role with one of the known roles.
+func (h *UserCommandHandler) AssignRole(ctx context.Context, cmd command.AssignRoleCommand) (*types.UserResponse, error) {
+	role, err := userDomain.ParseRole(cmd.Role)
+	if err != nil {
+		return nil, err
+	}
 
-	events, err := h.eventStore.GetEvents(ctx, userID)
+	currentUser, err := h.changeUser(ctx, cmd.UserID, nil, func(currentUser *userDomain.User) error {
+		if err := currentUser.AssignRole(role); err != nil {
+			return fmt.Errorf("assigning role: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("checking existing user: %w", err)
+		return nil, err
 	}
+	return userResponse(currentUser), nil
+}
+
+// changeUser applies change to the latest user and stores whatever it
+// records, retrying on conflicts, and returns the user as committed. A
+// non-nil mapSaveError may translate a failed save into the command's own
+// error; returning nil keeps the save error.
+func (h *UserCommandHandler) changeUser(
+	ctx context.Context,
+	userID string,
+	mapSaveError func(error) error,
+	change func(*userDomain.User) error,
+) (*userDomain.User, error) {
+	var (
+		currentUser     *userDomain.User
+		snapshotVersion int
+		newEvents       []shared.Event
+	)
+	err := retryOnConflict(ctx, func() error {
+		newEvents = nil
+
+		var err error
+		currentUser, snapshotVersion, err = h.loadUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := change(currentUser); err != nil {
+			return err
+		}
 
-	currentUser, err := userDomain.ReconstructFromEvents(events)
+		newEvents = currentUser.GetUncommittedChanges()
+		if len(newEvents) == 0 {
+			return nil
+		}
+		stampMetadata(ctx, newEvents)
+		if err := h.eventStore.SaveEvents(ctx, userID, newEvents); err != nil {
+			if mapSaveError != nil {
+				if mapped := mapSaveError(err); mapped != nil {
+					return mapped
+				}
+			}
+			return fmt.Errorf("saving events: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("applying events: %w", err)
+		return nil, err
 	}
 
-	if ok := currentUser.Authenticate(cmd.Password); !ok {
-		return nil, fmt.Errorf("wrong password")
+	h.publishEvents(ctx, newEvents)
+	if len(newEvents) > 0 {
+		h.snapshotIfDue(ctx, currentUser, snapshotVersion)
 	}
 
-	// maybe publish event
+	return currentUser, nil
+}
+
+// reservedAs reports a value reserved by another user as taken.
+func reservedAs(taken error) func(error) error {
+	return func(err error) error {
+		if errors.Is(err, secondary.ErrValueReserved) {
+			return taken
+		}
+		return nil
+	}
+}
+
+// EraseUser appends user.erased and then destroys the user's data key, which
+// leaves every personal data field in the stream unreadable. Erasing an
+// already erased user only retries the key deletion.
+func (h *UserCommandHandler) EraseUser(ctx context.Context, cmd command.EraseUserCommand) error {
+	var newEvents []shared.Event
+	err := retryOnConflict(ctx, func() error {
+		newEvents = nil
 
+		currentUser, _, err := h.loadUser(ctx, cmd.UserID)
+		if err != nil {
+			return err
+		}
+
+		if err := currentUser.Erase(); err != nil {
+			if errors.Is(err, userDomain.ErrUserErased) {
+				return nil
+			}
+			return fmt.Errorf("erasing user: %w", err)
+		}
+
+		newEvents = currentUser.GetUncommittedChanges()
+		stampMetadata(ctx, newEvents)
+		if err := h.eventStore.SaveEvents(ctx, cmd.UserID, newEvents); err != nil {
+			return fmt.Errorf("saving events: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := h.dataKeys.DeleteKey(ctx, cmd.UserID); err != nil {
+		return fmt.Errorf("deleting data key: %w", err)
+	}
+
+	h.publishEvents(ctx, newEvents)
+
+	return nil
+}
+
+func userResponse(currentUser *userDomain.User) *types.UserResponse {
 	return &types.UserResponse{
 		ID:       currentUser.ID,
 		Username: currentUser.Username,
-	}, nil
+		Email:    currentUser.Email,
+		Role:     string(currentUser.Role),
+		Locked:   currentUser.Locked,
+		Version:  currentUser.Version,
+	}
 }
 
-func (h *UserCommandHandler) ChangePassword(ctx context.Context, cmd command.ChangePasswordCommand) error {
-	events, err := h.eventStore.GetEvents(ctx, cmd.UserID)
-	if err != nil {
-		return fmt.Errorf("loading events: %w", err)
+// loadUser rebuilds the user from its latest snapshot and the events stored
+// after it, and returns the version of that snapshot, 0 without one.
+func (h *UserCommandHandler) loadUser(ctx context.Context, userID string) (*userDomain.User, int, error) {
+	var snapshot *shared.Snapshot
+	if h.snapshots != nil {
+		var err error
+		snapshot, err = h.snapshots.GetSnapshot(ctx, userID)
+		if err != nil && !errors.Is(err, secondary.ErrSnapshotNotFound) {
+			return nil, 0, fmt.Errorf("loading snapshot: %w", err)
+		}
 	}
 
-	currentUser, err := userDomain.ReconstructFromEvents(events)
+	var (
+		events []shared.Event
+		err    error
+	)
+	if snapshot == nil {
+		events, err = h.eventStore.GetEvents(ctx, userID)
+	} else {
+		events, err = h.snapshots.GetEventsAfterVersion(ctx, userID, snapshot.Version)
+	}
 	if err != nil {
-		return fmt.Errorf("applying events: %w", err)
+		return nil, 0, fmt.Errorf("loading events: %w", err)
+	}
+	if snapshot == nil && len(events) == 0 {
+		return nil, 0, userDomain.ErrUserNotFound
 	}
 
-	if err := currentUser.ChangePassword(cmd.OldPassword, cmd.NewPassword); err != nil {
-		return fmt.Errorf("changing password: %w", err)
+	currentUser, err := userDomain.RestoreFromSnapshot(snapshot, events)
+	if err != nil {
+		return nil, 0, fmt.Errorf("applying events: %w", err)
+	}
+	if snapshot == nil {
+		return currentUser, 0, nil
 	}
+	return currentUser, snapshot.Version, nil
+}
 
-	newEvents := currentUser.GetUncommittedChanges()
-	if err := h.eventStore.SaveEvents(ctx, cmd.UserID, newEvents); err != nil {
-		return fmt.Errorf("saving events: %w", err)
+// snapshotIfDue snapshots the user once snapshotInterval events follow the
+// snapshot it was loaded from. A failure is only logged, as the next load
+// merely replays a little more. Erased users are left alone: their data key
+// is gone and encrypting a snapshot would create a new one.
+func (h *UserCommandHandler) snapshotIfDue(ctx context.Context, currentUser *userDomain.User, snapshotVersion int) {
+	if h.snapshots == nil || currentUser.Erased || currentUser.Version-snapshotVersion < snapshotInterval {
+		return
 	}
 
-	for _, event := range newEvents {
-		if err := h.eventPublisher.PublishEvent(ctx, event); err != nil {
-			log.Printf("error publishing event: %v", err)
-		}
+	snapshot, err := currentUser.Snapshot()
+	if err == nil {
+		err = h.snapshots.SaveSnapshot(ctx, snapshot)
+	}
+	if err != nil {
+		log.Printf("error snapshotting user %s: %v", currentUser.ID, err)
 	}
+}
 
-	return nil
+func (h *UserCommandHandler) publishEvents(ctx context.Context, events []shared.Event) {
+	if err := h.eventPublisher.PublishEvents(ctx, events); err != nil {
+		log.Printf("error publishing events: %v", err)
+	}
 }
@@ -0,0 +1,780 @@
+package command_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/encryption"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/id"
+	memoryMessaging "github.com/ncfex/dcart-auth/internal/adapters/secondary/messaging/memory"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/memory"
+	"github.com/ncfex/dcart-auth/internal/application/command"
+	commandPort "github.com/ncfex/dcart-auth/internal/application/ports/primary/command"
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+	"github.com/ncfex/dcart-auth/pkg/httputil/request"
+)
+
+type fixture struct {
+	handler        commandPort.UserCommandPort
+	eventStore     *memory.EventStore
+	dataKeys       secondary.DataKeyRepository
+	publisher      *memoryMessaging.Publisher
+	userQueries    *memory.UserQueryHandler
+	passwordPolicy userDomain.PasswordPolicy
+	passwordHasher userDomain.PasswordHasher
+}
+
+// testArgon2idParams keep hashing cheap enough for tests
+var testArgon2idParams = userDomain.Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func newFixture() *fixture {
+	registry := shared.NewEventRegistry()
+	userDomain.RegisterEvents(registry)
+
+	readModel := memory.NewUserReadModelStore()
+	dataKeys := memory.NewDataKeyRepository()
+	eventStore := memory.NewEventStore(registry, encryption.NewFieldCipher(dataKeys, registry))
+	publisher := memoryMessaging.NewPublisher(memory.NewMemoryProjector(readModel))
+	passwordPolicy, err := userDomain.NewPasswordPolicy(userDomain.DefaultPasswordPolicyConfig, nil)
+	if err != nil {
+		panic(err)
+	}
+	passwordHasher, err := userDomain.NewArgon2idHasher(testArgon2idParams, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return &fixture{
+		handler: command.NewUserCommandHandler(
+			eventStore,
+			eventStore,
+			publisher,
+			dataKeys,
+			id.NewUUIDv7Generator(),
+			userDomain.NewUsernamePolicy(userDomain.DefaultReservedUsernames),
+			passwordPolicy,
+			passwordHasher,
+		),
+		eventStore:     eventStore,
+		dataKeys:       dataKeys,
+		publisher:      publisher,
+		userQueries:    memory.NewUserQueryHandler(readModel),
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
+	}
+}
+
+func TestUserCommandHandler_RegisterUser(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	registered, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, registered.ID)
+	assert.Equal(t, "alice", registered.Username)
+	assert.Equal(t, 1, registered.Version)
+
+	events, err := f.eventStore.GetEvents(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Len(t, f.publisher.PublishedEvents(), 1)
+
+	projected, err := f.userQueries.GetUserByID(ctx, query.GetUserByIDQuery{UserID: registered.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", projected.Username)
+
+	_, err = f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	assert.True(t, errors.Is(err, userDomain.ErrUserAlreadyExists))
+	assert.Len(t, f.publisher.PublishedEvents(), 1)
+}
+
+func TestUserCommandHandler_RegisterUserNormalizesUsername(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	registered, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "Alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", registered.Username)
+
+	_, err = f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "ＡＬＩＣＥ",
+		Password: "validpass123",
+	})
+	assert.True(t, errors.Is(err, userDomain.ErrUserAlreadyExists))
+
+	authenticated, err := f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "ALICE",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, registered.ID, authenticated.ID)
+
+	_, err = f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "Admin",
+		Password: "validpass123",
+	})
+	var validationErr *shared.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Fields, 1)
+	assert.Equal(t, userDomain.UsernameReserved, validationErr.Fields[0].Code)
+	assert.Len(t, f.publisher.PublishedEvents(), 2)
+}
+
+func TestUserCommandHandler_RegisterUserMetadata(t *testing.T) {
+	f := newFixture()
+
+	ctx := request.SetValueToContext(context.Background(), request.ContextRequestIDKey, "req-1")
+	ctx = request.SetValueToContext(ctx, request.ContextClientIPKey, "10.0.0.1")
+	ctx = request.SetValueToContext(ctx, request.ContextUserAgentKey, "curl/8.0")
+
+	registered, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	events, err := f.eventStore.GetEvents(ctx, registered.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	metadata := events[0].GetMetadata()
+	assert.Equal(t, "req-1", metadata[shared.MetadataCorrelationID])
+	assert.Equal(t, "req-1", metadata[shared.MetadataCausationID])
+	assert.Equal(t, "10.0.0.1", metadata[shared.MetadataClientIP])
+	assert.Equal(t, "curl/8.0", metadata[shared.MetadataUserAgent])
+	assert.NotContains(t, metadata, shared.MetadataActorID)
+
+	// a caller acting on another event names it as the cause
+	ctx = request.SetValueToContext(ctx, request.ContextCausationIDKey, "user-9-4")
+	registered, err = f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "bob",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	events, err = f.eventStore.GetEvents(ctx, registered.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "req-1", events[0].GetMetadata()[shared.MetadataCorrelationID])
+	assert.Equal(t, "user-9-4", events[0].GetMetadata()[shared.MetadataCausationID])
+}
+
+func TestUserCommandHandler_AuthenticateUser(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	_, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		username    string
+		password    string
+		shouldError bool
+	}{
+		{
+			name:     "correct credentials",
+			username: "alice",
+			password: "validpass123",
+		},
+		{
+			name:        "wrong password",
+			username:    "alice",
+			password:    "wrongpass123",
+			shouldError: true,
+		},
+		{
+			name:        "unknown user",
+			username:    "bob",
+			password:    "validpass123",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticated, err := f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+				Username: tt.username,
+				Password: tt.password,
+			})
+			if tt.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.username, authenticated.Username)
+		})
+	}
+}
+
+func TestUserCommandHandler_AuthenticateUserRecordsLogins(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	registered, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username:  "alice",
+		Password:  "wrongpass123",
+		IPAddress: "203.0.113.7",
+		UserAgent: "curl/8.0",
+	})
+	assert.True(t, errors.Is(err, userDomain.ErrInvalidCredentials))
+
+	authenticated, err := f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username:  "alice",
+		Password:  "validpass123",
+		IPAddress: "198.51.100.2",
+		UserAgent: "Mozilla/5.0",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, authenticated.Version)
+
+	// an unknown user has no stream to record the attempt in
+	_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{Username: "bob", Password: "validpass123"})
+	assert.True(t, errors.Is(err, userDomain.ErrInvalidCredentials))
+
+	events, err := f.eventStore.GetEvents(ctx, registered.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, string(userDomain.EventTypeUserLoginFailed), events[1].GetEventType())
+	assert.Equal(t, string(userDomain.EventTypeUserLoggedIn), events[2].GetEventType())
+
+	history, err := f.userQueries.GetLoginHistory(ctx, query.GetLoginHistoryQuery{UserID: registered.ID})
+	require.NoError(t, err)
+	require.Len(t, history.Logins, 2)
+	assert.Equal(t, query.LoginOutcomeSucceeded, history.Logins[0].Outcome)
+	assert.Equal(t, "198.51.100.2", history.Logins[0].IPAddress)
+	assert.Equal(t, query.LoginOutcomeFailed, history.Logins[1].Outcome)
+	assert.Equal(t, userDomain.LoginFailureInvalidPassword, history.Logins[1].Reason)
+	assert.Equal(t, "curl/8.0", history.Logins[1].UserAgent)
+
+	require.NoError(t, f.handler.EraseUser(ctx, commandPort.EraseUserCommand{UserID: registered.ID}))
+	history, err = f.userQueries.GetLoginHistory(ctx, query.GetLoginHistoryQuery{UserID: registered.ID})
+	require.NoError(t, err)
+	assert.Empty(t, history.Logins)
+}
+
+func TestUserCommandHandler_SnapshotsLongStreams(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	registered, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	for range 100 {
+		_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+			Username: "alice",
+			Password: "wrongpass123",
+		})
+		require.True(t, errors.Is(err, userDomain.ErrInvalidCredentials))
+	}
+
+	snapshot, err := f.eventStore.GetSnapshot(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, snapshot.Version)
+
+	// commands now start from the snapshot
+	_, err = f.handler.ChangePassword(ctx, commandPort.ChangePasswordCommand{
+		UserID:      registered.ID,
+		OldPassword: "validpass123",
+		NewPassword: "newpass12345",
+	})
+	require.NoError(t, err)
+
+	authenticated, err := f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "newpass12345",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 103, authenticated.Version)
+
+	// erasing must not leave a snapshot to restore the user from
+	require.NoError(t, f.handler.EraseUser(ctx, commandPort.EraseUserCommand{UserID: registered.ID}))
+	_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "newpass12345",
+	})
+	assert.True(t, errors.Is(err, userDomain.ErrInvalidCredentials))
+}
+
+func TestUserCommandHandler_ChangePassword(t *testing.T) {
+	f := newFixture()
+
+	registered, err := f.handler.RegisterUser(context.Background(), commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	ctx := request.SetValueToContext(context.Background(), request.ContextUserKey, registered.ID)
+	version, err := f.handler.ChangePassword(ctx, commandPort.ChangePasswordCommand{
+		UserID:      registered.ID,
+		OldPassword: "validpass123",
+		NewPassword: "newpass12345",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	events, err := f.eventStore.GetEvents(ctx, registered.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, registered.ID, events[1].GetMetadata()[shared.MetadataActorID])
+
+	_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "newpass12345",
+	})
+	assert.NoError(t, err)
+}
+
+func TestUserCommandHandler_PasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	_, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "a!",
+		Password: "short",
+	})
+	var validationErr *shared.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	var fields []string
+	for _, field := range validationErr.Fields {
+		fields = append(fields, field.Field+":"+field.Code)
+	}
+	assert.Equal(t, []string{
+		"username:" + userDomain.UsernameTooShort,
+		"username:" + userDomain.UsernameInvalidCharacters,
+		"password:" + userDomain.PasswordTooShort,
+	}, fields)
+
+	registered, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	_, err = f.handler.ChangePassword(ctx, commandPort.ChangePasswordCommand{
+		UserID:      registered.ID,
+		OldPassword: "validpass123",
+		NewPassword: "Alice2024!",
+	})
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Fields, 1)
+	assert.Equal(t, userDomain.PasswordContainsUsername, validationErr.Fields[0].Code)
+	assert.Len(t, f.publisher.PublishedEvents(), 1)
+}
+
+func TestUserCommandHandler_AuthenticateUserRehashesBcrypt(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("validpass123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, f.eventStore.SaveEvents(ctx, "user-1", []shared.Event{
+		userDomain.NewUserRegisteredEvent("user-1", "alice", string(legacy)),
+	}))
+
+	authenticated, err := f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, authenticated.Version)
+
+	events, err := f.eventStore.GetEvents(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, string(userDomain.EventTypeUserLoggedIn), events[1].GetEventType())
+	rehashed, ok := events[2].(*userDomain.UserPasswordRehashedEvent)
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(rehashed.NewPasswordHash, "$argon2id$"))
+	assert.Len(t, f.publisher.PublishedEvents(), 2)
+
+	_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	events, err = f.eventStore.GetEvents(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, events, 4)
+}
+
+func TestUserCommandHandler_ChangeUsername(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	alice, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	_, err = f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "bob",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	_, err = f.handler.ChangeUsername(ctx, commandPort.ChangeUsernameCommand{UserID: alice.ID, NewUsername: "bob"})
+	assert.True(t, errors.Is(err, userDomain.ErrUsernameTaken))
+
+	renamed, err := f.handler.ChangeUsername(ctx, commandPort.ChangeUsernameCommand{UserID: alice.ID, NewUsername: "alicia"})
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, renamed.ID)
+	assert.Equal(t, "alicia", renamed.Username)
+	assert.Equal(t, 2, renamed.Version)
+
+	projected, err := f.userQueries.GetUserByID(ctx, query.GetUserByIDQuery{UserID: alice.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "alicia", projected.Username)
+
+	authenticated, err := f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alicia",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, authenticated.ID)
+
+	_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	assert.True(t, errors.Is(err, userDomain.ErrInvalidCredentials))
+
+	// the old username is free for someone else
+	_, err = f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	_, err = f.handler.ChangeUsername(ctx, commandPort.ChangeUsernameCommand{UserID: "missing", NewUsername: "carol"})
+	assert.True(t, errors.Is(err, userDomain.ErrUserNotFound))
+}
+
+func TestUserCommandHandler_ChangeEmail(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	alice, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	bob, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "bob",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	changed, err := f.handler.ChangeEmail(ctx, commandPort.ChangeEmailCommand{UserID: alice.ID, Email: "Alice@Example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", changed.Email)
+	assert.Equal(t, 2, changed.Version)
+
+	_, err = f.handler.ChangeEmail(ctx, commandPort.ChangeEmailCommand{UserID: bob.ID, Email: "alice@example.com"})
+	assert.True(t, errors.Is(err, userDomain.ErrEmailTaken))
+
+	var validationErr *shared.ValidationError
+	_, err = f.handler.ChangeEmail(ctx, commandPort.ChangeEmailCommand{UserID: bob.ID, Email: "not an address"})
+	assert.True(t, errors.As(err, &validationErr))
+
+	projected, err := f.userQueries.GetUserByID(ctx, query.GetUserByIDQuery{UserID: alice.ID})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", projected.Email)
+
+	// erasing alice frees the address
+	require.NoError(t, f.handler.EraseUser(ctx, commandPort.EraseUserCommand{UserID: alice.ID}))
+	_, err = f.handler.ChangeEmail(ctx, commandPort.ChangeEmailCommand{UserID: bob.ID, Email: "alice@example.com"})
+	require.NoError(t, err)
+}
+
+func TestUserCommandHandler_LockAndAssignRole(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	registered, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, string(userDomain.DefaultRole), registered.Role)
+
+	locked, err := f.handler.LockUser(ctx, commandPort.LockUserCommand{UserID: registered.ID})
+	require.NoError(t, err)
+	assert.True(t, locked.Locked)
+
+	_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	assert.True(t, errors.Is(err, userDomain.ErrUserLocked))
+
+	projected, err := f.userQueries.GetUserByID(ctx, query.GetUserByIDQuery{UserID: registered.ID})
+	require.NoError(t, err)
+	assert.True(t, projected.Locked)
+
+	unlocked, err := f.handler.UnlockUser(ctx, commandPort.UnlockUserCommand{UserID: registered.ID})
+	require.NoError(t, err)
+	assert.False(t, unlocked.Locked)
+	_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	_, err = f.handler.AssignRole(ctx, commandPort.AssignRoleCommand{UserID: registered.ID, Role: "owner"})
+	assert.True(t, errors.Is(err, userDomain.ErrInvalidRole))
+
+	assigned, err := f.handler.AssignRole(ctx, commandPort.AssignRoleCommand{UserID: registered.ID, Role: "support"})
+	require.NoError(t, err)
+	assert.Equal(t, "support", assigned.Role)
+
+	list, err := f.userQueries.ListUsers(ctx, query.ListUsersQuery{Role: "support"})
+	require.NoError(t, err)
+	require.Len(t, list.Users, 1)
+	assert.Equal(t, registered.ID, list.Users[0].ID)
+
+	_, err = f.handler.LockUser(ctx, commandPort.LockUserCommand{UserID: "missing"})
+	assert.True(t, errors.Is(err, userDomain.ErrUserNotFound))
+}
+
+func TestReserveExistingUsernames(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture()
+
+	// users registered before reservations existed have none
+	var legacyIDs []string
+	for _, username := range []string{"alice", "bob"} {
+		registered, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+			Username: username,
+			Password: "validpass123",
+		})
+		require.NoError(t, err)
+		require.NoError(t, f.eventStore.Reserve(ctx, registered.ID, []shared.Reservation{
+			{Scope: userDomain.UsernameReservationScope},
+		}))
+		legacyIDs = append(legacyIDs, registered.ID)
+	}
+	require.NoError(t, f.handler.EraseUser(ctx, commandPort.EraseUserCommand{UserID: legacyIDs[1]}))
+
+	reserved, err := command.ReserveExistingUsernames(ctx, f.eventStore)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reserved)
+
+	authenticated, err := f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, legacyIDs[0], authenticated.ID)
+
+	reserved, err = command.ReserveExistingUsernames(ctx, f.eventStore)
+	require.NoError(t, err)
+	assert.Zero(t, reserved)
+}
+
+func TestUserCommandHandler_UpdateProfile(t *testing.T) {
+	f := newFixture()
+
+	registered, err := f.handler.RegisterUser(context.Background(), commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	displayName, locale := " Alice ", "en-us"
+	ctx := request.SetValueToContext(context.Background(), request.ContextUserKey, registered.ID)
+	updated, err := f.handler.UpdateProfile(ctx, commandPort.UpdateProfileCommand{
+		UserID:      registered.ID,
+		DisplayName: &displayName,
+		Locale:      &locale,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", updated.DisplayName)
+	assert.Equal(t, "en-US", updated.Locale)
+	assert.Equal(t, 2, updated.Version)
+
+	profile, err := f.userQueries.GetProfile(ctx, query.GetProfileQuery{UserID: registered.ID})
+	require.NoError(t, err)
+	assert.Equal(t, updated.DisplayName, profile.DisplayName)
+	assert.Equal(t, updated.Locale, profile.Locale)
+	assert.Equal(t, updated.Version, profile.Version)
+
+	// resubmitting the same values stores nothing
+	_, err = f.handler.UpdateProfile(ctx, commandPort.UpdateProfileCommand{
+		UserID:      registered.ID,
+		DisplayName: &displayName,
+	})
+	require.NoError(t, err)
+	assert.Len(t, f.publisher.PublishedEvents(), 2)
+
+	timezone := "Mars/Olympus_Mons"
+	_, err = f.handler.UpdateProfile(ctx, commandPort.UpdateProfileCommand{
+		UserID:   registered.ID,
+		Timezone: &timezone,
+	})
+	assert.True(t, errors.Is(err, userDomain.ErrInvalidProfile))
+
+	require.NoError(t, f.handler.EraseUser(ctx, commandPort.EraseUserCommand{UserID: registered.ID}))
+	_, err = f.userQueries.GetProfile(ctx, query.GetProfileQuery{UserID: registered.ID})
+	assert.True(t, errors.Is(err, userDomain.ErrUserNotFound))
+
+	_, err = f.handler.UpdateProfile(ctx, commandPort.UpdateProfileCommand{
+		UserID:      registered.ID,
+		DisplayName: &displayName,
+	})
+	assert.True(t, errors.Is(err, userDomain.ErrUserErased))
+}
+
+func TestUserCommandHandler_EraseUser(t *testing.T) {
+	f := newFixture()
+
+	registered, err := f.handler.RegisterUser(context.Background(), commandPort.RegisterUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	require.NoError(t, err)
+
+	ctx := request.SetValueToContext(context.Background(), request.ContextUserKey, registered.ID)
+	require.NoError(t, f.handler.EraseUser(ctx, commandPort.EraseUserCommand{UserID: registered.ID}))
+
+	events, err := f.eventStore.GetEvents(ctx, registered.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Empty(t, events[0].(*userDomain.UserRegisteredEvent).Username)
+	assert.Equal(t, string(userDomain.EventTypeUserErased), events[1].GetEventType())
+
+	_, err = f.dataKeys.GetKey(ctx, registered.ID)
+	assert.True(t, errors.Is(err, secondary.ErrDataKeyNotFound))
+
+	_, err = f.userQueries.GetUserByID(ctx, query.GetUserByIDQuery{UserID: registered.ID})
+	assert.True(t, errors.Is(err, userDomain.ErrUserNotFound))
+
+	_, err = f.handler.AuthenticateUser(ctx, commandPort.AuthenticateUserCommand{
+		Username: "alice",
+		Password: "validpass123",
+	})
+	assert.Error(t, err)
+
+	// erasing again is a no-op rather than a second user.erased
+	require.NoError(t, f.handler.EraseUser(ctx, commandPort.EraseUserCommand{UserID: registered.ID}))
+	assert.Len(t, f.publisher.PublishedEvents(), 2)
+
+	err = f.handler.EraseUser(ctx, commandPort.EraseUserCommand{UserID: "missing"})
+	assert.True(t, errors.Is(err, userDomain.ErrUserNotFound))
+}
+
+// conflictingEventStore fails the first conflicts saves as if another writer
+// had appended to the stream in between.
+type conflictingEventStore struct {
+	*memory.EventStore
+	conflicts int
+	saves     int
+}
+
+func (s *conflictingEventStore) SaveEvents(ctx context.Context, aggregateID string, events []shared.Event) error {
+	s.saves++
+	if s.saves <= s.conflicts {
+		return &secondary.ConcurrencyConflictError{
+			AggregateID:     aggregateID,
+			ExpectedVersion: events[0].GetVersion() - 1,
+			ActualVersion:   events[0].GetVersion(),
+		}
+	}
+	return s.EventStore.SaveEvents(ctx, aggregateID, events)
+}
+
+func TestUserCommandHandler_RetriesOnConflict(t *testing.T) {
+	tests := []struct {
+		name          string
+		conflicts     int
+		expectedSaves int
+		shouldError   bool
+	}{
+		{
+			name:          "succeeds after transient conflicts",
+			conflicts:     2,
+			expectedSaves: 3,
+		},
+		{
+			name:          "gives up after max attempts",
+			conflicts:     10,
+			expectedSaves: 3,
+			shouldError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newFixture()
+			registered, err := f.handler.RegisterUser(ctx, commandPort.RegisterUserCommand{
+				Username: "alice",
+				Password: "validpass123",
+			})
+			require.NoError(t, err)
+
+			store := &conflictingEventStore{EventStore: f.eventStore, conflicts: tt.conflicts}
+			handler := command.NewUserCommandHandler(
+				store,
+				nil,
+				f.publisher,
+				f.dataKeys,
+				id.NewUUIDv7Generator(),
+				userDomain.NewUsernamePolicy(nil),
+				f.passwordPolicy,
+				f.passwordHasher,
+			)
+
+			_, err = handler.ChangePassword(ctx, commandPort.ChangePasswordCommand{
+				UserID:      registered.ID,
+				OldPassword: "validpass123",
+				NewPassword: "newpass12345",
+			})
+			assert.Equal(t, tt.expectedSaves, store.saves)
+			if tt.shouldError {
+				assert.True(t, errors.Is(err, secondary.ErrConcurrencyConflict))
+				assert.Len(t, f.publisher.PublishedEvents(), 1)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, f.publisher.PublishedEvents(), 2)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+// ReserveExistingUsernames reserves the canonical username of every user
+// registered before usernames were reserved along with the events, leaving
+// their ids as they are. A user whose reservation predates canonical
+// usernames has it replaced. Running it again only reserves what is still
+// missing. A username another user already holds, such as one differing only
+// by case, is logged and skipped. It returns how many usernames were
+// reserved.
+func ReserveExistingUsernames(ctx context.Context, eventStore secondary.EventStore) (int, error) {
+	registrations, err := eventStore.GetEventsByType(ctx, string(userDomain.EventTypeUserRegistered))
+	if err != nil {
+		return 0, fmt.Errorf("loading registrations: %w", err)
+	}
+
+	reserved := 0
+	for _, registration := range registrations {
+		userID := registration.GetAggregateID()
+
+		events, err := eventStore.GetEvents(ctx, userID)
+		if err != nil {
+			return reserved, fmt.Errorf("loading events of %s: %w", userID, err)
+		}
+		user, err := userDomain.ReconstructFromEvents(events)
+		if err != nil {
+			return reserved, fmt.Errorf("applying events of %s: %w", userID, err)
+		}
+		if user.Erased {
+			continue
+		}
+
+		username := userDomain.NormalizeUsername(user.Username).String()
+		holder, err := eventStore.GetReservationHolder(ctx, userDomain.UsernameReservationScope, username)
+		if err == nil {
+			if holder != userID {
+				log.Printf("username of user %s is held by %s, not reserving it", userID, holder)
+			}
+			continue
+		}
+		if !errors.Is(err, secondary.ErrReservationNotFound) {
+			return reserved, fmt.Errorf("checking username of %s: %w", userID, err)
+		}
+
+		err = eventStore.Reserve(ctx, userID, []shared.Reservation{
+			{Scope: userDomain.UsernameReservationScope, Value: username},
+		})
+		if errors.Is(err, secondary.ErrValueReserved) {
+			log.Printf("username of user %s was reserved by another user meanwhile", userID)
+			continue
+		}
+		if err != nil {
+			return reserved, fmt.Errorf("reserving username of %s: %w", userID, err)
+		}
+		reserved++
+	}
+
+	return reserved, nil
+}
@@ -3,3 +3,9 @@ package id
 type IDGenerator interface {
 	GenerateFromData(data []byte) string
 }
+
+// UniqueIDGenerator returns a new id on every call, unrelated to any data
+// the identified entity holds.
+type UniqueIDGenerator interface {
+	Generate() string
+}
@@ -9,6 +9,9 @@ import (
 type AuthenticateUserCommand struct {
 	Username string
 	Password string
+	// IPAddress and UserAgent describe the client for the login history
+	IPAddress string
+	UserAgent string
 }
 
 type RegisterUserCommand struct {
@@ -22,8 +25,51 @@ type ChangePasswordCommand struct {
 	NewPassword string
 }
 
+type ChangeUsernameCommand struct {
+	UserID      string
+	NewUsername string
+}
+
+// UpdateProfileCommand changes the non-nil fields; empty strings clear them.
+type UpdateProfileCommand struct {
+	UserID      string
+	DisplayName *string
+	AvatarURL   *string
+	Locale      *string
+	Timezone    *string
+}
+
+type ChangeEmailCommand struct {
+	UserID string
+	Email  string
+}
+
+type LockUserCommand struct {
+	UserID string
+}
+
+type UnlockUserCommand struct {
+	UserID string
+}
+
+type AssignRoleCommand struct {
+	UserID string
+	Role   string
+}
+
+type EraseUserCommand struct {
+	UserID string
+}
+
 type UserCommandPort interface {
 	RegisterUser(ctx context.Context, cmd RegisterUserCommand) (*types.UserResponse, error)
 	AuthenticateUser(ctx context.Context, cmd AuthenticateUserCommand) (*types.UserResponse, error)
-	ChangePassword(ctx context.Context, cmd ChangePasswordCommand) error
+	ChangePassword(ctx context.Context, cmd ChangePasswordCommand) (int, error)
+	ChangeUsername(ctx context.Context, cmd ChangeUsernameCommand) (*types.UserResponse, error)
+	UpdateProfile(ctx context.Context, cmd UpdateProfileCommand) (*types.ProfileResponse, error)
+	ChangeEmail(ctx context.Context, cmd ChangeEmailCommand) (*types.UserResponse, error)
+	LockUser(ctx context.Context, cmd LockUserCommand) (*types.UserResponse, error)
+	UnlockUser(ctx context.Context, cmd UnlockUserCommand) (*types.UserResponse, error)
+	AssignRole(ctx context.Context, cmd AssignRoleCommand) (*types.UserResponse, error)
+	EraseUser(ctx context.Context, cmd EraseUserCommand) error
 }
@@ -0,0 +1,118 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+const (
+	UserSortCreatedAt = "created_at"
+	UserSortUsername  = "username"
+
+	DefaultUserListLimit = 50
+	MaxUserListLimit     = 200
+)
+
+var (
+	ErrInvalidUserQuery = errors.New("invalid user query")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+)
+
+// ListUsersQuery pages through users ordered by SortBy, ties broken by id.
+// Erased users are never listed.
+type ListUsersQuery struct {
+	UsernamePrefix string
+	// EmailPrefix matches regardless of case, as addresses are stored
+	// lowercased
+	EmailPrefix string
+	// Locked keeps only locked or only unlocked users; nil keeps both
+	Locked *bool
+	// Role is one of the user roles; empty keeps every role
+	Role string
+	// CreatedFrom is inclusive and CreatedTo exclusive; zero leaves a side open
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	SortBy      string
+	Descending  bool
+	Limit       int
+	// Cursor is the NextCursor of the previous page, for the same query
+	Cursor string
+}
+
+// UserCursor is the position after the last user of a page. It is bound to
+// the sort it was issued for, since a value of one field means nothing to
+// another.
+type UserCursor struct {
+	SortBy     string    `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	Username   string    `json:"u,omitempty"`
+	CreatedAt  time.Time `json:"c"`
+	ID         string    `json:"i"`
+}
+
+// Normalize fills in defaults and checks the query, decoding its cursor.
+func (q ListUsersQuery) Normalize() (ListUsersQuery, *UserCursor, error) {
+	switch q.SortBy {
+	case "":
+		q.SortBy = UserSortCreatedAt
+	case UserSortCreatedAt, UserSortUsername:
+	default:
+		return q, nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidUserQuery, q.SortBy)
+	}
+
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultUserListLimit
+	case q.Limit < 0 || q.Limit > MaxUserListLimit:
+		return q, nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidUserQuery, MaxUserListLimit)
+	}
+
+	if !q.CreatedFrom.IsZero() && !q.CreatedTo.IsZero() && !q.CreatedFrom.Before(q.CreatedTo) {
+		return q, nil, fmt.Errorf("%w: created range is empty", ErrInvalidUserQuery)
+	}
+
+	q.EmailPrefix = strings.ToLower(q.EmailPrefix)
+	if q.Role != "" {
+		role, err := userDomain.ParseRole(q.Role)
+		if err != nil {
+			return q, nil, fmt.Errorf("%w: unknown role %q", ErrInvalidUserQuery, q.Role)
+		}
+		q.Role = string(role)
+	}
+
+	if q.Cursor == "" {
+		return q, nil, nil
+	}
+	cursor, err := DecodeUserCursor(q.Cursor)
+	if err != nil {
+		return q, nil, err
+	}
+	if cursor.SortBy != q.SortBy || cursor.Descending != q.Descending {
+		return q, nil, fmt.Errorf("%w: issued for another sort", ErrInvalidCursor)
+	}
+	return q, &cursor, nil
+}
+
+func EncodeUserCursor(cursor UserCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeUserCursor(encoded string) (UserCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return UserCursor{}, ErrInvalidCursor
+	}
+
+	var cursor UserCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == "" {
+		return UserCursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
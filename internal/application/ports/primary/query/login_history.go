@@ -0,0 +1,30 @@
+package query
+
+import "fmt"
+
+const (
+	LoginOutcomeSucceeded = "succeeded"
+	LoginOutcomeFailed    = "failed"
+
+	// LoginHistorySize is how many attempts the read model keeps per user;
+	// older ones are dropped as new ones arrive
+	LoginHistorySize = 50
+)
+
+// GetLoginHistoryQuery asks for a user's most recent sign-in attempts,
+// newest first.
+type GetLoginHistoryQuery struct {
+	UserID string
+	Limit  int
+}
+
+// Normalize fills in the default limit and checks the query.
+func (q GetLoginHistoryQuery) Normalize() (GetLoginHistoryQuery, error) {
+	switch {
+	case q.Limit == 0:
+		q.Limit = LoginHistorySize
+	case q.Limit < 0 || q.Limit > LoginHistorySize:
+		return q, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidUserQuery, LoginHistorySize)
+	}
+	return q, nil
+}
@@ -2,12 +2,26 @@ package query
 
 import (
 	"context"
+	"errors"
 
 	"github.com/ncfex/dcart-auth/internal/application/ports/types"
 )
 
+// ErrVersionNotReached is returned when no source holds the user at the
+// MinVersion a query asked for.
+var ErrVersionNotReached = errors.New("version not reached")
+
 type GetUserByIDQuery struct {
 	UserID string
+	// MinVersion asks for a user at least this version; zero accepts whatever
+	// the read model holds and a version past the latest event is capped at it
+	MinVersion int
+}
+
+type GetProfileQuery struct {
+	UserID string
+	// MinVersion has the same meaning as on GetUserByIDQuery
+	MinVersion int
 }
 
 type GetUserByUsernameQuery struct {
@@ -17,4 +31,7 @@ type GetUserByUsernameQuery struct {
 type UserQueryPort interface {
 	GetUserByID(ctx context.Context, query GetUserByIDQuery) (*types.UserResponse, error)
 	GetUserByUsername(ctx context.Context, query GetUserByUsernameQuery) (*types.UserResponse, error)
+	GetProfile(ctx context.Context, query GetProfileQuery) (*types.ProfileResponse, error)
+	ListUsers(ctx context.Context, query ListUsersQuery) (*types.UserListResponse, error)
+	GetLoginHistory(ctx context.Context, query GetLoginHistoryQuery) (*types.LoginHistoryResponse, error)
 }
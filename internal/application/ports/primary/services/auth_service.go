@@ -9,7 +9,12 @@ import (
 type AuthenticationService interface {
 	Register(ctx context.Context, req types.RegisterRequest) (*types.UserResponse, error)
 	Login(ctx context.Context, req types.LoginRequest) (*types.TokenPairResponse, error)
-	ChangePassword(ctx context.Context, req types.ChangePasswordRequest) error
+	ChangePassword(ctx context.Context, req types.ChangePasswordRequest) (int, error)
+	ChangeUsername(ctx context.Context, req types.ChangeUsernameRequest) (*types.UserResponse, error)
+	ChangeEmail(ctx context.Context, req types.ChangeEmailRequest) (*types.UserResponse, error)
+	Profile(ctx context.Context, minVersion int) (*types.ProfileResponse, error)
+	UpdateProfile(ctx context.Context, req types.UpdateProfileRequest) (*types.ProfileResponse, error)
+	Erase(ctx context.Context) error
 	Refresh(ctx context.Context, req types.TokenRequest) (*types.TokenResponse, error)
 	Logout(ctx context.Context, req types.TokenRequest) error
 	Validate(ctx context.Context, req types.TokenRequest) (*types.ValidateResponse, error)
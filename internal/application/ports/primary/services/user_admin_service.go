@@ -0,0 +1,14 @@
+package services
+
+import (
+	"context"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+// UserAdminService changes other users' accounts on behalf of support staff.
+type UserAdminService interface {
+	LockUser(ctx context.Context, userID string) (*types.UserResponse, error)
+	UnlockUser(ctx context.Context, userID string) (*types.UserResponse, error)
+	AssignRole(ctx context.Context, userID string, req types.AssignRoleRequest) (*types.UserResponse, error)
+}
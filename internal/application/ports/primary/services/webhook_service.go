@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+var ErrInvalidWebhook = errors.New("invalid webhook request")
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, req types.CreateWebhookRequest) (*types.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]types.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]types.WebhookDelivery, error)
+	Redeliver(ctx context.Context, deliveryID string) (*types.WebhookDelivery, error)
+}
@@ -0,0 +1,20 @@
+package secondary
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrDataKeyNotFound = errors.New("data key not found")
+	ErrDataKeyExists   = errors.New("data key already exists")
+)
+
+// DataKeyRepository holds the per-subject keys personal data is encrypted
+// under. Deleting a key is irreversible and makes that data unreadable;
+// deleting a key that is already gone is not an error.
+type DataKeyRepository interface {
+	CreateKey(ctx context.Context, subjectID string, key []byte) error
+	GetKey(ctx context.Context, subjectID string) ([]byte, error)
+	DeleteKey(ctx context.Context, subjectID string) error
+}
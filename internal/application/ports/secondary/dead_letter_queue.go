@@ -0,0 +1,16 @@
+package secondary
+
+import (
+	"context"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+// DeadLetterQueue gives operators access to messages the subscriber gave up
+// on. Replay sends them back to the main exchange; ids select messages by
+// DeadLetter.ID and an empty list replays everything.
+type DeadLetterQueue interface {
+	List(ctx context.Context, limit int) ([]types.DeadLetter, error)
+	Replay(ctx context.Context, ids []string) (int, error)
+	Purge(ctx context.Context) (int, error)
+}
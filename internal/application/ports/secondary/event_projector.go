@@ -0,0 +1,11 @@
+package secondary
+
+import (
+	"context"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+type EventProjector interface {
+	ProjectEvent(ctx context.Context, event shared.Event) error
+}
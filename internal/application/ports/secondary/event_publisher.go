@@ -8,4 +8,7 @@ import (
 
 type EventPublisher interface {
 	PublishEvent(ctx context.Context, event shared.Event) error
+	// PublishEvents publishes events in order and reports every one that
+	// failed, rather than stopping at the first
+	PublishEvents(ctx context.Context, events []shared.Event) error
 }
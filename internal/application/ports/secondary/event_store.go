@@ -2,12 +2,50 @@ package secondary
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/ncfex/dcart-auth/internal/domain/shared"
 )
 
+var (
+	ErrConcurrencyConflict = errors.New("concurrency conflict")
+	// ErrValueReserved is returned by SaveEvents and Reserve when another
+	// aggregate already holds a value being reserved
+	ErrValueReserved       = errors.New("value already reserved")
+	ErrReservationNotFound = errors.New("reservation not found")
+)
+
+// ConcurrencyConflictError is returned by SaveEvents when the stream moved on
+// since the caller loaded it. ExpectedVersion is the stream version the new
+// events were decided against, ActualVersion the version found in the store.
+// It matches ErrConcurrencyConflict with errors.Is.
+type ConcurrencyConflictError struct {
+	AggregateID     string
+	ExpectedVersion int
+	ActualVersion   int
+}
+
+func (e *ConcurrencyConflictError) Error() string {
+	return fmt.Sprintf("%s on aggregate %s: expected version %d, actual version %d",
+		ErrConcurrencyConflict, e.AggregateID, e.ExpectedVersion, e.ActualVersion)
+}
+
+func (e *ConcurrencyConflictError) Is(target error) bool {
+	return target == ErrConcurrencyConflict
+}
+
 type EventStore interface {
 	SaveEvents(ctx context.Context, aggregateID string, events []shared.Event) error
 	GetEvents(ctx context.Context, aggregateID string) ([]shared.Event, error)
+	// GetVersion returns the version of the latest event of aggregateID, 0
+	// for an empty stream, without loading the stream.
+	GetVersion(ctx context.Context, aggregateID string) (int, error)
 	GetEventsByType(ctx context.Context, eventType string) ([]shared.Event, error)
+	// GetReservationHolder returns the id of the aggregate holding value
+	// within scope.
+	GetReservationHolder(ctx context.Context, scope, value string) (string, error)
+	// Reserve applies reservations for aggregateID without appending an
+	// event, for streams written before their events declared them.
+	Reserve(ctx context.Context, aggregateID string, reservations []shared.Reservation) error
 }
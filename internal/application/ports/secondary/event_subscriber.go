@@ -0,0 +1,11 @@
+package secondary
+
+import (
+	"context"
+)
+
+// EventSubscriber feeds committed events to an EventProjector until stopped.
+type EventSubscriber interface {
+	Start(ctx context.Context) error
+	Stop() error
+}
@@ -0,0 +1,19 @@
+package secondary
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+// PayloadCipher encrypts the personal data fields of a serialized event and
+// its personal metadata before they are stored, and decrypts them when they
+// are loaded. Fields whose key has been deleted come back as null; metadata
+// values are dropped.
+type PayloadCipher interface {
+	EncryptPayload(ctx context.Context, aggregateID string, eventType shared.EventType, payload json.RawMessage) (json.RawMessage, error)
+	DecryptPayload(ctx context.Context, aggregateID string, eventType shared.EventType, payload json.RawMessage) (json.RawMessage, error)
+	EncryptMetadata(ctx context.Context, aggregateID string, metadata shared.Metadata) (shared.Metadata, error)
+	DecryptMetadata(ctx context.Context, aggregateID string, metadata shared.Metadata) (shared.Metadata, error)
+}
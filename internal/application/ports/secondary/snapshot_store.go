@@ -0,0 +1,24 @@
+package secondary
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// SnapshotStore keeps the latest snapshot of each aggregate next to its
+// stream. Snapshot state is encrypted like event payloads, so deleting the
+// aggregate's data key shreds it as well.
+type SnapshotStore interface {
+	// SaveSnapshot replaces the aggregate's snapshot unless the stored one
+	// is as recent.
+	SaveSnapshot(ctx context.Context, snapshot shared.Snapshot) error
+	// GetSnapshot returns ErrSnapshotNotFound when none was saved.
+	GetSnapshot(ctx context.Context, aggregateID string) (*shared.Snapshot, error)
+	// GetEventsAfterVersion returns the aggregate's events above version, in
+	// version order.
+	GetEventsAfterVersion(ctx context.Context, aggregateID string, version int) ([]shared.Event, error)
+}
@@ -0,0 +1,34 @@
+package secondary
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookDeliveryExists   = errors.New("webhook delivery already exists")
+)
+
+// WebhookRepository stores subscriptions and their delivery log. Deleting a
+// subscription deletes its deliveries.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription types.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id string) (*types.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]types.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+
+	CreateDelivery(ctx context.Context, delivery types.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id string) (*types.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery types.WebhookDelivery) error
+	// ListDeliveries returns the newest deliveries of a subscription first;
+	// an empty status matches every status
+	ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]types.WebhookDelivery, error)
+	// DueDeliveries returns pending deliveries whose next attempt is due,
+	// oldest first
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]types.WebhookDelivery, error)
+}
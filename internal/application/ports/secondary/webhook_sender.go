@@ -0,0 +1,13 @@
+package secondary
+
+import (
+	"context"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+// WebhookSender posts a signed delivery to its subscription. It returns the
+// response status, if any, and an error unless the endpoint accepted it.
+type WebhookSender interface {
+	Send(ctx context.Context, subscription types.WebhookSubscription, delivery types.WebhookDelivery) (int, error)
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeadLetter is a message parked in the dead-letter queue. ID is the event
+// id (aggregate and version) when the message could be decoded.
+type DeadLetter struct {
+	ID            string            `json:"id"`
+	AggregateID   string            `json:"aggregate_id,omitempty"`
+	EventType     string            `json:"event_type,omitempty"`
+	Version       int               `json:"version,omitempty"`
+	SchemaVersion int               `json:"schema_version,omitempty"`
+	Timestamp     time.Time         `json:"timestamp,omitempty"`
+	Payload       json.RawMessage   `json:"payload,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Reason        string            `json:"reason"`
+	Retries       int               `json:"retries"`
+}
+
+type DeadLetterListResponse struct {
+	Messages []DeadLetter `json:"messages"`
+}
+
+type DeadLetterCountResponse struct {
+	Count int `json:"count"`
+}
@@ -15,10 +15,46 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" validate:"required"`
 }
 
+type ChangeUsernameRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+type ChangeEmailRequest struct {
+	Email string `json:"email" validate:"required"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// UpdateProfileRequest is a partial update: omitted fields are kept and
+// empty strings clear them.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	Locale      *string `json:"locale"`
+	Timezone    *string `json:"timezone"`
+}
+
 type TokenRequest struct {
 	Token string `json:"token" validate:"required"`
+	// MinVersion is the user version the caller has already written, so the
+	// lookup behind the token must not observe anything older
+	MinVersion int `json:"-"`
 }
 
 type CreateTokenParams struct {
 	UserID string `json:"user_id" validate:"required"`
 }
+
+// ReplayDeadLettersRequest replays the listed ids, or every message when empty.
+type ReplayDeadLettersRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// CreateWebhookRequest subscribes URL to EventTypes, or to every event when
+// EventTypes is empty.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required"`
+	EventTypes []string `json:"event_types"`
+}
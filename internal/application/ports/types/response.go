@@ -1,8 +1,16 @@
 package types
 
+import "time"
+
 type UserResponse struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	Role     string `json:"role,omitempty"`
+	// Locked users are refused new tokens
+	Locked bool `json:"locked,omitempty"`
+	// Version is the aggregate version the response reflects
+	Version int `json:"version,omitempty"`
 }
 
 type TokenPairResponse struct {
@@ -22,3 +30,60 @@ type ValidateResponse struct {
 type ValidateTokenResponse struct {
 	Subject string `json:"subject"`
 }
+
+// ProfileResponse is the signed-in user's own view of their account.
+type ProfileResponse struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	AvatarURL   string    `json:"avatar_url"`
+	Locale      string    `json:"locale"`
+	Timezone    string    `json:"timezone"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     int       `json:"version"`
+}
+
+// UserSummaryResponse is a user as listed to support staff.
+type UserSummaryResponse struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Locked    bool      `json:"locked"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Version   int       `json:"version"`
+}
+
+type UserListResponse struct {
+	Users []UserSummaryResponse `json:"users"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// LoginHistoryEntry is one sign-in attempt. Reason is set for failures.
+type LoginHistoryEntry struct {
+	Outcome    string    `json:"outcome"`
+	Reason     string    `json:"reason,omitempty"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type LoginHistoryResponse struct {
+	// Logins is newest first
+	Logins []LoginHistoryEntry `json:"logins"`
+}
+
+// ValidationErrorResponse reports every rejected input field at once.
+type ValidationErrorResponse struct {
+	Error  string               `json:"error"`
+	Fields []FieldErrorResponse `json:"fields"`
+}
+
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,218 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+type ConsistencyConfig struct {
+	// WaitTimeout bounds how long a query waits for the projection before it
+	// reads the event store instead
+	WaitTimeout  time.Duration
+	PollInterval time.Duration
+}
+
+// ConsistentUserQueryHandler gives read-your-writes on top of a read model
+// that is fed asynchronously. Queries carrying a MinVersion wait for the
+// projection to reach it and otherwise fall back to replaying the stream.
+// MinVersion comes from the client, so it is first capped at the stream's
+// current version: a version nobody wrote yet is never waited for. The
+// projections advance the user's version on every event, sign-ins included,
+// so the stream's version is one the read model can reach.
+type ConsistentUserQueryHandler struct {
+	query.UserQueryPort
+	eventStore secondary.EventStore
+	snapshots  secondary.SnapshotStore
+	config     ConsistencyConfig
+}
+
+// NewConsistentUserQueryHandler replays from the latest snapshot when it
+// falls back to the event store; nil snapshots replays the whole stream.
+func NewConsistentUserQueryHandler(
+	readModel query.UserQueryPort,
+	eventStore secondary.EventStore,
+	snapshots secondary.SnapshotStore,
+	config ConsistencyConfig,
+) *ConsistentUserQueryHandler {
+	return &ConsistentUserQueryHandler{
+		UserQueryPort: readModel,
+		eventStore:    eventStore,
+		snapshots:     snapshots,
+		config:        config,
+	}
+}
+
+func (h *ConsistentUserQueryHandler) GetUserByID(ctx context.Context, q query.GetUserByIDQuery) (*types.UserResponse, error) {
+	if q.MinVersion <= 0 {
+		return h.UserQueryPort.GetUserByID(ctx, q)
+	}
+
+	minVersion, err := h.reachableVersion(ctx, q.UserID, q.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	if minVersion <= 0 {
+		return h.UserQueryPort.GetUserByID(ctx, q)
+	}
+
+	var user *types.UserResponse
+	caughtUp, err := h.awaitVersion(ctx, minVersion, func(ctx context.Context) (int, error) {
+		var err error
+		user, err = h.UserQueryPort.GetUserByID(ctx, q)
+		if err != nil {
+			return 0, err
+		}
+		return user.Version, nil
+	})
+	if err != nil || caughtUp {
+		return user, err
+	}
+
+	replayed, err := h.fromEventStore(ctx, q.UserID, minVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &types.UserResponse{
+		ID:       replayed.ID,
+		Username: replayed.Username,
+		Email:    replayed.Email,
+		Role:     string(replayed.Role),
+		Locked:   replayed.Locked,
+		Version:  replayed.Version,
+	}, nil
+}
+
+func (h *ConsistentUserQueryHandler) GetProfile(ctx context.Context, q query.GetProfileQuery) (*types.ProfileResponse, error) {
+	if q.MinVersion <= 0 {
+		return h.UserQueryPort.GetProfile(ctx, q)
+	}
+
+	minVersion, err := h.reachableVersion(ctx, q.UserID, q.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	if minVersion <= 0 {
+		return h.UserQueryPort.GetProfile(ctx, q)
+	}
+
+	var profile *types.ProfileResponse
+	caughtUp, err := h.awaitVersion(ctx, minVersion, func(ctx context.Context) (int, error) {
+		var err error
+		profile, err = h.UserQueryPort.GetProfile(ctx, q)
+		if err != nil {
+			return 0, err
+		}
+		return profile.Version, nil
+	})
+	if err != nil || caughtUp {
+		return profile, err
+	}
+
+	replayed, err := h.fromEventStore(ctx, q.UserID, minVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ProfileResponse{
+		ID:          replayed.ID,
+		Username:    replayed.Username,
+		DisplayName: replayed.Profile.DisplayName,
+		AvatarURL:   replayed.Profile.AvatarURL,
+		Locale:      replayed.Profile.Locale,
+		Timezone:    replayed.Profile.Timezone,
+		CreatedAt:   replayed.CreatedAt,
+		UpdatedAt:   replayed.UpdatedAt,
+		Version:     replayed.Version,
+	}, nil
+}
+
+// reachableVersion caps minVersion at the version the stream has reached,
+// which is 0 when it has no events.
+func (h *ConsistentUserQueryHandler) reachableVersion(ctx context.Context, userID string, minVersion int) (int, error) {
+	current, err := h.eventStore.GetVersion(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("loading version: %w", err)
+	}
+	return min(minVersion, current), nil
+}
+
+// awaitVersion polls read, which reports the version it observed, until that
+// reaches minVersion. It returns false once WaitTimeout passes without the
+// projection catching up; a user the projection does not know yet counts as
+// not caught up rather than as an error.
+func (h *ConsistentUserQueryHandler) awaitVersion(
+	ctx context.Context,
+	minVersion int,
+	read func(ctx context.Context) (int, error),
+) (bool, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, h.config.WaitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(h.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		version, err := read(waitCtx)
+		switch {
+		case err == nil && version >= minVersion:
+			return true, nil
+		case err != nil && !errors.Is(err, userDomain.ErrUserNotFound) && waitCtx.Err() == nil:
+			return false, err
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return false, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// fromEventStore answers from the stream itself, which always holds the
+// caller's write once its command has returned. A stream still short of
+// minVersion is an error rather than an answer older than was asked for.
+func (h *ConsistentUserQueryHandler) fromEventStore(ctx context.Context, userID string, minVersion int) (*userDomain.User, error) {
+	var snapshot *shared.Snapshot
+	if h.snapshots != nil {
+		var err error
+		snapshot, err = h.snapshots.GetSnapshot(ctx, userID)
+		if err != nil && !errors.Is(err, secondary.ErrSnapshotNotFound) {
+			return nil, fmt.Errorf("loading snapshot: %w", err)
+		}
+	}
+
+	var (
+		events []shared.Event
+		err    error
+	)
+	if snapshot == nil {
+		events, err = h.eventStore.GetEvents(ctx, userID)
+	} else {
+		events, err = h.snapshots.GetEventsAfterVersion(ctx, userID, snapshot.Version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading events: %w", err)
+	}
+	if snapshot == nil && len(events) == 0 {
+		return nil, userDomain.ErrUserNotFound
+	}
+
+	user, err := userDomain.RestoreFromSnapshot(snapshot, events)
+	if err != nil {
+		return nil, fmt.Errorf("applying events: %w", err)
+	}
+	if user.Erased {
+		return nil, userDomain.ErrUserNotFound
+	}
+	if user.Version < minVersion {
+		return nil, fmt.Errorf("%w: user %s at version %d, expected %d", query.ErrVersionNotReached, userID, user.Version, minVersion)
+	}
+	return user, nil
+}
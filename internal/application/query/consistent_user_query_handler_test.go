@@ -0,0 +1,242 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/encryption"
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/memory"
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+var errReadModelDown = errors.New("connection refused")
+
+// laggingReadModel returns version 1 of the user until it has been asked
+// catchUpAfter times, then the version it was written up to.
+type laggingReadModel struct {
+	query.UserQueryPort
+	err          error
+	catchUpAfter int
+	version      int
+	calls        int
+}
+
+func (r *laggingReadModel) GetUserByID(_ context.Context, q query.GetUserByIDQuery) (*types.UserResponse, error) {
+	r.calls++
+	if r.err != nil {
+		return nil, r.err
+	}
+	version := 1
+	if r.catchUpAfter > 0 && r.calls > r.catchUpAfter {
+		version = r.version
+	}
+	return &types.UserResponse{ID: q.UserID, Username: "alice", Version: version}, nil
+}
+
+func newTestEventStore(t *testing.T) (*memory.EventStore, string) {
+	t.Helper()
+
+	registry := shared.NewEventRegistry()
+	userDomain.RegisterEvents(registry)
+	eventStore := memory.NewEventStore(registry, encryption.NewFieldCipher(memory.NewDataKeyRepository(), registry))
+
+	hasher, err := userDomain.NewArgon2idHasher(userDomain.Argon2idParams{
+		Memory:      64,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewArgon2idHasher() error = %v", err)
+	}
+	user, err := userDomain.NewUser("user-1", "alice", "validpass123", hasher)
+	if err != nil {
+		t.Fatalf("NewUser() error = %v", err)
+	}
+	if err := user.ChangePassword("validpass123", "newpass12345", hasher); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	if err := eventStore.SaveEvents(context.Background(), user.ID, user.GetUncommittedChanges()); err != nil {
+		t.Fatalf("SaveEvents() error = %v", err)
+	}
+	return eventStore, user.ID
+}
+
+func TestConsistentUserQueryHandler_GetUserByID(t *testing.T) {
+	tests := []struct {
+		name            string
+		userID          string
+		minVersion      int
+		readModel       *laggingReadModel
+		expectedVersion int
+		expectedErr     error
+		expectedCalls   int
+	}{
+		{
+			name:            "no minimum version reads the projection once",
+			readModel:       &laggingReadModel{},
+			expectedVersion: 1,
+			expectedCalls:   1,
+		},
+		{
+			name:            "projection already caught up",
+			minVersion:      1,
+			readModel:       &laggingReadModel{},
+			expectedVersion: 1,
+			expectedCalls:   1,
+		},
+		{
+			name:            "waits for the projection to catch up",
+			minVersion:      2,
+			readModel:       &laggingReadModel{catchUpAfter: 2, version: 2},
+			expectedVersion: 2,
+			expectedCalls:   3,
+		},
+		{
+			name:            "falls back to the event store",
+			minVersion:      2,
+			readModel:       &laggingReadModel{},
+			expectedVersion: 2,
+		},
+		{
+			name:            "version past the stream is capped at it",
+			minVersion:      999999,
+			readModel:       &laggingReadModel{catchUpAfter: 1, version: 2},
+			expectedVersion: 2,
+			expectedCalls:   2,
+		},
+		{
+			name:        "unknown user is not found in either",
+			userID:      "missing",
+			minVersion:  1,
+			readModel:   &laggingReadModel{err: userDomain.ErrUserNotFound},
+			expectedErr: userDomain.ErrUserNotFound,
+		},
+		{
+			name:          "read model failure is returned",
+			minVersion:    2,
+			readModel:     &laggingReadModel{err: errReadModelDown},
+			expectedErr:   errReadModelDown,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventStore, userID := newTestEventStore(t)
+			if tt.userID != "" {
+				userID = tt.userID
+			}
+
+			handler := NewConsistentUserQueryHandler(tt.readModel, eventStore, eventStore, ConsistencyConfig{
+				WaitTimeout:  50 * time.Millisecond,
+				PollInterval: time.Millisecond,
+			})
+
+			user, err := handler.GetUserByID(context.Background(), query.GetUserByIDQuery{
+				UserID:     userID,
+				MinVersion: tt.minVersion,
+			})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("GetUserByID() error = %v, expected %v", err, tt.expectedErr)
+			}
+			if tt.expectedErr != nil {
+				return
+			}
+			if user.Version != tt.expectedVersion {
+				t.Errorf("version = %d, expected %d", user.Version, tt.expectedVersion)
+			}
+			if tt.expectedCalls > 0 && tt.readModel.calls != tt.expectedCalls {
+				t.Errorf("read model queried %d times, expected %d", tt.readModel.calls, tt.expectedCalls)
+			}
+		})
+	}
+}
+
+func TestConsistentUserQueryHandler_GetProfileFallsBack(t *testing.T) {
+	eventStore, userID := newTestEventStore(t)
+
+	// an empty projection has not seen the user at all yet
+	readModel := memory.NewUserQueryHandler(memory.NewUserReadModelStore())
+	handler := NewConsistentUserQueryHandler(readModel, eventStore, eventStore, ConsistencyConfig{
+		WaitTimeout:  10 * time.Millisecond,
+		PollInterval: time.Millisecond,
+	})
+
+	profile, err := handler.GetProfile(context.Background(), query.GetProfileQuery{UserID: userID, MinVersion: 2})
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if profile.Username != "alice" || profile.Version != 2 {
+		t.Errorf("profile = %+v, expected alice at version 2", profile)
+	}
+
+	_, err = handler.GetProfile(context.Background(), query.GetProfileQuery{UserID: userID})
+	if !errors.Is(err, userDomain.ErrUserNotFound) {
+		t.Errorf("GetProfile() without a minimum version error = %v, expected %v", err, userDomain.ErrUserNotFound)
+	}
+}
+
+func TestConsistentUserQueryHandler_ReplayMustReachMinVersion(t *testing.T) {
+	eventStore, userID := newTestEventStore(t)
+	handler := NewConsistentUserQueryHandler(&laggingReadModel{}, eventStore, nil, ConsistencyConfig{})
+
+	if _, err := handler.fromEventStore(context.Background(), userID, 2); err != nil {
+		t.Fatalf("fromEventStore() at the stream version error = %v", err)
+	}
+	if _, err := handler.fromEventStore(context.Background(), userID, 3); !errors.Is(err, query.ErrVersionNotReached) {
+		t.Errorf("fromEventStore() past the stream version error = %v, expected %v", err, query.ErrVersionNotReached)
+	}
+}
+
+// replayCountingStore counts full stream loads.
+type replayCountingStore struct {
+	*memory.EventStore
+	replays int
+}
+
+func (s *replayCountingStore) GetEvents(ctx context.Context, aggregateID string) ([]shared.Event, error) {
+	s.replays++
+	return s.EventStore.GetEvents(ctx, aggregateID)
+}
+
+func TestConsistentUserQueryHandler_ReplaysFromSnapshot(t *testing.T) {
+	ctx := context.Background()
+	eventStore, userID := newTestEventStore(t)
+
+	events, err := eventStore.GetEvents(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	user, err := userDomain.ReconstructFromEvents(events)
+	if err != nil {
+		t.Fatalf("ReconstructFromEvents() error = %v", err)
+	}
+	snapshot, err := user.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if err := eventStore.SaveSnapshot(ctx, snapshot); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	store := &replayCountingStore{EventStore: eventStore}
+	handler := NewConsistentUserQueryHandler(&laggingReadModel{}, store, store, ConsistencyConfig{})
+
+	replayed, err := handler.fromEventStore(ctx, userID, 2)
+	if err != nil {
+		t.Fatalf("fromEventStore() error = %v", err)
+	}
+	if replayed.Username != "alice" || replayed.Version != 2 {
+		t.Errorf("user = %s at version %d, expected alice at version 2", replayed.Username, replayed.Version)
+	}
+	if store.replays != 0 {
+		t.Errorf("stream replayed %d times, expected the snapshot to be used", store.replays)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/ncfex/dcart-auth/internal/application/ports/primary/query"
 	"github.com/ncfex/dcart-auth/internal/application/ports/primary/services"
 	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	userDomain "github.com/ncfex/dcart-auth/internal/domain/user"
 	"github.com/ncfex/dcart-auth/pkg/httputil/request"
 )
 
@@ -40,8 +41,10 @@ func (as *authService) Register(ctx context.Context, req types.RegisterRequest)
 
 func (as *authService) Login(ctx context.Context, req types.LoginRequest) (*types.TokenPairResponse, error) {
 	authenticateCmd := command.AuthenticateUserCommand{
-		Username: req.Username,
-		Password: req.Password,
+		Username:  req.Username,
+		Password:  req.Password,
+		IPAddress: request.GetStringFromContext(ctx, request.ContextClientIPKey),
+		UserAgent: request.GetStringFromContext(ctx, request.ContextUserAgentKey),
 	}
 	authenticatedUser, err := as.userCommandHandler.AuthenticateUser(ctx, authenticateCmd)
 	if err != nil {
@@ -61,10 +64,10 @@ func (as *authService) Login(ctx context.Context, req types.LoginRequest) (*type
 	}, nil
 }
 
-func (as *authService) ChangePassword(ctx context.Context, req types.ChangePasswordRequest) error {
+func (as *authService) ChangePassword(ctx context.Context, req types.ChangePasswordRequest) (int, error) {
 	userID := ctx.Value(request.ContextUserKey).(string)
 	if userID == "" {
-		return fmt.Errorf("change password: %w", errors.New("invalid user id"))
+		return 0, fmt.Errorf("change password: %w", errors.New("invalid user id"))
 	}
 
 	changePasswordCmd := command.ChangePasswordCommand{
@@ -72,8 +75,91 @@ func (as *authService) ChangePassword(ctx context.Context, req types.ChangePassw
 		OldPassword: req.OldPassword,
 		NewPassword: req.NewPassword,
 	}
-	if err := as.userCommandHandler.ChangePassword(ctx, changePasswordCmd); err != nil {
-		return fmt.Errorf("change password: %w", err)
+	version, err := as.userCommandHandler.ChangePassword(ctx, changePasswordCmd)
+	if err != nil {
+		return 0, fmt.Errorf("change password: %w", err)
+	}
+	return version, nil
+}
+
+func (as *authService) ChangeUsername(ctx context.Context, req types.ChangeUsernameRequest) (*types.UserResponse, error) {
+	userID := request.GetStringFromContext(ctx, request.ContextUserKey)
+	if userID == "" {
+		return nil, fmt.Errorf("change username: %w", errors.New("invalid user id"))
+	}
+
+	user, err := as.userCommandHandler.ChangeUsername(ctx, command.ChangeUsernameCommand{
+		UserID:      userID,
+		NewUsername: req.Username,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("change username: %w", err)
+	}
+	return user, nil
+}
+
+func (as *authService) ChangeEmail(ctx context.Context, req types.ChangeEmailRequest) (*types.UserResponse, error) {
+	userID := request.GetStringFromContext(ctx, request.ContextUserKey)
+	if userID == "" {
+		return nil, fmt.Errorf("change email: %w", errors.New("invalid user id"))
+	}
+
+	user, err := as.userCommandHandler.ChangeEmail(ctx, command.ChangeEmailCommand{
+		UserID: userID,
+		Email:  req.Email,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("change email: %w", err)
+	}
+	return user, nil
+}
+
+// Profile returns the authenticated user's profile, at least at minVersion
+// when that is set.
+func (as *authService) Profile(ctx context.Context, minVersion int) (*types.ProfileResponse, error) {
+	userID := request.GetStringFromContext(ctx, request.ContextUserKey)
+	if userID == "" {
+		return nil, fmt.Errorf("get profile: %w", errors.New("invalid user id"))
+	}
+
+	profile, err := as.userQueryHandler.GetProfile(ctx, query.GetProfileQuery{
+		UserID:     userID,
+		MinVersion: minVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get profile: %w", err)
+	}
+	return profile, nil
+}
+
+func (as *authService) UpdateProfile(ctx context.Context, req types.UpdateProfileRequest) (*types.ProfileResponse, error) {
+	userID := request.GetStringFromContext(ctx, request.ContextUserKey)
+	if userID == "" {
+		return nil, fmt.Errorf("update profile: %w", errors.New("invalid user id"))
+	}
+
+	profile, err := as.userCommandHandler.UpdateProfile(ctx, command.UpdateProfileCommand{
+		UserID:      userID,
+		DisplayName: req.DisplayName,
+		AvatarURL:   req.AvatarURL,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("update profile: %w", err)
+	}
+	return profile, nil
+}
+
+// Erase shreds the personal data of the authenticated user.
+func (as *authService) Erase(ctx context.Context) error {
+	userID := request.GetStringFromContext(ctx, request.ContextUserKey)
+	if userID == "" {
+		return fmt.Errorf("erase user: %w", errors.New("invalid user id"))
+	}
+
+	if err := as.userCommandHandler.EraseUser(ctx, command.EraseUserCommand{UserID: userID}); err != nil {
+		return fmt.Errorf("erase user: %w", err)
 	}
 	return nil
 }
@@ -84,14 +170,18 @@ func (as *authService) Refresh(ctx context.Context, req types.TokenRequest) (*ty
 		return nil, fmt.Errorf("validate refresh token: %w", err)
 	}
 
-	// just to check user exists
+	// the user must still exist and may not be locked
 	getUserByIdQuery := query.GetUserByIDQuery{
-		UserID: refreshToken.Subject,
+		UserID:     refreshToken.Subject,
+		MinVersion: req.MinVersion,
 	}
-	_, err = as.userQueryHandler.GetUserByID(ctx, getUserByIdQuery)
+	user, err := as.userQueryHandler.GetUserByID(ctx, getUserByIdQuery)
 	if err != nil {
 		return nil, fmt.Errorf("get existing user : %w", err)
 	}
+	if user.Locked {
+		return nil, fmt.Errorf("refresh token: %w", userDomain.ErrUserLocked)
+	}
 
 	params := types.CreateTokenParams{
 		UserID: refreshToken.Subject,
@@ -119,17 +209,24 @@ func (as *authService) Validate(ctx context.Context, req types.TokenRequest) (*t
 	}
 
 	getUserByIdQuery := query.GetUserByIDQuery{
-		UserID: validateResp.Subject,
+		UserID:     validateResp.Subject,
+		MinVersion: req.MinVersion,
 	}
 	user, err := as.userQueryHandler.GetUserByID(ctx, getUserByIdQuery)
 	if err != nil {
 		return nil, fmt.Errorf("get existing user : %w", err)
 	}
+	if user.Locked {
+		return nil, fmt.Errorf("validate access token: %w", userDomain.ErrUserLocked)
+	}
 	return &types.ValidateResponse{
 		Valid: true,
 		User: types.UserResponse{
 			ID:       user.ID,
 			Username: user.Username,
+			Email:    user.Email,
+			Role:     user.Role,
+			Version:  user.Version,
 		},
 	}, nil
 }
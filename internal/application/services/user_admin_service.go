@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/command"
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/services"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+)
+
+type userAdminService struct {
+	userCommandHandler command.UserCommandPort
+}
+
+func NewUserAdminService(userCommandHandler command.UserCommandPort) services.UserAdminService {
+	return &userAdminService{
+		userCommandHandler: userCommandHandler,
+	}
+}
+
+func (s *userAdminService) LockUser(ctx context.Context, userID string) (*types.UserResponse, error) {
+	user, err := s.userCommandHandler.LockUser(ctx, command.LockUserCommand{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("lock user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *userAdminService) UnlockUser(ctx context.Context, userID string) (*types.UserResponse, error) {
+	user, err := s.userCommandHandler.UnlockUser(ctx, command.UnlockUserCommand{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("unlock user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *userAdminService) AssignRole(ctx context.Context, userID string, req types.AssignRoleRequest) (*types.UserResponse, error) {
+	user, err := s.userCommandHandler.AssignRole(ctx, command.AssignRoleCommand{
+		UserID: userID,
+		Role:   req.Role,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("assign role: %w", err)
+	}
+	return user, nil
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+type WebhookDispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// a failing delivery is attempted MaxAttempts times, waiting BaseDelay
+	// and doubling up to MaxDelay in between, before it is marked failed
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Timeout bounds a single attempt
+	Timeout time.Duration
+}
+
+// webhookPayload is the body every subscriber receives. Personal data is
+// never sent, since it could not be shredded once it left the service.
+type webhookPayload struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	Version     int             `json:"version"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// WebhookDispatcher is fed from the event stream like a projector. It only
+// records deliveries there; sending runs in the background so a slow
+// endpoint never holds up the stream.
+type WebhookDispatcher struct {
+	repo     secondary.WebhookRepository
+	sender   secondary.WebhookSender
+	registry shared.EventRegistry
+	config   WebhookDispatcherConfig
+	now      func() time.Time
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewWebhookDispatcher(
+	repo secondary.WebhookRepository,
+	sender secondary.WebhookSender,
+	registry shared.EventRegistry,
+	config WebhookDispatcherConfig,
+) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repo:     repo,
+		sender:   sender,
+		registry: registry,
+		config:   config,
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// ProjectEvent records a delivery of event for every interested subscription.
+// Deliveries are keyed by subscription and event, so an event the stream
+// hands over twice is only sent once.
+func (d *WebhookDispatcher) ProjectEvent(ctx context.Context, event shared.Event) error {
+	subscriptions, err := d.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+
+	var payload json.RawMessage
+	eventID := fmt.Sprintf("%s-%d", event.GetAggregateID(), event.GetVersion())
+	recorded := false
+	for _, subscription := range subscriptions {
+		if !subscribedTo(subscription, event.GetEventType()) {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = d.payload(eventID, event); err != nil {
+				return err
+			}
+		}
+
+		now := d.now().UTC()
+		err := d.repo.CreateDelivery(ctx, types.WebhookDelivery{
+			ID:             subscription.ID + "-" + eventID,
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      event.GetEventType(),
+			Payload:        payload,
+			Status:         types.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+		switch {
+		case errors.Is(err, secondary.ErrWebhookDeliveryExists), errors.Is(err, secondary.ErrWebhookNotFound):
+			// seen before, or unsubscribed since it was listed
+		case err != nil:
+			return fmt.Errorf("record webhook delivery: %w", err)
+		default:
+			recorded = true
+		}
+	}
+
+	if recorded {
+		d.notify()
+	}
+	return nil
+}
+
+func (d *WebhookDispatcher) payload(eventID string, event shared.Event) (json.RawMessage, error) {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event: %w", err)
+	}
+
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(eventJSON, &data); err != nil {
+		return nil, fmt.Errorf("unmarshal event: %w", err)
+	}
+	// the envelope already carries these
+	for _, field := range []string{"aggregate_id", "aggregate_type", "event_type", "version", "timestamp"} {
+		delete(data, field)
+	}
+	for _, field := range d.registry.PersonalDataFields(shared.EventType(event.GetEventType())) {
+		delete(data, field)
+	}
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal webhook data: %w", err)
+	}
+
+	return json.Marshal(webhookPayload{
+		ID:          eventID,
+		Type:        event.GetEventType(),
+		AggregateID: event.GetAggregateID(),
+		Version:     event.GetVersion(),
+		Timestamp:   event.GetTimestamp(),
+		Data:        dataJSON,
+	})
+}
+
+func (d *WebhookDispatcher) Start(ctx context.Context) error {
+	go d.run(ctx)
+	return nil
+}
+
+func (d *WebhookDispatcher) Stop() error {
+	close(d.stop)
+	<-d.done
+	return nil
+}
+
+func (d *WebhookDispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *WebhookDispatcher) run(ctx context.Context) {
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(ctx)
+
+		select {
+		case <-d.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// deliverDue attempts one batch of due deliveries, and asks for another
+// round straight away when the batch was full.
+func (d *WebhookDispatcher) deliverDue(ctx context.Context) {
+	due, err := d.repo.DueDeliveries(ctx, d.now().UTC(), d.config.BatchSize)
+	if err != nil {
+		log.Printf("error loading due webhook deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range due {
+		if err := d.attempt(ctx, delivery); err != nil {
+			log.Printf("error delivering webhook %s: %v", delivery.ID, err)
+		}
+	}
+
+	if len(due) == d.config.BatchSize {
+		d.notify()
+	}
+}
+
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery types.WebhookDelivery) error {
+	subscription, err := d.repo.GetSubscription(ctx, delivery.SubscriptionID)
+	if errors.Is(err, secondary.ErrWebhookNotFound) {
+		// its deliveries went with it
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get webhook subscription: %w", err)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	status, sendErr := d.sender.Send(attemptCtx, *subscription, delivery)
+	cancel()
+
+	now := d.now().UTC()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.UpdatedAt = now
+	switch {
+	case sendErr == nil:
+		delivery.Status = types.WebhookDeliverySucceeded
+		delivery.LastError = ""
+	case delivery.Attempts >= d.config.MaxAttempts:
+		delivery.Status = types.WebhookDeliveryFailed
+		delivery.LastError = sendErr.Error()
+	default:
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	}
+
+	if err := d.repo.UpdateDelivery(ctx, delivery); err != nil && !errors.Is(err, secondary.ErrWebhookDeliveryNotFound) {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// backoff is the wait after the given failed attempt.
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.BaseDelay
+	for i := 1; i < attempts && delay < d.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, d.config.MaxDelay)
+}
+
+func subscribedTo(subscription types.WebhookSubscription, eventType string) bool {
+	if len(subscription.EventTypes) == 0 {
+		return true
+	}
+	for _, subscribed := range subscription.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
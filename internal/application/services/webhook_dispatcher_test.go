@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/adapters/secondary/persistence/memory"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+	"github.com/ncfex/dcart-auth/internal/domain/user"
+)
+
+type fakeWebhookSender struct {
+	err  error
+	sent []types.WebhookDelivery
+}
+
+func (s *fakeWebhookSender) Send(_ context.Context, _ types.WebhookSubscription, delivery types.WebhookDelivery) (int, error) {
+	s.sent = append(s.sent, delivery)
+	if s.err != nil {
+		return 500, s.err
+	}
+	return 204, nil
+}
+
+func newTestDispatcher(t *testing.T, sender secondary.WebhookSender, subscriptions ...types.WebhookSubscription) (*WebhookDispatcher, secondary.WebhookRepository, *time.Time) {
+	t.Helper()
+
+	registry := shared.NewEventRegistry()
+	user.RegisterEvents(registry)
+
+	repo := memory.NewWebhookRepository()
+	for _, subscription := range subscriptions {
+		if err := repo.CreateSubscription(context.Background(), subscription); err != nil {
+			t.Fatalf("CreateSubscription() error = %v", err)
+		}
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	dispatcher := NewWebhookDispatcher(repo, sender, registry, WebhookDispatcherConfig{
+		PollInterval: time.Minute,
+		BatchSize:    10,
+		MaxAttempts:  3,
+		BaseDelay:    time.Second,
+		MaxDelay:     90 * time.Second,
+		Timeout:      time.Second,
+	})
+	dispatcher.now = func() time.Time { return now }
+	return dispatcher, repo, &now
+}
+
+func TestWebhookDispatcher_ProjectEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventTypes []string
+		event      shared.Event
+		expected   int
+	}{
+		{
+			name:     "no filter receives everything",
+			event:    user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+			expected: 1,
+		},
+		{
+			name:       "matching filter",
+			eventTypes: []string{string(user.EventTypeUserRegistered)},
+			event:      user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+			expected:   1,
+		},
+		{
+			name:       "other event types are skipped",
+			eventTypes: []string{string(user.EventTypeUserPasswordChanged)},
+			event:      user.NewUserRegisteredEvent("user-1", "alice", "hash"),
+			expected:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dispatcher, repo, _ := newTestDispatcher(t, &fakeWebhookSender{}, types.WebhookSubscription{
+				ID:         "sub-1",
+				URL:        "https://partner.example/hooks",
+				EventTypes: tt.eventTypes,
+			})
+
+			if err := dispatcher.ProjectEvent(ctx, tt.event); err != nil {
+				t.Fatalf("ProjectEvent() error = %v", err)
+			}
+			// a redelivered event must not be sent twice
+			if err := dispatcher.ProjectEvent(ctx, tt.event); err != nil {
+				t.Fatalf("ProjectEvent() error = %v", err)
+			}
+
+			deliveries, err := repo.ListDeliveries(ctx, "sub-1", "", 0)
+			if err != nil {
+				t.Fatalf("ListDeliveries() error = %v", err)
+			}
+			if len(deliveries) != tt.expected {
+				t.Fatalf("got %d deliveries, expected %d", len(deliveries), tt.expected)
+			}
+		})
+	}
+}
+
+func TestWebhookDispatcher_PayloadOmitsPersonalData(t *testing.T) {
+	ctx := context.Background()
+	dispatcher, repo, _ := newTestDispatcher(t, &fakeWebhookSender{}, types.WebhookSubscription{ID: "sub-1"})
+
+	if err := dispatcher.ProjectEvent(ctx, user.NewUserRegisteredEvent("user-1", "alice", "hash")); err != nil {
+		t.Fatalf("ProjectEvent() error = %v", err)
+	}
+
+	delivery, err := repo.GetDelivery(ctx, "sub-1-user-1-1")
+	if err != nil {
+		t.Fatalf("GetDelivery() error = %v", err)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(delivery.Payload, &payload); err != nil {
+		t.Fatalf("payload is not json: %v", err)
+	}
+	if payload.ID != "user-1-1" || payload.Type != string(user.EventTypeUserRegistered) || payload.AggregateID != "user-1" {
+		t.Errorf("unexpected envelope: %+v", payload)
+	}
+	if string(payload.Data) != "{}" {
+		t.Errorf("data = %s, expected personal data to be left out", payload.Data)
+	}
+}
+
+func TestWebhookDispatcher_Attempt(t *testing.T) {
+	tests := []struct {
+		name            string
+		sendErr         error
+		attempts        int
+		expectedStatus  string
+		expectedBackoff time.Duration
+	}{
+		{
+			name:           "success",
+			expectedStatus: types.WebhookDeliverySucceeded,
+		},
+		{
+			name:            "first failure backs off",
+			sendErr:         errors.New("boom"),
+			expectedStatus:  types.WebhookDeliveryPending,
+			expectedBackoff: time.Second,
+		},
+		{
+			name:            "backoff doubles",
+			sendErr:         errors.New("boom"),
+			attempts:        1,
+			expectedStatus:  types.WebhookDeliveryPending,
+			expectedBackoff: 2 * time.Second,
+		},
+		{
+			name:           "last attempt fails the delivery",
+			sendErr:        errors.New("boom"),
+			attempts:       2,
+			expectedStatus: types.WebhookDeliveryFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			sender := &fakeWebhookSender{err: tt.sendErr}
+			dispatcher, repo, now := newTestDispatcher(t, sender, types.WebhookSubscription{ID: "sub-1"})
+
+			delivery := types.WebhookDelivery{
+				ID:             "delivery-1",
+				SubscriptionID: "sub-1",
+				Status:         types.WebhookDeliveryPending,
+				Attempts:       tt.attempts,
+				NextAttemptAt:  *now,
+			}
+			if err := repo.CreateDelivery(ctx, delivery); err != nil {
+				t.Fatalf("CreateDelivery() error = %v", err)
+			}
+
+			dispatcher.deliverDue(ctx)
+
+			stored, err := repo.GetDelivery(ctx, "delivery-1")
+			if err != nil {
+				t.Fatalf("GetDelivery() error = %v", err)
+			}
+			if len(sender.sent) != 1 {
+				t.Fatalf("sent %d times, expected once", len(sender.sent))
+			}
+			if stored.Status != tt.expectedStatus {
+				t.Errorf("status = %s, expected %s", stored.Status, tt.expectedStatus)
+			}
+			if stored.Attempts != tt.attempts+1 {
+				t.Errorf("attempts = %d, expected %d", stored.Attempts, tt.attempts+1)
+			}
+			if tt.expectedBackoff > 0 && !stored.NextAttemptAt.Equal(now.Add(tt.expectedBackoff)) {
+				t.Errorf("next attempt at %v, expected %v", stored.NextAttemptAt, now.Add(tt.expectedBackoff))
+			}
+			if tt.sendErr != nil && stored.LastError == "" {
+				t.Error("expected the error to be recorded")
+			}
+		})
+	}
+}
+
+func TestWebhookDispatcher_Backoff(t *testing.T) {
+	dispatcher, _, _ := newTestDispatcher(t, &fakeWebhookSender{})
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	for i, delay := range expected {
+		if got := dispatcher.backoff(i + 1); got != delay {
+			t.Errorf("backoff(%d) = %v, expected %v", i+1, got, delay)
+		}
+	}
+	if got := dispatcher.backoff(30); got != 90*time.Second {
+		t.Errorf("backoff(30) = %v, expected the 90s cap", got)
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/application/ports/id"
+	"github.com/ncfex/dcart-auth/internal/application/ports/primary/services"
+	"github.com/ncfex/dcart-auth/internal/application/ports/secondary"
+	"github.com/ncfex/dcart-auth/internal/application/ports/security"
+	"github.com/ncfex/dcart-auth/internal/application/ports/types"
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+type webhookService struct {
+	repo      secondary.WebhookRepository
+	registry  shared.EventRegistry
+	idGen     id.UniqueIDGenerator
+	secretGen security.TokenGenerator
+	now       func() time.Time
+}
+
+func NewWebhookService(
+	repo secondary.WebhookRepository,
+	registry shared.EventRegistry,
+	idGen id.UniqueIDGenerator,
+	secretGen security.TokenGenerator,
+) services.WebhookService {
+	return &webhookService{
+		repo:      repo,
+		registry:  registry,
+		idGen:     idGen,
+		secretGen: secretGen,
+		now:       time.Now,
+	}
+}
+
+// CreateSubscription registers an endpoint. The generated secret is only
+// returned here; receivers need it to verify signatures.
+func (s *webhookService) CreateSubscription(ctx context.Context, req types.CreateWebhookRequest) (*types.WebhookSubscription, error) {
+	endpoint, err := url.Parse(req.URL)
+	if err != nil || !endpoint.IsAbs() || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https url", services.ErrInvalidWebhook)
+	}
+	for _, eventType := range req.EventTypes {
+		if _, ok := s.registry.CreateEvent(shared.EventType(eventType)); !ok {
+			return nil, fmt.Errorf("%w: unknown event type %q", services.ErrInvalidWebhook, eventType)
+		}
+	}
+
+	secret, err := s.secretGen.Generate("")
+	if err != nil {
+		return nil, fmt.Errorf("generate webhook secret: %w", err)
+	}
+
+	subscription := types.WebhookSubscription{
+		ID:         s.idGen.Generate(),
+		URL:        endpoint.String(),
+		EventTypes: append([]string{}, req.EventTypes...),
+		Secret:     secret,
+		CreatedAt:  s.now().UTC(),
+	}
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("create webhook subscription: %w", err)
+	}
+	return &subscription, nil
+}
+
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]types.WebhookSubscription, error) {
+	subscriptions, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, id string) error {
+	if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]types.WebhookDelivery, error) {
+	switch status {
+	case "", types.WebhookDeliveryPending, types.WebhookDeliverySucceeded, types.WebhookDeliveryFailed:
+	default:
+		return nil, fmt.Errorf("%w: unknown delivery status %q", services.ErrInvalidWebhook, status)
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	limit = min(limit, maxDeliveryLimit)
+
+	if _, err := s.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, fmt.Errorf("get webhook subscription: %w", err)
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, subscriptionID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues a delivery again with a fresh attempt budget, whatever
+// its current status.
+func (s *webhookService) Redeliver(ctx context.Context, deliveryID string) (*types.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook delivery: %w", err)
+	}
+
+	now := s.now().UTC()
+	delivery.Status = types.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+	if err := s.repo.UpdateDelivery(ctx, *delivery); err != nil {
+		return nil, fmt.Errorf("update webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
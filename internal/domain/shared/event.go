@@ -2,12 +2,30 @@ package shared
 
 import "time"
 
+// Metadata describes the circumstances an event was recorded in. It is stored
+// and transported next to the payload rather than inside it.
+type Metadata map[string]string
+
+const (
+	MetadataCorrelationID = "correlation_id"
+	MetadataCausationID   = "causation_id"
+	MetadataActorID       = "actor_id"
+	MetadataClientIP      = "client_ip"
+	MetadataUserAgent     = "user_agent"
+)
+
+// PersonalMetadata lists the metadata keys holding personal data, which the
+// event store keeps encrypted like the personal fields of a payload.
+var PersonalMetadata = []string{MetadataClientIP, MetadataUserAgent}
+
 type Event interface {
 	GetAggregateID() string
 	GetAggregateType() string
 	GetEventType() string
 	GetVersion() int
 	GetTimestamp() time.Time
+	GetMetadata() Metadata
+	SetMetadata(metadata Metadata)
 }
 
 type BaseEvent struct {
@@ -16,6 +34,7 @@ type BaseEvent struct {
 	EventType     string    `json:"event_type"`
 	Version       int       `json:"version"`
 	Timestamp     time.Time `json:"timestamp"`
+	Metadata      Metadata  `json:"-"`
 }
 
 func (e BaseEvent) GetAggregateID() string   { return e.AggregateID }
@@ -23,3 +42,6 @@ func (e BaseEvent) GetAggregateType() string { return e.AggregateType }
 func (e BaseEvent) GetEventType() string     { return e.EventType }
 func (e BaseEvent) GetVersion() int          { return e.Version }
 func (e BaseEvent) GetTimestamp() time.Time  { return e.Timestamp }
+func (e BaseEvent) GetMetadata() Metadata    { return e.Metadata }
+
+func (e *BaseEvent) SetMetadata(metadata Metadata) { e.Metadata = metadata }
@@ -1,19 +1,30 @@
 package shared
 
+import "encoding/json"
+
 type EventType string
 
 type EventRegistry interface {
 	CreateEvent(eventType EventType) (Event, bool)
 	RegisterEvent(eventType EventType, factory func() Event)
+	RegisterUpcaster(upcaster Upcaster)
+	SchemaVersion(eventType EventType) int
+	Upcast(eventType EventType, schemaVersion int, payload json.RawMessage) (json.RawMessage, error)
+	RegisterPersonalData(eventType EventType, fields ...string)
+	PersonalDataFields(eventType EventType) []string
 }
 
 type eventRegistry struct {
-	factories map[EventType]func() Event
+	factories    map[EventType]func() Event
+	upcasters    *upcasterChain
+	personalData map[EventType][]string
 }
 
 func NewEventRegistry() EventRegistry {
 	return &eventRegistry{
-		factories: make(map[EventType]func() Event),
+		factories:    make(map[EventType]func() Event),
+		upcasters:    newUpcasterChain(),
+		personalData: make(map[EventType][]string),
 	}
 }
 
@@ -28,3 +39,30 @@ func (r *eventRegistry) CreateEvent(eventType EventType) (Event, bool) {
 func (r *eventRegistry) RegisterEvent(eventType EventType, factory func() Event) {
 	r.factories[eventType] = factory
 }
+
+func (r *eventRegistry) RegisterUpcaster(upcaster Upcaster) {
+	r.upcasters.register(upcaster)
+}
+
+// SchemaVersion returns the current payload schema version of eventType.
+func (r *eventRegistry) SchemaVersion(eventType EventType) int {
+	return r.upcasters.schemaVersion(eventType)
+}
+
+// Upcast brings a payload written at schemaVersion up to the current shape.
+func (r *eventRegistry) Upcast(eventType EventType, schemaVersion int, payload json.RawMessage) (json.RawMessage, error) {
+	return r.upcasters.upcast(eventType, schemaVersion, payload)
+}
+
+// RegisterPersonalData marks top level payload fields of eventType as
+// personal data, which the event store keeps encrypted under a per-aggregate
+// key so erasing the key shreds them.
+func (r *eventRegistry) RegisterPersonalData(eventType EventType, fields ...string) {
+	r.personalData[eventType] = append(r.personalData[eventType], fields...)
+}
+
+// PersonalDataFields returns the payload fields of eventType holding personal
+// data.
+func (r *eventRegistry) PersonalDataFields(eventType EventType) []string {
+	return r.personalData[eventType]
+}
@@ -0,0 +1,29 @@
+package shared
+
+// Reservation claims Value within Scope for a single aggregate, the way a
+// username belongs to exactly one user. An aggregate holds at most one value
+// per scope.
+type Reservation struct {
+	Scope string
+	Value string
+}
+
+// Reserver is implemented by events that change what their aggregate has
+// reserved. Each reservation replaces the value the aggregate held in that
+// scope, and one with an empty Value releases it. Event stores apply them in
+// the transaction that appends the event, so two aggregates can never both
+// commit the same value.
+type Reserver interface {
+	Reservations() []Reservation
+}
+
+// ReservationsOf collects the reservations declared by events, in order.
+func ReservationsOf(events []Event) []Reservation {
+	var reservations []Reservation
+	for _, event := range events {
+		if reserver, ok := event.(Reserver); ok {
+			reservations = append(reservations, reserver.Reservations()...)
+		}
+	}
+	return reservations
+}
@@ -0,0 +1,19 @@
+package shared
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Snapshot is the state of an aggregate as of Version, so loading it only
+// replays the events recorded since. Type names the shape of State the way
+// an event type names a payload, and its personal data fields are registered
+// and encrypted the same way.
+type Snapshot struct {
+	AggregateID   string
+	AggregateType string
+	Type          EventType
+	Version       int
+	State         json.RawMessage
+	Timestamp     time.Time
+}
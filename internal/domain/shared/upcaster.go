@@ -0,0 +1,85 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const InitialSchemaVersion = 1
+
+var (
+	ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
+)
+
+// Upcaster transforms a stored payload of EventType from FromVersion to
+// FromVersion+1. Payloads are handled as decoded JSON objects so an upcaster
+// can rename, move or derive fields without knowing the old Go struct.
+type Upcaster struct {
+	EventType   EventType
+	FromVersion int
+	Upcast      func(payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+type upcasterChain struct {
+	upcasters map[EventType]map[int]Upcaster
+}
+
+func newUpcasterChain() *upcasterChain {
+	return &upcasterChain{
+		upcasters: make(map[EventType]map[int]Upcaster),
+	}
+}
+
+func (c *upcasterChain) register(upcaster Upcaster) {
+	steps, exists := c.upcasters[upcaster.EventType]
+	if !exists {
+		steps = make(map[int]Upcaster)
+		c.upcasters[upcaster.EventType] = steps
+	}
+	steps[upcaster.FromVersion] = upcaster
+}
+
+// schemaVersion is the version produced by the last contiguous upcaster
+// starting at InitialSchemaVersion.
+func (c *upcasterChain) schemaVersion(eventType EventType) int {
+	version := InitialSchemaVersion
+	steps := c.upcasters[eventType]
+	for {
+		if _, exists := steps[version]; !exists {
+			return version
+		}
+		version++
+	}
+}
+
+func (c *upcasterChain) upcast(eventType EventType, schemaVersion int, payload json.RawMessage) (json.RawMessage, error) {
+	if schemaVersion < InitialSchemaVersion {
+		schemaVersion = InitialSchemaVersion
+	}
+
+	currentVersion := c.schemaVersion(eventType)
+	if schemaVersion == currentVersion {
+		return payload, nil
+	}
+	if schemaVersion > currentVersion {
+		return nil, fmt.Errorf("%w: %s v%d (current v%d)",
+			ErrUnsupportedSchemaVersion, eventType, schemaVersion, currentVersion)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(payload, &document); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	steps := c.upcasters[eventType]
+	for version := schemaVersion; version < currentVersion; version++ {
+		upcasted, err := steps[version].Upcast(document)
+		if err != nil {
+			return nil, fmt.Errorf("upcast %s v%d: %w", eventType, version, err)
+		}
+		document = upcasted
+	}
+
+	return json.Marshal(document)
+}
@@ -0,0 +1,202 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+const testEventType EventType = "test.renamed"
+
+func renameField(from, to string) func(map[string]interface{}) (map[string]interface{}, error) {
+	return func(payload map[string]interface{}) (map[string]interface{}, error) {
+		value, exists := payload[from]
+		if !exists {
+			return nil, fmt.Errorf("missing field %q", from)
+		}
+		delete(payload, from)
+		payload[to] = value
+		return payload, nil
+	}
+}
+
+func newTestRegistry() EventRegistry {
+	registry := NewEventRegistry()
+	registry.RegisterUpcaster(Upcaster{
+		EventType:   testEventType,
+		FromVersion: 1,
+		Upcast:      renameField("name", "user_name"),
+	})
+	registry.RegisterUpcaster(Upcaster{
+		EventType:   testEventType,
+		FromVersion: 2,
+		Upcast:      renameField("user_name", "username"),
+	})
+	return registry
+}
+
+func TestEventRegistry_SchemaVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		upcasters       []Upcaster
+		eventType       EventType
+		expectedVersion int
+	}{
+		{
+			name:            "no upcasters",
+			eventType:       testEventType,
+			expectedVersion: 1,
+		},
+		{
+			name: "contiguous chain",
+			upcasters: []Upcaster{
+				{EventType: testEventType, FromVersion: 1},
+				{EventType: testEventType, FromVersion: 2},
+			},
+			eventType:       testEventType,
+			expectedVersion: 3,
+		},
+		{
+			name: "registered out of order",
+			upcasters: []Upcaster{
+				{EventType: testEventType, FromVersion: 2},
+				{EventType: testEventType, FromVersion: 1},
+			},
+			eventType:       testEventType,
+			expectedVersion: 3,
+		},
+		{
+			name: "chain with gap stops at gap",
+			upcasters: []Upcaster{
+				{EventType: testEventType, FromVersion: 1},
+				{EventType: testEventType, FromVersion: 3},
+			},
+			eventType:       testEventType,
+			expectedVersion: 2,
+		},
+		{
+			name: "other event type",
+			upcasters: []Upcaster{
+				{EventType: "test.other", FromVersion: 1},
+			},
+			eventType:       testEventType,
+			expectedVersion: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewEventRegistry()
+			for _, upcaster := range tt.upcasters {
+				registry.RegisterUpcaster(upcaster)
+			}
+
+			if version := registry.SchemaVersion(tt.eventType); version != tt.expectedVersion {
+				t.Errorf("SchemaVersion() = %v, expected %v", version, tt.expectedVersion)
+			}
+		})
+	}
+}
+
+func TestEventRegistry_Upcast(t *testing.T) {
+	tests := []struct {
+		name            string
+		eventType       EventType
+		schemaVersion   int
+		payload         string
+		expectedPayload string
+		expectedError   error
+	}{
+		{
+			name:            "from first version",
+			eventType:       testEventType,
+			schemaVersion:   1,
+			payload:         `{"aggregate_id":"a-1","name":"alice"}`,
+			expectedPayload: `{"aggregate_id":"a-1","username":"alice"}`,
+		},
+		{
+			name:            "from intermediate version",
+			eventType:       testEventType,
+			schemaVersion:   2,
+			payload:         `{"aggregate_id":"a-1","user_name":"alice"}`,
+			expectedPayload: `{"aggregate_id":"a-1","username":"alice"}`,
+		},
+		{
+			name:            "already current",
+			eventType:       testEventType,
+			schemaVersion:   3,
+			payload:         `{"aggregate_id":"a-1","username":"alice"}`,
+			expectedPayload: `{"aggregate_id":"a-1","username":"alice"}`,
+		},
+		{
+			name:            "unversioned payload treated as first version",
+			eventType:       testEventType,
+			schemaVersion:   0,
+			payload:         `{"aggregate_id":"a-1","name":"alice"}`,
+			expectedPayload: `{"aggregate_id":"a-1","username":"alice"}`,
+		},
+		{
+			name:            "event type without upcasters",
+			eventType:       "test.unchanged",
+			schemaVersion:   1,
+			payload:         `{"aggregate_id":"a-1"}`,
+			expectedPayload: `{"aggregate_id":"a-1"}`,
+		},
+		{
+			name:          "newer than current",
+			eventType:     testEventType,
+			schemaVersion: 4,
+			payload:       `{"aggregate_id":"a-1"}`,
+			expectedError: ErrUnsupportedSchemaVersion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := newTestRegistry()
+
+			payload, err := registry.Upcast(tt.eventType, tt.schemaVersion, json.RawMessage(tt.payload))
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("Upcast() error = %v, expected error %v", err, tt.expectedError)
+				return
+			}
+
+			if err == nil {
+				assertJSONEqual(t, tt.expectedPayload, payload)
+			}
+		})
+	}
+}
+
+func TestEventRegistry_UpcastFailure(t *testing.T) {
+	registry := newTestRegistry()
+
+	_, err := registry.Upcast(testEventType, 1, json.RawMessage(`{"aggregate_id":"a-1"}`))
+	if err == nil {
+		t.Error("Upcast() expected error for payload missing the renamed field")
+	}
+
+	_, err = registry.Upcast(testEventType, 1, json.RawMessage(`not json`))
+	if err == nil {
+		t.Error("Upcast() expected error for malformed payload")
+	}
+}
+
+func assertJSONEqual(t *testing.T, expected string, actual json.RawMessage) {
+	t.Helper()
+
+	var expectedDoc, actualDoc interface{}
+	if err := json.Unmarshal([]byte(expected), &expectedDoc); err != nil {
+		t.Fatalf("Failed to decode expected payload: %v", err)
+	}
+	if err := json.Unmarshal(actual, &actualDoc); err != nil {
+		t.Fatalf("Failed to decode actual payload: %v", err)
+	}
+
+	expectedJSON, _ := json.Marshal(expectedDoc)
+	actualJSON, _ := json.Marshal(actualDoc)
+	if string(expectedJSON) != string(actualJSON) {
+		t.Errorf("payload = %s, expected %s", actualJSON, expectedJSON)
+	}
+}
@@ -0,0 +1,58 @@
+package shared
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrValidation = errors.New("validation failed")
+
+// FieldError explains why the value of one input field was rejected. Code is
+// stable for clients to act on, Message is meant for people.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// ValidationError lists every problem found with an input. It matches
+// ErrValidation with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// JoinValidationErrors merges the fields of every *ValidationError in errs so
+// that one answer reports the problems with all inputs. Nil errors are
+// skipped; any other error is returned as it is.
+func JoinValidationErrors(errs ...error) error {
+	var joined *ValidationError
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			return err
+		}
+		if joined == nil {
+			joined = &ValidationError{}
+		}
+		joined.Fields = append(joined.Fields, validationErr.Fields...)
+	}
+	if joined == nil {
+		return nil
+	}
+	return joined
+}
@@ -0,0 +1,49 @@
+package user
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+const maxEmailLength = 254
+
+// EmailReservationScope is the scope email addresses are reserved in, so no
+// two users share one.
+const EmailReservationScope = "email"
+
+// codes reported in the FieldError of a rejected email address
+const (
+	EmailRequired      = "required"
+	EmailTooLong       = "too_long"
+	EmailInvalidFormat = "invalid_format"
+)
+
+// Email is an address in canonical form, trimmed and lower-cased, so lookups
+// and prefix searches need not care how it was typed.
+type Email string
+
+// ParseEmail returns the canonical form of raw, or a *shared.ValidationError
+// when raw is not a single bare address.
+func ParseEmail(raw string) (Email, error) {
+	email := strings.ToLower(strings.TrimSpace(raw))
+
+	reject := func(code, message string) error {
+		return &shared.ValidationError{Fields: []shared.FieldError{{Field: "email", Code: code, Message: message}}}
+	}
+	switch {
+	case email == "":
+		return "", reject(EmailRequired, "email is required")
+	case len(email) > maxEmailLength:
+		return "", reject(EmailTooLong, fmt.Sprintf("email is longer than %d bytes", maxEmailLength))
+	}
+
+	// a display name or angle brackets would parse, but are not an address
+	parsed, err := mail.ParseAddress(email)
+	if err != nil || parsed.Name != "" || parsed.Address != email {
+		return "", reject(EmailInvalidFormat, "email must be a plain address such as alice@example.com")
+	}
+	return Email(email), nil
+}
@@ -0,0 +1,83 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+func TestParseEmail(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		expected     Email
+		expectedCode string
+	}{
+		{
+			name:     "plain address",
+			raw:      "alice@example.com",
+			expected: "alice@example.com",
+		},
+		{
+			name:     "case and whitespace are dropped",
+			raw:      "  Alice.Smith@Example.COM ",
+			expected: "alice.smith@example.com",
+		},
+		{
+			name:         "empty address",
+			raw:          "  ",
+			expectedCode: EmailRequired,
+		},
+		{
+			name:         "too long",
+			raw:          strings.Repeat("a", maxEmailLength) + "@example.com",
+			expectedCode: EmailTooLong,
+		},
+		{
+			name:         "missing domain",
+			raw:          "alice",
+			expectedCode: EmailInvalidFormat,
+		},
+		{
+			name:         "display name",
+			raw:          "Alice <alice@example.com>",
+			expectedCode: EmailInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, err := ParseEmail(tt.raw)
+			if tt.expectedCode == "" {
+				if err != nil {
+					t.Fatalf("ParseEmail() error = %v, expected nil", err)
+				}
+				if email != tt.expected {
+					t.Errorf("ParseEmail() = %q, expected %q", email, tt.expected)
+				}
+				return
+			}
+
+			var validationErr *shared.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ParseEmail() error = %v, expected a *shared.ValidationError", err)
+			}
+			if len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "email" || validationErr.Fields[0].Code != tt.expectedCode {
+				t.Errorf("ParseEmail() fields = %v, expected email %s", validationErr.Fields, tt.expectedCode)
+			}
+		})
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	for raw, expected := range map[string]Role{"user": RoleUser, " Support ": RoleSupport, "ADMIN": RoleAdmin} {
+		if role, err := ParseRole(raw); err != nil || role != expected {
+			t.Errorf("ParseRole(%q) = (%s, %v), expected %s", raw, role, err, expected)
+		}
+	}
+	if _, err := ParseRole("owner"); !errors.Is(err, ErrInvalidRole) {
+		t.Errorf("ParseRole(owner) error = %v, expected %v", err, ErrInvalidRole)
+	}
+}
@@ -26,6 +26,10 @@ func NewUserRegisteredEvent(aggregateID string, username, passwordHash string) *
 	}
 }
 
+func (e *UserRegisteredEvent) Reservations() []shared.Reservation {
+	return []shared.Reservation{{Scope: UsernameReservationScope, Value: string(NormalizeUsername(e.Username))}}
+}
+
 type UserPasswordChangedEvent struct {
 	shared.BaseEvent
 	NewPasswordHash string `json:"new_password_hash"`
@@ -43,3 +47,241 @@ func NewUserPasswordChangedEvent(aggregateID string, newPasswordHash string, ver
 		NewPasswordHash: newPasswordHash,
 	}
 }
+
+// UserPasswordRehashedEvent replaces a hash made with an outdated algorithm
+// or parameters by one of the same password; the password itself is
+// unchanged.
+type UserPasswordRehashedEvent struct {
+	shared.BaseEvent
+	NewPasswordHash string `json:"new_password_hash"`
+}
+
+func NewUserPasswordRehashedEvent(aggregateID string, newPasswordHash string, version int) *UserPasswordRehashedEvent {
+	return &UserPasswordRehashedEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserPasswordRehashed),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+		NewPasswordHash: newPasswordHash,
+	}
+}
+
+// UserUsernameChangedEvent renames the user; the id, and with it every
+// reference to the user, stays the same.
+type UserUsernameChangedEvent struct {
+	shared.BaseEvent
+	Username string `json:"username"`
+}
+
+func NewUserUsernameChangedEvent(aggregateID, username string, version int) *UserUsernameChangedEvent {
+	return &UserUsernameChangedEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserUsernameChanged),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+		Username: username,
+	}
+}
+
+// Reservations moves the user's reservation to the new username, freeing
+// the old one in the same step.
+func (e *UserUsernameChangedEvent) Reservations() []shared.Reservation {
+	return []shared.Reservation{{Scope: UsernameReservationScope, Value: string(NormalizeUsername(e.Username))}}
+}
+
+// UserErasedEvent carries no personal data; it records that the user's data
+// key was destroyed and the rest of the stream is no longer readable.
+type UserErasedEvent struct {
+	shared.BaseEvent
+}
+
+func NewUserErasedEvent(aggregateID string, version int) *UserErasedEvent {
+	return &UserErasedEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserErased),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+	}
+}
+
+// Reservations releases the username and email address, so they can be
+// registered again and are not kept around once the rest of the user's data
+// is gone.
+func (e *UserErasedEvent) Reservations() []shared.Reservation {
+	return []shared.Reservation{{Scope: UsernameReservationScope}, {Scope: EmailReservationScope}}
+}
+
+// UserEmailChangedEvent sets the user's email address, which must come from
+// ParseEmail.
+type UserEmailChangedEvent struct {
+	shared.BaseEvent
+	Email string `json:"email"`
+}
+
+func NewUserEmailChangedEvent(aggregateID, email string, version int) *UserEmailChangedEvent {
+	return &UserEmailChangedEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserEmailChanged),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+		Email: email,
+	}
+}
+
+// Reservations moves the user's reservation to the new address.
+func (e *UserEmailChangedEvent) Reservations() []shared.Reservation {
+	return []shared.Reservation{{Scope: EmailReservationScope, Value: e.Email}}
+}
+
+// UserLockedEvent records that an admin stopped the user from signing in.
+type UserLockedEvent struct {
+	shared.BaseEvent
+}
+
+func NewUserLockedEvent(aggregateID string, version int) *UserLockedEvent {
+	return &UserLockedEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserLocked),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+	}
+}
+
+// UserUnlockedEvent lets a locked user sign in again.
+type UserUnlockedEvent struct {
+	shared.BaseEvent
+}
+
+func NewUserUnlockedEvent(aggregateID string, version int) *UserUnlockedEvent {
+	return &UserUnlockedEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserUnlocked),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+	}
+}
+
+// UserRoleAssignedEvent replaces the user's role.
+type UserRoleAssignedEvent struct {
+	shared.BaseEvent
+	Role string `json:"role"`
+}
+
+func NewUserRoleAssignedEvent(aggregateID string, role Role, version int) *UserRoleAssignedEvent {
+	return &UserRoleAssignedEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserRoleAssigned),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+		Role: string(role),
+	}
+}
+
+// reasons recorded on a failed sign-in
+const (
+	// LoginFailureInvalidPassword is recorded when the password did not match
+	LoginFailureInvalidPassword = "invalid_password"
+	// LoginFailureLocked is recorded when the password matched but the user
+	// is locked
+	LoginFailureLocked = "locked"
+)
+
+// UserLoggedInEvent records a successful sign-in and where it came from.
+type UserLoggedInEvent struct {
+	shared.BaseEvent
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+}
+
+func NewUserLoggedInEvent(aggregateID, ipAddress, userAgent string, version int) *UserLoggedInEvent {
+	return &UserLoggedInEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserLoggedIn),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+}
+
+// UserLoginFailedEvent records a rejected sign-in for an existing user.
+type UserLoginFailedEvent struct {
+	shared.BaseEvent
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+	Reason    string `json:"reason"`
+}
+
+func NewUserLoginFailedEvent(aggregateID, ipAddress, userAgent, reason string, version int) *UserLoginFailedEvent {
+	return &UserLoginFailedEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserLoginFailed),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		Reason:    reason,
+	}
+}
+
+// UserProfileUpdatedEvent carries the whole profile as it is after the
+// update, so projections never need to merge partial changes.
+type UserProfileUpdatedEvent struct {
+	shared.BaseEvent
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+	Locale      string `json:"locale"`
+	Timezone    string `json:"timezone"`
+}
+
+func NewUserProfileUpdatedEvent(aggregateID string, profile Profile, version int) *UserProfileUpdatedEvent {
+	return &UserProfileUpdatedEvent{
+		BaseEvent: shared.BaseEvent{
+			AggregateID:   aggregateID,
+			AggregateType: "USER",
+			EventType:     string(EventTypeUserProfileUpdated),
+			Version:       version,
+			Timestamp:     time.Now(),
+		},
+		DisplayName: profile.DisplayName,
+		AvatarURL:   profile.AvatarURL,
+		Locale:      profile.Locale,
+		Timezone:    profile.Timezone,
+	}
+}
+
+func (e *UserProfileUpdatedEvent) Profile() Profile {
+	return Profile{
+		DisplayName: e.DisplayName,
+		AvatarURL:   e.AvatarURL,
+		Locale:      e.Locale,
+		Timezone:    e.Timezone,
+	}
+}
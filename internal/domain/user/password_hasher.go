@@ -0,0 +1,211 @@
+package user
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidPasswordHasher = errors.New("invalid password hasher")
+
+// PasswordHasher turns passwords into self-describing hashes and checks
+// passwords against them.
+type PasswordHasher interface {
+	Hash(password Password) (string, error)
+	// Verify reports whether password matches hash, and for a match whether
+	// hash was made with an outdated algorithm or parameters and should be
+	// replaced.
+	Verify(password Password, hash string) (matches, outdated bool)
+}
+
+// Argon2idParams are the cost settings of new argon2id hashes. Memory is in
+// KiB, lengths are in bytes.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams are the second recommended option of RFC 9106, for
+// hosts that cannot spare 2 GiB per hash.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// argon2idHasher writes argon2id hashes in the PHC string format and still
+// verifies the bcrypt hashes written before it. When a pepper is set the
+// password is keyed with HMAC-SHA256 before hashing, and the hash records a
+// fingerprint of the pepper as its keyid.
+type argon2idHasher struct {
+	params   Argon2idParams
+	pepper   []byte
+	pepperID string
+}
+
+// NewArgon2idHasher returns the default PasswordHasher; pepper may be empty.
+// Hashes made without a pepper, or with a different cost, count as outdated.
+func NewArgon2idHasher(params Argon2idParams, pepper []byte) (PasswordHasher, error) {
+	switch {
+	case params.Iterations < 1:
+		return nil, fmt.Errorf("%w: argon2id needs at least one iteration", ErrInvalidPasswordHasher)
+	case params.Parallelism < 1:
+		return nil, fmt.Errorf("%w: argon2id needs a parallelism of at least 1", ErrInvalidPasswordHasher)
+	case params.Memory < 8*uint32(params.Parallelism):
+		return nil, fmt.Errorf("%w: argon2id needs at least 8 KiB of memory per lane", ErrInvalidPasswordHasher)
+	case params.SaltLength < 8:
+		return nil, fmt.Errorf("%w: argon2id salts must be at least 8 bytes", ErrInvalidPasswordHasher)
+	case params.KeyLength < 16:
+		return nil, fmt.Errorf("%w: argon2id keys must be at least 16 bytes", ErrInvalidPasswordHasher)
+	}
+
+	hasher := &argon2idHasher{params: params}
+	if len(pepper) > 0 {
+		fingerprint := sha256.Sum256(pepper)
+		hasher.pepper = pepper
+		hasher.pepperID = base64.RawStdEncoding.EncodeToString(fingerprint[:6])
+	}
+	return hasher, nil
+}
+
+func (h *argon2idHasher) Hash(password Password) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", ErrHashingPassword
+	}
+
+	key := argon2.IDKey(h.input(password, h.pepperID != ""), salt,
+		h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", h.params.Memory, h.params.Iterations, h.params.Parallelism)
+	if h.pepperID != "" {
+		params += ",keyid=" + h.pepperID
+	}
+	return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s",
+		argon2.Version,
+		params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(password Password, hash string) (bool, bool) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return h.verifyArgon2id(password, hash)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		// bcrypt hashes predate peppering and are always replaced
+		matches := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+		return matches, matches
+	default:
+		return false, false
+	}
+}
+
+func (h *argon2idHasher) verifyArgon2id(password Password, hash string) (bool, bool) {
+	phc, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false, false
+	}
+	// a hash keyed with another pepper cannot be checked at all
+	if phc.keyID != "" && phc.keyID != h.pepperID {
+		return false, false
+	}
+
+	key := argon2.IDKey(h.input(password, phc.keyID != ""), phc.salt,
+		phc.params.Iterations, phc.params.Memory, phc.params.Parallelism, uint32(len(phc.key)))
+	if subtle.ConstantTimeCompare(key, phc.key) != 1 {
+		return false, false
+	}
+
+	outdated := phc.params != h.params || phc.keyID != h.pepperID
+	return true, outdated
+}
+
+// input is what gets hashed: the password itself, or its HMAC under the
+// pepper for peppered hashes.
+func (h *argon2idHasher) input(password Password, peppered bool) []byte {
+	if !peppered {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
+}
+
+type argon2idHash struct {
+	params Argon2idParams
+	keyID  string
+	salt   []byte
+	key    []byte
+}
+
+// parseArgon2idHash reads $argon2id$v=19$m=<m>,t=<t>,p=<p>[,keyid=<id>]$<salt>$<key>.
+func parseArgon2idHash(hash string) (*argon2idHash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("not an argon2id hash")
+	}
+	if parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return nil, fmt.Errorf("unsupported argon2 %s", parts[2])
+	}
+
+	phc := &argon2idHash{}
+	for _, param := range strings.Split(parts[3], ",") {
+		name, value, _ := strings.Cut(param, "=")
+		var err error
+		switch name {
+		case "m":
+			phc.params.Memory, err = parseUint32(value)
+		case "t":
+			phc.params.Iterations, err = parseUint32(value)
+		case "p":
+			var parallelism uint64
+			parallelism, err = strconv.ParseUint(value, 10, 8)
+			phc.params.Parallelism = uint8(parallelism)
+		case "keyid":
+			phc.keyID = value
+		default:
+			err = fmt.Errorf("unknown parameter %q", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if phc.params.Iterations < 1 || phc.params.Parallelism < 1 {
+		return nil, errors.New("missing argon2id parameters")
+	}
+
+	var err error
+	if phc.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, err
+	}
+	if phc.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, err
+	}
+	if len(phc.key) == 0 {
+		return nil, errors.New("empty argon2id key")
+	}
+	phc.params.SaltLength = uint32(len(phc.salt))
+	phc.params.KeyLength = uint32(len(phc.key))
+	return phc, nil
+}
+
+func parseUint32(value string) (uint32, error) {
+	parsed, err := strconv.ParseUint(value, 10, 32)
+	return uint32(parsed), err
+}
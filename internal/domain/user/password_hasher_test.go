@@ -0,0 +1,152 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams keep hashing cheap enough for tests
+var testArgon2idParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var testHasher = newTestHasher(testArgon2idParams, nil)
+
+func newTestHasher(params Argon2idParams, pepper []byte) PasswordHasher {
+	hasher, err := NewArgon2idHasher(params, pepper)
+	if err != nil {
+		panic(err)
+	}
+	return hasher
+}
+
+func TestArgon2idHasher_Hash(t *testing.T) {
+	hash1, err := testHasher.Hash("validpass123")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(hash1, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash() = %q, expected an argon2id PHC string", hash1)
+	}
+
+	hash2, err := testHasher.Hash("validpass123")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if hash1 == hash2 {
+		t.Error("Hash() produced identical hashes for same password")
+	}
+}
+
+func TestArgon2idHasher_Verify(t *testing.T) {
+	hash := func(hasher PasswordHasher, password Password) string {
+		hashed, err := hasher.Hash(password)
+		if err != nil {
+			t.Fatalf("Hash() error = %v", err)
+		}
+		return hashed
+	}
+	legacy, err := bcrypt.GenerateFromPassword([]byte("validpass123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	peppered := newTestHasher(testArgon2idParams, []byte("pepper"))
+
+	tests := []struct {
+		name             string
+		hasher           PasswordHasher
+		hash             string
+		password         Password
+		expectedMatches  bool
+		expectedOutdated bool
+	}{
+		{
+			name:            "matching password",
+			hasher:          testHasher,
+			hash:            hash(testHasher, "validpass123"),
+			password:        "validpass123",
+			expectedMatches: true,
+		},
+		{
+			name:     "wrong password",
+			hasher:   testHasher,
+			hash:     hash(testHasher, "validpass123"),
+			password: "wrongpass123",
+		},
+		{
+			name:             "bcrypt hash is outdated",
+			hasher:           testHasher,
+			hash:             string(legacy),
+			password:         "validpass123",
+			expectedMatches:  true,
+			expectedOutdated: true,
+		},
+		{
+			name:     "wrong password against bcrypt",
+			hasher:   testHasher,
+			hash:     string(legacy),
+			password: "wrongpass123",
+		},
+		{
+			name:             "weaker parameters are outdated",
+			hasher:           newTestHasher(stronger, nil),
+			hash:             hash(testHasher, "validpass123"),
+			password:         "validpass123",
+			expectedMatches:  true,
+			expectedOutdated: true,
+		},
+		{
+			name:             "hash without pepper is outdated once one is set",
+			hasher:           peppered,
+			hash:             hash(testHasher, "validpass123"),
+			password:         "validpass123",
+			expectedMatches:  true,
+			expectedOutdated: true,
+		},
+		{
+			name:            "peppered hash",
+			hasher:          peppered,
+			hash:            hash(peppered, "validpass123"),
+			password:        "validpass123",
+			expectedMatches: true,
+		},
+		{
+			name:     "peppered hash without the pepper",
+			hasher:   testHasher,
+			hash:     hash(peppered, "validpass123"),
+			password: "validpass123",
+		},
+		{
+			name:     "malformed hash",
+			hasher:   testHasher,
+			hash:     "$argon2id$v=19$m=64$c2FsdA$aGFzaA",
+			password: "validpass123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, outdated := tt.hasher.Verify(tt.password, tt.hash)
+			if matches != tt.expectedMatches || outdated != tt.expectedOutdated {
+				t.Errorf("Verify() = (%v, %v), expected (%v, %v)", matches, outdated, tt.expectedMatches, tt.expectedOutdated)
+			}
+		})
+	}
+}
+
+func TestNewArgon2idHasher(t *testing.T) {
+	invalid := testArgon2idParams
+	invalid.Parallelism = 0
+	if _, err := NewArgon2idHasher(invalid, nil); err == nil {
+		t.Error("NewArgon2idHasher() accepted a parallelism of 0")
+	}
+}
@@ -0,0 +1,221 @@
+package user
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+// codes reported in the FieldError of a rejected password
+const (
+	PasswordRequired         = "required"
+	PasswordTooShort         = "too_short"
+	PasswordTooLong          = "too_long"
+	PasswordMissingClass     = "missing_character_class"
+	PasswordContainsUsername = "contains_username"
+	PasswordCommon           = "common"
+	PasswordTooWeak          = "too_weak"
+)
+
+var ErrInvalidPasswordPolicy = errors.New("invalid password policy")
+
+// PasswordCharacterClass groups the characters a policy can require.
+type PasswordCharacterClass string
+
+const (
+	PasswordLowercase PasswordCharacterClass = "lowercase"
+	PasswordUppercase PasswordCharacterClass = "uppercase"
+	PasswordDigit     PasswordCharacterClass = "digit"
+	// PasswordSymbol is any character that is neither a letter nor a digit
+	PasswordSymbol PasswordCharacterClass = "symbol"
+)
+
+// PasswordPolicyConfig sets the rules of a PasswordPolicy. Lengths count
+// characters, not bytes; a zero MaxLength or MinEntropyBits disables the rule.
+type PasswordPolicyConfig struct {
+	MinLength        int
+	MaxLength        int
+	RequiredClasses  []PasswordCharacterClass
+	DisallowUsername bool
+	MinEntropyBits   float64
+}
+
+// DefaultPasswordPolicyConfig keeps the rule passwords always had, at least
+// eight characters, and adds the checks that cost users nothing.
+var DefaultPasswordPolicyConfig = PasswordPolicyConfig{
+	MinLength:        8,
+	MaxLength:        128,
+	DisallowUsername: true,
+}
+
+// PasswordDictionary knows passwords that are too common or have been
+// breached, whatever their case.
+type PasswordDictionary interface {
+	Contains(password string) bool
+}
+
+// PasswordPolicy decides which passwords may be set.
+type PasswordPolicy struct {
+	config     PasswordPolicyConfig
+	dictionary PasswordDictionary
+}
+
+// NewPasswordPolicy checks config; dictionary may be nil.
+func NewPasswordPolicy(config PasswordPolicyConfig, dictionary PasswordDictionary) (PasswordPolicy, error) {
+	if config.MinLength < 1 {
+		return PasswordPolicy{}, fmt.Errorf("%w: minimum length must be at least 1", ErrInvalidPasswordPolicy)
+	}
+	if config.MaxLength != 0 && config.MaxLength < config.MinLength {
+		return PasswordPolicy{}, fmt.Errorf("%w: maximum length is below the minimum", ErrInvalidPasswordPolicy)
+	}
+	for _, class := range config.RequiredClasses {
+		switch class {
+		case PasswordLowercase, PasswordUppercase, PasswordDigit, PasswordSymbol:
+		default:
+			return PasswordPolicy{}, fmt.Errorf("%w: unknown character class %q", ErrInvalidPasswordPolicy, class)
+		}
+	}
+	return PasswordPolicy{config: config, dictionary: dictionary}, nil
+}
+
+// Parse returns raw as a Password, or a *shared.ValidationError listing
+// every rule it breaks. username is the name of the account the password is
+// for.
+func (p PasswordPolicy) Parse(raw string, username Username) (Password, error) {
+	var problems []shared.FieldError
+	reject := func(code, message string) {
+		problems = append(problems, shared.FieldError{Field: "password", Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(raw)
+	switch {
+	case length == 0:
+		reject(PasswordRequired, "is required")
+	case length < p.config.MinLength:
+		reject(PasswordTooShort, fmt.Sprintf("must be at least %d characters long", p.config.MinLength))
+	case p.config.MaxLength > 0 && length > p.config.MaxLength:
+		reject(PasswordTooLong, fmt.Sprintf("must be at most %d characters long", p.config.MaxLength))
+	}
+
+	classes := passwordCharacterClasses(raw)
+	for _, class := range p.config.RequiredClasses {
+		if !classes[class] {
+			reject(PasswordMissingClass, fmt.Sprintf("must contain a %s character", class))
+		}
+	}
+
+	if p.config.DisallowUsername && username != "" &&
+		strings.Contains(string(NormalizeUsername(raw)), string(username)) {
+		reject(PasswordContainsUsername, "must not contain the username")
+	}
+
+	if p.dictionary != nil && length > 0 && p.dictionary.Contains(raw) {
+		reject(PasswordCommon, "is too common")
+	} else if p.config.MinEntropyBits > 0 && length > 0 && EstimatePasswordEntropy(raw) < p.config.MinEntropyBits {
+		reject(PasswordTooWeak, "is too easy to guess")
+	}
+
+	if len(problems) > 0 {
+		return "", &shared.ValidationError{Fields: problems}
+	}
+	return Password(raw), nil
+}
+
+// EstimatePasswordEntropy returns a rough strength in bits: the number of
+// characters times the bits needed for the character classes used.
+// Repeating the previous character adds nothing, so aaaaaaaa scores no
+// better than a. It does not see words or keyboard patterns, which is what
+// a dictionary is for.
+func EstimatePasswordEntropy(raw string) float64 {
+	classes := passwordCharacterClasses(raw)
+	pool := 0
+	for class, size := range passwordClassSizes {
+		if classes[class] {
+			pool += size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	counted := 0
+	previous := utf8.RuneError
+	for _, r := range raw {
+		if r != previous {
+			counted++
+		}
+		previous = r
+	}
+	return float64(counted) * math.Log2(float64(pool))
+}
+
+// passwordOtherLetter covers letters without case, such as CJK, for the
+// entropy estimate only
+const passwordOtherLetter PasswordCharacterClass = "other_letter"
+
+var passwordClassSizes = map[PasswordCharacterClass]int{
+	PasswordLowercase:   26,
+	PasswordUppercase:   26,
+	PasswordDigit:       10,
+	PasswordSymbol:      33,
+	passwordOtherLetter: 100,
+}
+
+func passwordCharacterClasses(raw string) map[PasswordCharacterClass]bool {
+	classes := make(map[PasswordCharacterClass]bool, len(passwordClassSizes))
+	for _, r := range raw {
+		switch {
+		case unicode.IsLower(r):
+			classes[PasswordLowercase] = true
+		case unicode.IsUpper(r):
+			classes[PasswordUppercase] = true
+		case unicode.IsDigit(r):
+			classes[PasswordDigit] = true
+		case unicode.IsLetter(r):
+			classes[passwordOtherLetter] = true
+		default:
+			classes[PasswordSymbol] = true
+		}
+	}
+	return classes
+}
+
+// PasswordSet is a PasswordDictionary held in memory.
+type PasswordSet map[string]struct{}
+
+// ReadPasswordSet reads one password per line. Blank lines and lines
+// starting with # are skipped.
+func ReadPasswordSet(r io.Reader) (PasswordSet, error) {
+	set := PasswordSet{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[foldPassword(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading passwords: %w", err)
+	}
+	return set, nil
+}
+
+func (s PasswordSet) Contains(password string) bool {
+	_, ok := s[foldPassword(password)]
+	return ok
+}
+
+func foldPassword(password string) string {
+	return cases.Fold().String(norm.NFKC.String(password))
+}
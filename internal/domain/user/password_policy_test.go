@@ -0,0 +1,161 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+func TestPasswordPolicy_Parse(t *testing.T) {
+	dictionary, err := ReadPasswordSet(strings.NewReader("# common passwords\n\npassword123\nLetMeIn2024\n"))
+	if err != nil {
+		t.Fatalf("ReadPasswordSet() error = %v", err)
+	}
+
+	strict, err := NewPasswordPolicy(PasswordPolicyConfig{
+		MinLength:        10,
+		MaxLength:        20,
+		RequiredClasses:  []PasswordCharacterClass{PasswordUppercase, PasswordDigit, PasswordSymbol},
+		DisallowUsername: true,
+		MinEntropyBits:   60,
+	}, dictionary)
+	if err != nil {
+		t.Fatalf("NewPasswordPolicy() error = %v", err)
+	}
+	lenient, err := NewPasswordPolicy(DefaultPasswordPolicyConfig, dictionary)
+	if err != nil {
+		t.Fatalf("NewPasswordPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		policy        PasswordPolicy
+		raw           string
+		expectedCodes []string
+	}{
+		{
+			name:   "valid password",
+			policy: strict,
+			raw:    "Tr0ub4dor&3x",
+		},
+		{
+			name:   "default policy accepts eight characters",
+			policy: lenient,
+			raw:    "validpass123",
+		},
+		{
+			name:          "empty password",
+			policy:        lenient,
+			raw:           "",
+			expectedCodes: []string{PasswordRequired},
+		},
+		{
+			name:          "length counts characters rather than bytes",
+			policy:        lenient,
+			raw:           "ééééééé",
+			expectedCodes: []string{PasswordTooShort},
+		},
+		{
+			name:          "too long",
+			policy:        strict,
+			raw:           "Tr0ub4dor&3x" + strings.Repeat("yz", 5),
+			expectedCodes: []string{PasswordTooLong},
+		},
+		{
+			name:          "every missing class is reported",
+			policy:        strict,
+			raw:           "correcthorsebattery",
+			expectedCodes: []string{PasswordMissingClass, PasswordMissingClass, PasswordMissingClass},
+		},
+		{
+			name:          "contains the username in another case",
+			policy:        strict,
+			raw:           "X9!ALICE-wonder",
+			expectedCodes: []string{PasswordContainsUsername},
+		},
+		{
+			name:          "dictionary ignores case",
+			policy:        lenient,
+			raw:           "PASSWORD123",
+			expectedCodes: []string{PasswordCommon},
+		},
+		{
+			name:          "low entropy",
+			policy:        strict,
+			raw:           "Aaaaaaaaa1!",
+			expectedCodes: []string{PasswordTooWeak},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := tt.policy.Parse(tt.raw, "alice")
+			if len(tt.expectedCodes) == 0 {
+				if err != nil {
+					t.Fatalf("Parse() error = %v, expected nil", err)
+				}
+				if string(password) != tt.raw {
+					t.Errorf("Parse() = %q, expected %q", password, tt.raw)
+				}
+				return
+			}
+
+			var validationErr *shared.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Parse() error = %v, expected *shared.ValidationError", err)
+			}
+			var codes []string
+			for _, field := range validationErr.Fields {
+				if field.Field != "password" {
+					t.Errorf("Parse() field = %q, expected password", field.Field)
+				}
+				codes = append(codes, field.Code)
+			}
+			if strings.Join(codes, ",") != strings.Join(tt.expectedCodes, ",") {
+				t.Errorf("Parse() codes = %v, expected %v", codes, tt.expectedCodes)
+			}
+		})
+	}
+}
+
+func TestNewPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config PasswordPolicyConfig
+	}{
+		{
+			name:   "no minimum length",
+			config: PasswordPolicyConfig{},
+		},
+		{
+			name:   "maximum below minimum",
+			config: PasswordPolicyConfig{MinLength: 12, MaxLength: 8},
+		},
+		{
+			name:   "unknown character class",
+			config: PasswordPolicyConfig{MinLength: 8, RequiredClasses: []PasswordCharacterClass{"emoji"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPasswordPolicy(tt.config, nil); !errors.Is(err, ErrInvalidPasswordPolicy) {
+				t.Errorf("NewPasswordPolicy() error = %v, expected %v", err, ErrInvalidPasswordPolicy)
+			}
+		})
+	}
+}
+
+func TestEstimatePasswordEntropy(t *testing.T) {
+	if got := EstimatePasswordEntropy(""); got != 0 {
+		t.Errorf("EstimatePasswordEntropy(\"\") = %v, expected 0", got)
+	}
+	if repeated, single := EstimatePasswordEntropy("aaaaaaaa"), EstimatePasswordEntropy("a"); repeated != single {
+		t.Errorf("repeated characters scored %v, expected %v", repeated, single)
+	}
+	if weak, strong := EstimatePasswordEntropy("abcdefgh"), EstimatePasswordEntropy("aB3$eF7*"); weak >= strong {
+		t.Errorf("mixed classes scored %v, expected more than %v", strong, weak)
+	}
+}
@@ -0,0 +1,95 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	// timezones are validated against the embedded database so the outcome
+	// does not depend on the host
+	_ "time/tzdata"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	maxDisplayNameLength = 64
+	maxAvatarURLLength   = 2048
+)
+
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// Profile holds the user's self-managed presentation settings. Empty fields
+// are unset.
+type Profile struct {
+	DisplayName string
+	AvatarURL   string
+	Locale      string
+	Timezone    string
+}
+
+// ProfileChanges is a partial update; nil fields are left as they are and
+// empty strings clear them.
+type ProfileChanges struct {
+	DisplayName *string
+	AvatarURL   *string
+	Locale      *string
+	Timezone    *string
+}
+
+// apply returns p with changes applied, validated and normalized.
+func (p Profile) apply(changes ProfileChanges) (Profile, error) {
+	if changes.DisplayName != nil {
+		displayName := strings.TrimSpace(*changes.DisplayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			return p, fmt.Errorf("%w: display name is longer than %d characters", ErrInvalidProfile, maxDisplayNameLength)
+		}
+		if strings.IndexFunc(displayName, unicode.IsControl) >= 0 {
+			return p, fmt.Errorf("%w: display name contains control characters", ErrInvalidProfile)
+		}
+		p.DisplayName = displayName
+	}
+
+	if changes.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*changes.AvatarURL)
+		if avatarURL != "" {
+			if len(avatarURL) > maxAvatarURLLength {
+				return p, fmt.Errorf("%w: avatar url is longer than %d bytes", ErrInvalidProfile, maxAvatarURLLength)
+			}
+			parsed, err := url.Parse(avatarURL)
+			if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+				return p, fmt.Errorf("%w: avatar url must be an absolute http or https url", ErrInvalidProfile)
+			}
+		}
+		p.AvatarURL = avatarURL
+	}
+
+	if changes.Locale != nil {
+		locale := strings.TrimSpace(*changes.Locale)
+		if locale != "" {
+			tag, err := language.Parse(locale)
+			if err != nil {
+				return p, fmt.Errorf("%w: locale %q is not a BCP 47 language tag", ErrInvalidProfile, locale)
+			}
+			locale = tag.String()
+		}
+		p.Locale = locale
+	}
+
+	if changes.Timezone != nil {
+		timezone := strings.TrimSpace(*changes.Timezone)
+		if timezone != "" {
+			// Local depends on the host, so only names from the IANA database
+			// are accepted
+			if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+				return p, fmt.Errorf("%w: timezone %q is not an IANA time zone", ErrInvalidProfile, timezone)
+			}
+		}
+		p.Timezone = timezone
+	}
+
+	return p, nil
+}
@@ -0,0 +1,112 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestUser_UpdateProfile(t *testing.T) {
+	tests := []struct {
+		name          string
+		changes       ProfileChanges
+		expected      Profile
+		expectedError error
+		expectEvent   bool
+	}{
+		{
+			name: "sets and normalizes fields",
+			changes: ProfileChanges{
+				DisplayName: ptr("  Alice Liddell "),
+				AvatarURL:   ptr("https://cdn.example.com/a.png"),
+				Locale:      ptr("en-gb"),
+				Timezone:    ptr("Europe/London"),
+			},
+			expected: Profile{
+				DisplayName: "Alice Liddell",
+				AvatarURL:   "https://cdn.example.com/a.png",
+				Locale:      "en-GB",
+				Timezone:    "Europe/London",
+			},
+			expectEvent: true,
+		},
+		{
+			name:        "unchanged profile records nothing",
+			changes:     ProfileChanges{DisplayName: ptr("Alice")},
+			expected:    Profile{DisplayName: "Alice"},
+			expectEvent: false,
+		},
+		{
+			name:        "empty string clears a field",
+			changes:     ProfileChanges{DisplayName: ptr("")},
+			expected:    Profile{},
+			expectEvent: true,
+		},
+		{
+			name:          "display name too long",
+			changes:       ProfileChanges{DisplayName: ptr(strings.Repeat("é", maxDisplayNameLength+1))},
+			expectedError: ErrInvalidProfile,
+		},
+		{
+			name:          "display name with control characters",
+			changes:       ProfileChanges{DisplayName: ptr("Alice\x00")},
+			expectedError: ErrInvalidProfile,
+		},
+		{
+			name:          "relative avatar url",
+			changes:       ProfileChanges{AvatarURL: ptr("/a.png")},
+			expectedError: ErrInvalidProfile,
+		},
+		{
+			name:          "avatar url with another scheme",
+			changes:       ProfileChanges{AvatarURL: ptr("javascript:alert(1)")},
+			expectedError: ErrInvalidProfile,
+		},
+		{
+			name:          "malformed locale",
+			changes:       ProfileChanges{Locale: ptr("not a locale")},
+			expectedError: ErrInvalidProfile,
+		},
+		{
+			name:          "unknown timezone",
+			changes:       ProfileChanges{Timezone: ptr("Mars/Olympus_Mons")},
+			expectedError: ErrInvalidProfile,
+		},
+		{
+			name:          "host dependent timezone",
+			changes:       ProfileChanges{Timezone: ptr("Local")},
+			expectedError: ErrInvalidProfile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := NewUser("test", "testuser", "validpass123", testHasher)
+			if err != nil {
+				t.Fatalf("Failed to create user: %v", err)
+			}
+			u.Profile = Profile{DisplayName: "Alice"}
+			u.ClearUncommittedChanges()
+
+			err = u.UpdateProfile(tt.changes)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("UpdateProfile() error = %v, expected error %v", err, tt.expectedError)
+			}
+			if err != nil {
+				if len(u.GetUncommittedChanges()) != 0 {
+					t.Error("UpdateProfile() recorded an event for an invalid update")
+				}
+				return
+			}
+
+			if u.Profile != tt.expected {
+				t.Errorf("Profile = %+v, expected %+v", u.Profile, tt.expected)
+			}
+			if recorded := len(u.GetUncommittedChanges()) == 1; recorded != tt.expectEvent {
+				t.Errorf("recorded event = %v, expected %v", recorded, tt.expectEvent)
+			}
+		})
+	}
+}
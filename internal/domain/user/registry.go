@@ -5,10 +5,27 @@ import (
 )
 
 const (
-	EventTypeUserRegistered      shared.EventType = "user.registered"
-	EventTypeUserPasswordChanged shared.EventType = "user.passwordChanged"
+	EventTypeUserRegistered       shared.EventType = "user.registered"
+	EventTypeUserPasswordChanged  shared.EventType = "user.passwordChanged"
+	EventTypeUserErased           shared.EventType = "user.erased"
+	EventTypeUserLoggedIn         shared.EventType = "user.loggedIn"
+	EventTypeUserLoginFailed      shared.EventType = "user.loginFailed"
+	EventTypeUserProfileUpdated   shared.EventType = "user.profileUpdated"
+	EventTypeUserUsernameChanged  shared.EventType = "user.usernameChanged"
+	EventTypeUserPasswordRehashed shared.EventType = "user.passwordRehashed"
+	EventTypeUserEmailChanged     shared.EventType = "user.emailChanged"
+	EventTypeUserLocked           shared.EventType = "user.locked"
+	EventTypeUserUnlocked         shared.EventType = "user.unlocked"
+	EventTypeUserRoleAssigned     shared.EventType = "user.roleAssigned"
 )
 
+// UsernameReservationScope is the scope usernames are reserved in.
+const UsernameReservationScope = "username"
+
+// upcasters migrate payloads stored under an older schema version; append a
+// step whenever one of the event structs changes shape.
+var upcasters []shared.Upcaster
+
 func RegisterEvents(registry shared.EventRegistry) {
 	registry.RegisterEvent(EventTypeUserRegistered, func() shared.Event {
 		return &UserRegisteredEvent{}
@@ -16,4 +33,48 @@ func RegisterEvents(registry shared.EventRegistry) {
 	registry.RegisterEvent(EventTypeUserPasswordChanged, func() shared.Event {
 		return &UserPasswordChangedEvent{}
 	})
+	registry.RegisterEvent(EventTypeUserErased, func() shared.Event {
+		return &UserErasedEvent{}
+	})
+	registry.RegisterEvent(EventTypeUserLoggedIn, func() shared.Event {
+		return &UserLoggedInEvent{}
+	})
+	registry.RegisterEvent(EventTypeUserLoginFailed, func() shared.Event {
+		return &UserLoginFailedEvent{}
+	})
+	registry.RegisterEvent(EventTypeUserProfileUpdated, func() shared.Event {
+		return &UserProfileUpdatedEvent{}
+	})
+	registry.RegisterEvent(EventTypeUserUsernameChanged, func() shared.Event {
+		return &UserUsernameChangedEvent{}
+	})
+	registry.RegisterEvent(EventTypeUserPasswordRehashed, func() shared.Event {
+		return &UserPasswordRehashedEvent{}
+	})
+	registry.RegisterEvent(EventTypeUserEmailChanged, func() shared.Event {
+		return &UserEmailChangedEvent{}
+	})
+	registry.RegisterEvent(EventTypeUserLocked, func() shared.Event {
+		return &UserLockedEvent{}
+	})
+	registry.RegisterEvent(EventTypeUserUnlocked, func() shared.Event {
+		return &UserUnlockedEvent{}
+	})
+	registry.RegisterEvent(EventTypeUserRoleAssigned, func() shared.Event {
+		return &UserRoleAssignedEvent{}
+	})
+
+	registry.RegisterPersonalData(EventTypeUserRegistered, "username", "password_hash")
+	registry.RegisterPersonalData(EventTypeUserPasswordChanged, "new_password_hash")
+	registry.RegisterPersonalData(EventTypeUserLoggedIn, "ip_address", "user_agent")
+	registry.RegisterPersonalData(EventTypeUserLoginFailed, "ip_address", "user_agent")
+	registry.RegisterPersonalData(EventTypeUserProfileUpdated, "display_name", "avatar_url")
+	registry.RegisterPersonalData(EventTypeUserUsernameChanged, "username")
+	registry.RegisterPersonalData(EventTypeUserPasswordRehashed, "new_password_hash")
+	registry.RegisterPersonalData(EventTypeUserEmailChanged, "email")
+	registry.RegisterPersonalData(SnapshotTypeUser, "username", "password_hash", "display_name", "avatar_url", "email")
+
+	for _, upcaster := range upcasters {
+		registry.RegisterUpcaster(upcaster)
+	}
 }
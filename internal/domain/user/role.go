@@ -0,0 +1,32 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+
+// Role tells support staff what kind of account they are looking at. It is
+// assigned by admins and grants nothing within this service.
+type Role string
+
+const (
+	RoleUser    Role = "user"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+// DefaultRole is the role of every user until an admin assigns another.
+const DefaultRole = RoleUser
+
+// ParseRole returns the role named by raw, ignoring case.
+func ParseRole(raw string) (Role, error) {
+	switch role := Role(strings.ToLower(strings.TrimSpace(raw))); role {
+	case RoleUser, RoleSupport, RoleAdmin:
+		return role, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidRole, raw)
+	}
+}
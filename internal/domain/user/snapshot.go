@@ -0,0 +1,103 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+// SnapshotTypeUser is the type of user snapshots; its personal data fields
+// are registered with the events'.
+const SnapshotTypeUser shared.EventType = "user.snapshot"
+
+type userState struct {
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	DisplayName  string    `json:"display_name"`
+	AvatarURL    string    `json:"avatar_url"`
+	Locale       string    `json:"locale"`
+	Timezone     string    `json:"timezone"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	Locked       bool      `json:"locked"`
+	Erased       bool      `json:"erased"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Snapshot captures the user as of its current version, uncommitted changes
+// included.
+func (u *User) Snapshot() (shared.Snapshot, error) {
+	state, err := json.Marshal(userState{
+		Username:     u.Username,
+		PasswordHash: u.PasswordHash,
+		DisplayName:  u.Profile.DisplayName,
+		AvatarURL:    u.Profile.AvatarURL,
+		Locale:       u.Profile.Locale,
+		Timezone:     u.Profile.Timezone,
+		Email:        u.Email,
+		Role:         string(u.Role),
+		Locked:       u.Locked,
+		Erased:       u.Erased,
+		CreatedAt:    u.CreatedAt,
+		UpdatedAt:    u.UpdatedAt,
+	})
+	if err != nil {
+		return shared.Snapshot{}, err
+	}
+
+	return shared.Snapshot{
+		AggregateID:   u.ID,
+		AggregateType: "USER",
+		Type:          SnapshotTypeUser,
+		Version:       u.Version,
+		State:         state,
+		Timestamp:     time.Now(),
+	}, nil
+}
+
+// RestoreFromSnapshot rebuilds the user from snapshot, which may be nil, and
+// the events recorded after it.
+func RestoreFromSnapshot(snapshot *shared.Snapshot, events []shared.Event) (*User, error) {
+	if snapshot == nil {
+		return ReconstructFromEvents(events)
+	}
+	if snapshot.Type != SnapshotTypeUser {
+		return nil, fmt.Errorf("%w: snapshot type %s", ErrInvalidUser, snapshot.Type)
+	}
+
+	var state userState
+	if err := json.Unmarshal(snapshot.State, &state); err != nil {
+		return nil, fmt.Errorf("unmarshal snapshot: %w", err)
+	}
+
+	user := NewUserFactory().CreateEmpty(snapshot.AggregateID)
+	user.Version = snapshot.Version
+	user.Username = state.Username
+	user.PasswordHash = state.PasswordHash
+	user.Profile = Profile{
+		DisplayName: state.DisplayName,
+		AvatarURL:   state.AvatarURL,
+		Locale:      state.Locale,
+		Timezone:    state.Timezone,
+	}
+	user.Email = state.Email
+	user.Role = Role(state.Role)
+	if user.Role == "" {
+		user.Role = DefaultRole
+	}
+	user.Locked = state.Locked
+	user.Erased = state.Erased
+	user.CreatedAt = state.CreatedAt
+	user.UpdatedAt = state.UpdatedAt
+
+	for _, event := range events {
+		if event.GetVersion() != user.Version+1 {
+			return nil, fmt.Errorf("wrong event version: expected %d, got %d", user.Version+1, event.GetVersion())
+		}
+		user.Apply(event)
+	}
+	return user, nil
+}
@@ -12,27 +12,36 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrUserAlreadyExists  = errors.New("user already exists")
 	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserErased         = errors.New("user erased")
+	ErrUsernameTaken      = errors.New("username already taken")
+	ErrEmailTaken         = errors.New("email already taken")
+	ErrUserLocked         = errors.New("user locked")
 )
 
 type User struct {
 	shared.BaseAggregateRoot
 	Username     string
 	PasswordHash string
+	Profile      Profile
+	Email        string
+	Role         Role
+	Locked       bool
+	Erased       bool
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
 
-func NewUser(userID, username, rawPassword string) (*User, error) {
-	if err := validateUserName(username); err != nil {
-		return nil, err
+// NewUser registers username with password, which must come from
+// UsernamePolicy.Parse and PasswordPolicy.Parse.
+func NewUser(userID string, username Username, password Password, hasher PasswordHasher) (*User, error) {
+	if username == "" {
+		return nil, ErrInvalidCredentials
 	}
-
-	password, err := NewPassword(rawPassword)
-	if err != nil {
-		return nil, err
+	if password == "" {
+		return nil, ErrInvalidPassword
 	}
 
-	hashedPassword, err := password.Hash()
+	hashedPassword, err := hasher.Hash(password)
 	if err != nil {
 		return nil, err
 	}
@@ -44,46 +53,223 @@ func NewUser(userID, username, rawPassword string) (*User, error) {
 		},
 	}
 
-	event := NewUserRegisteredEvent(user.ID, username, hashedPassword)
+	event := NewUserRegisteredEvent(user.ID, string(username), hashedPassword)
 	user.Apply(event)
 	user.Changes = append(user.Changes, event)
 
 	return user, nil
 }
 
-func (u *User) Authenticate(rawPassword string) bool {
-	password := Password(rawPassword)
-	return password.Matches(u.PasswordHash)
+func (u *User) Authenticate(rawPassword string, hasher PasswordHasher) bool {
+	if u.Erased {
+		return false
+	}
+	matches, _ := hasher.Verify(Password(rawPassword), u.PasswordHash)
+	return matches
 }
 
-func (u *User) ChangePassword(rawOldPassword, rawNewPassword string) error {
-	ok := u.Authenticate(rawOldPassword)
+// ChangePassword sets newPassword, which must come from PasswordPolicy.Parse.
+func (u *User) ChangePassword(rawOldPassword string, newPassword Password, hasher PasswordHasher) error {
+	if u.Erased {
+		return ErrUserErased
+	}
+
+	ok := u.Authenticate(rawOldPassword, hasher)
 	if !ok {
 		return ErrInvalidPassword
 	}
 
-	newPassword, err := NewPassword(rawNewPassword)
+	if newPassword == "" {
+		return ErrInvalidPassword
+	}
+
+	newPasswordHash, err := hasher.Hash(newPassword)
 	if err != nil {
 		return err
 	}
 
-	newPasswordHash, err := newPassword.Hash()
+	event := NewUserPasswordChangedEvent(u.ID, newPasswordHash, u.Version+1)
+	u.Apply(event)
+	u.Changes = append(u.Changes, event)
+
+	return nil
+}
+
+// Login checks rawPassword and records the attempt either way, so the
+// outcome becomes part of the user's history. An erased user cannot sign in
+// and leaves no record, as there is no data key left to protect one. A
+// locked user is only told so once the password matched, so the lock gives
+// nothing away to someone guessing. A password stored under an outdated hash
+// is rehashed along with the sign-in; should that fail the old hash stays,
+// as it still works.
+func (u *User) Login(rawPassword, ipAddress, userAgent string, hasher PasswordHasher) error {
+	if u.Erased {
+		return ErrUserErased
+	}
+
+	matches, outdated := hasher.Verify(Password(rawPassword), u.PasswordHash)
+	if !matches {
+		event := NewUserLoginFailedEvent(u.ID, ipAddress, userAgent, LoginFailureInvalidPassword, u.Version+1)
+		u.Apply(event)
+		u.Changes = append(u.Changes, event)
+		return ErrInvalidCredentials
+	}
+	if u.Locked {
+		event := NewUserLoginFailedEvent(u.ID, ipAddress, userAgent, LoginFailureLocked, u.Version+1)
+		u.Apply(event)
+		u.Changes = append(u.Changes, event)
+		return ErrUserLocked
+	}
+
+	event := NewUserLoggedInEvent(u.ID, ipAddress, userAgent, u.Version+1)
+	u.Apply(event)
+	u.Changes = append(u.Changes, event)
+
+	if outdated {
+		if newPasswordHash, err := hasher.Hash(Password(rawPassword)); err == nil {
+			rehashed := NewUserPasswordRehashedEvent(u.ID, newPasswordHash, u.Version+1)
+			u.Apply(rehashed)
+			u.Changes = append(u.Changes, rehashed)
+		}
+	}
+
+	return nil
+}
+
+// UpdateProfile applies changes to the profile. An update that leaves the
+// profile as it was records nothing.
+func (u *User) UpdateProfile(changes ProfileChanges) error {
+	if u.Erased {
+		return ErrUserErased
+	}
+
+	profile, err := u.Profile.apply(changes)
 	if err != nil {
 		return err
 	}
+	if profile == u.Profile {
+		return nil
+	}
 
-	event := NewUserPasswordChangedEvent(u.ID, newPasswordHash, u.Version+1)
+	event := NewUserProfileUpdatedEvent(u.ID, profile, u.Version+1)
 	u.Apply(event)
 	u.Changes = append(u.Changes, event)
 
 	return nil
 }
 
-// todo - use value object
-func validateUserName(username string) error {
-	if username == "" {
+// ChangeUsername renames the user to newUsername, which must come from
+// UsernamePolicy.Parse. Renaming to the current username records nothing;
+// whether the new one is free is only known once the event store tries to
+// reserve it.
+func (u *User) ChangeUsername(newUsername Username) error {
+	if u.Erased {
+		return ErrUserErased
+	}
+
+	if newUsername == "" {
 		return ErrInvalidCredentials
 	}
+	if string(newUsername) == u.Username {
+		return nil
+	}
+
+	event := NewUserUsernameChangedEvent(u.ID, string(newUsername), u.Version+1)
+	u.Apply(event)
+	u.Changes = append(u.Changes, event)
+
+	return nil
+}
+
+// ChangeEmail sets the user's email address to email, which must come from
+// ParseEmail. Setting the current address records nothing; whether the new
+// one is free is only known once the event store tries to reserve it.
+func (u *User) ChangeEmail(email Email) error {
+	if u.Erased {
+		return ErrUserErased
+	}
+
+	if email == "" {
+		return ErrInvalidUser
+	}
+	if string(email) == u.Email {
+		return nil
+	}
+
+	event := NewUserEmailChangedEvent(u.ID, string(email), u.Version+1)
+	u.Apply(event)
+	u.Changes = append(u.Changes, event)
+
+	return nil
+}
+
+// Lock stops the user from signing in until Unlock. Tokens already issued
+// stay valid until they expire, but cannot be refreshed. Locking a locked
+// user records nothing.
+func (u *User) Lock() error {
+	if u.Erased {
+		return ErrUserErased
+	}
+	if u.Locked {
+		return nil
+	}
+
+	event := NewUserLockedEvent(u.ID, u.Version+1)
+	u.Apply(event)
+	u.Changes = append(u.Changes, event)
+
+	return nil
+}
+
+// Unlock lets a locked user sign in again. Unlocking a user that is not
+// locked records nothing.
+func (u *User) Unlock() error {
+	if u.Erased {
+		return ErrUserErased
+	}
+	if !u.Locked {
+		return nil
+	}
+
+	event := NewUserUnlockedEvent(u.ID, u.Version+1)
+	u.Apply(event)
+	u.Changes = append(u.Changes, event)
+
+	return nil
+}
+
+// AssignRole replaces the user's role with role, which must come from
+// ParseRole. Assigning the current role records nothing.
+func (u *User) AssignRole(role Role) error {
+	if u.Erased {
+		return ErrUserErased
+	}
+
+	if role == "" {
+		return ErrInvalidRole
+	}
+	if role == u.Role {
+		return nil
+	}
+
+	event := NewUserRoleAssignedEvent(u.ID, role, u.Version+1)
+	u.Apply(event)
+	u.Changes = append(u.Changes, event)
+
+	return nil
+}
+
+// Erase records the right-to-erasure request. The personal data itself is
+// shredded by destroying the user's data key once the event is stored.
+func (u *User) Erase() error {
+	if u.Erased {
+		return ErrUserErased
+	}
+
+	event := NewUserErasedEvent(u.ID, u.Version+1)
+	u.Apply(event)
+	u.Changes = append(u.Changes, event)
+
 	return nil
 }
 
@@ -95,11 +281,40 @@ func (u *User) Apply(event shared.Event) {
 	case *UserRegisteredEvent:
 		u.Username = e.Username
 		u.PasswordHash = e.PasswordHash
+		u.Role = DefaultRole
 		u.CreatedAt = event.GetTimestamp()
 		u.UpdatedAt = event.GetTimestamp()
 	case *UserPasswordChangedEvent:
 		u.PasswordHash = e.NewPasswordHash
 		u.UpdatedAt = event.GetTimestamp()
+	case *UserPasswordRehashedEvent:
+		u.PasswordHash = e.NewPasswordHash
+		u.UpdatedAt = event.GetTimestamp()
+	case *UserUsernameChangedEvent:
+		u.Username = e.Username
+		u.UpdatedAt = event.GetTimestamp()
+	case *UserProfileUpdatedEvent:
+		u.Profile = e.Profile()
+		u.UpdatedAt = event.GetTimestamp()
+	case *UserEmailChangedEvent:
+		u.Email = e.Email
+		u.UpdatedAt = event.GetTimestamp()
+	case *UserLockedEvent:
+		u.Locked = true
+		u.UpdatedAt = event.GetTimestamp()
+	case *UserUnlockedEvent:
+		u.Locked = false
+		u.UpdatedAt = event.GetTimestamp()
+	case *UserRoleAssignedEvent:
+		u.Role = Role(e.Role)
+		u.UpdatedAt = event.GetTimestamp()
+	case *UserErasedEvent:
+		u.Username = ""
+		u.PasswordHash = ""
+		u.Profile = Profile{}
+		u.Email = ""
+		u.Erased = true
+		u.UpdatedAt = event.GetTimestamp()
 	}
 }
 
@@ -1,14 +1,19 @@
 package user
 
 import (
+	"strings"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
 )
 
 func TestNewUser(t *testing.T) {
 	tests := []struct {
 		name          string
-		username      string
-		password      string
+		username      Username
+		password      Password
 		expectedError error
 	}{
 		{
@@ -24,23 +29,23 @@ func TestNewUser(t *testing.T) {
 			expectedError: ErrInvalidCredentials,
 		},
 		{
-			name:          "password too short",
+			name:          "empty password",
 			username:      "testuser",
-			password:      "short",
-			expectedError: ErrPasswordTooShort,
+			password:      "",
+			expectedError: ErrInvalidPassword,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			u, err := NewUser("test", tt.username, tt.password)
+			u, err := NewUser("test", tt.username, tt.password, testHasher)
 			if err != tt.expectedError {
 				t.Errorf("NewUser() error = %v, expected error %v", err, tt.expectedError)
 				return
 			}
 
 			if err == nil {
-				if u.Username != tt.username {
+				if u.Username != string(tt.username) {
 					t.Errorf("NewUser() username = %v, expected %v", u.Username, tt.username)
 				}
 
@@ -71,8 +76,8 @@ func TestNewUser(t *testing.T) {
 func TestUser_Authenticate(t *testing.T) {
 	tests := []struct {
 		name          string
-		username      string
-		password      string
+		username      Username
+		password      Password
 		testPassword  string
 		shouldSucceed bool
 	}{
@@ -101,42 +106,305 @@ func TestUser_Authenticate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			u, err := NewUser("test", tt.username, tt.password)
+			u, err := NewUser("test", tt.username, tt.password, testHasher)
 			if err != nil {
 				t.Fatalf("Failed to create user: %v", err)
 			}
 
-			if authenticated := u.Authenticate(tt.testPassword); authenticated != tt.shouldSucceed {
+			if authenticated := u.Authenticate(tt.testPassword, testHasher); authenticated != tt.shouldSucceed {
 				t.Errorf("Authenticate() = %v, expected %v", authenticated, tt.shouldSucceed)
 			}
 		})
 	}
 }
 
-func TestValidateUsername(t *testing.T) {
+func TestUser_Erase(t *testing.T) {
+	u, err := NewUser("test", "testuser", "validpass123", testHasher)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	u.ClearUncommittedChanges()
+
+	if err := u.Erase(); err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+
+	changes := u.GetUncommittedChanges()
+	if len(changes) != 1 || changes[0].GetVersion() != 2 {
+		t.Fatalf("Erase() changes = %v, expected one user.erased event at version 2", changes)
+	}
+	if u.Username != "" || u.PasswordHash != "" {
+		t.Error("Erase() kept personal data on the aggregate")
+	}
+	if u.Authenticate("validpass123", testHasher) {
+		t.Error("Authenticate() succeeded for an erased user")
+	}
+	if err := u.ChangePassword("validpass123", "newpass12345", testHasher); err != ErrUserErased {
+		t.Errorf("ChangePassword() error = %v, expected %v", err, ErrUserErased)
+	}
+	if err := u.Erase(); err != ErrUserErased {
+		t.Errorf("Erase() error = %v, expected %v", err, ErrUserErased)
+	}
+}
+
+func TestUser_Login(t *testing.T) {
 	tests := []struct {
 		name          string
-		username      string
+		password      string
+		erased        bool
+		locked        bool
 		expectedError error
+		expectedEvent shared.EventType
 	}{
 		{
-			name:          "valid username",
-			username:      "testuser",
-			expectedError: nil,
+			name:          "correct password",
+			password:      "validpass123",
+			expectedEvent: EventTypeUserLoggedIn,
+		},
+		{
+			name:          "incorrect password",
+			password:      "wrongpass123",
+			expectedError: ErrInvalidCredentials,
+			expectedEvent: EventTypeUserLoginFailed,
+		},
+		{
+			name:          "erased user",
+			password:      "validpass123",
+			erased:        true,
+			expectedError: ErrUserErased,
+		},
+		{
+			name:          "locked user with the correct password",
+			password:      "validpass123",
+			locked:        true,
+			expectedError: ErrUserLocked,
+			expectedEvent: EventTypeUserLoginFailed,
+		},
+		{
+			name:          "locked user with an incorrect password",
+			password:      "wrongpass123",
+			locked:        true,
+			expectedError: ErrInvalidCredentials,
+			expectedEvent: EventTypeUserLoginFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := NewUser("test", "testuser", "validpass123", testHasher)
+			if err != nil {
+				t.Fatalf("Failed to create user: %v", err)
+			}
+			if tt.erased {
+				if err := u.Erase(); err != nil {
+					t.Fatalf("Erase() error = %v", err)
+				}
+			}
+			if tt.locked {
+				if err := u.Lock(); err != nil {
+					t.Fatalf("Lock() error = %v", err)
+				}
+			}
+			u.ClearUncommittedChanges()
+			version := u.Version
+
+			if err := u.Login(tt.password, "203.0.113.7", "curl/8.0", testHasher); err != tt.expectedError {
+				t.Errorf("Login() error = %v, expected error %v", err, tt.expectedError)
+			}
+
+			changes := u.GetUncommittedChanges()
+			if tt.expectedEvent == "" {
+				if len(changes) != 0 {
+					t.Errorf("Login() recorded %v, expected nothing", changes)
+				}
+				return
+			}
+			if len(changes) != 1 || changes[0].GetEventType() != string(tt.expectedEvent) || changes[0].GetVersion() != version+1 {
+				t.Fatalf("Login() changes = %v, expected one %s event at version %d", changes, tt.expectedEvent, version+1)
+			}
+		})
+	}
+}
+
+func TestUser_LoginRehashesOutdatedHash(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("validpass123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() error = %v", err)
+	}
+	u := &User{}
+	u.Apply(NewUserRegisteredEvent("test", "testuser", string(legacy)))
+
+	if err := u.Login("validpass123", "203.0.113.7", "curl/8.0", testHasher); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	changes := u.GetUncommittedChanges()
+	if len(changes) != 2 || changes[0].GetEventType() != string(EventTypeUserLoggedIn) ||
+		changes[1].GetEventType() != string(EventTypeUserPasswordRehashed) || changes[1].GetVersion() != 3 {
+		t.Fatalf("Login() changes = %v, expected %s then %s at version 3", changes, EventTypeUserLoggedIn, EventTypeUserPasswordRehashed)
+	}
+	if !strings.HasPrefix(u.PasswordHash, "$argon2id$") {
+		t.Errorf("PasswordHash = %q, expected an argon2id hash", u.PasswordHash)
+	}
+
+	u.ClearUncommittedChanges()
+	if err := u.Login("validpass123", "203.0.113.7", "curl/8.0", testHasher); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if changes := u.GetUncommittedChanges(); len(changes) != 1 {
+		t.Errorf("Login() changes = %v, expected no second rehash", changes)
+	}
+}
+
+func TestUser_ChangeUsername(t *testing.T) {
+	tests := []struct {
+		name          string
+		newUsername   Username
+		erased        bool
+		expectedError error
+		expectEvent   bool
+	}{
+		{
+			name:        "new username",
+			newUsername: "alice",
+			expectEvent: true,
+		},
+		{
+			name:        "same username records nothing",
+			newUsername: "testuser",
 		},
 		{
 			name:          "empty username",
-			username:      "",
+			newUsername:   "",
 			expectedError: ErrInvalidCredentials,
 		},
+		{
+			name:          "erased user",
+			newUsername:   "alice",
+			erased:        true,
+			expectedError: ErrUserErased,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := NewUser("test", tt.username, "validpass123")
-			if err != tt.expectedError {
-				t.Errorf("validateUsername() error = %v, expected error %v", err, tt.expectedError)
+			u, err := NewUser("test", "testuser", "validpass123", testHasher)
+			if err != nil {
+				t.Fatalf("Failed to create user: %v", err)
+			}
+			if tt.erased {
+				if err := u.Erase(); err != nil {
+					t.Fatalf("Erase() error = %v", err)
+				}
+			}
+			u.ClearUncommittedChanges()
+
+			if err := u.ChangeUsername(tt.newUsername); err != tt.expectedError {
+				t.Fatalf("ChangeUsername() error = %v, expected error %v", err, tt.expectedError)
+			}
+
+			changes := u.GetUncommittedChanges()
+			if !tt.expectEvent {
+				if len(changes) != 0 {
+					t.Errorf("ChangeUsername() recorded %v, expected nothing", changes)
+				}
+				return
+			}
+			if len(changes) != 1 || changes[0].GetEventType() != string(EventTypeUserUsernameChanged) {
+				t.Fatalf("ChangeUsername() changes = %v, expected one %s event", changes, EventTypeUserUsernameChanged)
+			}
+			if u.ID != "test" || u.Username != string(tt.newUsername) {
+				t.Errorf("user = (%s, %s), expected (test, %s)", u.ID, u.Username, tt.newUsername)
+			}
+
+			reservations := changes[0].(shared.Reserver).Reservations()
+			expected := shared.Reservation{Scope: UsernameReservationScope, Value: string(tt.newUsername)}
+			if len(reservations) != 1 || reservations[0] != expected {
+				t.Errorf("Reservations() = %v, expected %v", reservations, expected)
 			}
 		})
 	}
 }
+
+func TestUser_LockAndRole(t *testing.T) {
+	u, err := NewUser("test", "testuser", "validpass123", testHasher)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if u.Role != DefaultRole || u.Locked {
+		t.Fatalf("new user role = %s locked = %v, expected %s and unlocked", u.Role, u.Locked, DefaultRole)
+	}
+	u.ClearUncommittedChanges()
+
+	steps := []struct {
+		name          string
+		apply         func() error
+		expectedEvent shared.EventType
+	}{
+		{"lock", u.Lock, EventTypeUserLocked},
+		{"lock again", u.Lock, ""},
+		{"assign role", func() error { return u.AssignRole(RoleSupport) }, EventTypeUserRoleAssigned},
+		{"assign the same role", func() error { return u.AssignRole(RoleSupport) }, ""},
+		{"unlock", u.Unlock, EventTypeUserUnlocked},
+		{"unlock again", u.Unlock, ""},
+	}
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: error = %v", step.name, err)
+		}
+		changes := u.GetUncommittedChanges()
+		u.ClearUncommittedChanges()
+		if step.expectedEvent == "" {
+			if len(changes) != 0 {
+				t.Errorf("%s: recorded %v, expected nothing", step.name, changes)
+			}
+			continue
+		}
+		if len(changes) != 1 || changes[0].GetEventType() != string(step.expectedEvent) {
+			t.Errorf("%s: recorded %v, expected one %s event", step.name, changes, step.expectedEvent)
+		}
+	}
+
+	if u.Role != RoleSupport || u.Locked {
+		t.Errorf("user role = %s locked = %v, expected support and unlocked", u.Role, u.Locked)
+	}
+	if err := u.AssignRole(""); err != ErrInvalidRole {
+		t.Errorf("AssignRole(\"\") error = %v, expected %v", err, ErrInvalidRole)
+	}
+}
+
+func TestUser_ChangeEmail(t *testing.T) {
+	u, err := NewUser("test", "testuser", "validpass123", testHasher)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	u.ClearUncommittedChanges()
+
+	if err := u.ChangeEmail("alice@example.com"); err != nil {
+		t.Fatalf("ChangeEmail() error = %v", err)
+	}
+	changes := u.GetUncommittedChanges()
+	if len(changes) != 1 || changes[0].GetEventType() != string(EventTypeUserEmailChanged) || u.Email != "alice@example.com" {
+		t.Fatalf("ChangeEmail() changes = %v email = %s, expected one %s event", changes, u.Email, EventTypeUserEmailChanged)
+	}
+	reservations := changes[0].(shared.Reserver).Reservations()
+	expected := shared.Reservation{Scope: EmailReservationScope, Value: "alice@example.com"}
+	if len(reservations) != 1 || reservations[0] != expected {
+		t.Errorf("Reservations() = %v, expected %v", reservations, expected)
+	}
+
+	u.ClearUncommittedChanges()
+	if err := u.ChangeEmail("alice@example.com"); err != nil || len(u.GetUncommittedChanges()) != 0 {
+		t.Errorf("ChangeEmail() to the same address = %v, %v, expected nothing recorded", err, u.GetUncommittedChanges())
+	}
+
+	if err := u.Erase(); err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+	if u.Email != "" {
+		t.Errorf("Erase() kept email %s on the aggregate", u.Email)
+	}
+	if err := u.ChangeEmail("bob@example.com"); err != ErrUserErased {
+		t.Errorf("ChangeEmail() after Erase() error = %v, expected %v", err, ErrUserErased)
+	}
+}
@@ -0,0 +1,138 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 32
+
+	// usernameSeparators may appear between letters and digits, one at a time
+	usernameSeparators = "._-"
+)
+
+// codes reported in the FieldError of a rejected username
+const (
+	UsernameRequired          = "required"
+	UsernameTooShort          = "too_short"
+	UsernameTooLong           = "too_long"
+	UsernameInvalidCharacters = "invalid_characters"
+	UsernameInvalidFormat     = "invalid_format"
+	UsernameReserved          = "reserved"
+)
+
+// DefaultReservedUsernames are kept from registration unless configured
+// otherwise, so no account can pass itself off as the operators.
+var DefaultReservedUsernames = []string{
+	"abuse", "admin", "administrator", "anonymous", "api", "auth", "help",
+	"hostmaster", "info", "mail", "me", "noreply", "null", "postmaster",
+	"root", "security", "support", "system", "undefined", "webmaster", "www",
+}
+
+// Username is a username in canonical form. Spellings people would read as
+// the same name, such as Alice, ALICE and full-width Ａｌｉｃｅ, share one
+// canonical form and therefore one account.
+type Username string
+
+// NormalizeUsername returns the canonical form of raw: trimmed, NFKC
+// normalized and case folded. It applies no policy, so it is what lookups
+// by username use.
+func NormalizeUsername(raw string) Username {
+	folded := cases.Fold().String(norm.NFKC.String(strings.TrimSpace(raw)))
+	// folding can leave text that is no longer in NFKC
+	return Username(norm.NFKC.String(folded))
+}
+
+func (u Username) String() string {
+	return string(u)
+}
+
+// UsernamePolicy decides which usernames may be taken.
+type UsernamePolicy struct {
+	reserved map[Username]struct{}
+}
+
+func NewUsernamePolicy(reserved []string) UsernamePolicy {
+	policy := UsernamePolicy{reserved: make(map[Username]struct{}, len(reserved))}
+	for _, name := range reserved {
+		policy.reserved[NormalizeUsername(name)] = struct{}{}
+	}
+	return policy
+}
+
+// Parse returns the canonical form of raw, or a *shared.ValidationError
+// listing every rule it breaks.
+func (p UsernamePolicy) Parse(raw string) (Username, error) {
+	username := NormalizeUsername(raw)
+
+	var problems []shared.FieldError
+	reject := func(code, message string) {
+		problems = append(problems, shared.FieldError{Field: "username", Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(string(username))
+	switch {
+	case length == 0:
+		reject(UsernameRequired, "is required")
+	case length < MinUsernameLength:
+		reject(UsernameTooShort, fmt.Sprintf("must be at least %d characters long", MinUsernameLength))
+	case length > MaxUsernameLength:
+		reject(UsernameTooLong, fmt.Sprintf("must be at most %d characters long", MaxUsernameLength))
+	}
+
+	if strings.IndexFunc(string(username), func(r rune) bool { return !isUsernameRune(r) }) >= 0 {
+		reject(UsernameInvalidCharacters, "may only contain letters, digits and the separators "+usernameSeparators)
+	} else if length > 0 && !hasUsernameFormat(username) {
+		reject(UsernameInvalidFormat, "must start with a letter or digit, not end in a separator and not repeat separators")
+	}
+
+	if len(problems) == 0 {
+		if _, reserved := p.reserved[username]; reserved {
+			reject(UsernameReserved, "is reserved")
+		}
+	}
+
+	if len(problems) > 0 {
+		return "", &shared.ValidationError{Fields: problems}
+	}
+	return username, nil
+}
+
+func isUsernameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc) ||
+		isUsernameSeparator(r)
+}
+
+func isUsernameSeparator(r rune) bool {
+	return strings.ContainsRune(usernameSeparators, r)
+}
+
+// hasUsernameFormat reports whether username, made of username runes only,
+// starts with a letter or digit, does not end in a separator and never has
+// two separators in a row.
+func hasUsernameFormat(username Username) bool {
+	first, _ := utf8.DecodeRuneInString(string(username))
+	last, _ := utf8.DecodeLastRuneInString(string(username))
+	if !unicode.IsLetter(first) && !unicode.IsDigit(first) || isUsernameSeparator(last) {
+		return false
+	}
+
+	previousSeparator := false
+	for _, r := range string(username) {
+		separator := isUsernameSeparator(r)
+		if separator && previousSeparator {
+			return false
+		}
+		previousSeparator = separator
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ncfex/dcart-auth/internal/domain/shared"
+)
+
+func TestUsernamePolicy_Parse(t *testing.T) {
+	policy := NewUsernamePolicy([]string{"admin", "Support"})
+
+	tests := []struct {
+		name          string
+		raw           string
+		expected      Username
+		expectedCodes []string
+	}{
+		{
+			name:     "valid username",
+			raw:      "alice",
+			expected: "alice",
+		},
+		{
+			name:     "case is folded",
+			raw:      "Alice.Smith",
+			expected: "alice.smith",
+		},
+		{
+			name:     "full-width characters are normalized",
+			raw:      "Ａｌｉｃｅ",
+			expected: "alice",
+		},
+		{
+			name:     "surrounding whitespace is trimmed",
+			raw:      "  bob_1  ",
+			expected: "bob_1",
+		},
+		{
+			name:     "letters outside ascii are allowed",
+			raw:      "José",
+			expected: "josé",
+		},
+		{
+			name:          "empty username",
+			raw:           "   ",
+			expectedCodes: []string{UsernameRequired},
+		},
+		{
+			name:          "too short",
+			raw:           "ab",
+			expectedCodes: []string{UsernameTooShort},
+		},
+		{
+			name:          "too long",
+			raw:           strings.Repeat("a", MaxUsernameLength+1),
+			expectedCodes: []string{UsernameTooLong},
+		},
+		{
+			name:          "invalid characters",
+			raw:           "alice smith!",
+			expectedCodes: []string{UsernameInvalidCharacters},
+		},
+		{
+			name:          "leading separator",
+			raw:           "_alice",
+			expectedCodes: []string{UsernameInvalidFormat},
+		},
+		{
+			name:          "trailing separator",
+			raw:           "alice.",
+			expectedCodes: []string{UsernameInvalidFormat},
+		},
+		{
+			name:          "repeated separators",
+			raw:           "alice..smith",
+			expectedCodes: []string{UsernameInvalidFormat},
+		},
+		{
+			name:          "every problem is reported",
+			raw:           "a!",
+			expectedCodes: []string{UsernameTooShort, UsernameInvalidCharacters},
+		},
+		{
+			name:          "reserved username",
+			raw:           "admin",
+			expectedCodes: []string{UsernameReserved},
+		},
+		{
+			name:          "reserved username in another case",
+			raw:           "SUPPORT",
+			expectedCodes: []string{UsernameReserved},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			username, err := policy.Parse(tt.raw)
+			if len(tt.expectedCodes) == 0 {
+				if err != nil {
+					t.Fatalf("Parse() error = %v, expected nil", err)
+				}
+				if username != tt.expected {
+					t.Errorf("Parse() = %q, expected %q", username, tt.expected)
+				}
+				return
+			}
+
+			if !errors.Is(err, shared.ErrValidation) {
+				t.Fatalf("Parse() error = %v, expected %v", err, shared.ErrValidation)
+			}
+			var validationErr *shared.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Parse() error = %T, expected *shared.ValidationError", err)
+			}
+			var codes []string
+			for _, field := range validationErr.Fields {
+				if field.Field != "username" {
+					t.Errorf("Parse() field = %q, expected username", field.Field)
+				}
+				codes = append(codes, field.Code)
+			}
+			if strings.Join(codes, ",") != strings.Join(tt.expectedCodes, ",") {
+				t.Errorf("Parse() codes = %v, expected %v", codes, tt.expectedCodes)
+			}
+		})
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	spellings := []string{"Alice", "ALICE", " alice ", "Ａｌｉｃｅ"}
+	for _, spelling := range spellings {
+		if got := NormalizeUsername(spelling); got != "alice" {
+			t.Errorf("NormalizeUsername(%q) = %q, expected alice", spelling, got)
+		}
+	}
+}
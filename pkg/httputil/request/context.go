@@ -6,7 +6,13 @@ import (
 
 type ContextKey string
 
-const ContextUserKey ContextKey = "user"
+const (
+	ContextUserKey        ContextKey = "user"
+	ContextRequestIDKey   ContextKey = "request_id"
+	ContextCausationIDKey ContextKey = "causation_id"
+	ContextClientIPKey    ContextKey = "client_ip"
+	ContextUserAgentKey   ContextKey = "user_agent"
+)
 
 func SetValueToContext(ctx context.Context, key ContextKey, value interface{}) context.Context {
 	return context.WithValue(ctx, key, value)
@@ -16,3 +22,8 @@ func GetDataFromContext[T any](ctx context.Context, ctxKey ContextKey) (*T, bool
 	dat, ok := ctx.Value(ContextUserKey).(*T)
 	return dat, ok
 }
+
+func GetStringFromContext(ctx context.Context, ctxKey ContextKey) string {
+	value, _ := ctx.Value(ctxKey).(string)
+	return value
+}
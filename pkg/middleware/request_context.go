@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+
+	"github.com/ncfex/dcart-auth/pkg/httputil/request"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	// CausationIDHeader is sent by callers acting on something that happened
+	// elsewhere, such as a webhook subscriber reacting to a delivery, with the
+	// ID of that event or message
+	CausationIDHeader = "X-Causation-ID"
+)
+
+// RequestContext stores the request ID, causation ID, client IP and user
+// agent in the request context. An incoming X-Request-ID is reused so the ID
+// can be correlated across services; otherwise a new one is generated.
+func RequestContext() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			ctx := request.SetValueToContext(r.Context(), request.ContextRequestIDKey, requestID)
+			if causationID := r.Header.Get(CausationIDHeader); causationID != "" {
+				ctx = request.SetValueToContext(ctx, request.ContextCausationIDKey, causationID)
+			}
+			ctx = request.SetValueToContext(ctx, request.ContextClientIPKey, clientIP(r))
+			ctx = request.SetValueToContext(ctx, request.ContextUserAgentKey, r.UserAgent())
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}